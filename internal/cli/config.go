@@ -2,7 +2,9 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 
@@ -13,29 +15,215 @@ import (
 // newConfigCmd creates a new config command
 func newConfigCmd() *cobra.Command {
 	var configPath string
+	var showOrigin bool
 
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Display current configuration",
-		Long: `Display the current soba configuration from .soba/config.yml file.
-Sensitive information like tokens and webhook URLs will be masked.`,
+		Long: `Display the current soba configuration, merged from the global config,
+.soba/config.yml, the --profile config and SOBA_* environment variables.
+Tokens, webhook URLs, values marked !secret and values read from secret
+references are masked.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runConfig(cmd, configPath)
+			return runConfig(cmd, configPath, showOrigin)
 		},
 	}
 
 	cmd.Flags().StringVarP(&configPath, "config", "c", "", "config file path (default: .soba/config.yml)")
+	cmd.Flags().BoolVar(&showOrigin, "show-origin", false, "show the layer, file and line that set each value")
+
+	cmd.AddCommand(newConfigValidateCmd())
+	cmd.AddCommand(newConfigSchemaCmd())
+	cmd.AddCommand(newConfigMigrateCmd())
+
+	return cmd
+}
+
+// newConfigValidateCmd creates the config validate command
+func newConfigValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [path]",
+		Short: "Check a config file for unknown keys and invalid values",
+		Long: `Check a config file (default: .soba/config.yml) and report every unknown key,
+value of the wrong type and invalid setting with its line and column.
+The global config, the --profile config and SOBA_* environment variables are
+checked too. soba refuses to start with a config that fails these checks.`,
+		Args: cobra.MaximumNArgs(1),
+		// main prints the error; the problems are already printed
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigValidate(cmd, args)
+		},
+	}
+}
+
+// runConfigValidate executes the config validate command
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		resolved, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+		path = resolved
+	}
+
+	// Load falls back to the defaults for a missing file
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	// Check ${cmd:...} and ${keyring:...} references without running them
+	layers := config.DefaultLayers(path, profile)
+	layers.SkipSecretCommands = true
+	out := cmd.OutOrStdout()
+	cfg, _, err := config.LoadLayers(layers)
+	if err != nil {
+		var validationErr *config.ValidationError
+		if !errors.As(err, &validationErr) {
+			return err
+		}
+		for _, problem := range validationErr.Problems {
+			// Problems in the global or profile config, or in SOBA_* variables, name their source
+			source := problem.Source
+			if source == "" {
+				source = path
+			}
+			fmt.Fprintf(out, "%s:%s\n", source, formatProblem(problem))
+		}
+		return fmt.Errorf("%d problems found in %s", len(validationErr.Problems), path)
+	}
+
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning)
+	}
+	fmt.Fprintf(out, "%s is valid\n", path)
+	return nil
+}
+
+// formatProblem formats a problem as "line:column: field: message" like compiler errors
+func formatProblem(problem config.Problem) string {
+	if problem.Line > 0 {
+		return fmt.Sprintf("%d:%d: %s: %s", problem.Line, problem.Column, problem.Field, problem.Message)
+	}
+	return fmt.Sprintf(" %s: %s", problem.Field, problem.Message)
+}
+
+// newConfigSchemaCmd creates the config schema command
+func newConfigSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for .soba/config.yml",
+		Long: `Print the JSON Schema for .soba/config.yml. Editors that support JSON Schema
+for YAML use it to complete and check the config file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schema, err := config.JSONSchema()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(schema)
+			return err
+		},
+	}
+}
+
+// newConfigMigrateCmd creates the config migrate command
+func newConfigMigrateCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate [path]",
+		Short: "Upgrade a config file to the current version",
+		Long: `Upgrade a config file (default: .soba/config.yml) to the current config version.
+Keys are renamed, settings added since the file's version are written with their
+defaults and comments, and version is set. Comments and the order of the existing
+keys are kept. The original file is saved as <path>.v<version>.bak.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigMigrate(cmd, args, dryRun)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the changes without writing the file")
 
 	return cmd
 }
 
+// runConfigMigrate executes the config migrate command
+func runConfigMigrate(cmd *cobra.Command, args []string, dryRun bool) error {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		resolved, err := resolveConfigPath()
+		if err != nil {
+			return err
+		}
+		path = resolved
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path) // #nosec G304 - the path is given by the user
+	if err != nil {
+		return err
+	}
+	result, err := config.Migrate(content)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", path, err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(result.Changes) == 0 && len(result.Unresolved) == 0 {
+		fmt.Fprintf(out, "%s is already at version %d\n", path, result.To)
+		return nil
+	}
+	for _, change := range result.Changes {
+		fmt.Fprintf(out, "  - %s\n", change)
+	}
+	// The file is left as it is until every value works with the new version
+	if len(result.Unresolved) > 0 {
+		fmt.Fprintf(out, "Rewrite these values first:\n")
+		for _, value := range result.Unresolved {
+			fmt.Fprintf(out, "  - %s\n", value)
+		}
+		return fmt.Errorf("%s cannot be migrated to version %d until %d values are rewritten", path, config.CurrentConfigVersion, len(result.Unresolved))
+	}
+	if dryRun {
+		fmt.Fprintf(out, "%s would be migrated from version %d to %d\n", path, result.From, result.To)
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, result.From)
+	if err := os.WriteFile(backup, content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, result.Content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Fprintf(out, "Migrated %s from version %d to %d (backup: %s)\n", path, result.From, result.To, backup)
+	return nil
+}
+
 // runConfig executes the config command
-func runConfig(cmd *cobra.Command, _ string) error {
+func runConfig(cmd *cobra.Command, _ string, showOrigin bool) error {
 	// Get config from global app
 	cfg := app.Config()
 
 	// 設定内容を表示用に整形
-	output, err := config.DisplayConfig(cfg)
+	var output string
+	var err error
+	if showOrigin {
+		output, err = config.DisplayOrigins(cfg, app.ConfigOrigins())
+	} else {
+		output, err = config.DisplayConfig(cfg)
+	}
 	if err != nil {
 		cmd.PrintErrf("Error: Failed to format config: %v\n", err)
 		return err
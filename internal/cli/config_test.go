@@ -91,6 +91,12 @@ func TestRunConfig_FileNotFound(t *testing.T) {
 	tempDir := t.TempDir()
 	nonExistentPath := filepath.Join(tempDir, ".soba", "config.yml")
 
+	// デフォルトのログ出力先.soba/logsを一時ディレクトリに作る
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tempDir))
+
 	// Create minimal config for app initialization
 	minimalConfigPath := filepath.Join(tempDir, "minimal.yml")
 	configData := []byte(`github:
@@ -184,3 +190,184 @@ log:
 	assert.Contains(t, output, "repository: test/repo")
 	assert.Contains(t, output, "interval: 20")
 }
+
+func TestRunConfig_ShowOrigin(t *testing.T) {
+	helper := app.NewTestHelper(t)
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("SOBA_WORKFLOW_INTERVAL", "45")
+
+	// デフォルトのログ出力先.soba/logsを一時ディレクトリに作る
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(dir))
+
+	global := filepath.Join(dir, "soba", "config.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(global), 0755))
+	require.NoError(t, os.WriteFile(global, []byte("slack:\n  webhook_url: https://hooks.slack.com/personal\n"), 0600))
+	configPath := filepath.Join(dir, ".soba", "config.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(configPath), 0755))
+	require.NoError(t, os.WriteFile(configPath, []byte("github:\n  repository: owner/repo\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".soba", "config.ci.yml"), []byte("log:\n  level: debug\n"), 0600))
+
+	helper.InitializeForTestWithOptions(configPath, &app.InitOptions{Profile: "ci"})
+
+	cmd := newConfigCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"--show-origin"})
+	require.NoError(t, cmd.Execute())
+
+	lines := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		fields := strings.Fields(line)
+		lines[fields[0]] = strings.Join(fields[1:], " ")
+	}
+	assert.Equal(t, "owner/repo project "+configPath+":2", lines["github.repository"])
+	assert.Equal(t, "***MASKED*** global "+global+":2", lines["slack.webhook_url"])
+	assert.Equal(t, "debug profile "+filepath.Join(dir, ".soba", "config.ci.yml")+":2", lines["log.level"])
+	assert.Equal(t, "45 env SOBA_WORKFLOW_INTERVAL", lines["workflow.interval"])
+	assert.Equal(t, "main default", lines["git.base_branch"])
+}
+
+func TestConfigValidateCmd(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("reports problems with their position", func(t *testing.T) {
+		path := filepath.Join(dir, "invalid.yml")
+		require.NoError(t, os.WriteFile(path, []byte("github:\n  repository: owner/repo\nworkflow:\n  intervall: 5\nlog:\n  level: loud\n"), 0600))
+
+		cmd := newConfigCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetArgs([]string{"validate", path})
+		err := cmd.Execute()
+
+		require.Error(t, err)
+		assert.Equal(t, "2 problems found in "+path, err.Error())
+		assert.Contains(t, buf.String(), path+":4:3: workflow.intervall: unknown field (did you mean interval?)")
+		assert.Contains(t, buf.String(), path+`:6:10: log.level: must be one of`)
+	})
+
+	t.Run("accepts a valid config", func(t *testing.T) {
+		path := filepath.Join(dir, "valid.yml")
+		require.NoError(t, os.WriteFile(path, []byte("github:\n  repository: owner/repo\n"), 0600))
+
+		cmd := newConfigCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetArgs([]string{"validate", path})
+
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, path+" is valid\n", buf.String())
+	})
+
+	t.Run("reports invalid environment overrides", func(t *testing.T) {
+		path := filepath.Join(dir, "valid.yml")
+		t.Setenv("SOBA_WORKFLOW_USE_TMUX", "sometimes")
+
+		cmd := newConfigCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetArgs([]string{"validate", path})
+
+		require.Error(t, cmd.Execute())
+		assert.Equal(t, "SOBA_WORKFLOW_USE_TMUX: workflow.use_tmux: cannot use \"sometimes\" as boolean value\n", buf.String())
+	})
+
+	t.Run("fails for a missing file", func(t *testing.T) {
+		cmd := newConfigCmd()
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetArgs([]string{"validate", filepath.Join(dir, "missing.yml")})
+
+		assert.Error(t, cmd.Execute())
+	})
+}
+
+func TestConfigSchemaCmd(t *testing.T) {
+	cmd := newConfigCmd()
+	buf := new(bytes.Buffer)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"schema"})
+
+	require.NoError(t, cmd.Execute())
+	schema, err := config.JSONSchema()
+	require.NoError(t, err)
+	assert.Equal(t, string(schema), buf.String())
+}
+
+func TestConfigMigrateCmd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	original := "github:\n  repository: owner/repo\nphase:\n  plan:\n    command: claude\n    parameter: '/soba:plan {{issue-number}}'\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0600))
+
+	migrate := func(args ...string) (string, error) {
+		cmd := newConfigCmd()
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetArgs(append([]string{"migrate"}, args...))
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	t.Run("lists the changes with --dry-run", func(t *testing.T) {
+		output, err := migrate("--dry-run", path)
+		require.NoError(t, err)
+
+		assert.Contains(t, output, "  - add workflow.post_merge with the defaults of version 2\n")
+		assert.Contains(t, output, "  - rewrite the issue number placeholder in phase.plan.parameter as {{.IssueNumber}}\n")
+		assert.True(t, strings.HasSuffix(output, path+" would be migrated from version 1 to 2\n"))
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("writes the migrated file and a backup", func(t *testing.T) {
+		output, err := migrate(path)
+		require.NoError(t, err)
+
+		backup := path + ".v1.bak"
+		assert.True(t, strings.HasSuffix(output, "Migrated "+path+" from version 1 to 2 (backup: "+backup+")\n"))
+		saved, err := os.ReadFile(backup)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(saved))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		cfg, _, err := config.LoadLayers(config.Layers{Project: path})
+		require.NoError(t, err)
+		assert.Equal(t, config.CurrentConfigVersion, cfg.Version)
+		assert.Equal(t, "/soba:plan {{.IssueNumber}}", cfg.Phase.Plan.Parameter)
+	})
+
+	t.Run("leaves a current file alone", func(t *testing.T) {
+		output, err := migrate(path)
+		require.NoError(t, err)
+		assert.Equal(t, path+" is already at version 2\n", output)
+	})
+
+	t.Run("refuses to migrate commands with shell syntax", func(t *testing.T) {
+		shell := filepath.Join(dir, "shell.yml")
+		content := "phase:\n  plan:\n    command: cd x && claude\n"
+		require.NoError(t, os.WriteFile(shell, []byte(content), 0600))
+
+		output, err := migrate(shell)
+		assert.EqualError(t, err, shell+" cannot be migrated to version 2 until 1 values are rewritten")
+		assert.Contains(t, output, "Rewrite these values first:\n  - rewrite phase.plan.command \"cd x && claude\" without shell syntax")
+		saved, err := os.ReadFile(shell)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(saved))
+		assert.NoFileExists(t, shell+".v1.bak")
+	})
+
+	t.Run("rejects a newer version", func(t *testing.T) {
+		newer := filepath.Join(dir, "newer.yml")
+		require.NoError(t, os.WriteFile(newer, []byte("version: 3\n"), 0600))
+
+		_, err := migrate(newer)
+		assert.EqualError(t, err, "failed to migrate "+newer+": config version 3 is newer than this soba supports (2); upgrade soba")
+	})
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/service"
+)
+
+// usageOutputLimit is how much of the agent's output is kept to find its usage result.
+const usageOutputLimit = 1 << 20
+
+type costCmd struct {
+	file  string
+	by    string
+	issue int
+	phase string
+	now   func() time.Time
+	exit  func(code int)
+}
+
+func newCostCmd() *cobra.Command {
+	c := &costCmd{
+		now:  time.Now,
+		exit: os.Exit,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Show the cost and token usage of phase runs",
+		Long: `Show the cost and token usage recorded for phase runs, broken down by
+issue, day or week.
+
+Usage is recorded when workflow.usage.enabled is set and the phase command
+prints a usage result, such as claude -p --output-format json.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runReport(cmd)
+		},
+	}
+	cmd.Flags().StringVar(&c.file, "file", service.UsageLogPath, "usage log file")
+	cmd.Flags().StringVar(&c.by, "by", service.UsageByIssue, "breakdown: issue, day or week")
+	cmd.Flags().IntVar(&c.issue, "issue", 0, "only show usage of this issue")
+
+	recordCmd := &cobra.Command{
+		Use:    "record --issue <number> --phase <phase> -- <command> [args...]",
+		Short:  "Run a phase command and record the usage it reports",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		// Runs inside issue worktrees, where there is no config to load
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runRecord(cmd, args)
+		},
+	}
+	recordCmd.Flags().SetInterspersed(false)
+	recordCmd.Flags().StringVar(&c.file, "file", service.UsageLogPath, "usage log file")
+	recordCmd.Flags().IntVar(&c.issue, "issue", 0, "issue number")
+	recordCmd.Flags().StringVar(&c.phase, "phase", "", "phase name")
+
+	cmd.AddCommand(recordCmd)
+	return cmd
+}
+
+func (c *costCmd) runReport(cmd *cobra.Command) error {
+	records, err := service.LoadUsageRecords(c.file)
+	if err != nil {
+		return err
+	}
+
+	if c.issue != 0 {
+		var filtered []service.UsageRecord
+		for _, record := range records {
+			if record.Issue == c.issue {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	totals, err := service.SummarizeUsage(records, c.by)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if len(records) == 0 {
+		fmt.Fprintln(out, "No usage recorded")
+		return nil
+	}
+
+	writeUsageTable(out, strings.ToUpper(c.by), totals, service.SumUsage(records))
+	return nil
+}
+
+func writeUsageTable(out io.Writer, keyHeader string, totals []service.UsageTotal, sum service.UsageTotal) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tRUNS\tCOST (USD)\tINPUT\tOUTPUT\tCACHE\tDURATION\n", keyHeader)
+	for _, total := range append(totals, sum) {
+		key := total.Key
+		if key == "total" {
+			key = "TOTAL"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%d\t%d\t%d\t%s\n",
+			key,
+			total.Runs,
+			total.CostUSD,
+			total.InputTokens,
+			total.OutputTokens,
+			total.CacheTokens,
+			total.Duration(),
+		)
+	}
+	tw.Flush()
+}
+
+// runRecord runs the phase command with its output passed through, then
+// appends the usage it reported to the usage log and exits with its exit code.
+func (c *costCmd) runRecord(cmd *cobra.Command, args []string) error {
+	start := c.now()
+	output := &tailBuffer{limit: usageOutputLimit}
+
+	child := exec.CommandContext(cmd.Context(), args[0], args[1:]...) // #nosec G204 - the phase command comes from the soba config
+	child.Stdin = cmd.InOrStdin()
+	child.Stdout = io.MultiWriter(cmd.OutOrStdout(), output)
+	child.Stderr = cmd.ErrOrStderr()
+
+	exitCode := 0
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("failed to run phase command: %w", err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	record, ok := service.ParseAgentUsage(output.Bytes())
+	if !ok {
+		fmt.Fprintln(cmd.ErrOrStderr(), "soba: no usage result found in the command output")
+	}
+	record.Time = c.now()
+	record.Issue = c.issue
+	record.Phase = c.phase
+	record.ExitCode = exitCode
+	if record.DurationMS == 0 {
+		record.DurationMS = record.Time.Sub(start).Milliseconds()
+	}
+
+	if err := service.AppendUsageRecord(c.file, record); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "soba: failed to record usage: %v\n", err)
+	}
+
+	if exitCode != 0 {
+		c.exit(exitCode)
+	}
+	return nil
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	return b.buf
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/service"
+)
+
+func TestCostRecord(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "usage.jsonl")
+	exitCode := 0
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := &costCmd{
+		now:  func() time.Time { return start },
+		exit: func(code int) { exitCode = code },
+	}
+	cmd := newCostCmd()
+	cmd.SetContext(context.Background())
+
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	c.file, c.issue, c.phase = file, 7, "implement"
+
+	err := c.runRecord(cmd, []string{"sh", "-c",
+		`echo working; echo '{"type":"result","total_cost_usd":0.5,"duration_ms":1200,"usage":{"input_tokens":10,"output_tokens":2}}'; exit 3`})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+	assert.Contains(t, out.String(), "working")
+
+	records, err := service.LoadUsageRecords(file)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, 7, records[0].Issue)
+	assert.Equal(t, "implement", records[0].Phase)
+	assert.InDelta(t, 0.5, records[0].CostUSD, 1e-9)
+	assert.Equal(t, int64(1200), records[0].DurationMS)
+	assert.Equal(t, 3, records[0].ExitCode)
+	assert.Equal(t, start, records[0].Time)
+}
+
+func TestCostRecordWithoutUsageResult(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "usage.jsonl")
+	c := &costCmd{file: file, issue: 3, phase: "plan", now: time.Now, exit: func(int) { t.Fatal("unexpected exit") }}
+	cmd := newCostCmd()
+	cmd.SetContext(context.Background())
+
+	var errOut bytes.Buffer
+	cmd.SetErr(&errOut)
+
+	require.NoError(t, c.runRecord(cmd, []string{"true"}))
+	assert.Contains(t, errOut.String(), "no usage result found")
+
+	records, err := service.LoadUsageRecords(file)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Zero(t, records[0].CostUSD)
+}
+
+func TestCostReport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "usage.jsonl")
+	require.NoError(t, service.AppendUsageRecord(file, service.UsageRecord{Time: time.Now(), Issue: 12, Phase: "plan", CostUSD: 0.25}))
+	require.NoError(t, service.AppendUsageRecord(file, service.UsageRecord{Time: time.Now(), Issue: 4, Phase: "implement", CostUSD: 1, InputTokens: 300}))
+
+	t.Run("should show per-issue breakdown with total", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := newCostCmd()
+		cmd.SetOut(&out)
+		c := &costCmd{file: file, by: service.UsageByIssue}
+
+		require.NoError(t, c.runReport(cmd))
+		output := out.String()
+		assert.Contains(t, output, "ISSUE")
+		assert.Contains(t, output, "#4")
+		assert.Contains(t, output, "300")
+		assert.Contains(t, output, "TOTAL")
+		assert.Contains(t, output, "1.25")
+		assert.Less(t, bytes.Index(out.Bytes(), []byte("#4")), bytes.Index(out.Bytes(), []byte("#12")))
+	})
+
+	t.Run("should filter by issue", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := newCostCmd()
+		cmd.SetOut(&out)
+		c := &costCmd{file: file, by: service.UsageByDay, issue: 12}
+
+		require.NoError(t, c.runReport(cmd))
+		assert.Contains(t, out.String(), "DAY")
+		assert.NotContains(t, out.String(), "1.25")
+	})
+
+	t.Run("should report when nothing is recorded", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := newCostCmd()
+		cmd.SetOut(&out)
+		c := &costCmd{file: filepath.Join(t.TempDir(), "missing.jsonl"), by: service.UsageByWeek}
+
+		require.NoError(t, c.runReport(cmd))
+		assert.Contains(t, out.String(), "No usage recorded")
+	})
+}
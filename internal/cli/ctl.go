@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/service"
+)
+
+// controlSocketPath はCLIが接続する制御APIのソケット（テストで差し替える）
+var controlSocketPath = service.ControlSocketPath
+
+// dialControl はデーモンが制御APIに応答する場合にクライアントを返す。応答しない場合はnil
+func dialControl(ctx context.Context) *service.ControlClient {
+	client := service.NewControlClient(controlSocketPath)
+	if !client.Available(ctx) {
+		return nil
+	}
+	return client
+}
+
+// requireControl はdialControlと同じだが、応答しない場合はエラーを返す
+func requireControl(ctx context.Context) (*service.ControlClient, error) {
+	client := dialControl(ctx)
+	if client == nil {
+		return nil, fmt.Errorf("soba daemon is not running or its control API is unavailable at %s", controlSocketPath)
+	}
+	return client, nil
+}
+
+func newCtlCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ctl",
+		Short: "Control the running daemon",
+		Long: `Send commands to the running daemon through its control API on .soba/soba.sock.
+
+soba status, soba stop and soba log use the same API when it is available.`,
+	}
+
+	cmd.AddCommand(newCtlActionCmd("watch", "Run a watch cycle now", (*service.ControlClient).Watch))
+	cmd.AddCommand(newCtlActionCmd("pause", "Stop enqueuing issues and starting phases", (*service.ControlClient).Pause))
+	cmd.AddCommand(newCtlActionCmd("resume", "Resume after pause", (*service.ControlClient).Resume))
+	cmd.AddCommand(newCtlActionCmd("reload", "Reload the config file", (*service.ControlClient).Reload))
+	cmd.AddCommand(newCtlActionCmd("drain", "Stop accepting new issues and exit once issues in progress finish", (*service.ControlClient).Drain))
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel <issue>",
+		Short: "Stop the running phase of an issue and remove its soba labels",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			number, err := strconv.Atoi(args[0])
+			if err != nil || number <= 0 {
+				return fmt.Errorf("invalid issue number: %s", args[0])
+			}
+			return runCtlAction(cmd, func(c *service.ControlClient, ctx context.Context) (string, error) {
+				return c.CancelIssue(ctx, number)
+			})
+		},
+	}
+	cmd.AddCommand(cancelCmd)
+
+	return cmd
+}
+
+func newCtlActionCmd(use, short string, action func(*service.ControlClient, context.Context) (string, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCtlAction(cmd, action)
+		},
+	}
+}
+
+func runCtlAction(cmd *cobra.Command, action func(*service.ControlClient, context.Context) (string, error)) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	client, err := requireControl(ctx)
+	if err != nil {
+		return err
+	}
+	message, err := action(client, ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), message)
+	return nil
+}
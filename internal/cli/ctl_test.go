@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/service"
+)
+
+// startFakeControlAPI は制御APIを模したサーバーを起動し、CLIの接続先を差し替える
+func startFakeControlAPI(t *testing.T, handler http.Handler) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "soba")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "soba.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := &http.Server{Handler: handler}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	original := controlSocketPath
+	controlSocketPath = socketPath
+	t.Cleanup(func() { controlSocketPath = original })
+}
+
+func fakeStatusHandler(status service.ControlStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(status)
+	}
+}
+
+func TestCtlCommand(t *testing.T) {
+	t.Run("sends the action to the daemon", func(t *testing.T) {
+		var called []string
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /v1/status", fakeStatusHandler(service.ControlStatus{PID: 1}))
+		mux.HandleFunc("POST /v1/pause", func(w http.ResponseWriter, r *http.Request) {
+			called = append(called, r.URL.Path)
+			_ = json.NewEncoder(w).Encode(service.ControlResponse{Message: "paused"})
+		})
+		mux.HandleFunc("POST /v1/issues/{number}/cancel", func(w http.ResponseWriter, r *http.Request) {
+			called = append(called, r.URL.Path)
+			_ = json.NewEncoder(w).Encode(service.ControlResponse{Message: "issue #7 cancelled"})
+		})
+		startFakeControlAPI(t, mux)
+
+		var buf bytes.Buffer
+		cmd := newCtlCmd()
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"pause"})
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "paused\n", buf.String())
+
+		buf.Reset()
+		cmd = newCtlCmd()
+		cmd.SetOut(&buf)
+		cmd.SetArgs([]string{"cancel", "7"})
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "issue #7 cancelled\n", buf.String())
+
+		assert.Equal(t, []string{"/v1/pause", "/v1/issues/7/cancel"}, called)
+	})
+
+	t.Run("fails when the daemon is not reachable", func(t *testing.T) {
+		original := controlSocketPath
+		controlSocketPath = filepath.Join(t.TempDir(), "missing.sock")
+		t.Cleanup(func() { controlSocketPath = original })
+
+		cmd := newCtlCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"watch"})
+		assert.ErrorContains(t, cmd.Execute(), "control API is unavailable")
+	})
+}
+
+func TestStatusCommand_ControlAPI(t *testing.T) {
+	startFakeControlAPI(t, fakeStatusHandler(service.ControlStatus{
+		PID:          4242,
+		Uptime:       "5m0s",
+		Paused:       true,
+		CurrentIssue: 12,
+		Issues: []service.ControlIssue{
+			{Number: 12, Title: "Add feature", State: "soba:doing", Phase: "implement", Pane: "soba-repo:issue-12.1"},
+		},
+		Watchers: map[string]service.WatcherStatus{
+			"issue_watcher": {Alive: true, Errors: 2, LastError: "rate limited"},
+		},
+	}))
+
+	var buf bytes.Buffer
+	cmd := newStatusCmd()
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+
+	output := buf.String()
+	assert.Contains(t, output, "Daemon Status: Running (PID: 4242, Uptime: 5m0s)")
+	assert.Contains(t, output, "Mode: Paused")
+	assert.Contains(t, output, "Current Issue: #12")
+	assert.Contains(t, output, "issue_watcher: alive, 2 errors, last: rate limited")
+	assert.Contains(t, output, "#12 [soba:doing] Add feature (pane soba-repo:issue-12.1)")
+
+	buf.Reset()
+	cmd = newStatusCmd()
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"--json"})
+	require.NoError(t, cmd.Execute())
+
+	var decoded service.ControlStatus
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, 4242, decoded.PID)
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/service"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+type doctorCmd struct {
+	fix       bool
+	newDoctor func() (*service.Doctor, error)
+}
+
+func newDoctorCmd() *cobra.Command {
+	return newDoctorCmdWithDoctor(defaultDoctor)
+}
+
+// newDoctorCmdWithDoctor builds the doctor command with the given doctor constructor
+func newDoctorCmdWithDoctor(newDoctor func() (*service.Doctor, error)) *cobra.Command {
+	d := &doctorCmd{newDoctor: newDoctor}
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment and repository setup soba depends on",
+		Long: `Check everything soba depends on and report each check as pass, warn or fail
+with a hint on how to fix it:
+
+- the config file, tmux, git and git worktree support
+- the GitHub token (gh auth token or GITHUB_TOKEN) and its scopes
+- the agent commands on PATH and the .claude/commands/soba templates
+- the soba labels and their colors, the base branch and the worktree path
+- the Slack webhook URL
+
+--fix applies the fixes that are safe to automate: creating missing labels,
+resetting label colors, installing missing command templates and creating the
+worktree directory. Existing templates are never overwritten.`,
+		// The checks are already printed; the error only sets the exit code
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return d.run(cmd)
+		},
+	}
+	cmd.Flags().BoolVar(&d.fix, "fix", false, "fix the problems that are safe to fix automatically")
+
+	return cmd
+}
+
+func (d *doctorCmd) run(cmd *cobra.Command) error {
+	doctor, err := d.newDoctor()
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	results := doctor.Run(ctx, d.fix)
+
+	out := cmd.OutOrStdout()
+	writeDoctorResults(out, results)
+
+	failed := 0
+	warned := 0
+	fixable := 0
+	for _, result := range results {
+		switch result.Status {
+		case service.DoctorFail:
+			failed++
+		case service.DoctorWarn:
+			warned++
+		}
+		if result.Fixable && !result.Fixed {
+			fixable++
+		}
+	}
+	fmt.Fprintf(out, "\n%d passed, %d warnings, %d failed\n", len(results)-failed-warned, warned, failed)
+	if fixable > 0 && !d.fix {
+		fmt.Fprintf(out, "Run `soba doctor --fix` to fix %d of them automatically\n", fixable)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	return nil
+}
+
+// writeDoctorResults prints one line per check followed by its hint
+func writeDoctorResults(out io.Writer, results []service.DoctorResult) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, result := range results {
+		status := strings.ToUpper(result.Status)
+		if result.Fixed {
+			status = "FIXED"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status, result.Name, result.Message)
+		if result.Hint != "" {
+			fmt.Fprintf(w, "\t\t  hint: %s\n", result.Hint)
+		}
+	}
+	w.Flush()
+}
+
+// defaultDoctor builds a doctor for the repository in the current directory
+func defaultDoctor() (*service.Doctor, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	configPath, err := resolveConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenProvider := github.NewDefaultTokenProvider()
+	var client service.DoctorGitHubClient
+	if githubClient, err := github.NewClient(tokenProvider, &github.ClientOptions{Logger: logging.NewMockLogger()}); err == nil {
+		client = githubClient
+	}
+	return service.NewDoctor(config.DefaultLayers(configPath, profile), workDir, tokenProvider, client), nil
+}
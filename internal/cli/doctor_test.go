@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/service"
+)
+
+type noTokenProvider struct{}
+
+func (noTokenProvider) GetToken(ctx context.Context) (string, error) {
+	return "", errors.New("no token")
+}
+
+func TestDoctorCommand(t *testing.T) {
+	workDir := t.TempDir()
+	cmd := newDoctorCmdWithDoctor(func() (*service.Doctor, error) {
+		return service.NewDoctor(config.Layers{Project: filepath.Join(workDir, ".soba", "config.yml")}, workDir, noTokenProvider{}, nil), nil
+	})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{})
+
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checks failed")
+
+	output := out.String()
+	assert.Regexp(t, `FAIL\s+config\s+\.soba/config\.yml not found`, output)
+	assert.Contains(t, output, "hint: run `soba init` in the repository root")
+	assert.Regexp(t, `WARN\s+labels\s+skipped: no GitHub token`, output)
+	assert.Contains(t, output, "Run `soba doctor --fix` to fix")
+}
+
+func TestWriteDoctorResults(t *testing.T) {
+	var out bytes.Buffer
+	writeDoctorResults(&out, []service.DoctorResult{
+		{Name: "tmux", Status: service.DoctorPass, Message: "tmux 3.4"},
+		{Name: "labels", Status: service.DoctorPass, Message: "created 1 and updated 0 labels", Fixed: true},
+	})
+
+	assert.Equal(t, "PASS   tmux    tmux 3.4\nFIXED  labels  created 1 and updated 0 labels\n", out.String())
+}
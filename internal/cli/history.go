@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/service"
+)
+
+// historyCommandWidth is how much of a dispatched command the timeline shows.
+const historyCommandWidth = 80
+
+type historyCmd struct {
+	dir        string
+	jsonOutput bool
+}
+
+func newHistoryCmd() *cobra.Command {
+	h := &historyCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "history <issue>",
+		Short: "Show what soba did to an issue",
+		Long: `Show the timeline soba recorded for an issue: enqueueing, phase starts and
+ends, label changes, dispatched commands, failures, merges and cleanup.
+
+Events are appended to .soba/history/issue-<N>.jsonl by the daemon and are
+kept across restarts and log rotation.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return h.run(cmd, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&h.dir, "dir", service.HistoryDir, "history directory")
+	cmd.Flags().BoolVar(&h.jsonOutput, "json", false, "print the events as JSON lines")
+
+	return cmd
+}
+
+func (h *historyCmd) run(cmd *cobra.Command, arg string) error {
+	issueNumber, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || issueNumber <= 0 {
+		return fmt.Errorf("invalid issue number: %s", arg)
+	}
+
+	events, err := service.LoadIssueHistory(h.dir, issueNumber)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if h.jsonOutput {
+		encoder := json.NewEncoder(out)
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(events) == 0 {
+		fmt.Fprintf(out, "No history recorded for issue #%d\n", issueNumber)
+		return nil
+	}
+
+	writeHistoryTimeline(out, events)
+	return nil
+}
+
+func writeHistoryTimeline(out io.Writer, events []service.HistoryEvent) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tEVENT\tPHASE\tDETAILS")
+	for _, event := range events {
+		phase := event.Phase
+		if phase == "" {
+			phase = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			event.Time.Local().Format("2006-01-02 15:04:05"),
+			event.Type,
+			phase,
+			formatHistoryDetails(event),
+		)
+	}
+	tw.Flush()
+}
+
+func formatHistoryDetails(event service.HistoryEvent) string {
+	var parts []string
+	for _, label := range event.Added {
+		parts = append(parts, "+"+label)
+	}
+	for _, label := range event.Removed {
+		parts = append(parts, "-"+label)
+	}
+	if event.PR > 0 {
+		parts = append(parts, fmt.Sprintf("PR #%d", event.PR))
+	}
+	if event.Detail != "" {
+		parts = append(parts, event.Detail)
+	}
+	if event.Command != "" {
+		command := strings.Join(strings.Fields(event.Command), " ")
+		if len(command) > historyCommandWidth {
+			command = command[:historyCommandWidth-3] + "..."
+		}
+		parts = append(parts, command)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/service"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestHistoryCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	history := service.NewEventHistory(dir, logging.NewMockLogger())
+	ctx := context.Background()
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	history.Record(ctx, service.HistoryEvent{Time: start, Issue: 12, Type: service.HistoryEnqueued})
+	history.Record(ctx, service.HistoryEvent{Time: start.Add(time.Minute), Issue: 12, Type: service.HistoryLabelChanged,
+		Added: []string{"soba:planning"}, Removed: []string{"soba:ready"}})
+	history.Record(ctx, service.HistoryEvent{Time: start.Add(2 * time.Minute), Issue: 12, Type: service.HistoryCommandDispatched,
+		Phase: "plan", Command: "claude \"/soba:plan 12\"\n"})
+	history.Record(ctx, service.HistoryEvent{Time: start.Add(time.Hour), Issue: 12, Type: service.HistoryMerged, PR: 34})
+
+	t.Run("renders the timeline", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := newHistoryCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"--dir", dir, "#12"})
+		require.NoError(t, cmd.Execute())
+
+		lines := out.String()
+		assert.Contains(t, lines, "TIME")
+		assert.Contains(t, lines, "2026-10-17 09:00:00  enqueued")
+		assert.Contains(t, lines, "+soba:planning -soba:ready")
+		assert.Contains(t, lines, `claude "/soba:plan 12"`)
+		assert.Contains(t, lines, "PR #34")
+	})
+
+	t.Run("prints json lines", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := newHistoryCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"--dir", dir, "--json", "12"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Equal(t, 4, bytes.Count(out.Bytes(), []byte("\n")))
+	})
+
+	t.Run("issue without history", func(t *testing.T) {
+		var out bytes.Buffer
+		cmd := newHistoryCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{"--dir", dir, "5"})
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "No history recorded for issue #5\n", out.String())
+	})
+
+	t.Run("invalid issue number", func(t *testing.T) {
+		cmd := newHistoryCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"abc"})
+		assert.ErrorContains(t, cmd.Execute(), "invalid issue number")
+	})
+}
@@ -18,22 +18,44 @@ import (
 )
 
 func newInitCmd() *cobra.Command {
+	var agent string
+
 	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize soba configuration",
-		Long:  `Initialize soba configuration by creating a .soba/config.yml file in the current directory`,
+		Long: `Initialize soba configuration by creating a .soba/config.yml file in the current directory
+
+The --agent flag selects the coding agent preset. It sets the phase commands
+in the config file and writes the phase prompts where that agent reads them.
+
+Available agents:
+` + agentPresetList(),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			// Skip parent's PersistentPreRunE
 			return nil
 		},
-		RunE: runInit,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInit(cmd, agent)
+		},
 	}
 
+	cmd.Flags().StringVar(&agent, "agent", config.DefaultAgent, "coding agent preset ("+strings.Join(config.AgentPresetNames(), ", ")+")")
+
 	return cmd
 }
 
-func runInit(cmd *cobra.Command, args []string) error {
-	err := runInitWithClient(context.Background(), args, nil)
+// agentPresetList formats the built-in agent presets for the help text
+func agentPresetList() string {
+	var b strings.Builder
+	for _, name := range config.AgentPresetNames() {
+		preset, _ := config.GetAgentPreset(name)
+		fmt.Fprintf(&b, "  %-8s %s\n", name, preset.Description)
+	}
+	return b.String()
+}
+
+func runInit(cmd *cobra.Command, agent string) error {
+	err := runInitWithAgent(context.Background(), agent, nil)
 	if err == nil {
 		cmd.Printf("Successfully created config file\n")
 	}
@@ -42,8 +64,18 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 // runInitWithClient allows dependency injection for testing
 func runInitWithClient(ctx context.Context, _ []string, gitHubClient GitHubLabelsClient) error {
+	return runInitWithAgent(ctx, config.DefaultAgent, gitHubClient)
+}
+
+// runInitWithAgent initializes soba for the given agent preset
+func runInitWithAgent(ctx context.Context, agent string, gitHubClient GitHubLabelsClient) error {
 	log := logging.NewMockLogger()
 
+	preset, err := config.GetAgentPreset(agent)
+	if err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
 	// Get current directory
 	currentDir, err := os.Getwd()
 	if err != nil {
@@ -99,6 +131,7 @@ func runInitWithClient(ctx context.Context, _ []string, gitHubClient GitHubLabel
 	opts := &config.TemplateOptions{
 		Repository: repository,
 		LogLevel:   "info", // Set default log level to info as requested
+		Phases:     preset.PhaseCommands(),
 	}
 	configContent := config.GenerateTemplateWithOptions(opts)
 
@@ -120,10 +153,13 @@ func runInitWithClient(ctx context.Context, _ []string, gitHubClient GitHubLabel
 		log.Warn(ctx, "Failed to create GitHub labels", logging.Field{Key: "error", Value: err.Error()})
 	}
 
-	// Try to copy Claude command templates
-	if err := copyClaudeCommandTemplates(); err != nil {
+	// Try to write the agent's phase prompts
+	if err := copyAgentPromptTemplates(preset); err != nil {
 		// Log the error but don't fail the init command
-		log.Warn(ctx, "Failed to copy Claude command templates", logging.Field{Key: "error", Value: err.Error()})
+		log.Warn(ctx, "Failed to write agent prompt templates",
+			logging.Field{Key: "agent", Value: preset.Name},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
 	}
 
 	return nil
@@ -217,23 +253,17 @@ func createGitHubLabelsIfConfigured(ctx context.Context, configPath string, clie
 	return nil
 }
 
-// copyClaudeCommandTemplates copies Claude command templates to .claude/commands/soba/
-func copyClaudeCommandTemplates() error {
+// copyAgentPromptTemplates writes the phase prompts of the agent preset under the current directory
+func copyAgentPromptTemplates(preset config.AgentPreset) error {
 	// Get current working directory
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
-	// Define target directory
-	targetDir := filepath.Join(currentDir, ".claude", "commands", "soba")
-
-	// Get the ClaudeCommandsManager
-	manager := config.GetClaudeCommandsManager()
-
-	// Copy templates using the manager
-	if err := manager.CopyTemplates(targetDir); err != nil {
-		return fmt.Errorf("failed to copy Claude command templates: %w", err)
+	// Write prompts from the embedded command templates
+	if err := preset.WritePrompts(config.GetClaudeCommandsManager(), currentDir); err != nil {
+		return fmt.Errorf("failed to write %s prompt templates: %w", preset.Name, err)
 	}
 
 	return nil
@@ -401,6 +401,13 @@ func (m *MockGitHubClient) ListLabels(ctx context.Context, owner, repo string) (
 	return m.ExistingLabels, nil
 }
 
+func mustAgentPreset(t *testing.T, name string) config.AgentPreset {
+	t.Helper()
+	preset, err := config.GetAgentPreset(name)
+	require.NoError(t, err)
+	return preset
+}
+
 func TestCopyClaudeCommandTemplates(t *testing.T) {
 	t.Run("should copy embedded template files to target directory", func(t *testing.T) {
 		// Setup
@@ -412,7 +419,7 @@ func TestCopyClaudeCommandTemplates(t *testing.T) {
 		require.NoError(t, os.Chdir(tempDir))
 
 		// Execute
-		err := copyClaudeCommandTemplates()
+		err := copyAgentPromptTemplates(mustAgentPreset(t, config.DefaultAgent))
 
 		// Assert
 		assert.NoError(t, err)
@@ -450,7 +457,7 @@ func TestCopyClaudeCommandTemplates(t *testing.T) {
 		require.NoError(t, os.Chdir(tempDir))
 
 		// Execute
-		err := copyClaudeCommandTemplates()
+		err := copyAgentPromptTemplates(mustAgentPreset(t, config.DefaultAgent))
 
 		// Assert
 		assert.NoError(t, err)
@@ -478,7 +485,7 @@ func TestCopyClaudeCommandTemplates(t *testing.T) {
 		require.NoError(t, os.Chdir(tempDir))
 
 		// Execute
-		err := copyClaudeCommandTemplates()
+		err := copyAgentPromptTemplates(mustAgentPreset(t, config.DefaultAgent))
 
 		// Assert
 		assert.NoError(t, err)
@@ -520,9 +527,84 @@ func TestCopyClaudeCommandTemplates(t *testing.T) {
 		require.NoError(t, os.Chdir(tempDir))
 
 		// Execute
-		err := copyClaudeCommandTemplates()
+		err := copyAgentPromptTemplates(mustAgentPreset(t, config.DefaultAgent))
 
 		// Assert - should return error but function should handle it gracefully
 		assert.Error(t, err)
 	})
 }
+
+func TestCopyAgentPromptTemplates(t *testing.T) {
+	t.Run("should write Gemini CLI custom commands", func(t *testing.T) {
+		tempDir := t.TempDir()
+		oldDir, _ := os.Getwd()
+		defer os.Chdir(oldDir)
+		require.NoError(t, os.Chdir(tempDir))
+
+		err := copyAgentPromptTemplates(mustAgentPreset(t, "gemini"))
+
+		assert.NoError(t, err)
+		for _, phase := range []string{"plan", "implement", "review", "revise"} {
+			content, err := os.ReadFile(filepath.Join(tempDir, ".gemini", "commands", "soba", phase+".toml"))
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "description = ")
+			assert.Contains(t, string(content), "prompt = '''")
+			assert.NotContains(t, string(content), "$ARGUMENTS")
+		}
+	})
+
+	t.Run("should write Markdown prompts for codex", func(t *testing.T) {
+		tempDir := t.TempDir()
+		oldDir, _ := os.Getwd()
+		defer os.Chdir(oldDir)
+		require.NoError(t, os.Chdir(tempDir))
+
+		err := copyAgentPromptTemplates(mustAgentPreset(t, "codex"))
+
+		assert.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(tempDir, ".soba", "prompts", "review.md"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "allowed-tools")
+		assert.Contains(t, string(content), "$ARGUMENTS")
+	})
+}
+
+func TestInitCommandWithAgent(t *testing.T) {
+	setupRepo := func(t *testing.T) string {
+		tempDir := t.TempDir()
+		oldDir, _ := os.Getwd()
+		t.Cleanup(func() { os.Chdir(oldDir) })
+		require.NoError(t, os.Chdir(tempDir))
+
+		output, err := exec.Command("git", "init").CombinedOutput()
+		require.NoError(t, err, "Failed to init git repository: %s", string(output))
+		output, err = exec.Command("git", "remote", "add", "origin", "https://github.com/test-owner/test-repo.git").CombinedOutput()
+		require.NoError(t, err, "Failed to add git remote: %s", string(output))
+		return tempDir
+	}
+
+	t.Run("should write phase commands and prompts for the selected agent", func(t *testing.T) {
+		tempDir := setupRepo(t)
+
+		err := runInitWithAgent(context.Background(), "gemini", &MockGitHubClient{})
+
+		require.NoError(t, err)
+		cfg, err := config.Load(filepath.Join(tempDir, ".soba", "config.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "gemini", cfg.Phase.Plan.Command)
+		assert.Equal(t, []string{"--yolo", "--prompt-interactive"}, cfg.Phase.Implement.Options)
+		assert.Equal(t, "/soba:review {{issue-number}}", cfg.Phase.Review.Parameter)
+		assert.FileExists(t, filepath.Join(tempDir, ".gemini", "commands", "soba", "revise.toml"))
+		assert.NoDirExists(t, filepath.Join(tempDir, ".claude"))
+	})
+
+	t.Run("should reject unknown agents before creating files", func(t *testing.T) {
+		tempDir := setupRepo(t)
+
+		err := runInitWithAgent(context.Background(), "unknown", &MockGitHubClient{})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "available: claude, codex, gemini")
+		assert.NoDirExists(t, filepath.Join(tempDir, ".soba"))
+	})
+}
@@ -25,8 +25,9 @@ func newLogCmd() *cobra.Command {
 		Long: `Display logs from the running soba process by reading from the log file
 associated with the currently running daemon process.
 
-The command reads the PID from .soba/soba.pid and displays the corresponding
-log file .soba/logs/soba-{pid}.log.
+The command asks the daemon for its log file through the control API on
+.soba/soba.sock. When the API is unavailable, it reads the PID from
+.soba/soba.pid and displays the corresponding log file .soba/logs/soba-{pid}.log.
 
 Options:
 - Default: Show last 30 lines (equivalent to tail -n 30)
@@ -44,26 +45,11 @@ Options:
 }
 
 func runLog(cmd *cobra.Command, lines int, follow bool) error {
-	// Read PID from .soba/soba.pid
-	pidPath := filepath.Join(".soba", "soba.pid")
-	pidBytes, err := os.ReadFile(pidPath)
+	logPath, err := resolveLogPath()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("PID file not found at %s. Is soba daemon running?", pidPath)
-		}
-		return fmt.Errorf("failed to read PID file: %w", err)
+		return err
 	}
 
-	// Parse PID
-	pidStr := strings.TrimSpace(string(pidBytes))
-	pid, err := strconv.Atoi(pidStr)
-	if err != nil {
-		return fmt.Errorf("invalid PID in file %s: %s", pidPath, pidStr)
-	}
-
-	// Construct log file path
-	logPath := filepath.Join(".soba", "logs", fmt.Sprintf("soba-%d.log", pid))
-
 	// Check if log file exists
 	if _, err := os.Stat(logPath); err != nil {
 		if os.IsNotExist(err) {
@@ -79,6 +65,35 @@ func runLog(cmd *cobra.Command, lines int, follow bool) error {
 	return tailLog(cmd, logPath, lines)
 }
 
+// resolveLogPath は実行中のデーモンのログファイルを、制御API、PIDファイルの順に求める
+func resolveLogPath() (string, error) {
+	if client := dialControl(context.Background()); client != nil {
+		if status, err := client.Status(context.Background()); err == nil && status.LogFile != "" {
+			return status.LogFile, nil
+		}
+	}
+
+	// Read PID from .soba/soba.pid
+	pidPath := filepath.Join(".soba", "soba.pid")
+	pidBytes, err := os.ReadFile(pidPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("PID file not found at %s. Is soba daemon running?", pidPath)
+		}
+		return "", fmt.Errorf("failed to read PID file: %w", err)
+	}
+
+	// Parse PID
+	pidStr := strings.TrimSpace(string(pidBytes))
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid PID in file %s: %s", pidPath, pidStr)
+	}
+
+	// Construct log file path
+	return filepath.Join(".soba", "logs", fmt.Sprintf("soba-%d.log", pid)), nil
+}
+
 func tailLog(cmd *cobra.Command, logPath string, lines int) error {
 	// Use tail command to display log file
 	return executeTail(cmd.OutOrStdout(), logPath, lines, false)
@@ -79,6 +79,16 @@ func (m *MockTmuxClient) SendCommand(sessionName, windowName string, paneIndex i
 	return args.Error(0)
 }
 
+func (m *MockTmuxClient) CapturePane(sessionName, windowName string, paneIndex int) (string, error) {
+	args := m.Called(sessionName, windowName, paneIndex)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTmuxClient) PipePane(sessionName, windowName string, paneIndex int, logPath string) error {
+	args := m.Called(sessionName, windowName, paneIndex, logPath)
+	return args.Error(0)
+}
+
 func (m *MockTmuxClient) KillSession(sessionName string) error {
 	args := m.Called(sessionName)
 	return args.Error(0)
@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/service"
+	"github.com/douhashi/soba/pkg/app"
+)
+
+// Report output formats
+const (
+	reportFormatTable    = "table"
+	reportFormatJSON     = "json"
+	reportFormatMarkdown = "markdown"
+)
+
+type reportCmd struct {
+	since      string
+	source     string
+	format     string
+	historyDir string
+	now        func() time.Time
+	newReader  func() (service.ReportIssueReader, error)
+}
+
+func newReportCmd() *cobra.Command {
+	r := &reportCmd{now: time.Now}
+	r.newReader = r.defaultReader
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Show workflow analytics",
+		Long: `Show how the workflow performed over a period: lead time from soba:todo to
+merge, queue wait, time spent in each phase, review and revise iterations,
+failure rates and merges per day.
+
+By default the local history in .soba/history is used when it exists;
+otherwise the label events of the repository's issues are read from GitHub.
+A phase run counts as failed when its execution label is removed without a
+completion label.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.run(cmd)
+		},
+	}
+	cmd.Flags().StringVar(&r.since, "since", "30d", "start of the period: a duration such as 30d, 2w or 72h, or a date")
+	cmd.Flags().StringVar(&r.source, "source", "auto", "data source: auto, history or github")
+	cmd.Flags().StringVar(&r.format, "format", reportFormatTable, "output format: table, json or markdown")
+	cmd.Flags().StringVar(&r.historyDir, "history-dir", service.HistoryDir, "history directory")
+
+	return cmd
+}
+
+func (r *reportCmd) run(cmd *cobra.Command) error {
+	switch r.format {
+	case reportFormatTable, reportFormatJSON, reportFormatMarkdown:
+	default:
+		return fmt.Errorf("invalid format: %s. Valid formats are: table, json, markdown", r.format)
+	}
+
+	now := r.now()
+	since, err := service.ParseReportSince(r.since, now)
+	if err != nil {
+		return err
+	}
+
+	source := r.source
+	if source == "auto" {
+		source = service.ReportSourceGitHub
+		if service.HistoryAvailable(r.historyDir) {
+			source = service.ReportSourceHistory
+		}
+	}
+
+	var timelines []service.IssueTimeline
+	switch source {
+	case service.ReportSourceHistory:
+		timelines, err = service.LoadHistoryTimelines(r.historyDir)
+	case service.ReportSourceGitHub:
+		timelines, err = r.loadGitHubTimelines(cmd.Context(), since)
+	default:
+		return fmt.Errorf("invalid source: %s. Valid sources are: auto, history, github", r.source)
+	}
+	if err != nil {
+		return err
+	}
+
+	report := service.BuildWorkflowReport(timelines, source, since, now)
+
+	out := cmd.OutOrStdout()
+	switch r.format {
+	case reportFormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case reportFormatMarkdown:
+		writeReportMarkdown(out, report)
+	default:
+		writeReportTable(out, report)
+	}
+	return nil
+}
+
+func (r *reportCmd) loadGitHubTimelines(ctx context.Context, since time.Time) ([]service.IssueTimeline, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cfg := app.Config()
+	if cfg == nil {
+		return nil, fmt.Errorf("config is required to read issues from GitHub")
+	}
+	parts := strings.Split(cfg.GitHub.Repository, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid repository configuration: %s", cfg.GitHub.Repository)
+	}
+
+	reader, err := r.newReader()
+	if err != nil {
+		return nil, err
+	}
+	return service.LoadGitHubTimelines(ctx, reader, parts[0], parts[1], since)
+}
+
+func (r *reportCmd) defaultReader() (service.ReportIssueReader, error) {
+	client, err := github.NewClient(github.NewDefaultTokenProvider(), &github.ClientOptions{
+		Logger: app.LogFactory().CreateComponentLogger("github-client"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GitHub client: %w", err)
+	}
+	return client, nil
+}
+
+// reportRows returns the summary rows shared by the table and Markdown output.
+func reportRows(report *service.WorkflowReport) [][]string {
+	rows := [][]string{
+		{"Issues", fmt.Sprintf("%d", report.Issues)},
+		{"Merged", fmt.Sprintf("%d (%.2f per day)", report.Merged, report.AvgMergesPerDay)},
+		{"Lead time (todo to merge)", formatDurationStats(report.LeadTime)},
+		{"Queue wait (todo to queued)", formatDurationStats(report.QueueWait)},
+		{"Reviews per issue", fmt.Sprintf("%.1f", report.Iterations.AvgReviews)},
+		{"Revisions per issue", fmt.Sprintf("%.1f (max %d)", report.Iterations.AvgRevisions, report.Iterations.MaxRevisions)},
+	}
+	if report.Source == service.ReportSourceHistory {
+		rows = append(rows, []string{"Dispatch failures", fmt.Sprintf("%d", report.DispatchFailures)})
+	}
+	return rows
+}
+
+func phaseRow(phase service.PhaseReport) []string {
+	return []string{
+		phase.Phase,
+		fmt.Sprintf("%d", phase.Runs),
+		fmt.Sprintf("%d", phase.Failed),
+		fmt.Sprintf("%.0f%%", phase.FailureRate*100),
+		formatDuration(phase.Duration.Mean, phase.Duration.Count),
+		formatDuration(phase.Duration.Median, phase.Duration.Count),
+		formatDuration(phase.Duration.P90, phase.Duration.Count),
+	}
+}
+
+var phaseHeader = []string{"PHASE", "RUNS", "FAILED", "FAILURE RATE", "MEAN", "MEDIAN", "P90"}
+
+func reportPeriod(report *service.WorkflowReport) string {
+	return fmt.Sprintf("%s to %s (source: %s)",
+		report.Since.Local().Format("2006-01-02 15:04"),
+		report.Until.Local().Format("2006-01-02 15:04"),
+		report.Source,
+	)
+}
+
+func writeReportTable(out io.Writer, report *service.WorkflowReport) {
+	fmt.Fprintf(out, "Workflow report: %s\n\n", reportPeriod(report))
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, row := range reportRows(report) {
+		fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+	}
+	tw.Flush()
+
+	fmt.Fprintln(out)
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(phaseHeader, "\t"))
+	for _, phase := range report.Phases {
+		fmt.Fprintln(tw, strings.Join(phaseRow(phase), "\t"))
+	}
+	tw.Flush()
+
+	if len(report.MergesPerDay) > 0 {
+		fmt.Fprintln(out)
+		tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "DAY\tMERGES")
+		for _, day := range report.MergesPerDay {
+			fmt.Fprintf(tw, "%s\t%d\n", day.Day, day.Merges)
+		}
+		tw.Flush()
+	}
+}
+
+func writeReportMarkdown(out io.Writer, report *service.WorkflowReport) {
+	fmt.Fprintf(out, "## Workflow report\n\n%s\n\n", reportPeriod(report))
+
+	fmt.Fprintln(out, "| Metric | Value |")
+	fmt.Fprintln(out, "|--------|-------|")
+	for _, row := range reportRows(report) {
+		fmt.Fprintf(out, "| %s | %s |\n", row[0], row[1])
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "| %s |\n", strings.Join(phaseHeader, " | "))
+	fmt.Fprintf(out, "|%s\n", strings.Repeat("---|", len(phaseHeader)))
+	for _, phase := range report.Phases {
+		fmt.Fprintf(out, "| %s |\n", strings.Join(phaseRow(phase), " | "))
+	}
+
+	if len(report.MergesPerDay) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "| Day | Merges |")
+		fmt.Fprintln(out, "|-----|--------|")
+		for _, day := range report.MergesPerDay {
+			fmt.Fprintf(out, "| %s | %d |\n", day.Day, day.Merges)
+		}
+	}
+}
+
+func formatDurationStats(stats service.DurationStats) string {
+	if stats.Count == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("mean %s, median %s, p90 %s (n=%d)",
+		formatDuration(stats.Mean, stats.Count),
+		formatDuration(stats.Median, stats.Count),
+		formatDuration(stats.P90, stats.Count),
+		stats.Count,
+	)
+}
+
+// formatDuration shows the two largest units, such as 2d3h or 5m12s.
+func formatDuration(d time.Duration, count int) string {
+	if count == 0 {
+		return "-"
+	}
+	d = d.Round(time.Second)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", d/(24*time.Hour), (d%(24*time.Hour))/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%dm", d/time.Hour, (d%time.Hour)/time.Minute)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%ds", d/time.Minute, (d%time.Minute)/time.Second)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/service"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestReportCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	history := service.NewEventHistory(dir, logging.NewMockLogger())
+	ctx := context.Background()
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	history.Record(ctx, service.HistoryEvent{Time: start, Issue: 7, Type: service.HistoryLabelChanged, Added: []string{"soba:todo"}})
+	history.Record(ctx, service.HistoryEvent{Time: start.Add(5 * time.Minute), Issue: 7, Type: service.HistoryEnqueued})
+	history.Record(ctx, service.HistoryEvent{Time: start.Add(6 * time.Minute), Issue: 7, Type: service.HistoryLabelChanged,
+		Added: []string{"soba:planning"}, Removed: []string{"soba:queued"}})
+	history.Record(ctx, service.HistoryEvent{Time: start.Add(36 * time.Minute), Issue: 7, Type: service.HistoryLabelChanged,
+		Added: []string{"soba:ready"}, Removed: []string{"soba:planning"}})
+	history.Record(ctx, service.HistoryEvent{Time: start.Add(3 * time.Hour), Issue: 7, Type: service.HistoryMerged, PR: 8})
+
+	execute := func(t *testing.T, args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		cmd := newReportCmd()
+		cmd.SetOut(&out)
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"--history-dir", dir, "--since", "2026-10-01"}, args...))
+		require.NoError(t, cmd.Execute())
+		return out.String()
+	}
+
+	t.Run("table", func(t *testing.T) {
+		out := execute(t)
+		assert.Contains(t, out, "source: history")
+		assert.Contains(t, out, "Merged:")
+		assert.Contains(t, out, "mean 3h0m, median 3h0m, p90 3h0m (n=1)")
+		assert.Contains(t, out, "mean 5m0s")
+		assert.Regexp(t, `plan\s+1\s+0\s+0%\s+30m0s`, out)
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		out := execute(t, "--format", "markdown")
+		assert.Contains(t, out, "## Workflow report")
+		assert.Contains(t, out, "| Metric | Value |")
+		assert.Contains(t, out, "| plan | 1 | 0 | 0% | 30m0s | 30m0s | 30m0s |")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var report struct {
+			Source string `json:"source"`
+			Issues int    `json:"issues"`
+			Merged int    `json:"merged"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(execute(t, "--format", "json")), &report))
+		assert.Equal(t, service.ReportSourceHistory, report.Source)
+		assert.Equal(t, 1, report.Issues)
+		assert.Equal(t, 1, report.Merged)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		cmd := newReportCmd()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs([]string{"--format", "csv"})
+		assert.ErrorContains(t, cmd.Execute(), "invalid format")
+	})
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "-", formatDuration(time.Hour, 0))
+	assert.Equal(t, "12s", formatDuration(12*time.Second, 1))
+	assert.Equal(t, "5m12s", formatDuration(5*time.Minute+12*time.Second, 1))
+	assert.Equal(t, "1h5m", formatDuration(65*time.Minute, 1))
+	assert.Equal(t, "2d3h", formatDuration(51*time.Hour, 1))
+}
@@ -16,6 +16,7 @@ var (
 	cfgFile  string
 	verbose  bool
 	logLevel string
+	profile  string
 	Version  string
 	Commit   string
 	Date     string
@@ -45,12 +46,25 @@ development workflows through seamless integration with Claude Code AI.`,
 				cmdName = cmd.Name()
 			}
 
-			if cmdName == "init" || cmdName == "version" || cmdName == "stop" || cmdName == "log" {
+			if cmdName == "init" || cmdName == "version" || cmdName == "stop" || cmdName == "log" || cmdName == "doctor" {
+				return nil
+			}
+			// ctlのサブコマンドは制御APIだけを使う
+			if cmd.HasParent() && cmd.Parent().Name() == "ctl" {
+				return nil
+			}
+			// templatesのサブコマンドはコマンドテンプレートだけを扱う
+			if cmd.HasParent() && cmd.Parent().Name() == "templates" {
+				return nil
+			}
+			// config validate, schema and migrate read or describe the config file themselves
+			if cmd.HasParent() && cmd.Parent().Name() == "config" && (cmdName == "validate" || cmdName == "schema" || cmdName == "migrate") {
 				return nil
 			}
 
 			// Initialize app with CLI options (only once)
-			return initializeApp()
+			// soba config shows secret references instead of running them
+			return initializeApp(cmdName == "config")
 		},
 	}
 
@@ -63,6 +77,14 @@ development workflows through seamless integration with Claude Code AI.`,
 	cmd.AddCommand(newStopCmd())
 	cmd.AddCommand(newOpenCmd())
 	cmd.AddCommand(newLogCmd())
+	cmd.AddCommand(newWorktreeCmd())
+	cmd.AddCommand(newCostCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newReportCmd())
+	cmd.AddCommand(newRunCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newTemplatesCmd())
+	cmd.AddCommand(newCtlCmd())
 
 	return cmd
 }
@@ -78,6 +100,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file path")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "set log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "merge .soba/config.<profile>.yml over the project config")
 }
 
 // validateLogLevel validates the log level flag
@@ -111,17 +134,13 @@ func getEffectiveLogLevel() string {
 }
 
 // initializeApp initializes the application with CLI options
-func initializeApp() error {
+func initializeApp(skipSecretCommands bool) error {
 	appInitOnce.Do(func() {
 		// Get config file path
-		configPath := cfgFile
-		if configPath == "" {
-			cwd, err := os.Getwd()
-			if err != nil {
-				appInitErr = err
-				return
-			}
-			configPath = filepath.Join(cwd, ".soba", "config.yml")
+		configPath, err := resolveConfigPath()
+		if err != nil {
+			appInitErr = err
+			return
 		}
 
 		// Initialize with CLI options
@@ -132,10 +151,24 @@ func initializeApp() error {
 		}()
 
 		app.MustInitializeWithOptions(configPath, &app.InitOptions{
-			LogLevel: logLevel,
-			Verbose:  verbose,
+			LogLevel:           logLevel,
+			Verbose:            verbose,
+			Profile:            profile,
+			SkipSecretCommands: skipSecretCommands,
 		})
 	})
 
 	return appInitErr
 }
+
+// resolveConfigPath returns the --config path, or .soba/config.yml in the current directory
+func resolveConfigPath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, ".soba", "config.yml"), nil
+}
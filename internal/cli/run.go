@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/git"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/tmux"
+	"github.com/douhashi/soba/internal/service"
+	"github.com/douhashi/soba/pkg/app"
+)
+
+// phaseRunner runs a single phase; implemented by service.PhaseRunner.
+type phaseRunner interface {
+	Run(ctx context.Context, cfg *config.Config, issueNumber int, opts service.PhaseRunOptions) (*service.PhaseRunResult, error)
+	WaitForCompletion(ctx context.Context, cfg *config.Config, result *service.PhaseRunResult, labelsUpdated bool) error
+}
+
+type runCmd struct {
+	phase      string
+	foreground bool
+	noLabels   bool
+	newRunner  func() (phaseRunner, error)
+}
+
+func newRunCmd() *cobra.Command {
+	return newRunCmdWithRunner(defaultPhaseRunner)
+}
+
+// newRunCmdWithRunner builds the run command with the given runner constructor
+func newRunCmdWithRunner(newRunner func() (phaseRunner, error)) *cobra.Command {
+	r := &runCmd{newRunner: newRunner}
+
+	cmd := &cobra.Command{
+		Use:   "run <issue>",
+		Short: "Run a single phase for a single issue",
+		Long: `Run one workflow phase for one issue without starting the daemon.
+
+The phase is taken from the issue's soba label unless --phase is given. Like
+the daemon, soba prepares the worktree, moves the issue from the trigger label
+to the execution label (skip this with --no-labels) and runs the phase command,
+then reports the completion label the phase added.
+
+By default the command is sent to the issue's tmux window and soba waits for
+the completion label (Ctrl+C stops waiting; the phase keeps running). With
+--foreground the command runs attached to the current terminal instead.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.run(cmd, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&r.phase, "phase", "", "phase to run: plan, implement, review or revise (default: from the issue's labels)")
+	cmd.Flags().BoolVarP(&r.foreground, "foreground", "f", false, "run the command attached to the current terminal instead of tmux")
+	cmd.Flags().BoolVar(&r.noLabels, "no-labels", false, "do not update the issue's labels")
+
+	return cmd
+}
+
+func (r *runCmd) run(cmd *cobra.Command, arg string) error {
+	issueNumber, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || issueNumber <= 0 {
+		return fmt.Errorf("invalid issue number: %s", arg)
+	}
+
+	cfg := app.Config()
+	if cfg == nil {
+		return fmt.Errorf("config is required to run a phase")
+	}
+
+	runner, err := r.newRunner()
+	if err != nil {
+		return err
+	}
+
+	// Ctrl+C reaches the phase command directly; soba only stops waiting
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := service.PhaseRunOptions{
+		Phase:    domain.Phase(r.phase),
+		NoLabels: r.noLabels,
+	}
+	if r.foreground {
+		opts.Terminal = &service.PhaseTerminal{
+			Stdin:  cmd.InOrStdin(),
+			Stdout: cmd.OutOrStdout(),
+			Stderr: cmd.ErrOrStderr(),
+		}
+	}
+
+	result, err := runner.Run(ctx, cfg, issueNumber, opts)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if !r.foreground {
+		fmt.Fprintf(out, "Started the %s phase for issue #%d in tmux window %s\n", result.Phase, issueNumber, result.Window)
+		fmt.Fprintln(out, "Waiting for a completion label (Ctrl+C to stop waiting)...")
+		if err := runner.WaitForCompletion(ctx, cfg, result, !r.noLabels); err != nil {
+			if ctx.Err() != nil {
+				fmt.Fprintf(out, "Stopped waiting; the %s phase keeps running in %s\n", result.Phase, result.Window)
+				return nil
+			}
+			return err
+		}
+	}
+
+	if result.CompletionLabel == "" {
+		return fmt.Errorf("the %s phase for issue #%d finished without a completion label (labels: %s)",
+			result.Phase, issueNumber, strings.Join(result.Labels, ", "))
+	}
+	fmt.Fprintf(out, "The %s phase for issue #%d completed with %s\n", result.Phase, issueNumber, result.CompletionLabel)
+	return nil
+}
+
+// defaultPhaseRunner builds a runner for the repository in the current directory
+func defaultPhaseRunner() (phaseRunner, error) {
+	cfg := app.Config()
+
+	githubClient, err := github.NewClient(github.NewDefaultTokenProvider(), &github.ClientOptions{
+		Logger: app.LogFactory().CreateComponentLogger("github-client"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GitHub client: %w", err)
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	gitClient, err := git.NewClient(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize git client: %w", err)
+	}
+
+	logger := app.LogFactory().CreateComponentLogger("run")
+	runner := service.NewPhaseRunner(githubClient, tmux.NewClient(), service.NewGitWorkspaceManager(cfg, gitClient), logger)
+	runner.SetHistory(service.NewEventHistory(service.HistoryDir, logger))
+	return runner, nil
+}
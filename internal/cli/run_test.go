@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/service"
+	"github.com/douhashi/soba/pkg/app"
+)
+
+type fakePhaseRunner struct {
+	opts       service.PhaseRunOptions
+	issue      int
+	result     *service.PhaseRunResult
+	waited     bool
+	completion string
+}
+
+func (f *fakePhaseRunner) Run(ctx context.Context, cfg *config.Config, issueNumber int, opts service.PhaseRunOptions) (*service.PhaseRunResult, error) {
+	f.issue = issueNumber
+	f.opts = opts
+	return f.result, nil
+}
+
+func (f *fakePhaseRunner) WaitForCompletion(ctx context.Context, cfg *config.Config, result *service.PhaseRunResult, labelsUpdated bool) error {
+	f.waited = true
+	result.CompletionLabel = f.completion
+	return nil
+}
+
+func newTestRunCmd(runner *fakePhaseRunner) (*cobra.Command, *bytes.Buffer) {
+	cmd := newRunCmdWithRunner(func() (phaseRunner, error) { return runner, nil })
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	return cmd, &out
+}
+
+func TestRunCommand(t *testing.T) {
+	app.NewTestHelper(t).InitializeForTest()
+
+	t.Run("waits for the completion label in tmux", func(t *testing.T) {
+		runner := &fakePhaseRunner{
+			result:     &service.PhaseRunResult{Issue: 12, Phase: domain.PhasePlan, Window: "soba-test-repo:issue-12"},
+			completion: domain.LabelReady,
+		}
+		cmd, out := newTestRunCmd(runner)
+		cmd.SetArgs([]string{"#12", "--phase", "plan"})
+		require.NoError(t, cmd.Execute())
+
+		assert.Equal(t, 12, runner.issue)
+		assert.Equal(t, domain.PhasePlan, runner.opts.Phase)
+		assert.Nil(t, runner.opts.Terminal)
+		assert.True(t, runner.waited)
+		assert.Contains(t, out.String(), "tmux window soba-test-repo:issue-12")
+		assert.Contains(t, out.String(), "completed with soba:ready")
+	})
+
+	t.Run("runs in the foreground without labels", func(t *testing.T) {
+		runner := &fakePhaseRunner{
+			result: &service.PhaseRunResult{Issue: 12, Phase: domain.PhaseImplement, CompletionLabel: domain.LabelReviewRequested},
+		}
+		cmd, out := newTestRunCmd(runner)
+		cmd.SetArgs([]string{"12", "--phase", "implement", "--foreground", "--no-labels"})
+		require.NoError(t, cmd.Execute())
+
+		assert.NotNil(t, runner.opts.Terminal)
+		assert.True(t, runner.opts.NoLabels)
+		assert.False(t, runner.waited)
+		assert.Contains(t, out.String(), "completed with soba:review-requested")
+	})
+
+	t.Run("fails without a completion label", func(t *testing.T) {
+		runner := &fakePhaseRunner{
+			result: &service.PhaseRunResult{Issue: 12, Phase: domain.PhasePlan, Labels: []string{"soba:planning"}},
+		}
+		cmd, _ := newTestRunCmd(runner)
+		cmd.SetArgs([]string{"12", "-f"})
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "without a completion label")
+	})
+
+	t.Run("rejects an invalid issue number", func(t *testing.T) {
+		cmd, _ := newTestRunCmd(&fakePhaseRunner{})
+		cmd.SetArgs([]string{"abc"})
+		err := cmd.Execute()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid issue number")
+	})
+}
@@ -13,19 +13,29 @@ import (
 
 func newStartCmd() *cobra.Command {
 	var foreground bool
+	var dryRun bool
 
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start Issue monitoring (daemon mode by default)",
 		Long: `Start Issue monitoring process. By default, runs in daemon mode (background).
 Use -f/--foreground flag to run in foreground mode.
-Use -v/--verbose flag to enable debug logging.`,
+Use -v/--verbose flag to enable debug logging.
+
+Use --dry-run to run the watchers against live GitHub data without changing
+anything: label changes, merges, comments, worktree and tmux operations and
+commands sent to panes are printed instead of executed. A dry run always
+runs in the foreground and can run next to a running daemon.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if dryRun {
+				return runStartDryRun(cmd)
+			}
 			return runStart(cmd, args, foreground)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&foreground, "foreground", "f", false, "run in foreground mode")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print intended actions instead of executing them (implies --foreground)")
 
 	return cmd
 }
@@ -38,6 +48,25 @@ func runStart(cmd *cobra.Command, args []string, foreground bool) error {
 	return runStartWithService(cmd, args, foreground, daemonService)
 }
 
+// runStartDryRun runs the watchers in the foreground and prints every mutation
+// instead of executing it.
+func runStartDryRun(cmd *cobra.Command) error {
+	// Nothing is sent to Slack during a dry run
+	slack.Disable()
+
+	daemonService, err := service.NewDryRunDaemonService(app.Config(), app.LogFactory(), cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Dry run: intended actions are printed instead of executed (Ctrl+C to stop)\n")
+	err = daemonService.StartForeground(context.Background(), app.Config())
+	if err == nil {
+		cmd.Printf("Dry run stopped\n")
+	}
+	return err
+}
+
 // DaemonServiceInterface はデーモンサービスのインターフェース（テスト用）
 type DaemonServiceInterface interface {
 	StartForeground(ctx context.Context, cfg *config.Config) error
@@ -27,6 +27,9 @@ func TestNewStartCmd(t *testing.T) {
 	require.NotNil(t, foregroundFlag)
 	assert.Equal(t, "bool", foregroundFlag.Value.Type())
 
+	dryRunFlag := cmd.Flags().Lookup("dry-run")
+	require.NotNil(t, dryRunFlag)
+	assert.Equal(t, "false", dryRunFlag.DefValue)
 }
 
 func TestRunStart_ForegroundMode(t *testing.T) {
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -13,19 +15,51 @@ import (
 )
 
 func newStatusCmd() *cobra.Command {
+	var jsonOutput bool
+
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Display the current status of soba",
 		Long: `Display the current status of soba including:
 - Daemon process status
 - Tmux session information
-- Issue processing state`,
-		RunE: runStatus,
+- Issue processing state
+
+When the daemon serves its control API on .soba/soba.sock, the live status
+(current issue, phases, panes, last watch cycles and errors) is read from it.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if client := dialControl(context.Background()); client != nil {
+				return runControlStatus(cmd, client, jsonOutput)
+			}
+			if jsonOutput {
+				return fmt.Errorf("--json requires a running daemon with the control API")
+			}
+			return runStatus(cmd, args)
+		},
 	}
 
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "print the live status from the control API as JSON")
+
 	return cmd
 }
 
+// runControlStatus は制御APIから取得したデーモンの状態を表示する
+func runControlStatus(cmd *cobra.Command, client *service.ControlClient, jsonOutput bool) error {
+	status, err := client.Status(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(status)
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), formatControlStatus(status))
+	return nil
+}
+
 func runStatus(cmd *cobra.Command, args []string) error {
 	log := app.LogFactory().CreateComponentLogger("cli")
 	log.Debug(context.Background(), "Running status command")
@@ -101,7 +135,69 @@ func formatStatus(status *builder.Status) string {
 	if len(status.Issues) > 0 {
 		output.WriteString("\nActive Issues:\n")
 		for _, issue := range status.Issues {
-			output.WriteString(fmt.Sprintf("  #%d [%s] %s\n", issue.Number, issue.State, issue.Title))
+			output.WriteString(fmt.Sprintf("  #%d [%s] %s", issue.Number, issue.State, issue.Title))
+			if issue.PullRequest > 0 {
+				output.WriteString(fmt.Sprintf(" (PR #%d)", issue.PullRequest))
+			}
+			output.WriteString("\n")
+		}
+	} else {
+		output.WriteString("\nNo active issues with soba labels\n")
+	}
+
+	return output.String()
+}
+
+func formatControlStatus(status *service.ControlStatus) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Daemon Status: Running (PID: %d, Uptime: %s)\n", status.PID, status.Uptime))
+	switch {
+	case status.Draining:
+		output.WriteString("Mode: Draining\n")
+	case status.Paused:
+		output.WriteString("Mode: Paused\n")
+	}
+	if status.CurrentIssue > 0 {
+		output.WriteString(fmt.Sprintf("Current Issue: #%d\n", status.CurrentIssue))
+	}
+
+	if len(status.Watchers) > 0 {
+		names := make([]string, 0, len(status.Watchers))
+		for name := range status.Watchers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		output.WriteString("\nWatchers:\n")
+		for _, name := range names {
+			watcher := status.Watchers[name]
+			state := "alive"
+			if !watcher.Alive {
+				state = "stopped"
+			}
+			output.WriteString(fmt.Sprintf("  - %s: %s", name, state))
+			if watcher.LastCycleAt != nil {
+				output.WriteString(fmt.Sprintf(", last cycle %s (%s)", watcher.LastCycleAt.Local().Format("15:04:05"), watcher.LastCycleDuration))
+			}
+			if watcher.Errors > 0 {
+				output.WriteString(fmt.Sprintf(", %d errors, last: %s", watcher.Errors, watcher.LastError))
+			}
+			output.WriteString("\n")
+		}
+	}
+
+	if len(status.Issues) > 0 {
+		output.WriteString("\nActive Issues:\n")
+		for _, issue := range status.Issues {
+			output.WriteString(fmt.Sprintf("  #%d [%s] %s", issue.Number, issue.State, issue.Title))
+			if issue.Paused {
+				output.WriteString(" (paused)")
+			}
+			if issue.Pane != "" {
+				output.WriteString(fmt.Sprintf(" (pane %s)", issue.Pane))
+			}
+			output.WriteString("\n")
 		}
 	} else {
 		output.WriteString("\nNo active issues with soba labels\n")
@@ -2,6 +2,8 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -11,18 +13,65 @@ import (
 )
 
 func newStopCmd() *cobra.Command {
+	var drain bool
+
 	cmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the daemon process",
-		Long:  `Stop the running soba daemon process and clean up associated tmux sessions.`,
+		Long: `Stop the running soba daemon process and clean up associated tmux sessions.
+
+When the daemon serves its control API on .soba/soba.sock, the stop request is
+sent through it. With --drain the daemon stops enqueuing new issues and exits
+once the issues in progress finish.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if client := dialControl(context.Background()); client != nil {
+				return runStopWithControl(cmd, client, drain)
+			}
+			if drain {
+				return fmt.Errorf("--drain requires a running daemon with the control API")
+			}
 			return runStop(cmd, args)
 		},
 	}
 
+	cmd.Flags().BoolVar(&drain, "drain", false, "exit after the issues in progress finish")
+
 	return cmd
 }
 
+// controlStopTimeout は制御APIで停止を要求した後、デーモンの終了を待つ上限
+const controlStopTimeout = 30 * time.Second
+
+// runStopWithControl は制御APIでデーモンに停止またはドレインを要求する
+func runStopWithControl(cmd *cobra.Command, client *service.ControlClient, drain bool) error {
+	ctx := context.Background()
+
+	if drain {
+		message, err := client.Drain(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to drain daemon: %w", err)
+		}
+		cmd.Printf("%s\n", message)
+		return nil
+	}
+
+	if _, err := client.Stop(ctx); err != nil {
+		return fmt.Errorf("failed to stop daemon: %w", err)
+	}
+
+	// デーモンは自分でtmuxセッションとPIDファイルを片付け、ソケットを閉じる
+	deadline := time.Now().Add(controlStopTimeout)
+	for client.Available(ctx) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("daemon did not stop within %s", controlStopTimeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	cmd.Printf("Daemon stopped successfully\n")
+	return nil
+}
+
 func runStop(cmd *cobra.Command, args []string) error {
 	// Initialize app with minimal setup for stop command
 	// This avoids the need for config file
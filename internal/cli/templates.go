@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/config"
+)
+
+type templatesCmd struct {
+	manager   *config.ClaudeCommandsManager
+	targetDir func() (string, error)
+	upstream  bool
+	dryRun    bool
+}
+
+func newTemplatesCmd() *cobra.Command {
+	return newTemplatesCmdWithManager(config.GetClaudeCommandsManager(), defaultTemplatesDir)
+}
+
+// newTemplatesCmdWithManager builds the templates command for the given templates and directory
+func newTemplatesCmdWithManager(manager *config.ClaudeCommandsManager, targetDir func() (string, error)) *cobra.Command {
+	t := &templatesCmd{manager: manager, targetDir: targetDir}
+
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Inspect and upgrade the Claude command templates",
+		Long: `Inspect and upgrade the Claude command templates soba installed in
+.claude/commands/soba.
+
+soba records the version of each template it installs in
+.claude/commands/soba.manifest.json. Commit it together with the templates:
+it lets upgrade tell your changes from the changes in a new soba version.`,
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show which templates are customized or outdated",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return t.runStatus(cmd)
+		},
+	}
+
+	diffCmd := &cobra.Command{
+		Use:   "diff [template...]",
+		Short: "Show how the installed templates differ from this soba version",
+		Long: `Show a unified diff from each installed template to the version shipped with
+this soba. With --upstream, show what soba changed since the template was
+installed instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return t.runDiff(cmd, args)
+		},
+	}
+	diffCmd.Flags().BoolVar(&t.upstream, "upstream", false, "show the changes soba made since the templates were installed")
+
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade the templates, merging your changes",
+		Long: `Upgrade the templates to the version shipped with this soba.
+
+Missing and unchanged templates are written. A template you customized is
+merged three-way between the version soba installed, your copy and the new
+version; regions both sides changed are written with conflict markers
+(<<<<<<< yours, =======, >>>>>>> soba) for you to resolve. Templates installed
+before the manifest existed are merged the same way from the version soba
+shipped then.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return t.runUpgrade(cmd)
+		},
+	}
+	upgradeCmd.Flags().BoolVar(&t.dryRun, "dry-run", false, "show what would be done without writing anything")
+
+	cmd.AddCommand(statusCmd)
+	cmd.AddCommand(diffCmd)
+	cmd.AddCommand(upgradeCmd)
+	return cmd
+}
+
+// defaultTemplatesDir returns the Claude command directory of the current directory
+func defaultTemplatesDir() (string, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	preset, err := config.GetAgentPreset(config.DefaultAgent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(currentDir, preset.PromptDir), nil
+}
+
+func (t *templatesCmd) runStatus(cmd *cobra.Command) error {
+	dir, err := t.targetDir()
+	if err != nil {
+		return err
+	}
+	statuses, err := t.manager.Status(dir)
+	if err != nil {
+		return err
+	}
+	manifest, err := config.ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+	version, err := t.manager.Version()
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	installed := manifest.Version
+	if installed == "" {
+		installed = "unknown"
+	}
+	fmt.Fprintf(out, "Templates in %s (installed: %s, this soba: %s)\n\n", dir, installed, version)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tSTATE")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "%s\t%s\n", status.Name, status.State)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	writeTemplatesHint(out, statuses)
+	return nil
+}
+
+// writeTemplatesHint prints what to do about the templates that need attention
+func writeTemplatesHint(out io.Writer, statuses []config.TemplateStatus) {
+	for _, status := range statuses {
+		switch status.State {
+		case config.TemplateMissing, config.TemplateOutdated, config.TemplateNeedsMerge:
+			fmt.Fprintln(out, "\nRun `soba templates upgrade` to upgrade them; your changes are merged.")
+			return
+		case config.TemplateConflict:
+			fmt.Fprintln(out, "\nResolve the conflict markers left by `soba templates upgrade`.")
+			return
+		}
+	}
+}
+
+func (t *templatesCmd) runDiff(cmd *cobra.Command, names []string) error {
+	dir, err := t.targetDir()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		statuses, err := t.manager.Status(dir)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if status.State != config.TemplateUpToDate && status.State != config.TemplateMissing {
+				names = append(names, status.Name)
+			}
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".md") {
+			name += ".md"
+		}
+		diff, err := t.manager.Diff(dir, name, t.upstream)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, diff)
+	}
+	return nil
+}
+
+func (t *templatesCmd) runUpgrade(cmd *cobra.Command) error {
+	dir, err := t.targetDir()
+	if err != nil {
+		return err
+	}
+	results, err := t.manager.Upgrade(dir, t.dryRun)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tSTATE\tACTION")
+	conflicts := 0
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Name, result.State, result.Action)
+		if result.Conflicts > 0 {
+			conflicts++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if t.dryRun {
+		fmt.Fprintln(out, "\nDry run: nothing was written")
+		return nil
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("%d templates have conflicts; resolve the conflict markers in %s", conflicts, dir)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+)
+
+func newTestTemplatesManager(review string) *config.ClaudeCommandsManager {
+	return config.NewClaudeCommandsManager(fstest.MapFS{
+		"soba/plan.md":   {Data: []byte("# Plan\n")},
+		"soba/review.md": {Data: []byte(review)},
+	}, "soba")
+}
+
+func TestTemplatesCmd(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".claude", "commands", "soba")
+	require.NoError(t, newTestTemplatesManager("# Review\n\nRun the tests.\n").CopyTemplates(dir))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review.md"), []byte("# Review (team rules)\n\nRun the tests.\n"), 0644))
+	manager := newTestTemplatesManager("# Review\n\nRun the tests.\nPost the result.\n")
+
+	run := func(args ...string) (string, error) {
+		cmd := newTemplatesCmdWithManager(manager, func() (string, error) { return dir, nil })
+		buf := new(bytes.Buffer)
+		cmd.SetOut(buf)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return buf.String(), err
+	}
+
+	t.Run("status lists the state of each template", func(t *testing.T) {
+		output, err := run("status")
+		require.NoError(t, err)
+
+		assert.Regexp(t, `plan\.md\s+up to date\n`, output)
+		assert.Regexp(t, `review\.md\s+needs merge\n`, output)
+		assert.Contains(t, output, "soba templates upgrade")
+	})
+
+	t.Run("diff shows the templates that differ", func(t *testing.T) {
+		output, err := run("diff", "review")
+		require.NoError(t, err)
+
+		assert.Contains(t, output, "-# Review (team rules)\n")
+		assert.Contains(t, output, "+Post the result.\n")
+		assert.NotContains(t, output, "plan.md")
+	})
+
+	t.Run("upgrade merges the customized template", func(t *testing.T) {
+		output, err := run("upgrade")
+		require.NoError(t, err)
+
+		assert.Regexp(t, `review\.md\s+needs merge\s+merged\n`, output)
+		review, err := os.ReadFile(filepath.Join(dir, "review.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Review (team rules)\n\nRun the tests.\nPost the result.\n", string(review))
+
+		output, err = run("status")
+		require.NoError(t, err)
+		assert.Regexp(t, `review\.md\s+customized\n`, output)
+	})
+
+	t.Run("upgrade fails when conflicts are left", func(t *testing.T) {
+		manager = newTestTemplatesManager("# Review (shipped)\n\nRun the tests.\nPost the result.\n")
+
+		_, err := run("upgrade")
+		assert.EqualError(t, err, "1 templates have conflicts; resolve the conflict markers in "+dir)
+	})
+}
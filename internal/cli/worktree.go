@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/douhashi/soba/internal/infra/git"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/service"
+	"github.com/douhashi/soba/pkg/app"
+)
+
+type worktreeCmd struct {
+	newCollector func() (*service.WorktreeCollector, error)
+	dryRun       bool
+}
+
+func newWorktreeCmd() *cobra.Command {
+	w := &worktreeCmd{}
+	w.newCollector = w.defaultCollector
+
+	cmd := &cobra.Command{
+		Use:   "worktree",
+		Short: "Manage issue worktrees",
+		Long: `Inspect and clean up the worktrees soba creates under git.worktree_base_path.
+
+The daemon runs the same reconciliation periodically when
+workflow.closed_issue_cleanup_enabled is set.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List issue worktrees and what prune would do with them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.runList(cmd)
+		},
+	}
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove worktrees of closed or merged issues and stale branches",
+		Long: `Removes worktrees whose issue is closed or whose pull request is merged,
+prunes dangling git worktree metadata, deletes merged soba/N branches and
+evicts the least recently used worktrees beyond git.worktree_max_count or
+git.worktree_max_size_mb.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.runPrune(cmd)
+		},
+	}
+	pruneCmd.Flags().BoolVar(&w.dryRun, "dry-run", false, "show what would be removed without removing anything")
+
+	cmd.AddCommand(listCmd)
+	cmd.AddCommand(pruneCmd)
+	return cmd
+}
+
+func (w *worktreeCmd) runList(cmd *cobra.Command) error {
+	collector, err := w.newCollector()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := collector.List(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(statuses) == 0 {
+		fmt.Fprintln(out, "No issue worktrees found")
+		return nil
+	}
+
+	writeWorktreeTable(out, statuses)
+	return nil
+}
+
+func (w *worktreeCmd) runPrune(cmd *cobra.Command) error {
+	collector, err := w.newCollector()
+	if err != nil {
+		return err
+	}
+
+	result, err := collector.Prune(cmd.Context(), w.dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	verb := "Removed"
+	if w.dryRun {
+		verb = "Would remove"
+	}
+
+	if len(result.Removed) == 0 && len(result.DeletedBranches) == 0 {
+		fmt.Fprintln(out, "Nothing to prune")
+		return nil
+	}
+	for _, status := range result.Removed {
+		fmt.Fprintf(out, "%s worktree for issue #%d (%s): %s\n", verb, status.IssueNumber, status.Reason, status.Path)
+	}
+	for _, branch := range result.DeletedBranches {
+		if w.dryRun {
+			fmt.Fprintf(out, "Would delete branch %s\n", branch)
+		} else {
+			fmt.Fprintf(out, "Deleted branch %s\n", branch)
+		}
+	}
+	return nil
+}
+
+// defaultCollector builds a collector for the repository in the current directory
+func (w *worktreeCmd) defaultCollector() (*service.WorktreeCollector, error) {
+	cfg := app.Config()
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	gitClient, err := git.NewClient(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize git client: %w", err)
+	}
+
+	// Without GitHub access only size limits and merged branches are handled
+	var issues service.WorktreeIssueReader
+	githubClient, err := github.NewClient(github.NewDefaultTokenProvider(), &github.ClientOptions{
+		Logger: app.LogFactory().CreateComponentLogger("github-client"),
+	})
+	if err == nil {
+		issues = githubClient
+	}
+
+	collector := service.NewWorktreeCollector(gitClient, issues, cfg)
+	collector.SetLogger(app.LogFactory().CreateComponentLogger("worktree"))
+	return collector, nil
+}
+
+func writeWorktreeTable(out io.Writer, statuses []service.WorktreeStatus) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ISSUE\tBRANCH\tSIZE\tLAST USED\tSTATE\tACTION")
+	for _, status := range statuses {
+		state := status.IssueState
+		if state == "" {
+			state = "unknown"
+		}
+		if status.Merged {
+			state = "merged"
+		}
+
+		action := "keep"
+		if status.Action != service.WorktreeActionKeep {
+			action = fmt.Sprintf("%s (%s)", status.Action, status.Reason)
+		}
+
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\t%s\t%s\n",
+			status.IssueNumber,
+			status.Branch,
+			formatBytes(status.SizeBytes),
+			formatLastUsed(status.LastUsed),
+			state,
+			action,
+		)
+	}
+	tw.Flush()
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func formatLastUsed(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
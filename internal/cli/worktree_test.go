@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/service"
+)
+
+func TestNewWorktreeCmd(t *testing.T) {
+	cmd := newWorktreeCmd()
+	require.NotNil(t, cmd)
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs([]string{"prune", "--help"})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "--dry-run")
+}
+
+func TestWriteWorktreeTable(t *testing.T) {
+	var buf bytes.Buffer
+	writeWorktreeTable(&buf, []service.WorktreeStatus{
+		{
+			IssueNumber: 3,
+			Branch:      "soba/3",
+			SizeBytes:   2048,
+			LastUsed:    time.Date(2025, 1, 2, 3, 4, 0, 0, time.Local),
+			IssueState:  "open",
+			Active:      true,
+		},
+		{
+			IssueNumber: 5,
+			Branch:      "soba/5",
+			SizeBytes:   512,
+			Merged:      true,
+			Action:      service.WorktreeActionRemove,
+			Reason:      "pull request merged",
+		},
+	})
+
+	output := buf.String()
+	assert.Contains(t, output, "ISSUE")
+	assert.Contains(t, output, "#3")
+	assert.Contains(t, output, "2.0 KB")
+	assert.Contains(t, output, "2025-01-02 03:04")
+	assert.Contains(t, output, "keep")
+	assert.Contains(t, output, "merged")
+	assert.Contains(t, output, "remove (pull request merged)")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KB", formatBytes(1536))
+	assert.Equal(t, "3.0 MB", formatBytes(3*1024*1024))
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAgent is the agent preset used when none is specified.
+const DefaultAgent = "claude"
+
+// Prompt file formats written by agent presets.
+const (
+	// PromptFormatClaude copies the embedded templates as Claude Code slash commands.
+	PromptFormatClaude = "claude"
+	// PromptFormatGeminiTOML converts the templates into Gemini CLI custom commands.
+	PromptFormatGeminiTOML = "gemini-toml"
+	// PromptFormatMarkdown writes the template bodies as plain Markdown instructions.
+	PromptFormatMarkdown = "markdown"
+)
+
+// phaseNames lists the phases that run an agent command, in workflow order.
+var phaseNames = []string{"plan", "implement", "review", "revise"}
+
+// AgentPreset describes how a CLI coding agent is started for each phase and
+// where its phase prompts live in the repository.
+type AgentPreset struct {
+	Name        string
+	Description string
+	Command     string
+	Options     []string
+	// ParameterFormat is formatted with the phase name to build the phase parameter.
+	// It must not contain $: the config file goes through environment variable expansion.
+	ParameterFormat string
+	// PromptDir is the directory, relative to the repository root, that holds the phase prompts.
+	PromptDir    string
+	PromptFormat string
+}
+
+var agentPresets = map[string]AgentPreset{
+	"claude": {
+		Name:            "claude",
+		Description:     "Claude Code slash commands in .claude/commands/soba",
+		Command:         "claude",
+		Options:         []string{"--dangerously-skip-permissions"},
+		ParameterFormat: "/soba:%s {{issue-number}}",
+		PromptDir:       filepath.Join(".claude", "commands", "soba"),
+		PromptFormat:    PromptFormatClaude,
+	},
+	"gemini": {
+		Name:            "gemini",
+		Description:     "Gemini CLI custom commands in .gemini/commands/soba",
+		Command:         "gemini",
+		Options:         []string{"--yolo", "--prompt-interactive"},
+		ParameterFormat: "/soba:%s {{issue-number}}",
+		PromptDir:       filepath.Join(".gemini", "commands", "soba"),
+		PromptFormat:    PromptFormatGeminiTOML,
+	},
+	"codex": {
+		Name:            "codex",
+		Description:     "Codex CLI with Markdown instructions in .soba/prompts",
+		Command:         "codex",
+		Options:         []string{"--dangerously-bypass-approvals-and-sandbox"},
+		ParameterFormat: "Follow the instructions in .soba/prompts/%s.md, reading the ARGUMENTS placeholder in them as {{issue-number}}.",
+		PromptDir:       filepath.Join(".soba", "prompts"),
+		PromptFormat:    PromptFormatMarkdown,
+	},
+}
+
+// GetAgentPreset returns the named agent preset.
+func GetAgentPreset(name string) (AgentPreset, error) {
+	preset, ok := agentPresets[name]
+	if !ok {
+		return AgentPreset{}, fmt.Errorf("unknown agent %q (available: %s)", name, strings.Join(AgentPresetNames(), ", "))
+	}
+	return preset, nil
+}
+
+// AgentPresetNames returns the names of the built-in agent presets in sorted order.
+func AgentPresetNames() []string {
+	names := make([]string, 0, len(agentPresets))
+	for name := range agentPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PhaseCommands returns the phase commands the preset writes into the config template.
+func (p AgentPreset) PhaseCommands() []TemplatePhase {
+	phases := make([]TemplatePhase, 0, len(phaseNames))
+	for _, phase := range phaseNames {
+		phases = append(phases, TemplatePhase{
+			Name:      phase,
+			Command:   p.Command,
+			Options:   p.Options,
+			Parameter: fmt.Sprintf(p.ParameterFormat, phase),
+		})
+	}
+	return phases
+}
+
+// WritePrompts writes the phase prompts from the embedded templates under rootDir
+// in the layout the agent expects. Existing files are left untouched.
+func (p AgentPreset) WritePrompts(manager *ClaudeCommandsManager, rootDir string) error {
+	targetDir := filepath.Join(rootDir, p.PromptDir)
+	if p.PromptFormat == PromptFormatClaude {
+		return manager.CopyTemplates(targetDir)
+	}
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create target directory: %w", err)
+	}
+
+	templates, err := manager.ListTemplates()
+	if err != nil {
+		return err
+	}
+
+	for _, tmpl := range templates {
+		reader, err := manager.GetTemplate(tmpl)
+		if err != nil {
+			continue
+		}
+		source, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", tmpl, err)
+		}
+
+		name, content, err := p.convertPrompt(tmpl, source)
+		if err != nil {
+			return err
+		}
+
+		targetPath := filepath.Join(targetDir, name)
+		if _, err := os.Stat(targetPath); err == nil {
+			// File exists, skip
+			continue
+		}
+		if err := os.WriteFile(targetPath, content, 0644); err != nil {
+			return fmt.Errorf("failed to create file %s: %w", targetPath, err)
+		}
+	}
+
+	return nil
+}
+
+// convertPrompt converts a Claude command template into the preset's prompt format.
+func (p AgentPreset) convertPrompt(filename string, source []byte) (string, []byte, error) {
+	description, body := splitPromptFrontMatter(string(source))
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	switch p.PromptFormat {
+	case PromptFormatGeminiTOML:
+		body = strings.ReplaceAll(body, "$ARGUMENTS", "{{args}}")
+		if strings.Contains(body, "'''") {
+			return "", nil, fmt.Errorf("template %s cannot be written as a TOML literal string", filename)
+		}
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "description = %q\n", description)
+		fmt.Fprintf(&b, "prompt = '''\n%s'''\n", body)
+		return base + ".toml", b.Bytes(), nil
+	case PromptFormatMarkdown:
+		return base + ".md", []byte(body), nil
+	default:
+		return "", nil, fmt.Errorf("unknown prompt format %q", p.PromptFormat)
+	}
+}
+
+// splitPromptFrontMatter separates the YAML front matter of a Claude command
+// template and returns its description and the remaining body.
+func splitPromptFrontMatter(source string) (string, string) {
+	if !strings.HasPrefix(source, "---\n") {
+		return "", source
+	}
+	rest := source[len("---\n"):]
+	end := strings.Index(rest, "\n---\n")
+	if end < 0 {
+		return "", source
+	}
+
+	var frontMatter struct {
+		Description string `yaml:"description"`
+	}
+	_ = yaml.Unmarshal([]byte(rest[:end]), &frontMatter)
+	return frontMatter.Description, strings.TrimLeft(rest[end+len("\n---\n"):], "\n")
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGetAgentPreset(t *testing.T) {
+	for _, name := range AgentPresetNames() {
+		preset, err := GetAgentPreset(name)
+		if err != nil {
+			t.Fatalf("GetAgentPreset(%q) error = %v", name, err)
+		}
+		if preset.Name != name {
+			t.Errorf("preset.Name = %q, want %q", preset.Name, name)
+		}
+	}
+
+	if _, err := GetAgentPreset("unknown"); err == nil || !strings.Contains(err.Error(), "available: claude, codex, gemini") {
+		t.Errorf("GetAgentPreset(unknown) error = %v", err)
+	}
+}
+
+func TestAgentPresetTemplate(t *testing.T) {
+	for _, name := range AgentPresetNames() {
+		t.Run(name, func(t *testing.T) {
+			preset, _ := GetAgentPreset(name)
+			rendered, err := NewTemplateManager().RenderTemplate(&TemplateOptions{
+				Repository: "owner/repo",
+				Phases:     preset.PhaseCommands(),
+			})
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+
+			var cfg Config
+			if err := yaml.Unmarshal([]byte(rendered), &cfg); err != nil {
+				t.Fatalf("rendered template is not valid YAML: %v", err)
+			}
+			for _, phase := range phaseNames {
+				command := cfg.Phase.ForPhase(phase)
+				if command.Command != preset.Command {
+					t.Errorf("%s command = %q, want %q", phase, command.Command, preset.Command)
+				}
+				if !strings.Contains(command.Parameter, "{{issue-number}}") {
+					t.Errorf("%s parameter = %q, want issue number placeholder", phase, command.Parameter)
+				}
+			}
+
+			// The parameters go through environment variable expansion when the config is loaded
+			var loaded *Config
+			stderr := captureStderr(t, func() {
+				var err error
+				if loaded, err = Load(writeConfig(t, rendered)); err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+			})
+			if stderr != "" {
+				t.Errorf("loading the rendered template printed %q", stderr)
+			}
+			for _, phase := range phaseNames {
+				if got, want := loaded.Phase.ForPhase(phase).Parameter, fmt.Sprintf(preset.ParameterFormat, phase); got != want {
+					t.Errorf("loaded %s parameter = %q, want %q", phase, got, want)
+				}
+			}
+		})
+	}
+}
+
+// captureStderr returns what fn writes to os.Stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	os.Stderr = w
+	defer func() { os.Stderr = oldStderr }()
+
+	fn()
+
+	_ = w.Close()
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+	return buf.String()
+}
+
+func TestAgentPresetConvertPrompt(t *testing.T) {
+	source := []byte("---\nallowed-tools: Bash\ndescription: \"Review PR\"\n---\n\n## Overview\n\nIssue number: $ARGUMENTS\n")
+
+	gemini, _ := GetAgentPreset("gemini")
+	name, content, err := gemini.convertPrompt("review.md", source)
+	if err != nil {
+		t.Fatalf("convertPrompt() error = %v", err)
+	}
+	want := "description = \"Review PR\"\nprompt = '''\n## Overview\n\nIssue number: {{args}}\n'''\n"
+	if name != "review.toml" || string(content) != want {
+		t.Errorf("convertPrompt() = %q, %q, want review.toml, %q", name, content, want)
+	}
+
+	codex, _ := GetAgentPreset("codex")
+	name, content, err = codex.convertPrompt("review.md", source)
+	if err != nil {
+		t.Fatalf("convertPrompt() error = %v", err)
+	}
+	if name != "review.md" || string(content) != "## Overview\n\nIssue number: $ARGUMENTS\n" {
+		t.Errorf("convertPrompt() = %q, %q", name, content)
+	}
+
+	if _, _, err := gemini.convertPrompt("bad.md", []byte("'''")); err == nil {
+		t.Errorf("convertPrompt() should reject content containing '''")
+	}
+}
@@ -1,7 +1,6 @@
 package config
 
 import (
-	"embed"
 	"fmt"
 	"io"
 	"io/fs"
@@ -12,12 +11,17 @@ import (
 
 // ClaudeCommandsManager manages Claude command templates using embedded file system
 type ClaudeCommandsManager struct {
-	embedFS  embed.FS
+	embedFS  fs.FS
 	rootPath string
+	// legacyHashes holds the SHA-256 of each template shipped before soba
+	// recorded a manifest, the merge base of the templates installed without
+	// one. legacyPath holds the content of those that changed since.
+	legacyHashes map[string]string
+	legacyPath   string
 }
 
 // NewClaudeCommandsManager creates a new ClaudeCommandsManager
-func NewClaudeCommandsManager(embedFS embed.FS, rootPath string) *ClaudeCommandsManager {
+func NewClaudeCommandsManager(embedFS fs.FS, rootPath string) *ClaudeCommandsManager {
 	return &ClaudeCommandsManager{
 		embedFS:  embedFS,
 		rootPath: rootPath,
@@ -106,7 +110,8 @@ func (m *ClaudeCommandsManager) CopyTemplates(targetDir string) error {
 		}
 	}
 
-	return nil
+	// Record the installed templates so that soba templates upgrade can merge later versions
+	return m.recordInstalled(targetDir)
 }
 
 // ValidateTemplates checks if the embedded templates are accessible
@@ -0,0 +1,419 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// TemplateState describes an installed command template compared with the
+// version soba installed and the version this soba ships.
+type TemplateState string
+
+const (
+	// TemplateUpToDate is a template identical to the shipped version.
+	TemplateUpToDate TemplateState = "up to date"
+	// TemplateCustomized is a template the user changed while the shipped version stayed the same.
+	TemplateCustomized TemplateState = "customized"
+	// TemplateOutdated is an unchanged template whose shipped version changed.
+	TemplateOutdated TemplateState = "outdated"
+	// TemplateNeedsMerge is a customized template whose shipped version changed as well.
+	TemplateNeedsMerge TemplateState = "needs merge"
+	// TemplateConflict is a template with conflict markers left by an upgrade.
+	TemplateConflict TemplateState = "conflict"
+	// TemplateUntracked is a template installed without a manifest entry that
+	// differs from the shipped version and has no version shipped before the
+	// manifest, so soba cannot tell what the user changed.
+	TemplateUntracked TemplateState = "untracked"
+	// TemplateMissing is a shipped template that is not installed.
+	TemplateMissing TemplateState = "missing"
+)
+
+// TemplateManifest records the version of the templates soba installed in a
+// directory and the shipped content of each, the base of the three-way merge
+// done by Upgrade. It is stored next to the directory, see ManifestPath.
+type TemplateManifest struct {
+	Version   string                   `json:"version"`
+	Templates map[string]TemplateEntry `json:"templates"`
+}
+
+// TemplateEntry is a template as shipped by the soba version that installed it.
+type TemplateEntry struct {
+	SHA256  string `json:"sha256"`
+	Content string `json:"content"`
+}
+
+// TemplateStatus is the state of one template in a directory.
+type TemplateStatus struct {
+	Name  string
+	State TemplateState
+}
+
+// TemplateUpgrade is what Upgrade did to one template.
+type TemplateUpgrade struct {
+	Name   string
+	State  TemplateState
+	Action string
+	// Conflicts is the number of regions written with conflict markers.
+	Conflicts int
+}
+
+// ManifestPath returns the manifest file of the templates installed in
+// targetDir, e.g. .claude/commands/soba.manifest.json. It is kept outside
+// the directory so that the agent does not load it as a command.
+func ManifestPath(targetDir string) string {
+	return filepath.Clean(targetDir) + ".manifest.json"
+}
+
+// ReadManifest reads the manifest of targetDir. A missing manifest is
+// returned empty.
+func ReadManifest(targetDir string) (*TemplateManifest, error) {
+	manifest := &TemplateManifest{Templates: map[string]TemplateEntry{}}
+	data, err := os.ReadFile(ManifestPath(targetDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestPath(targetDir), err)
+	}
+	if manifest.Templates == nil {
+		manifest.Templates = map[string]TemplateEntry{}
+	}
+	return manifest, nil
+}
+
+func writeManifest(targetDir string, manifest *TemplateManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ManifestPath(targetDir), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write template manifest: %w", err)
+	}
+	return nil
+}
+
+// Version returns the version of the shipped templates, derived from their
+// names and content so that it only changes when a template changes.
+func (m *ClaudeCommandsManager) Version() (string, error) {
+	shipped, err := m.shippedTemplates()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, name := range sortedKeys(shipped) {
+		fmt.Fprintf(hash, "%s\x00%s\x00", name, shipped[name])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12], nil
+}
+
+// Status compares the templates installed in targetDir with the manifest
+// and the shipped templates.
+func (m *ClaudeCommandsManager) Status(targetDir string) ([]TemplateStatus, error) {
+	shipped, err := m.shippedTemplates()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := ReadManifest(targetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]TemplateStatus, 0, len(shipped))
+	for _, name := range sortedKeys(shipped) {
+		installed, err := readInstalled(targetDir, name)
+		if err != nil {
+			return nil, err
+		}
+		base, err := m.installedBase(name, shipped[name], manifest)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, TemplateStatus{Name: name, State: templateState(installed, shipped[name], base)})
+	}
+	return statuses, nil
+}
+
+// templateState classifies an installed template. installed is nil when the
+// template is not installed, and base is nil when soba does not know the
+// version it was installed from.
+func templateState(installed []byte, shipped string, base *TemplateEntry) TemplateState {
+	if installed == nil {
+		return TemplateMissing
+	}
+	if string(installed) == shipped {
+		return TemplateUpToDate
+	}
+	if hasConflictMarkers(string(installed)) {
+		return TemplateConflict
+	}
+	if base == nil {
+		return TemplateUntracked
+	}
+
+	switch {
+	case sha256Hex(string(installed)) == base.SHA256:
+		return TemplateOutdated
+	case sha256Hex(shipped) == base.SHA256:
+		return TemplateCustomized
+	default:
+		return TemplateNeedsMerge
+	}
+}
+
+// installedBase returns the version of a template soba installed in a
+// directory: the manifest entry, or for a template installed before the
+// manifest existed, the version shipped then. It returns nil when neither
+// is known.
+func (m *ClaudeCommandsManager) installedBase(name, shipped string, manifest *TemplateManifest) (*TemplateEntry, error) {
+	if entry, ok := manifest.Templates[name]; ok {
+		return &entry, nil
+	}
+	hash, ok := m.legacyHashes[name]
+	if !ok {
+		return nil, nil
+	}
+	if sha256Hex(shipped) == hash {
+		return &TemplateEntry{SHA256: hash, Content: shipped}, nil
+	}
+	content, err := fs.ReadFile(m.embedFS, path.Join(m.legacyPath, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy template %s: %w", name, err)
+	}
+	return &TemplateEntry{SHA256: hash, Content: string(content)}, nil
+}
+
+// Diff returns a unified diff of a template installed in targetDir against
+// the shipped version. With upstream it shows the changes soba made to the
+// template since it was installed instead.
+func (m *ClaudeCommandsManager) Diff(targetDir, name string, upstream bool) (string, error) {
+	shipped, err := m.shippedTemplates()
+	if err != nil {
+		return "", err
+	}
+	content, ok := shipped[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %s", name)
+	}
+	version, err := m.Version()
+	if err != nil {
+		return "", err
+	}
+
+	diff := difflib.UnifiedDiff{
+		B:        difflib.SplitLines(content),
+		ToFile:   "soba " + version + "/" + name,
+		FromFile: filepath.Join(targetDir, name),
+		Context:  3,
+	}
+	if upstream {
+		manifest, err := ReadManifest(targetDir)
+		if err != nil {
+			return "", err
+		}
+		base, err := m.installedBase(name, content, manifest)
+		if err != nil {
+			return "", err
+		}
+		if base == nil {
+			return "", fmt.Errorf("no record of the installed version of %s in %s", name, ManifestPath(targetDir))
+		}
+		diff.A = difflib.SplitLines(base.Content)
+		diff.FromFile = "soba " + manifest.Version + "/" + name
+		if _, tracked := manifest.Templates[name]; !tracked {
+			diff.FromFile = "soba (before the manifest)/" + name
+		}
+	} else {
+		installed, err := readInstalled(targetDir, name)
+		if err != nil {
+			return "", err
+		}
+		diff.A = difflib.SplitLines(string(installed))
+	}
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// Upgrade brings the templates in targetDir to the shipped version without
+// losing changes made by the user. Missing and unchanged templates are
+// written, customized templates are merged three-way between the version in
+// the manifest, the user's copy and the shipped version, and regions changed
+// on both sides are written with conflict markers. Templates installed
+// before the manifest existed are merged from the version shipped then.
+// Untracked templates are kept and recorded as customized from the shipped
+// version. The manifest is updated unless dryRun is set.
+func (m *ClaudeCommandsManager) Upgrade(targetDir string, dryRun bool) ([]TemplateUpgrade, error) {
+	shipped, err := m.shippedTemplates()
+	if err != nil {
+		return nil, err
+	}
+	version, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := ReadManifest(targetDir)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := os.MkdirAll(targetDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create target directory: %w", err)
+		}
+	}
+
+	upgraded := &TemplateManifest{Version: version, Templates: map[string]TemplateEntry{}}
+	var results []TemplateUpgrade
+	for _, name := range sortedKeys(shipped) {
+		content := shipped[name]
+		installed, err := readInstalled(targetDir, name)
+		if err != nil {
+			return nil, err
+		}
+		base, err := m.installedBase(name, content, manifest)
+		if err != nil {
+			return nil, err
+		}
+		result := TemplateUpgrade{Name: name, State: templateState(installed, content, base)}
+		upgraded.Templates[name] = TemplateEntry{SHA256: sha256Hex(content), Content: content}
+
+		write, output := false, content
+		switch result.State {
+		case TemplateUpToDate:
+			result.Action = "unchanged"
+		case TemplateCustomized:
+			result.Action = "kept your changes"
+		case TemplateMissing:
+			result.Action, write = "installed", true
+		case TemplateOutdated:
+			result.Action, write = "updated", true
+		case TemplateNeedsMerge:
+			merged, conflicts := mergeText(base.Content, string(installed), content, version)
+			result.Conflicts = conflicts
+			if conflicts > 0 {
+				result.Action = fmt.Sprintf("merged with %d conflicts", conflicts)
+			} else {
+				result.Action = "merged"
+			}
+			write, output = true, merged
+		case TemplateConflict:
+			// The earlier upgrade already recorded the version the conflicts were merged with
+			result.Action = "kept; resolve the conflict markers"
+			if entry, ok := manifest.Templates[name]; ok {
+				upgraded.Templates[name] = entry
+			}
+		case TemplateUntracked:
+			result.Action = "kept; compare it with `soba templates diff`"
+		}
+		results = append(results, result)
+
+		if !write || dryRun {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(targetDir, name), []byte(output), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+	return results, writeManifest(targetDir, upgraded)
+}
+
+// recordInstalled adds the templates in targetDir that are identical to the
+// shipped version to the manifest.
+func (m *ClaudeCommandsManager) recordInstalled(targetDir string) error {
+	shipped, err := m.shippedTemplates()
+	if err != nil {
+		return err
+	}
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	manifest, err := ReadManifest(targetDir)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, name := range sortedKeys(shipped) {
+		installed, err := readInstalled(targetDir, name)
+		if err != nil {
+			return err
+		}
+		if installed == nil || string(installed) != shipped[name] {
+			continue
+		}
+		entry := TemplateEntry{SHA256: sha256Hex(shipped[name]), Content: shipped[name]}
+		if manifest.Templates[name] != entry {
+			manifest.Templates[name] = entry
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	// The version is the newest shipped version any template was installed from
+	manifest.Version = version
+	return writeManifest(targetDir, manifest)
+}
+
+// shippedTemplates returns the content of the shipped templates by name.
+func (m *ClaudeCommandsManager) shippedTemplates() (map[string]string, error) {
+	names, err := m.ListTemplates()
+	if err != nil {
+		return nil, err
+	}
+	shipped := make(map[string]string, len(names))
+	for _, name := range names {
+		reader, err := m.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		shipped[name] = string(content)
+	}
+	return shipped, nil
+}
+
+// readInstalled reads an installed template, returning nil when it does not exist.
+func readInstalled(targetDir, name string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(targetDir, name)) // #nosec G304 - the name comes from the shipped templates
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return content, nil
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTemplates returns a manager shipping the given templates.
+func newTestTemplates(templates map[string]string) *ClaudeCommandsManager {
+	fsys := fstest.MapFS{}
+	for name, content := range templates {
+		fsys["soba/"+name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return &ClaudeCommandsManager{embedFS: fsys, rootPath: "soba"}
+}
+
+const (
+	reviewV1 = "# Review\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n"
+	reviewV2 = "# Review\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n4. Add the label\n"
+	planV1   = "# Plan\n\nWrite a plan.\n"
+	planV2   = "# Plan\n\nWrite a short plan.\n"
+)
+
+func TestClaudeCommandsManager_Upgrade(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".claude", "commands", "soba")
+	v1 := newTestTemplates(map[string]string{"review.md": reviewV1, "plan.md": planV1, "revise.md": "# Revise\n"})
+	require.NoError(t, v1.CopyTemplates(dir))
+
+	manifest, err := ReadManifest(dir)
+	require.NoError(t, err)
+	version, err := v1.Version()
+	require.NoError(t, err)
+	assert.Equal(t, version, manifest.Version)
+	assert.Equal(t, TemplateEntry{SHA256: sha256Hex(reviewV1), Content: reviewV1}, manifest.Templates["review.md"])
+
+	// The user customizes review.md and plan.md; the next soba changes both and removes revise.md
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review.md"), []byte("# Review (team rules)\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan.md"), []byte("# Plan\n\nWrite a detailed plan.\n"), 0644))
+	v2 := newTestTemplates(map[string]string{"review.md": reviewV2, "plan.md": planV2, "implement.md": "# Implement\n"})
+
+	statuses, err := v2.Status(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []TemplateStatus{
+		{Name: "implement.md", State: TemplateMissing},
+		{Name: "plan.md", State: TemplateNeedsMerge},
+		{Name: "review.md", State: TemplateNeedsMerge},
+	}, statuses)
+
+	dryRun, err := v2.Upgrade(dir, true)
+	require.NoError(t, err)
+	assert.Len(t, dryRun, 3)
+	assert.NoFileExists(t, filepath.Join(dir, "implement.md"))
+
+	results, err := v2.Upgrade(dir, false)
+	require.NoError(t, err)
+	v2Version, err := v2.Version()
+	require.NoError(t, err)
+	assert.Equal(t, []TemplateUpgrade{
+		{Name: "implement.md", State: TemplateMissing, Action: "installed"},
+		{Name: "plan.md", State: TemplateNeedsMerge, Action: "merged with 1 conflicts", Conflicts: 1},
+		{Name: "review.md", State: TemplateNeedsMerge, Action: "merged"},
+	}, results)
+
+	review, err := os.ReadFile(filepath.Join(dir, "review.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Review (team rules)\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n4. Add the label\n", string(review))
+	plan, err := os.ReadFile(filepath.Join(dir, "plan.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Plan\n\n<<<<<<< yours\nWrite a detailed plan.\n=======\nWrite a short plan.\n>>>>>>> soba "+v2Version+"\n", string(plan))
+
+	statuses, err = v2.Status(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []TemplateStatus{
+		{Name: "implement.md", State: TemplateUpToDate},
+		{Name: "plan.md", State: TemplateConflict},
+		{Name: "review.md", State: TemplateCustomized},
+	}, statuses)
+	manifest, err = ReadManifest(dir)
+	require.NoError(t, err)
+	assert.Equal(t, v2Version, manifest.Version)
+	assert.Equal(t, reviewV2, manifest.Templates["review.md"].Content)
+	assert.NotContains(t, manifest.Templates, "revise.md")
+}
+
+func TestClaudeCommandsManager_StatusWithoutManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan.md"), []byte(planV1), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review.md"), []byte("customized"), 0644))
+	manager := newTestTemplates(map[string]string{"plan.md": planV1, "review.md": reviewV1})
+
+	statuses, err := manager.Status(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []TemplateStatus{
+		{Name: "plan.md", State: TemplateUpToDate},
+		{Name: "review.md", State: TemplateUntracked},
+	}, statuses)
+
+	// Untracked templates are kept and merged from the current version on
+	results, err := manager.Upgrade(dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, TemplateUntracked, results[1].State)
+	review, err := os.ReadFile(filepath.Join(dir, "review.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "customized", string(review))
+	statuses, err = manager.Status(dir)
+	require.NoError(t, err)
+	assert.Equal(t, TemplateCustomized, statuses[1].State)
+}
+
+func TestClaudeCommandsManager_Diff(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, newTestTemplates(map[string]string{"review.md": reviewV1}).CopyTemplates(dir))
+	manager := newTestTemplates(map[string]string{"review.md": reviewV2})
+	version, err := manager.Version()
+	require.NoError(t, err)
+
+	diff, err := manager.Diff(dir, "review.md", false)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- "+filepath.Join(dir, "review.md")+"\n+++ soba "+version+"/review.md\n")
+	assert.Contains(t, diff, "+4. Add the label\n")
+
+	upstream, err := manager.Diff(dir, "review.md", true)
+	require.NoError(t, err)
+	assert.Contains(t, upstream, "+4. Add the label\n")
+
+	_, err = manager.Diff(dir, "unknown.md", false)
+	assert.EqualError(t, err, "unknown template unknown.md")
+}
+
+func TestClaudeCommandsManager_LegacyTemplates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan.md"), []byte(planV1), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "review.md"), []byte("# Review (team rules)\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n"), 0644))
+	manager := newTestTemplates(map[string]string{"plan.md": planV2, "review.md": reviewV2, "revise.md": "# Revise\n"})
+	manager.embedFS.(fstest.MapFS)["legacy/plan.md"] = &fstest.MapFile{Data: []byte(planV1)}
+	manager.embedFS.(fstest.MapFS)["legacy/review.md"] = &fstest.MapFile{Data: []byte(reviewV1)}
+	manager.legacyHashes = map[string]string{
+		"plan.md":   sha256Hex(planV1),
+		"review.md": sha256Hex(reviewV1),
+		"revise.md": sha256Hex("# Revise\n"),
+	}
+	manager.legacyPath = "legacy"
+	// revise.md did not change, so only its hash is kept
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "revise.md"), []byte("# Revise (team rules)\n"), 0644))
+
+	// Templates installed before the manifest are compared with the version shipped then
+	statuses, err := manager.Status(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []TemplateStatus{
+		{Name: "plan.md", State: TemplateOutdated},
+		{Name: "review.md", State: TemplateNeedsMerge},
+		{Name: "revise.md", State: TemplateCustomized},
+	}, statuses)
+
+	upstream, err := manager.Diff(dir, "review.md", true)
+	require.NoError(t, err)
+	assert.Contains(t, upstream, "--- soba (before the manifest)/review.md\n")
+	assert.Contains(t, upstream, "+4. Add the label\n")
+
+	results, err := manager.Upgrade(dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, []TemplateUpgrade{
+		{Name: "plan.md", State: TemplateOutdated, Action: "updated"},
+		{Name: "review.md", State: TemplateNeedsMerge, Action: "merged"},
+		{Name: "revise.md", State: TemplateCustomized, Action: "kept your changes"},
+	}, results)
+	review, err := os.ReadFile(filepath.Join(dir, "review.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Review (team rules)\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n4. Add the label\n", string(review))
+}
+
+func TestLegacyTemplates(t *testing.T) {
+	manager := GetClaudeCommandsManager()
+	shipped, err := manager.shippedTemplates()
+	require.NoError(t, err)
+
+	// Every template shipped before the manifest has a legacy version to merge
+	// from, and only the templates changed since embed their old content
+	for name, content := range shipped {
+		base, err := manager.installedBase(name, content, &TemplateManifest{})
+		require.NoError(t, err)
+		require.NotNil(t, base, name)
+		assert.Equal(t, base.SHA256, sha256Hex(base.Content), name)
+		_, err = fs.Stat(manager.embedFS, path.Join(manager.legacyPath, name))
+		assert.Equal(t, base.Content != content, err == nil, name)
+	}
+
+	// A customized revise.md from before the manifest gets the lines added since
+	base, err := manager.installedBase("revise.md", shipped["revise.md"], &TemplateManifest{})
+	require.NoError(t, err)
+	assert.Equal(t, "37f01f6427cb9b7f77ce42f470d12817c6cdd320089d3b7a187e6765c8a6dabe", base.SHA256)
+	dir := t.TempDir()
+	customized := strings.Replace(base.Content, "# Revise PR\n", "# Revise PR (team rules)\n", 1)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "revise.md"), []byte(customized), 0644))
+
+	results, err := manager.Upgrade(dir, false)
+	require.NoError(t, err)
+	for _, result := range results {
+		if result.Name == "revise.md" {
+			assert.Equal(t, TemplateUpgrade{Name: "revise.md", State: TemplateNeedsMerge, Action: "merged"}, result)
+		}
+	}
+	revise, err := os.ReadFile(filepath.Join(dir, "revise.md"))
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(shipped["revise.md"], "# Revise PR\n", "# Revise PR (team rules)\n", 1), string(revise))
+}
@@ -4,44 +4,124 @@ package config
 import (
 	"fmt"
 	"os"
-
-	yaml "gopkg.in/yaml.v3"
-
-	"github.com/douhashi/soba/internal/infra"
+	"strings"
 )
 
 type Config struct {
-	GitHub   GitHubConfig   `yaml:"github"`
-	Workflow WorkflowConfig `yaml:"workflow"`
-	Slack    SlackConfig    `yaml:"slack"`
-	Git      GitConfig      `yaml:"git"`
-	Phase    PhaseConfig    `yaml:"phase"`
-	Log      LogConfig      `yaml:"log"`
+	Version  int                    `yaml:"version,omitempty"` // config file format version, see CurrentConfigVersion
+	GitHub   GitHubConfig           `yaml:"github"`
+	Workflow WorkflowConfig         `yaml:"workflow"`
+	Slack    SlackConfig            `yaml:"slack"`
+	Git      GitConfig              `yaml:"git"`
+	Phase    PhaseConfig            `yaml:"phase"`
+	Profiles map[string]PhaseConfig `yaml:"profiles"`
+	Log      LogConfig              `yaml:"log"`
+	Metrics  MetricsConfig          `yaml:"metrics"`
+
+	// secrets are the fields marked !secret or read from a secret reference
+	secrets map[string]bool
+	// warnings are reported by LoadLayers for the caller to show
+	warnings []string
+}
+
+// Warnings returns what LoadLayers found worth telling the user about a
+// config that still loads, e.g. an outdated version.
+func (c *Config) Warnings() []string {
+	if c == nil {
+		return nil
+	}
+	return c.warnings
 }
 
 type GitHubConfig struct {
-	Token      string `yaml:"token"`
+	Token      string `yaml:"token" secret:"true"`
 	Repository string `yaml:"repository"`
 	AuthMethod string `yaml:"auth_method"`
 }
 
 type WorkflowConfig struct {
-	Interval                   int  `yaml:"interval"`
-	UseTmux                    bool `yaml:"use_tmux"`
-	AutoMergeEnabled           bool `yaml:"auto_merge_enabled"`
-	ClosedIssueCleanupEnabled  bool `yaml:"closed_issue_cleanup_enabled"`
-	ClosedIssueCleanupInterval int  `yaml:"closed_issue_cleanup_interval"`
-	TmuxCommandDelay           int  `yaml:"tmux_command_delay"`
+	Interval                   int               `yaml:"interval"`
+	UseTmux                    bool              `yaml:"use_tmux"`
+	AutoMergeEnabled           bool              `yaml:"auto_merge_enabled"`
+	ClosedIssueCleanupEnabled  bool              `yaml:"closed_issue_cleanup_enabled"`
+	ClosedIssueCleanupInterval int               `yaml:"closed_issue_cleanup_interval"`
+	TmuxCommandDelay           int               `yaml:"tmux_command_delay"`
+	BranchUpdateMethod         string            `yaml:"branch_update_method"` // "api", "rebase" or "none"
+	RequestChangesOnConflict   bool              `yaml:"request_changes_on_conflict"`
+	PostMerge                  PostMergeConfig   `yaml:"post_merge"`
+	HumanReview                HumanReviewConfig `yaml:"human_review"`
+	ChatOps                    ChatOpsConfig     `yaml:"chatops"`
+	Transcript                 TranscriptConfig  `yaml:"transcript"`
+	Usage                      UsageConfig       `yaml:"usage"`
+	Budget                     BudgetConfig      `yaml:"budget"`
+	Schedule                   ScheduleConfig    `yaml:"schedule"`
+}
+
+// PostMergeConfig controls the cleanup steps run after soba merges a PR.
+// Each step is off unless the config sets it.
+type PostMergeConfig struct {
+	CloseIssue         bool `yaml:"close_issue"`
+	DeleteRemoteBranch bool `yaml:"delete_remote_branch"`
+	RemoveWorktree     bool `yaml:"remove_worktree"`
+	KillTmuxWindow     bool `yaml:"kill_tmux_window"`
+}
+
+// HumanReviewConfig controls how GitHub pull request reviews from people drive the workflow
+type HumanReviewConfig struct {
+	RequestChanges    bool `yaml:"request_changes"`
+	RequiredApprovals int  `yaml:"required_approvals"` // 0 leaves the AI review as the only gate
+	RequireAIApproval bool `yaml:"require_ai_approval"`
+}
+
+// ChatOpsConfig controls /soba commands in issue and pull request comments
+type ChatOpsConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	AllowedUsers []string `yaml:"allowed_users"` // empty allows repository owners, members and collaborators
+}
+
+// TranscriptConfig controls how the output of phase panes is recorded and shared
+type TranscriptConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	CommentEnabled bool `yaml:"comment_enabled"`
+	CommentLines   int  `yaml:"comment_lines"`
+}
+
+// UsageConfig controls how the cost and token usage of phase runs is recorded
+type UsageConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	CommentEnabled bool `yaml:"comment_enabled"`
+}
+
+// BudgetConfig limits how much work soba starts per day and per month.
+// A zero value means no limit. Cost limits apply to usage recorded by workflow.usage.
+type BudgetConfig struct {
+	MaxPhaseRunsPerDay   int     `yaml:"max_phase_runs_per_day"`
+	MaxPhaseRunsPerMonth int     `yaml:"max_phase_runs_per_month"`
+	MaxIssuesPerDay      int     `yaml:"max_issues_per_day"`
+	MaxIssuesPerMonth    int     `yaml:"max_issues_per_month"`
+	MaxCostPerDay        float64 `yaml:"max_cost_per_day"`
+	MaxCostPerMonth      float64 `yaml:"max_cost_per_month"`
+}
+
+// ScheduleConfig restricts when soba starts new phases.
+// Empty fields mean no restriction.
+type ScheduleConfig struct {
+	// ActiveHours is a "HH:MM-HH:MM" range; an end before the start spans midnight.
+	ActiveHours string   `yaml:"active_hours"`
+	Weekdays    []string `yaml:"weekdays"`
+	Timezone    string   `yaml:"timezone"`
 }
 
 type SlackConfig struct {
-	WebhookURL           string `yaml:"webhook_url"`
+	WebhookURL           string `yaml:"webhook_url" secret:"true"`
 	NotificationsEnabled bool   `yaml:"notifications_enabled"`
 }
 
 type GitConfig struct {
-	WorktreeBasePath string `yaml:"worktree_base_path"`
-	BaseBranch       string `yaml:"base_branch"`
+	WorktreeBasePath  string `yaml:"worktree_base_path"`
+	BaseBranch        string `yaml:"base_branch"`
+	WorktreeMaxCount  int    `yaml:"worktree_max_count"`   // 0 means unlimited
+	WorktreeMaxSizeMB int    `yaml:"worktree_max_size_mb"` // 0 means unlimited
 }
 
 type PhaseConfig struct {
@@ -51,10 +131,64 @@ type PhaseConfig struct {
 	Revise    PhaseCommand `yaml:"revise"`
 }
 
+// ForPhase returns the command configured for the named phase.
+func (p PhaseConfig) ForPhase(phase string) PhaseCommand {
+	switch phase {
+	case "plan":
+		return p.Plan
+	case "implement":
+		return p.Implement
+	case "review":
+		return p.Review
+	case "revise":
+		return p.Revise
+	default:
+		return PhaseCommand{}
+	}
+}
+
+// PhaseCommand is the command soba runs for a phase.
+// Command, Options, Parameter, Env values and Workdir are rendered as text/template.
 type PhaseCommand struct {
-	Command   string   `yaml:"command"`
-	Options   []string `yaml:"options"`
-	Parameter string   `yaml:"parameter"`
+	Command   string            `yaml:"command"`
+	Options   []string          `yaml:"options"`
+	Parameter string            `yaml:"parameter"`
+	Env       map[string]string `yaml:"env"`
+	Workdir   string            `yaml:"workdir"` // defaults to the issue worktree for phases that use one
+}
+
+// Merge returns a copy of c with the fields set in override replacing its own.
+// Env entries are merged key by key.
+func (c PhaseCommand) Merge(override PhaseCommand) PhaseCommand {
+	merged := c
+	if override.Command != "" {
+		merged.Command = override.Command
+	}
+	if override.Options != nil {
+		merged.Options = override.Options
+	}
+	if override.Parameter != "" {
+		merged.Parameter = override.Parameter
+	}
+	if override.Workdir != "" {
+		merged.Workdir = override.Workdir
+	}
+	if len(override.Env) > 0 {
+		merged.Env = make(map[string]string, len(c.Env)+len(override.Env))
+		for key, value := range c.Env {
+			merged.Env[key] = value
+		}
+		for key, value := range override.Env {
+			merged.Env[key] = value
+		}
+	}
+	return merged
+}
+
+// MetricsConfig controls the local HTTP listener serving /metrics and /healthz
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"`
 }
 
 type LogConfig struct {
@@ -64,49 +198,41 @@ type LogConfig struct {
 	Format         string `yaml:"format"` // "json" or "text"
 }
 
+// Load reads the config file at path. The defaults apply when it does not exist.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Return default config when file doesn't exist
-			cfg := &Config{}
-			cfg.setDefaults()
-			return cfg, nil
-		}
-		if os.IsPermission(err) {
-			return nil, infra.NewConfigLoadError(path, "permission denied")
-		}
-		return nil, infra.WrapInfraError(err, "failed to read config file")
-	}
-
-	// First pass: parse config without environment variable expansion to get conditional settings
-	var tempCfg Config
-	if err := yaml.Unmarshal(data, &tempCfg); err != nil {
-		return nil, infra.NewConfigLoadError(path, "invalid YAML format")
-	}
-	tempCfg.setDefaults()
-
-	// Second pass: expand environment variables with conditional warnings based on parsed config
-	content := expandEnvVarsWithConfig(string(data), &tempCfg)
-
-	var cfg Config
-	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
-		return nil, infra.NewConfigLoadError(path, "invalid YAML format")
-	}
-
-	cfg.setDefaults()
+	cfg, _, err := LoadLayers(Layers{Project: path})
+	return cfg, err
+}
 
-	return &cfg, nil
+// yamlErrorDetail strips the "yaml: " prefix from a parse error.
+func yamlErrorDetail(err error) string {
+	return strings.TrimPrefix(err.Error(), "yaml: ")
 }
 
 // expandEnvVarsWithConfig expands environment variables with conditional warnings
 // based on the configuration settings
+// Comment lines are left as they are, e.g. the $schema modeline of the template.
 func expandEnvVarsWithConfig(content string, cfg *Config) string {
-	return os.Expand(content, func(key string) string {
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines[i] = expandEnvVarsInLine(line, cfg)
+	}
+	return strings.Join(lines, "")
+}
+
+func expandEnvVarsInLine(line string, cfg *Config) string {
+	return os.Expand(line, func(key string) string {
 		// Special handling for PID - don't expand it here, it's replaced at daemon startup
 		if key == "PID" {
 			return "${PID}"
 		}
+		// Secret references are resolved after the layers are merged
+		if isSecretReference(key) {
+			return "${" + key + "}"
+		}
 
 		if value, ok := os.LookupEnv(key); ok {
 			return value
@@ -129,6 +255,18 @@ func shouldWarnForEnvVar(key string, cfg *Config) bool {
 	return classifier.ShouldWarn(key, cfg)
 }
 
+// setDefaultTrue sets the boolean settings that default to true. setDefaults
+// cannot tell an omitted bool from false, so these are set before the config
+// is decoded and only the settings it contains replace them.
+func (c *Config) setDefaultTrue() {
+	c.Workflow.RequestChangesOnConflict = true
+	c.Workflow.HumanReview.RequestChanges = true
+	c.Workflow.HumanReview.RequireAIApproval = true
+	c.Workflow.ChatOps.Enabled = true
+	c.Workflow.Transcript.Enabled = true
+	c.Workflow.Usage.CommentEnabled = true
+}
+
 func (c *Config) setDefaults() {
 	if c.Workflow.Interval == 0 {
 		c.Workflow.Interval = 20
@@ -139,6 +277,12 @@ func (c *Config) setDefaults() {
 	if c.Workflow.TmuxCommandDelay == 0 {
 		c.Workflow.TmuxCommandDelay = 3
 	}
+	if c.Workflow.BranchUpdateMethod == "" {
+		c.Workflow.BranchUpdateMethod = DefaultBranchUpdateMethod
+	}
+	if c.Workflow.Transcript.CommentLines == 0 {
+		c.Workflow.Transcript.CommentLines = DefaultTranscriptCommentLines
+	}
 	if c.Git.WorktreeBasePath == "" {
 		c.Git.WorktreeBasePath = DefaultWorktreeBasePath
 	}
@@ -154,4 +298,7 @@ func (c *Config) setDefaults() {
 	if c.Log.Format == "" {
 		c.Log.Format = "text" // Default to text format
 	}
+	if c.Metrics.Listen == "" {
+		c.Metrics.Listen = DefaultMetricsListen
+	}
 }
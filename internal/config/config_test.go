@@ -162,6 +162,91 @@ github:
 	}
 }
 
+func TestLoadConfigDefaultTrueSettings(t *testing.T) {
+	load := func(t *testing.T, content string) *Config {
+		t.Helper()
+		configPath := filepath.Join(t.TempDir(), "config.yml")
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test config file: %v", err)
+		}
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Fatalf("Failed to load config %q: %v", content, err)
+		}
+		return cfg
+	}
+
+	settings := []struct {
+		path string
+		get  func(cfg *Config) bool
+	}{
+		{"workflow.request_changes_on_conflict", func(cfg *Config) bool { return cfg.Workflow.RequestChangesOnConflict }},
+		{"workflow.human_review.request_changes", func(cfg *Config) bool { return cfg.Workflow.HumanReview.RequestChanges }},
+		{"workflow.human_review.require_ai_approval", func(cfg *Config) bool { return cfg.Workflow.HumanReview.RequireAIApproval }},
+		{"workflow.chatops.enabled", func(cfg *Config) bool { return cfg.Workflow.ChatOps.Enabled }},
+		{"workflow.transcript.enabled", func(cfg *Config) bool { return cfg.Workflow.Transcript.Enabled }},
+		{"workflow.usage.comment_enabled", func(cfg *Config) bool { return cfg.Workflow.Usage.CommentEnabled }},
+	}
+
+	for _, setting := range settings {
+		t.Run(setting.path, func(t *testing.T) {
+			keys := strings.Split(setting.path, ".")
+			base := "github:\n  repository: owner/repo\n"
+			set := func(value string) string {
+				var b strings.Builder
+				b.WriteString(base)
+				for i, key := range keys {
+					b.WriteString(strings.Repeat("  ", i) + key + ":")
+					if i < len(keys)-1 {
+						b.WriteString("\n")
+					}
+				}
+				return b.String() + " " + value + "\n"
+			}
+
+			// An omitted or empty setting takes the default; false is kept
+			for content, want := range map[string]bool{
+				base:                   true,
+				base + keys[0] + ":\n": true,
+				set(""):                true,
+				set("false"):           false,
+				set("true"):            true,
+			} {
+				if got := setting.get(load(t, content)); got != want {
+					t.Errorf("%s in %q = %v, want %v", setting.path, content, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("keeps the defaults next to the settings of a section", func(t *testing.T) {
+		cfg := load(t, "workflow:\n  human_review:\n    required_approvals: 1\n")
+
+		// Human approvals alone must not merge before the AI review
+		if cfg.Workflow.HumanReview.RequiredApprovals != 1 || !cfg.Workflow.HumanReview.RequireAIApproval || !cfg.Workflow.HumanReview.RequestChanges {
+			t.Errorf("human_review = %+v, want the defaults next to required_approvals", cfg.Workflow.HumanReview)
+		}
+	})
+
+	t.Run("leaves the post-merge cleanup off unless it is set", func(t *testing.T) {
+		cfg := load(t, "github:\n  repository: owner/repo\nworkflow:\n  post_merge:\n    kill_tmux_window: true\n")
+
+		if cfg.Workflow.PostMerge != (PostMergeConfig{KillTmuxWindow: true}) {
+			t.Errorf("post_merge = %+v, want only kill_tmux_window", cfg.Workflow.PostMerge)
+		}
+	})
+
+	t.Run("applies without a config file", func(t *testing.T) {
+		cfg, err := Load(filepath.Join(t.TempDir(), "missing.yml"))
+		if err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if !cfg.Workflow.RequestChangesOnConflict {
+			t.Errorf("request_changes_on_conflict = false, want true")
+		}
+	})
+}
+
 func TestLoadConfigFileNotFound(t *testing.T) {
 	cfg, err := Load("/nonexistent/path/config.yml")
 	if err != nil {
@@ -314,6 +399,13 @@ func TestExpandEnvVarsWithConditionalWarnings(t *testing.T) {
 			},
 			unexpectedWarn: []string{},
 		},
+		{
+			name:           "No warning for comment lines",
+			config:         Config{},
+			content:        "# yaml-language-server: $schema=https://example.com/schema.json\nlog:\n  level: info",
+			expectedWarn:   []string{},
+			unexpectedWarn: []string{"Warning: undefined environment variable: schema"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -516,6 +608,13 @@ github:
 	if cfg.Log.RetentionCount != 10 {
 		t.Errorf("Default log retention_count = %v, want 10", cfg.Log.RetentionCount)
 	}
+
+	if cfg.Metrics.Enabled {
+		t.Errorf("Default metrics enabled = %v, want false", cfg.Metrics.Enabled)
+	}
+	if cfg.Metrics.Listen != DefaultMetricsListen {
+		t.Errorf("Default metrics listen = %v, want %v", cfg.Metrics.Listen, DefaultMetricsListen)
+	}
 }
 
 func TestPIDVariableNoWarning(t *testing.T) {
@@ -688,3 +787,89 @@ log:
 		t.Errorf("Default log level = %v, want empty string", cfg.Log.Level)
 	}
 }
+
+func TestPhaseConfig_ForPhase(t *testing.T) {
+	phases := PhaseConfig{
+		Plan:      PhaseCommand{Command: "plan"},
+		Implement: PhaseCommand{Command: "implement"},
+		Review:    PhaseCommand{Command: "review"},
+		Revise:    PhaseCommand{Command: "revise"},
+	}
+
+	for _, phase := range []string{"plan", "implement", "review", "revise"} {
+		if got := phases.ForPhase(phase).Command; got != phase {
+			t.Errorf("ForPhase(%q).Command = %q, want %q", phase, got, phase)
+		}
+	}
+	if got := phases.ForPhase("unknown"); !reflect.DeepEqual(got, PhaseCommand{}) {
+		t.Errorf("ForPhase(unknown) = %+v, want zero value", got)
+	}
+}
+
+func TestPhaseCommand_Merge(t *testing.T) {
+	base := PhaseCommand{
+		Command:   "claude",
+		Options:   []string{"--dangerously-skip-permissions"},
+		Parameter: "/soba:implement {{.IssueNumber}}",
+		Env:       map[string]string{"A": "1", "B": "2"},
+	}
+
+	t.Run("empty override keeps base", func(t *testing.T) {
+		if got := base.Merge(PhaseCommand{}); !reflect.DeepEqual(got, base) {
+			t.Errorf("Merge(empty) = %+v, want %+v", got, base)
+		}
+	})
+
+	t.Run("set fields override and env merges per key", func(t *testing.T) {
+		got := base.Merge(PhaseCommand{
+			Options: []string{},
+			Workdir: "{{.WorktreePath}}/web",
+			Env:     map[string]string{"B": "3", "C": "4"},
+		})
+		want := PhaseCommand{
+			Command:   "claude",
+			Options:   []string{},
+			Parameter: "/soba:implement {{.IssueNumber}}",
+			Env:       map[string]string{"A": "1", "B": "3", "C": "4"},
+			Workdir:   "{{.WorktreePath}}/web",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Merge() = %+v, want %+v", got, want)
+		}
+		if base.Env["B"] != "2" {
+			t.Errorf("Merge() modified base env")
+		}
+	})
+}
+
+func TestLoadConfigWithProfiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yml")
+
+	configContent := `
+github:
+  repository: owner/repo
+
+profiles:
+  fast:
+    implement:
+      options:
+        - --model=haiku
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	fast, ok := cfg.Profiles["fast"]
+	if !ok {
+		t.Fatalf("profile fast not loaded")
+	}
+	if !reflect.DeepEqual(fast.Implement.Options, []string{"--model=haiku"}) {
+		t.Errorf("fast.implement.options = %v", fast.Implement.Options)
+	}
+}
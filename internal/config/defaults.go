@@ -5,4 +5,7 @@ const (
 	DefaultClosedIssueCleanupInterval = 300
 	DefaultTmuxCommandDelay           = 3
 	DefaultWorktreeBasePath           = ".git/soba/worktrees"
+	DefaultBranchUpdateMethod         = "api"
+	DefaultTranscriptCommentLines     = 50
+	DefaultMetricsListen              = "127.0.0.1:9464"
 )
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change is a setting whose value differs between two configs. Old or New is
+// empty when the setting exists on one side only, e.g. a removed env entry.
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// Diff returns the settings that differ between old and new, sorted by field.
+// Secret settings are masked so that the changes are safe to log.
+func Diff(old, new *Config) []Change {
+	oldValues := flatten(old)
+	newValues := flatten(new)
+	secrets := secretFields(old)
+	for field := range secretFields(new) {
+		secrets[field] = true
+	}
+
+	var changes []Change
+	for field, oldValue := range oldValues {
+		if newValue := newValues[field]; newValue != oldValue {
+			changes = append(changes, maskChange(Change{Field: field, Old: oldValue, New: newValue}, secrets))
+		}
+	}
+	for field, newValue := range newValues {
+		if _, ok := oldValues[field]; !ok && newValue != "" {
+			changes = append(changes, maskChange(Change{Field: field, New: newValue}, secrets))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// flatten returns the value of every setting by its YAML path.
+func flatten(cfg *Config) map[string]string {
+	values := make(map[string]string)
+	walkFields(reflect.ValueOf(*cfg), "", "", func(field, _ string, v reflect.Value) {
+		values[field] = fmt.Sprint(v.Interface())
+	})
+	return values
+}
+
+func maskChange(c Change, secrets map[string]bool) Change {
+	if secrets[c.Field] {
+		if c.Old != "" {
+			c.Old = MaskedValue
+		}
+		if c.New != "" {
+			c.New = MaskedValue
+		}
+	}
+	return c
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := &Config{
+		GitHub:   GitHubConfig{Token: "old-token", Repository: "owner/repo"},
+		Workflow: WorkflowConfig{Interval: 20},
+		Phase:    PhaseConfig{Plan: PhaseCommand{Command: "claude", Env: map[string]string{"A": "1"}}},
+	}
+	updated := &Config{
+		GitHub:   GitHubConfig{Token: "new-token", Repository: "owner/repo"},
+		Workflow: WorkflowConfig{Interval: 30},
+		Phase:    PhaseConfig{Plan: PhaseCommand{Command: "claude", Options: []string{"-v"}, Env: map[string]string{"B": "2"}}},
+	}
+
+	assert.Equal(t, []Change{
+		{Field: "github.token", Old: "***MASKED***", New: "***MASKED***"},
+		{Field: "phase.plan.env.A", Old: "1"},
+		{Field: "phase.plan.env.B", New: "2"},
+		{Field: "phase.plan.options[0]", New: "-v"},
+		{Field: "workflow.interval", Old: "20", New: "30"},
+	}, Diff(old, updated))
+	assert.Empty(t, Diff(old, old))
+}
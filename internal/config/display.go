@@ -3,6 +3,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
 
 	"gopkg.in/yaml.v3"
 )
@@ -25,20 +29,41 @@ func DisplayConfig(cfg *Config) (string, error) {
 	return string(data), nil
 }
 
+// DisplayOrigins 各設定の値と、その値を設定したレイヤーを1行ずつ返す
+func DisplayOrigins(cfg *Config, origins Origins) (string, error) {
+	if cfg == nil {
+		return "", errors.New("config is nil")
+	}
+
+	secrets := secretFields(cfg)
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	walkFields(reflect.ValueOf(*cfg), "", "", func(field, _ string, v reflect.Value) {
+		value := fmt.Sprint(v.Interface())
+		if secrets[field] && value != "" {
+			value = MaskedValue
+		}
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", field, value, origins.Of(field))
+	})
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
 // MaskSensitiveConfig センシティブな情報をマスキングした設定のコピーを返す
+// secretタグのフィールド、!secretの値、シークレット参照から読み込んだ値をマスクする
 func MaskSensitiveConfig(cfg *Config) *Config {
 	if cfg == nil {
 		return nil
 	}
 
-	// ディープコピーを作成
+	// マップとスライスも複製してからマスクする
 	masked := *cfg
-
-	// GitHubトークンをマスク（空でもマスク）
-	masked.GitHub.Token = "***MASKED***"
-
-	// Slack WebhookURLをマスク（空でもマスク）
-	masked.Slack.WebhookURL = "***MASKED***"
+	maskSecrets(reflect.ValueOf(&masked).Elem(), "", "", cfg.secrets)
 
 	return &masked
 }
@@ -0,0 +1,474 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/douhashi/soba/internal/infra"
+)
+
+// Names of the layers a config is merged from, lowest priority first.
+const (
+	LayerDefault = "default"
+	LayerGlobal  = "global"
+	LayerProject = "project"
+	LayerProfile = "profile"
+	LayerEnv     = "env"
+)
+
+// EnvOverridePrefix starts the environment variables that override a setting,
+// e.g. SOBA_WORKFLOW_INTERVAL for workflow.interval.
+const EnvOverridePrefix = "SOBA_"
+
+// Layers are the sources a config is merged from. Each layer overrides the
+// settings of the layers before it; lists are replaced, not appended to.
+type Layers struct {
+	Global  string   // user-global config file, skipped when missing
+	Project string   // project config file, the defaults apply when missing
+	Profile string   // profile name, read from config.<profile>.yml next to Project
+	Environ []string // KEY=value pairs; SOBA_* entries override single settings
+
+	// SkipSecretCommands leaves ${cmd:...} and ${keyring:...} references
+	// unresolved, for commands that only check or show the config.
+	SkipSecretCommands bool
+}
+
+// DefaultLayers returns the layers soba reads for the project config at path:
+// the user-global config, the project config, the named profile and the
+// SOBA_* environment variables.
+func DefaultLayers(path, profile string) Layers {
+	return Layers{
+		Global:  DefaultGlobalConfigPath(),
+		Project: path,
+		Profile: profile,
+		Environ: os.Environ(),
+	}
+}
+
+// DefaultGlobalConfigPath returns $XDG_CONFIG_HOME/soba/config.yml, or
+// ~/.config/soba/config.yml when XDG_CONFIG_HOME is not set. It returns ""
+// when the home directory is unknown.
+func DefaultGlobalConfigPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "soba", "config.yml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "soba", "config.yml")
+}
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ProfilePath returns the file of the named profile next to the project config.
+func ProfilePath(projectPath, profile string) string {
+	return filepath.Join(filepath.Dir(projectPath), "config."+profile+".yml")
+}
+
+// Files returns the config files of the layers that are set, whether or not they exist.
+func (l Layers) Files() []string {
+	var files []string
+	if l.Global != "" {
+		files = append(files, l.Global)
+	}
+	if l.Project != "" {
+		files = append(files, l.Project)
+		if l.Profile != "" {
+			files = append(files, ProfilePath(l.Project, l.Profile))
+		}
+	}
+	return files
+}
+
+// Origin is where the value of a setting came from.
+type Origin struct {
+	Layer  string
+	Source string // the config file or environment variable
+	Line   int
+	Column int
+}
+
+func (o Origin) String() string {
+	switch {
+	case o.Source == "":
+		return o.Layer
+	case o.Line > 0:
+		return fmt.Sprintf("%s %s:%d", o.Layer, o.Source, o.Line)
+	default:
+		return fmt.Sprintf("%s %s", o.Layer, o.Source)
+	}
+}
+
+// Origins maps the YAML path of each setting to where it was set.
+type Origins map[string]Origin
+
+// Of returns where the setting at field was set. Settings that no layer sets
+// come from the defaults, even when a layer sets other settings of their
+// section.
+func (o Origins) Of(field string) Origin {
+	for {
+		if origin, ok := o[field]; ok {
+			return origin
+		}
+		// List items come from the layer that set the whole list
+		i := strings.LastIndex(field, "[")
+		if i <= 0 || !strings.HasSuffix(field, "]") {
+			return Origin{Layer: LayerDefault}
+		}
+		field = field[:i]
+	}
+}
+
+// LoadLayers reads and merges the layers into one config, validates it and
+// returns where each setting came from.
+func LoadLayers(layers Layers) (*Config, Origins, error) {
+	origins := make(Origins)
+	var problems []Problem
+	var warnings []string
+	var merged *yaml.Node
+	// The version of the project file decides how its phase commands are
+	// quoted; the global and profile files do not change it
+	var projectVersion int
+
+	type layerFile struct {
+		layer    string
+		path     string
+		required bool
+	}
+	files := []layerFile{{layer: LayerGlobal, path: layers.Global}, {layer: LayerProject, path: layers.Project}}
+	if layers.Profile != "" {
+		if !profileNamePattern.MatchString(layers.Profile) {
+			return nil, nil, fmt.Errorf("invalid profile name %q: use letters, digits, - and _", layers.Profile)
+		}
+		files = append(files, layerFile{layer: LayerProfile, path: ProfilePath(layers.Project, layers.Profile), required: true})
+	}
+
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		node, positions, layerProblems, err := readLayer(file.path, file.required)
+		if err != nil {
+			return nil, nil, err
+		}
+		for field, pos := range positions {
+			origins[field] = Origin{Layer: file.layer, Source: file.path, Line: pos.line, Column: pos.column}
+		}
+		for _, problem := range layerProblems {
+			problem.Source = file.path
+			problems = append(problems, problem)
+		}
+		if node != nil {
+			if version, err := fileVersion(node); err == nil && version > CurrentConfigVersion {
+				_, value := mappingEntry(node, "version")
+				problems = append(problems, Problem{Field: "version", Source: file.path, Line: value.Line, Column: value.Column,
+					Message: fmt.Sprintf("version %d is newer than this soba supports (%d); upgrade soba", version, CurrentConfigVersion)})
+			} else if err == nil && file.layer == LayerProject {
+				if _, value := mappingEntry(node, "version"); value != nil {
+					projectVersion = version
+				}
+				if version < CurrentConfigVersion {
+					if warning := outdatedWarning(file.path, version); warning != "" {
+						warnings = append(warnings, warning)
+					}
+				}
+			}
+		}
+		merged = mergeNode(merged, node)
+	}
+
+	envNode, envSources, envProblems := envOverrides(layers.Environ)
+	problems = append(problems, envProblems...)
+	if envNode != nil {
+		for field, name := range envSources {
+			origins[field] = Origin{Layer: LayerEnv, Source: name}
+		}
+		var typeProblems []Problem
+		checkNode(envNode, reflect.TypeOf(Config{}), "", make(map[string]position), &typeProblems)
+		for _, problem := range typeProblems {
+			problem.Source = origins.Of(problem.Field).Source
+			problems = append(problems, problem)
+		}
+		merged = mergeNode(merged, envNode)
+	}
+
+	// Only the user's own files run commands: the project and profile
+	// configs are committed to the repository
+	secrets := make(map[string]bool)
+	policy := secretPolicy{
+		trusted: func(field string) bool {
+			layer := origins.Of(field).Layer
+			return layer == LayerGlobal || layer == LayerEnv
+		},
+		skipCommands: layers.SkipSecretCommands,
+		unresolved:   make(map[string]bool),
+	}
+	for _, problem := range resolveSecrets(context.Background(), merged, "", policy, secrets) {
+		origin := origins.Of(problem.Field)
+		problem.Source, problem.Line, problem.Column = origin.Source, origin.Line, origin.Column
+		problems = append(problems, problem)
+	}
+
+	var cfg Config
+	cfg.setDefaultTrue()
+	if merged != nil {
+		if err := decodeLenient(merged, &cfg); err != nil {
+			return nil, nil, infra.NewConfigLoadError(layers.Project, "invalid YAML format: "+yamlErrorDetail(err))
+		}
+	}
+	cfg.setDefaults()
+	cfg.Version = projectVersion
+	if len(secrets) > 0 {
+		cfg.secrets = secrets
+	}
+	cfg.warnings = warnings
+
+	reported := make(map[string]bool, len(problems))
+	for _, problem := range problems {
+		reported[problem.Field] = true
+	}
+	for _, problem := range Validate(&cfg) {
+		if reported[problem.Field] || policy.unresolved[problem.Field] {
+			continue
+		}
+		if origin, ok := origins[problem.Field]; ok {
+			problem.Source, problem.Line, problem.Column = origin.Source, origin.Line, origin.Column
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) > 0 {
+		return nil, nil, &ValidationError{Path: layers.Project, Problems: problems}
+	}
+
+	return &cfg, origins, nil
+}
+
+// outdatedWarning explains the changes `soba config migrate` would make to
+// an outdated project config. soba still runs it with the zero values for
+// the settings it lacks.
+func outdatedWarning(path string, version int) string {
+	data, err := os.ReadFile(path) // #nosec G304 - the path comes from --config or the working directory
+	if err != nil {
+		return ""
+	}
+	result, err := Migrate(data)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s uses config version %d; run `soba config migrate` to upgrade it to version %d:", path, version, CurrentConfigVersion)
+	for _, change := range result.Changes {
+		fmt.Fprintf(&b, "\n  - %s", change)
+	}
+	for _, value := range result.Unresolved {
+		fmt.Fprintf(&b, "\n  - %s", value)
+	}
+	return b.String()
+}
+
+// readLayer reads one config file with its environment variables expanded.
+// A missing file that is not required is skipped.
+func readLayer(path string, required bool) (*yaml.Node, map[string]position, []Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if required {
+				return nil, nil, nil, infra.NewConfigLoadError(path, "file not found")
+			}
+			return nil, nil, nil, nil
+		}
+		if os.IsPermission(err) {
+			return nil, nil, nil, infra.NewConfigLoadError(path, "permission denied")
+		}
+		return nil, nil, nil, infra.WrapInfraError(err, "failed to read config file")
+	}
+
+	// First pass: parse config without environment variable expansion to get conditional settings.
+	// Values of the wrong type are reported by parseLayer below
+	var tempCfg Config
+	var typeErr *yaml.TypeError
+	if err := yaml.Unmarshal(data, &tempCfg); err != nil && !errors.As(err, &typeErr) {
+		return nil, nil, nil, infra.NewConfigLoadError(path, "invalid YAML format: "+yamlErrorDetail(err))
+	}
+	tempCfg.setDefaults()
+
+	// Second pass: expand environment variables with conditional warnings based on parsed config
+	content := expandEnvVarsWithConfig(string(data), &tempCfg)
+
+	node, positions, problems, err := parseLayer([]byte(content))
+	if err != nil {
+		return nil, nil, nil, infra.NewConfigLoadError(path, "invalid YAML format: "+yamlErrorDetail(err))
+	}
+	return node, positions, problems, nil
+}
+
+// mergeNode merges src over dst. Mappings are merged key by key; any other
+// value in src replaces the one in dst. A null value in src keeps dst.
+func mergeNode(dst, src *yaml.Node) *yaml.Node {
+	if src == nil || (src.Kind == yaml.ScalarNode && src.Tag == "!!null") {
+		return dst
+	}
+	if dst == nil || dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		merged := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				dst.Content[j+1] = mergeNode(dst.Content[j+1], value)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+	return dst
+}
+
+// envOverrides builds a mapping from the SOBA_* environment variables, e.g.
+// SOBA_WORKFLOW_INTERVAL=30 becomes workflow: {interval: 30}. List settings
+// take comma separated values. It returns the variable that set each field.
+// Variables that start with a section name but match no setting are problems;
+// other SOBA_* variables are left alone.
+func envOverrides(environ []string) (*yaml.Node, map[string]string, []Problem) {
+	environ = append([]string(nil), environ...)
+	sort.Strings(environ)
+	configType := reflect.TypeOf(Config{})
+	sections := yamlFields(configType)
+
+	var root *yaml.Node
+	sources := make(map[string]string)
+	var problems []Problem
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(name, EnvOverridePrefix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, EnvOverridePrefix)
+
+		path, t, ok := resolveEnvKey(configType, key)
+		if !ok {
+			for section := range sections {
+				upper := strings.ToUpper(section)
+				if key == upper || strings.HasPrefix(key, upper+"_") {
+					problems = append(problems, Problem{
+						Field:   section + "." + strings.ToLower(strings.TrimPrefix(key, upper+"_")),
+						Source:  name,
+						Message: "unknown field",
+					})
+					break
+				}
+			}
+			continue
+		}
+
+		if root == nil {
+			root = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		setEnvValue(root, path, envValueNode(t, value))
+		sources[strings.Join(path, ".")] = name
+	}
+	return root, sources, problems
+}
+
+// resolveEnvKey finds the setting an upper case, underscore separated key
+// names, trying longer field names first since names contain underscores too.
+func resolveEnvKey(t reflect.Type, key string) ([]string, reflect.Type, bool) {
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := yamlFields(t)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+		for _, name := range names {
+			upper := strings.ToUpper(name)
+			fieldType := fields[name].Type
+			if key == upper && fieldType.Kind() != reflect.Struct && fieldType.Kind() != reflect.Map {
+				return []string{name}, fieldType, true
+			}
+			if rest, ok := strings.CutPrefix(key, upper+"_"); ok {
+				if path, leaf, ok := resolveEnvKey(fieldType, rest); ok {
+					return append([]string{name}, path...), leaf, true
+				}
+			}
+		}
+	case reflect.Map:
+		// phase env keys are kept as they are, profile names are lower case
+		if t.Elem().Kind() != reflect.Struct {
+			return []string{key}, t.Elem(), key != ""
+		}
+		name, rest, ok := strings.Cut(key, "_")
+		if !ok {
+			return nil, nil, false
+		}
+		if path, leaf, ok := resolveEnvKey(t.Elem(), rest); ok {
+			return append([]string{strings.ToLower(name)}, path...), leaf, true
+		}
+	}
+	return nil, nil, false
+}
+
+// envValueNode returns the YAML node of an environment variable value.
+func envValueNode(t reflect.Type, value string) *yaml.Node {
+	if t.Kind() != reflect.Slice {
+		return envScalarNode(t, value)
+	}
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			node.Content = append(node.Content, envScalarNode(t.Elem(), item))
+		}
+	}
+	return node
+}
+
+// envScalarNode keeps string values such as "on" or "1" from being resolved as other types.
+func envScalarNode(t reflect.Type, value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	if t.Kind() == reflect.String {
+		node.Tag = "!!str"
+	}
+	return node
+}
+
+// setEnvValue sets value at path in the mapping root, creating the mappings on the way.
+func setEnvValue(root *yaml.Node, path []string, value *yaml.Node) {
+	node := root
+	for i, name := range path {
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == name {
+				child = node.Content[j+1]
+				break
+			}
+		}
+		if i == len(path)-1 {
+			if child != nil {
+				*child = *value
+			} else {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+			}
+			return
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, child)
+		}
+		node = child
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global.yml")
+	require.NoError(t, os.WriteFile(global, []byte(`github:
+  auth_method: gh
+slack:
+  webhook_url: https://hooks.slack.com/services/personal
+  notifications_enabled: true
+workflow:
+  interval: 60
+`), 0600))
+	project := filepath.Join(dir, ".soba", "config.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(project), 0755))
+	require.NoError(t, os.WriteFile(project, []byte(`github:
+  repository: owner/repo
+workflow:
+  interval: 30
+phase:
+  plan:
+    command: claude
+    options: [--verbose]
+`), 0600))
+	require.NoError(t, os.WriteFile(ProfilePath(project, "ci"), []byte("phase:\n  plan:\n    options: [--print]\n"), 0600))
+
+	t.Run("merges global, project, profile and environment", func(t *testing.T) {
+		cfg, origins, err := LoadLayers(Layers{
+			Global:  global,
+			Project: project,
+			Profile: "ci",
+			Environ: []string{"SOBA_WORKFLOW_INTERVAL=45", "SOBA_LOG_LEVEL=debug", "SOBA_WORKFLOW_SCHEDULE_WEEKDAYS=mon, tue", "HOME=/root"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "gh", cfg.GitHub.AuthMethod)
+		assert.Equal(t, "owner/repo", cfg.GitHub.Repository)
+		assert.True(t, cfg.Slack.NotificationsEnabled)
+		assert.Equal(t, 45, cfg.Workflow.Interval)
+		assert.Equal(t, "debug", cfg.Log.Level)
+		assert.Equal(t, []string{"mon", "tue"}, cfg.Workflow.Schedule.Weekdays)
+		assert.Equal(t, "claude", cfg.Phase.Plan.Command)
+		assert.Equal(t, []string{"--print"}, cfg.Phase.Plan.Options)
+
+		assert.Equal(t, Origin{Layer: LayerGlobal, Source: global, Line: 5, Column: 26}, origins.Of("slack.notifications_enabled"))
+		assert.Equal(t, Origin{Layer: LayerProject, Source: project, Line: 2, Column: 15}, origins.Of("github.repository"))
+		assert.Equal(t, LayerProfile, origins.Of("phase.plan.options[0]").Layer)
+		assert.Equal(t, Origin{Layer: LayerEnv, Source: "SOBA_WORKFLOW_INTERVAL"}, origins.Of("workflow.interval"))
+		assert.Equal(t, "SOBA_WORKFLOW_SCHEDULE_WEEKDAYS", origins.Of("workflow.schedule.weekdays[1]").Source)
+		assert.Equal(t, Origin{Layer: LayerDefault}, origins.Of("git.base_branch"))
+	})
+
+	t.Run("keeps the defaults for unset settings of a section that is set", func(t *testing.T) {
+		_, origins, err := LoadLayers(Layers{Global: global, Project: project, Profile: "ci"})
+		require.NoError(t, err)
+
+		assert.Equal(t, LayerProject, origins.Of("workflow.interval").Layer)
+		assert.Equal(t, Origin{Layer: LayerDefault}, origins.Of("github.token"))
+		assert.Equal(t, Origin{Layer: LayerDefault}, origins.Of("workflow.use_tmux"))
+		assert.Equal(t, Origin{Layer: LayerDefault}, origins.Of("phase.plan.parameter"))
+		assert.Equal(t, LayerProject, origins.Of("phase.plan.command").Layer)
+	})
+
+	t.Run("reports invalid environment overrides", func(t *testing.T) {
+		_, _, err := LoadLayers(Layers{
+			Project: project,
+			Environ: []string{"SOBA_WORKFLOW_INTERVALL=5", "SOBA_WORKFLOW_USE_TMUX=sometimes", "SOBA_TEST_MODE=true"},
+		})
+
+		problems := validationProblems(t, err)
+		require.Len(t, problems, 2)
+		assert.Equal(t, Problem{Field: "workflow.intervall", Source: "SOBA_WORKFLOW_INTERVALL", Message: "unknown field"}, problems[0])
+		assert.Equal(t, Problem{Field: "workflow.use_tmux", Source: "SOBA_WORKFLOW_USE_TMUX", Message: `cannot use "sometimes" as boolean value`}, problems[1])
+	})
+
+	t.Run("points problems at the layer that set the value", func(t *testing.T) {
+		_, _, err := LoadLayers(Layers{Global: global, Project: project, Environ: []string{"SOBA_SLACK_WEBHOOK_URL=hooks.slack.com"}})
+
+		problems := validationProblems(t, err)
+		require.Len(t, problems, 1)
+		assert.Equal(t, "SOBA_SLACK_WEBHOOK_URL", problems[0].Source)
+	})
+
+	t.Run("fails for a missing profile", func(t *testing.T) {
+		_, _, err := LoadLayers(Layers{Project: project, Profile: "missing"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "config.missing.yml")
+
+		_, _, err = LoadLayers(Layers{Project: project, Profile: "../ci"})
+		assert.ErrorContains(t, err, "invalid profile name")
+	})
+
+	t.Run("maps env keys with underscores in field names", func(t *testing.T) {
+		cfg, _, err := LoadLayers(Layers{Project: project, Environ: []string{
+			"SOBA_WORKFLOW_CLOSED_ISSUE_CLEANUP_INTERVAL=600",
+			"SOBA_PHASE_PLAN_ENV_API_KEY=secret",
+			"SOBA_PROFILES_FAST_IMPLEMENT_COMMAND=codex",
+			"SOBA_GITHUB_REPOSITORY=other/repo",
+		}})
+		require.NoError(t, err)
+
+		assert.Equal(t, 600, cfg.Workflow.ClosedIssueCleanupInterval)
+		assert.Equal(t, "secret", cfg.Phase.Plan.Env["API_KEY"])
+		assert.Equal(t, "codex", cfg.Profiles["fast"].Implement.Command)
+		assert.Equal(t, "other/repo", cfg.GitHub.Repository)
+	})
+}
@@ -0,0 +1,428 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the version of the config file format written by
+// soba init and soba config migrate. Files without a version field are
+// version 1.
+const CurrentConfigVersion = 2
+
+// QuotedPhaseCommandsVersion is the first config version that passes the
+// command, each option and the parameter of a phase command to the shell
+// as single words. Version 1 joined them as they were.
+const QuotedPhaseCommandsVersion = 2
+
+// LegacyPlaceholders rewrites the placeholders of version 1 phase commands
+// as text/template actions.
+var LegacyPlaceholders = strings.NewReplacer(
+	"{{issue-number}}", "{{.IssueNumber}}",
+	"{issue_number}", "{{.IssueNumber}}",
+)
+
+// migrationStep changes a config file and records in result each change it
+// made and each value it could not change. defaults is the mapping of the
+// current config template.
+type migrationStep func(root, defaults *yaml.Node, result *MigrationResult)
+
+// migration upgrades a config file from version from to from+1.
+type migration struct {
+	from  int
+	steps []migrationStep
+}
+
+// migrations upgrade config files one version at a time, oldest first.
+// No key has been renamed yet; a renamed key adds a renameKey step.
+var migrations = []migration{
+	{from: 1, steps: []migrationStep{
+		// Settings added after version 1. Without them the file keeps the zero
+		// values, e.g. no cleanup after merge and no ChatOps.
+		addDefault("workflow.branch_update_method"),
+		addDefault("workflow.request_changes_on_conflict"),
+		addDefault("workflow.post_merge"),
+		addDefault("workflow.human_review"),
+		addDefault("workflow.chatops"),
+		addDefault("workflow.transcript"),
+		addDefault("workflow.usage"),
+		addDefault("workflow.budget"),
+		addDefault("workflow.schedule"),
+		addDefault("git.worktree_max_count"),
+		addDefault("git.worktree_max_size_mb"),
+		addDefault("metrics"),
+		rewriteLegacyPlaceholders("phase", "profiles"),
+		splitCommandWords,
+	}},
+}
+
+// MigrationResult is a config file upgraded by Migrate.
+type MigrationResult struct {
+	From    int
+	To      int
+	Changes []string
+	// Unresolved are the values the user must rewrite before the version can
+	// be set, e.g. phase commands with shell syntax.
+	Unresolved []string
+	Content    []byte
+}
+
+// Migrate upgrades the content of a config file to CurrentConfigVersion.
+// It edits the YAML nodes so that comments and the order of the keys are
+// kept, renames keys, adds the settings of newer versions with the values
+// and comments of the config template and sets version. Environment
+// variables and secret references are left as they are. While values are
+// unresolved the version is not set, and To stays at From.
+func Migrate(content []byte) (*MigrationResult, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid YAML format: %s", yamlErrorDetail(err))
+	}
+	if len(document.Content) == 0 {
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping at the top level")
+	}
+
+	from, err := fileVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	result := &MigrationResult{From: from, To: CurrentConfigVersion, Content: content}
+	if from > CurrentConfigVersion {
+		return nil, fmt.Errorf("config version %d is newer than this soba supports (%d); upgrade soba", from, CurrentConfigVersion)
+	}
+	if from == CurrentConfigVersion {
+		return result, nil
+	}
+
+	defaults, err := templateDefaults()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if m.from < from {
+			continue
+		}
+		for _, step := range m.steps {
+			step(root, defaults, result)
+		}
+	}
+	if len(result.Unresolved) > 0 {
+		result.To = from
+	} else {
+		setVersion(root, CurrentConfigVersion)
+		result.Changes = append(result.Changes, fmt.Sprintf("set version to %d", CurrentConfigVersion))
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	result.Content = separateSections(buf.Bytes())
+	return result, nil
+}
+
+// separateSections puts a blank line before each top-level key and its
+// comments, which yaml.v3 drops when it encodes a document.
+func separateSections(content []byte) []byte {
+	lines := strings.SplitAfter(string(content), "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 && line != "" && line[0] != ' ' && line[0] != '\n' {
+			previous := lines[i-1]
+			if previous != "\n" && !strings.HasPrefix(previous, "#") {
+				b.WriteString("\n")
+			}
+		}
+		b.WriteString(line)
+	}
+	return []byte(b.String())
+}
+
+// fileVersion returns the version of a config file mapping. A file without
+// a version is version 1.
+func fileVersion(root *yaml.Node) (int, error) {
+	_, value := mappingEntry(root, "version")
+	if value == nil || value.Tag == "!!null" {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(value.Value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("line %d: version must be a positive integer, got %q", value.Line, value.Value)
+	}
+	return version, nil
+}
+
+// setVersion sets version to v, adding it as the first key when missing.
+func setVersion(root *yaml.Node, v int) {
+	if _, value := mappingEntry(root, "version"); value != nil {
+		value.Kind, value.Tag, value.Value, value.Style = yaml.ScalarNode, "!!int", strconv.Itoa(v), 0
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version", HeadComment: "# Config file format version, upgraded by `soba config migrate`"}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(v)}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// templateDefaults returns the mapping of the current config template.
+func templateDefaults() (*yaml.Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(GenerateTemplate()), &document); err != nil {
+		return nil, fmt.Errorf("invalid config template: %w", err)
+	}
+	if len(document.Content) == 0 {
+		return nil, fmt.Errorf("empty config template")
+	}
+	return document.Content[0], nil
+}
+
+// addDefault adds the setting at path with the value and comments of the
+// config template when the file does not set it. Missing parent sections
+// are added empty, so that only the new setting is added.
+func addDefault(path string) migrationStep {
+	return func(root, defaults *yaml.Node, result *MigrationResult) {
+		keys := strings.Split(path, ".")
+		defaultKey, defaultValue := lookupEntry(defaults, keys)
+		if defaultValue == nil {
+			return
+		}
+
+		parent := root
+		for _, name := range keys[:len(keys)-1] {
+			key, value := mappingEntry(parent, name)
+			if value == nil {
+				value = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+			} else if value.Tag == "!!null" {
+				value.Kind, value.Tag, value.Value = yaml.MappingNode, "!!map", ""
+				key.LineComment = ""
+			}
+			if value.Kind != yaml.MappingNode {
+				return
+			}
+			parent = value
+		}
+		if _, value := mappingEntry(parent, keys[len(keys)-1]); value != nil {
+			return
+		}
+		parent.Content = append(parent.Content, copyNode(defaultKey), copyNode(defaultValue))
+		result.Changes = append(result.Changes, fmt.Sprintf("add %s with the defaults of version %d", path, CurrentConfigVersion))
+	}
+}
+
+// renameKey moves the setting at from to to, keeping its value and
+// comments. The setting at to wins when both are set.
+func renameKey(from, to string) migrationStep {
+	return func(root, _ *yaml.Node, result *MigrationResult) {
+		fromKeys := strings.Split(from, ".")
+		fromParent := root
+		if len(fromKeys) > 1 {
+			_, fromParent = lookupEntry(root, fromKeys[:len(fromKeys)-1])
+		}
+		key, value := mappingEntry(fromParent, fromKeys[len(fromKeys)-1])
+		if value == nil {
+			return
+		}
+		removeEntry(fromParent, key)
+
+		toKeys := strings.Split(to, ".")
+		parent := root
+		for _, name := range toKeys[:len(toKeys)-1] {
+			_, next := mappingEntry(parent, name)
+			if next == nil {
+				next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, next)
+			}
+			if next.Kind != yaml.MappingNode {
+				result.Changes = append(result.Changes, fmt.Sprintf("remove %s; %s is not a mapping", from, strings.Join(toKeys[:len(toKeys)-1], ".")))
+				return
+			}
+			parent = next
+		}
+		if _, existing := mappingEntry(parent, toKeys[len(toKeys)-1]); existing != nil {
+			result.Changes = append(result.Changes, fmt.Sprintf("remove %s, which is overridden by %s", from, to))
+			return
+		}
+		key.Value = toKeys[len(toKeys)-1]
+		parent.Content = append(parent.Content, key, value)
+		result.Changes = append(result.Changes, fmt.Sprintf("rename %s to %s", from, to))
+	}
+}
+
+// rewriteLegacyPlaceholders rewrites {{issue-number}} and {issue_number} in
+// the values under the given sections as {{.IssueNumber}}.
+func rewriteLegacyPlaceholders(sections ...string) migrationStep {
+	return func(root, _ *yaml.Node, result *MigrationResult) {
+		var rewrite func(node *yaml.Node, field string)
+		rewrite = func(node *yaml.Node, field string) {
+			switch node.Kind {
+			case yaml.MappingNode:
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					rewrite(node.Content[i+1], joinField(field, node.Content[i].Value))
+				}
+			case yaml.SequenceNode:
+				for i, item := range node.Content {
+					rewrite(item, fmt.Sprintf("%s[%d]", field, i))
+				}
+			case yaml.ScalarNode:
+				if replaced := LegacyPlaceholders.Replace(node.Value); replaced != node.Value {
+					node.Value = replaced
+					result.Changes = append(result.Changes, fmt.Sprintf("rewrite the issue number placeholder in %s as {{.IssueNumber}}", field))
+				}
+			}
+		}
+		for _, section := range sections {
+			if _, value := mappingEntry(root, section); value != nil {
+				rewrite(value, section)
+			}
+		}
+	}
+}
+
+// shellSyntax matches the characters that make the shell read a value as
+// more than plain words, e.g. variables, quotes and redirections.
+var shellSyntax = regexp.MustCompile("[$`\\\\'\"|&;<>()*?~]")
+
+// splitCommandWords splits the command and the options of the phase commands
+// at spaces. Version 1 joined them into the shell command as they were, so
+// "npx claude" ran npx with the argument claude; version 2 passes each of them
+// as a single word. Values with shell syntax cannot be split without changing
+// what they do and are left unresolved for the user to rewrite.
+func splitCommandWords(root, _ *yaml.Node, result *MigrationResult) {
+	split := func(section *yaml.Node, prefix string) {
+		if section == nil || section.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(section.Content); i += 2 {
+			if command := section.Content[i+1]; command.Kind == yaml.MappingNode {
+				splitPhaseCommand(command, joinField(prefix, section.Content[i].Value), result)
+			}
+		}
+	}
+
+	_, phases := mappingEntry(root, "phase")
+	split(phases, "phase")
+	if _, profiles := mappingEntry(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			split(profiles.Content[i+1], "profiles."+profiles.Content[i].Value)
+		}
+	}
+}
+
+// splitPhaseCommand splits the command and the options of one phase command.
+// The words after the first word of command become the first options.
+func splitPhaseCommand(command *yaml.Node, field string, result *MigrationResult) {
+	words := func(node *yaml.Node, name string) []string {
+		if node.Kind != yaml.ScalarNode || len(strings.Fields(node.Value)) < 2 {
+			return nil
+		}
+		if shellSyntax.MatchString(node.Value) {
+			result.Unresolved = append(result.Unresolved, fmt.Sprintf("rewrite %s %q without shell syntax: version %d passes it to the shell as one word", name, node.Value, QuotedPhaseCommandsVersion))
+			return nil
+		}
+		result.Changes = append(result.Changes, fmt.Sprintf("split %s %q into words", name, node.Value))
+		return strings.Fields(node.Value)
+	}
+	scalars := func(values []string) []*yaml.Node {
+		nodes := make([]*yaml.Node, 0, len(values))
+		for _, value := range values {
+			nodes = append(nodes, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+		}
+		return nodes
+	}
+
+	var extra []string
+	if _, value := mappingEntry(command, "command"); value != nil {
+		if split := words(value, field+".command"); split != nil {
+			value.Value, value.Style = split[0], 0
+			extra = split[1:]
+		}
+	}
+
+	optionsKey, options := mappingEntry(command, "options")
+	if options != nil && options.Kind == yaml.SequenceNode {
+		var content []*yaml.Node
+		for i, option := range options.Content {
+			if split := words(option, fmt.Sprintf("%s.options[%d]", field, i)); split != nil {
+				content = append(content, scalars(split)...)
+				continue
+			}
+			content = append(content, option)
+		}
+		options.Content = content
+	}
+	if len(extra) == 0 {
+		return
+	}
+
+	switch {
+	case options == nil:
+		command.Content = append(command.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "options"},
+			&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: scalars(extra)})
+	case options.Tag == "!!null":
+		options.Kind, options.Tag, options.Value, options.Content = yaml.SequenceNode, "!!seq", "", scalars(extra)
+		optionsKey.LineComment = ""
+	case options.Kind == yaml.SequenceNode:
+		options.Content = append(scalars(extra), options.Content...)
+	}
+}
+
+// mappingEntry returns the key and value nodes of name in a mapping.
+func mappingEntry(mapping *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// lookupEntry returns the key and value nodes at the path of keys.
+func lookupEntry(mapping *yaml.Node, keys []string) (*yaml.Node, *yaml.Node) {
+	var key, value *yaml.Node
+	for _, name := range keys {
+		key, value = mappingEntry(mapping, name)
+		if value == nil {
+			return nil, nil
+		}
+		mapping = value
+	}
+	return key, value
+}
+
+// removeEntry removes the entry with key from a mapping.
+func removeEntry(mapping, key *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i] == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// copyNode returns a deep copy of node.
+func copyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyNode(child)
+	}
+	return &copied
+}
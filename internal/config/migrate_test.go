@@ -0,0 +1,204 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// versionOneConfig is a config file written by soba init before versioning.
+const versionOneConfig = `# GitHub settings
+github:
+  auth_method: gh  # or 'env', or omit for auto-detect
+  repository: owner/repo
+
+# Workflow settings
+workflow:
+  # Issue polling interval in seconds (default: 20)
+  interval: 30
+  auto_merge_enabled: true
+
+# Slack notifications
+slack:
+  webhook_url: ${SLACK_WEBHOOK_URL}
+
+# Phase commands
+phase:
+  plan:
+    command: claude
+    parameter: '/soba:plan {{issue-number}}'
+  implement:
+    command: claude
+    parameter: '/soba:implement {issue_number}'
+`
+
+func TestMigrate(t *testing.T) {
+	t.Run("upgrades a version 1 file keeping its comments and values", func(t *testing.T) {
+		result, err := Migrate([]byte(versionOneConfig))
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, result.From)
+		assert.Equal(t, CurrentConfigVersion, result.To)
+		assert.Contains(t, result.Changes, "add workflow.post_merge with the defaults of version 2")
+		assert.Contains(t, result.Changes, "add metrics with the defaults of version 2")
+		assert.Contains(t, result.Changes, "rewrite the issue number placeholder in phase.implement.parameter as {{.IssueNumber}}")
+		assert.Equal(t, "set version to 2", result.Changes[len(result.Changes)-1])
+
+		content := string(result.Content)
+		assert.True(t, strings.HasPrefix(content, "# Config file format version, upgraded by `soba config migrate`\nversion: 2\n\n# GitHub settings\ngithub:\n"), content)
+		assert.Contains(t, content, "auth_method: gh # or 'env', or omit for auto-detect")
+		assert.Contains(t, content, "  # Issue polling interval in seconds (default: 20)\n  interval: 30\n")
+		assert.Contains(t, content, "webhook_url: ${SLACK_WEBHOOK_URL}")
+		assert.Contains(t, content, "  # Cleanup after soba merges a PR\n  post_merge:\n")
+		assert.Contains(t, content, "parameter: '/soba:plan {{.IssueNumber}}'")
+		assert.Contains(t, content, "\n\n# Slack notifications\n")
+
+		cfg, _, err := LoadLayers(Layers{Project: writeConfig(t, content)})
+		require.NoError(t, err)
+		assert.Equal(t, CurrentConfigVersion, cfg.Version)
+		assert.Equal(t, 30, cfg.Workflow.Interval)
+		assert.True(t, cfg.Workflow.PostMerge.CloseIssue)
+		assert.True(t, cfg.Workflow.ChatOps.Enabled)
+	})
+
+	t.Run("leaves a current file unchanged", func(t *testing.T) {
+		result, err := Migrate([]byte(versionOneConfig))
+		require.NoError(t, err)
+
+		again, err := Migrate(result.Content)
+		require.NoError(t, err)
+		assert.Empty(t, again.Changes)
+		assert.Equal(t, result.Content, again.Content)
+	})
+
+	t.Run("keeps settings the file already has", func(t *testing.T) {
+		result, err := Migrate([]byte("workflow:\n  post_merge:\n    close_issue: false\nmetrics:\n"))
+		require.NoError(t, err)
+
+		assert.NotContains(t, result.Changes, "add workflow.post_merge with the defaults of version 2")
+		assert.Contains(t, string(result.Content), "close_issue: false")
+		assert.NotContains(t, string(result.Content), "delete_remote_branch")
+	})
+
+	t.Run("splits commands and options into words", func(t *testing.T) {
+		result, err := Migrate([]byte(`phase:
+  plan:
+    command: npx claude
+    options: [--model opus, --verbose]
+    parameter: '/soba:plan {{issue-number}}'
+  implement:
+    command: claude
+  review:
+    command: "$HOME/bin/agent --fast"
+    options:
+      - --token "${TOKEN}"
+profiles:
+  fast:
+    revise:
+      command: npx claude
+      options:
+`))
+		require.NoError(t, err)
+
+		assert.Contains(t, result.Changes, `split phase.plan.command "npx claude" into words`)
+		assert.Contains(t, result.Changes, `split phase.plan.options[0] "--model opus" into words`)
+		assert.Equal(t, []string{
+			`rewrite phase.review.command "$HOME/bin/agent --fast" without shell syntax: version 2 passes it to the shell as one word`,
+			`rewrite phase.review.options[0] "--token \"${TOKEN}\"" without shell syntax: version 2 passes it to the shell as one word`,
+		}, result.Unresolved)
+		// The version 1 quoting keeps working until the values are rewritten
+		assert.Equal(t, 1, result.To)
+		assert.NotContains(t, result.Changes, "set version to 2")
+		assert.NotContains(t, string(result.Content), "version:")
+
+		var cfg Config
+		require.NoError(t, yaml.Unmarshal(result.Content, &cfg))
+		assert.Equal(t, PhaseCommand{Command: "npx", Options: []string{"claude", "--model", "opus", "--verbose"}, Parameter: "/soba:plan {{.IssueNumber}}"}, cfg.Phase.Plan)
+		assert.Equal(t, PhaseCommand{Command: "claude"}, cfg.Phase.Implement)
+		assert.Equal(t, PhaseCommand{Command: "$HOME/bin/agent --fast", Options: []string{`--token "${TOKEN}"`}}, cfg.Phase.Review)
+		assert.Equal(t, PhaseCommand{Command: "npx", Options: []string{"claude"}}, cfg.Profiles["fast"].Revise)
+	})
+
+	t.Run("rejects a newer version", func(t *testing.T) {
+		_, err := Migrate([]byte("version: 3\n"))
+		assert.EqualError(t, err, "config version 3 is newer than this soba supports (2); upgrade soba")
+	})
+
+	t.Run("adds every setting of the current template", func(t *testing.T) {
+		result, err := Migrate([]byte("version: 1\n"))
+		require.NoError(t, err)
+
+		// A setting missing from config_template.yml would be skipped silently
+		var added int
+		for _, change := range result.Changes {
+			if strings.HasPrefix(change, "add ") {
+				added++
+			}
+		}
+		assert.Equal(t, 12, added)
+		_, _, err = LoadLayers(Layers{Project: writeConfig(t, string(result.Content))})
+		assert.NoError(t, err)
+	})
+}
+
+func TestRenameKey(t *testing.T) {
+	var document yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("workflow:\n  # merge PRs\n  auto_merge: true\n"), &document))
+	root := document.Content[0]
+
+	var result MigrationResult
+	renameKey("workflow.auto_merge", "workflow.auto_merge_enabled")(root, nil, &result)
+
+	assert.Equal(t, []string{"rename workflow.auto_merge to workflow.auto_merge_enabled"}, result.Changes)
+	out, err := yaml.Marshal(&document)
+	require.NoError(t, err)
+	assert.Equal(t, "workflow:\n    # merge PRs\n    auto_merge_enabled: true\n", string(out))
+}
+
+func TestLoadLayers_Version(t *testing.T) {
+	t.Run("takes the version from the project config only", func(t *testing.T) {
+		global := writeConfig(t, "version: 2\n")
+		project := writeConfig(t, "github:\n  repository: owner/repo\n")
+		cfg, _, err := LoadLayers(Layers{Global: global, Project: project})
+		require.NoError(t, err)
+		assert.Equal(t, 0, cfg.Version)
+
+		project = writeConfig(t, "version: 2\ngithub:\n  repository: owner/repo\n")
+		cfg, _, err = LoadLayers(Layers{Global: writeConfig(t, "version: 1\n"), Project: project})
+		require.NoError(t, err)
+		assert.Equal(t, 2, cfg.Version)
+		assert.Empty(t, cfg.Warnings())
+	})
+
+	t.Run("returns the changes of an outdated project config as a warning", func(t *testing.T) {
+		path := writeConfig(t, versionOneConfig)
+		cfg, _, err := LoadLayers(Layers{Project: path})
+		require.NoError(t, err)
+
+		require.Len(t, cfg.Warnings(), 1)
+		assert.True(t, strings.HasPrefix(cfg.Warnings()[0], path+" uses config version 1; run `soba config migrate` to upgrade it to version 2:\n  - add "), cfg.Warnings()[0])
+		assert.Contains(t, cfg.Warnings()[0], "\n  - set version to 2")
+	})
+}
+
+func TestTemplate_IsCurrentVersion(t *testing.T) {
+	result, err := Migrate([]byte(GenerateTemplate()))
+	require.NoError(t, err)
+	assert.Equal(t, CurrentConfigVersion, result.From, "config_template.yml must set version to CurrentConfigVersion")
+
+	result, err = Migrate([]byte(generateFallbackTemplate()))
+	require.NoError(t, err)
+	assert.Equal(t, CurrentConfigVersion, result.From)
+}
+
+func TestLoadLayers_NewerVersion(t *testing.T) {
+	path := writeConfig(t, "version: 3\ngithub:\n  repository: owner/repo\n")
+	_, _, err := LoadLayers(Layers{Project: path})
+
+	problems := validationProblems(t, err)
+	require.Len(t, problems, 1)
+	assert.Equal(t, Problem{Field: "version", Source: path, Line: 1, Column: 10, Message: "version 3 is newer than this soba supports (2); upgrade soba"}, problems[0])
+}
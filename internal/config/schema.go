@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// SchemaID is the $id of the JSON Schema for .soba/config.yml.
+const SchemaID = "https://raw.githubusercontent.com/douhashi/soba/main/docs/config.schema.json"
+
+// JSONSchema returns a JSON Schema for .soba/config.yml generated from Config
+// and the validation rules, so editors can complete and check the file.
+func JSONSchema() ([]byte, error) {
+	schema := schemaFor(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaID
+	schema["title"] = "soba configuration"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaFor returns the schema of values of type t found at rulePath.
+func schemaFor(t reflect.Type, rulePath string) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for name, field := range yamlFields(t) {
+			properties[name] = schemaFor(field.Type, joinField(rulePath, name))
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": schemaFor(t.Elem(), joinField(rulePath, "*")),
+		}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": schemaFor(t.Elem(), rulePath+".*"),
+		}
+	}
+
+	schema := map[string]interface{}{"type": typeName(t)}
+	rule, ok := fieldRules[rulePath]
+	if !ok {
+		return schema
+	}
+	if len(rule.Enum) > 0 {
+		// An empty string leaves the setting unset
+		schema["enum"] = append([]string{""}, rule.Enum...)
+	}
+	if rule.Pattern != "" {
+		schema["pattern"] = rule.Pattern
+	}
+	if rule.Min != nil {
+		schema["minimum"] = *rule.Min
+	}
+	if rule.Max != nil {
+		schema["maximum"] = *rule.Max
+	}
+	return schema
+}
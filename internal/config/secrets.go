@@ -0,0 +1,291 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// MaskedValue replaces the value of a secret setting in output, logs and
+// transcripts.
+const MaskedValue = logging.RedactedValue
+
+// SecretTag marks a value in a config file as secret, e.g.
+// `API_KEY: !secret sk-...`, so that it is masked like a token.
+const SecretTag = "!secret"
+
+// secretCommandTimeout bounds the commands run for ${cmd:...} and
+// ${keyring:...} references.
+const secretCommandTimeout = 10 * time.Second
+
+// secretReferencePattern matches ${file:/path}, ${cmd:command} and
+// ${keyring:service/key}.
+var secretReferencePattern = regexp.MustCompile(`\$\{(file|cmd|keyring):([^}]*)\}`)
+
+// secretResolvers read the value of a secret reference by its scheme.
+var secretResolvers = map[string]func(ctx context.Context, ref string) (string, error){
+	"file":    resolveFileSecret,
+	"cmd":     resolveCommandSecret,
+	"keyring": resolveKeyringSecret,
+}
+
+// runSecretCommand runs a command and returns its standard output. It is a
+// variable so that tests can replace the keyring tools.
+var runSecretCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 - the command comes from the soba config
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// isSecretReference reports whether the name inside ${...} is a secret
+// reference rather than an environment variable.
+func isSecretReference(name string) bool {
+	scheme, _, ok := strings.Cut(name, ":")
+	_, known := secretResolvers[scheme]
+	return ok && known
+}
+
+// secretPolicy says which secret references resolveSecrets runs.
+type secretPolicy struct {
+	// trusted reports whether the value of a field may run a command for a
+	// cmd: or keyring: reference; nil trusts every field
+	trusted func(field string) bool
+	// skipCommands leaves cmd: and keyring: references unresolved
+	skipCommands bool
+	// unresolved collects the fields whose references were left unresolved
+	unresolved map[string]bool
+}
+
+// runsCommand reports whether a reference of the scheme runs a command.
+func runsCommand(scheme string) bool {
+	return scheme == "cmd" || scheme == "keyring"
+}
+
+// resolveSecrets replaces the secret references in the scalars under node
+// and strips the !secret tags. It returns the fields that hold a secret,
+// by YAML path.
+func resolveSecrets(ctx context.Context, node *yaml.Node, field string, policy secretPolicy, secrets map[string]bool) []Problem {
+	if node == nil {
+		return nil
+	}
+	var problems []Problem
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, resolveSecrets(ctx, node.Content[i+1], joinField(field, node.Content[i].Value), policy, secrets)...)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			problems = append(problems, resolveSecrets(ctx, item, fmt.Sprintf("%s[%d]", field, i), policy, secrets)...)
+		}
+	case yaml.ScalarNode:
+		if node.Tag == SecretTag {
+			node.Tag = "!!str"
+			secrets[field] = true
+		}
+		if !secretReferencePattern.MatchString(node.Value) {
+			return nil
+		}
+		secrets[field] = true
+		node.Value = secretReferencePattern.ReplaceAllStringFunc(node.Value, func(reference string) string {
+			match := secretReferencePattern.FindStringSubmatch(reference)
+			if runsCommand(match[1]) {
+				if policy.trusted != nil && !policy.trusted(field) {
+					problems = append(problems, Problem{Field: field, Message: fmt.Sprintf("cannot resolve %s: %s: references are only allowed in the global config and SOBA_* environment variables", reference, match[1])})
+					return reference
+				}
+				if policy.skipCommands {
+					if policy.unresolved != nil {
+						policy.unresolved[field] = true
+					}
+					return reference
+				}
+			}
+			value, err := secretResolvers[match[1]](ctx, strings.TrimSpace(match[2]))
+			if err != nil {
+				problems = append(problems, Problem{Field: field, Message: fmt.Sprintf("cannot resolve %s: %v", reference, err)})
+				return ""
+			}
+			return value
+		})
+	}
+	return problems
+}
+
+// resolveFileSecret reads a secret from a file, e.g. ${file:~/.config/soba/token}.
+func resolveFileSecret(_ context.Context, path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	data, err := os.ReadFile(path) // #nosec G304 - the path comes from the soba config
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveCommandSecret runs a shell command and uses its output, e.g.
+// ${cmd:gh auth token} or ${cmd:op read op://vault/soba/token}.
+func resolveCommandSecret(ctx context.Context, command string) (string, error) {
+	if command == "" {
+		return "", fmt.Errorf("empty command")
+	}
+	ctx, cancel := context.WithTimeout(ctx, secretCommandTimeout)
+	defer cancel()
+	out, err := runSecretCommand(ctx, "sh", "-c", command)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// resolveKeyringSecret reads a password from the OS keyring, e.g.
+// ${keyring:soba/github-token}. It uses the macOS keychain through security
+// and the Secret Service (GNOME Keyring, KWallet) through secret-tool.
+func resolveKeyringSecret(ctx context.Context, ref string) (string, error) {
+	service, key, ok := strings.Cut(ref, "/")
+	if !ok || service == "" || key == "" {
+		return "", fmt.Errorf("must be service/key")
+	}
+	ctx, cancel := context.WithTimeout(ctx, secretCommandTimeout)
+	defer cancel()
+
+	var out []byte
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		out, err = runSecretCommand(ctx, "security", "find-generic-password", "-s", service, "-a", key, "-w")
+	case "windows":
+		return "", fmt.Errorf("the keyring is not supported on windows")
+	default:
+		out, err = runSecretCommand(ctx, "secret-tool", "lookup", "service", service, "username", key)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// secretRulePaths are the settings tagged `secret:"true"`, by rule path.
+var secretRulePaths = taggedSecretPaths(reflect.TypeOf(Config{}), "", make(map[string]bool))
+
+func taggedSecretPaths(t reflect.Type, rulePath string, paths map[string]bool) map[string]bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" {
+				paths[joinField(rulePath, name)] = true
+			}
+			taggedSecretPaths(field.Type, joinField(rulePath, name), paths)
+		}
+	case reflect.Map, reflect.Slice:
+		taggedSecretPaths(t.Elem(), rulePath+".*", paths)
+	}
+	return paths
+}
+
+// secretFields returns the settings of cfg that hold a secret: the fields
+// tagged `secret:"true"`, values tagged !secret and values read from a
+// secret reference.
+func secretFields(cfg *Config) map[string]bool {
+	fields := make(map[string]bool)
+	if cfg == nil {
+		return fields
+	}
+	walkFields(reflect.ValueOf(*cfg), "", "", func(field, rulePath string, _ reflect.Value) {
+		if secretRulePaths[rulePath] || cfg.secrets[field] {
+			fields[field] = true
+		}
+	})
+	return fields
+}
+
+// SecretValues returns the non-empty values of the secret settings, longest
+// first, so that they can be redacted from logs and transcripts.
+func (c *Config) SecretValues() []string {
+	if c == nil {
+		return nil
+	}
+	secrets := secretFields(c)
+	var values []string
+	walkFields(reflect.ValueOf(*c), "", "", func(field, _ string, v reflect.Value) {
+		if secrets[field] && v.Kind() == reflect.String && v.String() != "" {
+			values = append(values, v.String())
+		}
+	})
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values
+}
+
+// maskSecrets replaces the secret strings under v, which must be settable.
+// Tagged fields are masked even when empty so that the output does not tell
+// whether they are set.
+func maskSecrets(v reflect.Value, field, rulePath string, secrets map[string]bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			maskSecrets(v.Field(i), joinField(field, name), joinField(rulePath, name), secrets)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		masked := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			maskSecrets(elem, joinField(field, key.String()), joinField(rulePath, "*"), secrets)
+			masked.SetMapIndex(key, elem)
+		}
+		v.Set(masked)
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		masked := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(masked, v)
+		for i := 0; i < masked.Len(); i++ {
+			maskSecrets(masked.Index(i), fmt.Sprintf("%s[%d]", field, i), rulePath+".*", secrets)
+		}
+		v.Set(masked)
+	case reflect.String:
+		if secretRulePaths[rulePath] || (secrets[field] && v.String() != "") {
+			v.SetString(MaskedValue)
+		}
+	}
+}
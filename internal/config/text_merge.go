@@ -0,0 +1,166 @@
+package config
+
+import (
+	"slices"
+	"strings"
+)
+
+// Conflict markers written by mergeText around the lines it cannot merge.
+const (
+	conflictStart     = "<<<<<<< yours"
+	conflictSeparator = "======="
+	conflictEnd       = ">>>>>>> soba"
+)
+
+// matchingBlock is a run of equal lines, starting at base in the base text
+// and at other in the other text.
+type matchingBlock struct {
+	base, other, length int
+}
+
+// syncRegion is a run of lines that are equal in the base, ours and theirs.
+type syncRegion struct {
+	baseStart, baseEnd     int
+	oursStart, oursEnd     int
+	theirsStart, theirsEnd int
+}
+
+// mergeText merges the changes from base to ours and from base to theirs
+// line by line, like diff3. A region changed differently on both sides is
+// written with conflict markers, ours first, and labelled with label. It
+// returns the merged text and the number of conflicts.
+func mergeText(base, ours, theirs, label string) (string, int) {
+	baseLines, oursLines, theirsLines := splitLines(base), splitLines(ours), splitLines(theirs)
+
+	var b strings.Builder
+	conflicts := 0
+	write := func(lines []string) {
+		for _, line := range lines {
+			b.WriteString(line)
+		}
+	}
+	baseAt, oursAt, theirsAt := 0, 0, 0
+	for _, region := range syncRegions(baseLines, oursLines, theirsLines) {
+		baseChunk := baseLines[baseAt:region.baseStart]
+		oursChunk := oursLines[oursAt:region.oursStart]
+		theirsChunk := theirsLines[theirsAt:region.theirsStart]
+		switch {
+		case slices.Equal(oursChunk, theirsChunk), slices.Equal(theirsChunk, baseChunk):
+			write(oursChunk)
+		case slices.Equal(oursChunk, baseChunk):
+			write(theirsChunk)
+		default:
+			conflicts++
+			b.WriteString(conflictStart + "\n")
+			write(terminated(oursChunk))
+			b.WriteString(conflictSeparator + "\n")
+			write(terminated(theirsChunk))
+			b.WriteString(conflictEnd + " " + label + "\n")
+		}
+		write(baseLines[region.baseStart:region.baseEnd])
+		baseAt, oursAt, theirsAt = region.baseEnd, region.oursEnd, region.theirsEnd
+	}
+	return b.String(), conflicts
+}
+
+// hasConflictMarkers reports whether text still contains a conflict written
+// by mergeText.
+func hasConflictMarkers(text string) bool {
+	for _, line := range splitLines(text) {
+		if strings.HasPrefix(line, conflictStart) || strings.HasPrefix(line, conflictEnd+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// syncRegions returns the runs of lines the three texts share, in order,
+// ending with an empty region at the end of all three.
+func syncRegions(base, ours, theirs []string) []syncRegion {
+	oursBlocks := matchingBlocks(base, ours)
+	theirsBlocks := matchingBlocks(base, theirs)
+
+	var regions []syncRegion
+	for o, t := 0, 0; o < len(oursBlocks) && t < len(theirsBlocks); {
+		ob, tb := oursBlocks[o], theirsBlocks[t]
+		start := max(ob.base, tb.base)
+		end := min(ob.base+ob.length, tb.base+tb.length)
+		if start < end {
+			oursStart := ob.other + start - ob.base
+			theirsStart := tb.other + start - tb.base
+			regions = append(regions, syncRegion{
+				baseStart: start, baseEnd: end,
+				oursStart: oursStart, oursEnd: oursStart + end - start,
+				theirsStart: theirsStart, theirsEnd: theirsStart + end - start,
+			})
+		}
+		if ob.base+ob.length < tb.base+tb.length {
+			o++
+		} else {
+			t++
+		}
+	}
+	return append(regions, syncRegion{
+		baseStart: len(base), baseEnd: len(base),
+		oursStart: len(ours), oursEnd: len(ours),
+		theirsStart: len(theirs), theirsEnd: len(theirs),
+	})
+}
+
+// matchingBlocks returns the runs of lines in a longest common subsequence
+// of base and other.
+func matchingBlocks(base, other []string) []matchingBlock {
+	// lcs[i][j] is the length of the longest common subsequence of base[i:] and other[j:]
+	lcs := make([][]int, len(base)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(other)+1)
+	}
+	for i := len(base) - 1; i >= 0; i-- {
+		for j := len(other) - 1; j >= 0; j-- {
+			if base[i] == other[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var blocks []matchingBlock
+	for i, j := 0, 0; i < len(base) && j < len(other); {
+		switch {
+		case base[i] == other[j]:
+			if n := len(blocks); n > 0 && blocks[n-1].base+blocks[n-1].length == i && blocks[n-1].other+blocks[n-1].length == j {
+				blocks[n-1].length++
+			} else {
+				blocks = append(blocks, matchingBlock{base: i, other: j, length: 1})
+			}
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return blocks
+}
+
+// splitLines splits text into lines that keep their line endings.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// terminated ends the last of lines with a newline so that a conflict
+// marker can follow it.
+func terminated(lines []string) []string {
+	if len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "\n") {
+		return lines
+	}
+	out := append([]string(nil), lines...)
+	out[len(out)-1] += "\n"
+	return out
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeText(t *testing.T) {
+	base := "# Review\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n"
+
+	tests := []struct {
+		name      string
+		ours      string
+		theirs    string
+		want      string
+		conflicts int
+	}{
+		{
+			name:   "takes changes from both sides in different regions",
+			ours:   "# Review\n\n1. Read the diff carefully\n2. Run the tests\n3. Post the result\n",
+			theirs: "# Review\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n4. Add the label\n",
+			want:   "# Review\n\n1. Read the diff carefully\n2. Run the tests\n3. Post the result\n4. Add the label\n",
+		},
+		{
+			name:   "accepts the same change on both sides",
+			ours:   "# Review\n\n1. Read the diff\n2. Run make test\n3. Post the result\n",
+			theirs: "# Review\n\n1. Read the diff\n2. Run make test\n3. Post the result\n",
+			want:   "# Review\n\n1. Read the diff\n2. Run make test\n3. Post the result\n",
+		},
+		{
+			name:      "marks conflicting changes",
+			ours:      "# Review\n\n1. Read the diff\n2. Run go test ./...\n3. Post the result\n",
+			theirs:    "# Review\n\n1. Read the diff\n2. Run make test\n3. Post the result\n",
+			want:      "# Review\n\n1. Read the diff\n<<<<<<< yours\n2. Run go test ./...\n=======\n2. Run make test\n>>>>>>> soba v2\n3. Post the result\n",
+			conflicts: 1,
+		},
+		{
+			name:      "ends a last line without a newline before a marker",
+			ours:      base + "Be strict",
+			theirs:    base + "Be kind\n",
+			want:      base + "<<<<<<< yours\nBe strict\n=======\nBe kind\n>>>>>>> soba v2\n",
+			conflicts: 1,
+		},
+		{
+			name:   "keeps lines deleted on one side deleted",
+			ours:   "# Review\n\n1. Read the diff\n3. Post the result\n",
+			theirs: "# Review\n\n1. Read the diff\n2. Run the tests\n3. Post the result\n\nDone.\n",
+			want:   "# Review\n\n1. Read the diff\n3. Post the result\n\nDone.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := mergeText(base, tt.ours, tt.theirs, "v2")
+
+			assert.Equal(t, tt.want, merged)
+			assert.Equal(t, tt.conflicts, conflicts)
+			assert.Equal(t, tt.conflicts > 0, hasConflictMarkers(merged))
+		})
+	}
+}
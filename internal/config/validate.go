@@ -0,0 +1,431 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Problem is one invalid setting. Source is the config file or environment
+// variable that set it. Line and Column are 0 when the setting does not come
+// from a file, e.g. a required field that is missing.
+type Problem struct {
+	Field   string `json:"field"`
+	Source  string `json:"source,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.Source != "" {
+		fmt.Fprintf(&b, "%s: ", p.Source)
+	}
+	if p.Line > 0 {
+		fmt.Fprintf(&b, "line %d, column %d: ", p.Line, p.Column)
+	}
+	fmt.Fprintf(&b, "%s: %s", p.Field, p.Message)
+	return b.String()
+}
+
+// ValidationError reports every problem found in a config file.
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config %s:", e.Path)
+	for _, problem := range e.Problems {
+		if problem.Source == e.Path {
+			problem.Source = ""
+		}
+		fmt.Fprintf(&b, "\n  %s", problem)
+	}
+	return b.String()
+}
+
+// fieldRule constrains the value of a scalar setting. Paths use * for map keys
+// and list items. An empty string always means the setting is unset.
+// The same rules generate the enums and bounds of the JSON Schema.
+type fieldRule struct {
+	Enum    []string
+	Min     *float64
+	Max     *float64
+	Pattern string
+	// Format describes Pattern in error messages.
+	Format string
+}
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+var fieldRules = map[string]fieldRule{
+	"version":                                  {Min: bound(0), Max: bound(CurrentConfigVersion)},
+	"github.repository":                        {Pattern: `^([A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+)?$`, Format: "owner/repo"},
+	"github.auth_method":                       {Enum: []string{"gh", "env", "token"}},
+	"workflow.interval":                        {Min: bound(1), Max: bound(3600)},
+	"workflow.closed_issue_cleanup_interval":   {Min: bound(0)},
+	"workflow.tmux_command_delay":              {Min: bound(0)},
+	"workflow.branch_update_method":            {Enum: []string{"api", "rebase", "none"}},
+	"workflow.human_review.required_approvals": {Min: bound(0)},
+	"workflow.transcript.comment_lines":        {Min: bound(0)},
+	"workflow.budget.max_phase_runs_per_day":   {Min: bound(0)},
+	"workflow.budget.max_phase_runs_per_month": {Min: bound(0)},
+	"workflow.budget.max_issues_per_day":       {Min: bound(0)},
+	"workflow.budget.max_issues_per_month":     {Min: bound(0)},
+	"workflow.budget.max_cost_per_day":         {Min: bound(0)},
+	"workflow.budget.max_cost_per_month":       {Min: bound(0)},
+	"workflow.schedule.active_hours":           {Pattern: `^(\d{2}:\d{2}-\d{2}:\d{2})?$`, Format: "HH:MM-HH:MM"},
+	"workflow.schedule.weekdays.*":             {Enum: weekdayNames},
+	"git.worktree_max_count":                   {Min: bound(0)},
+	"git.worktree_max_size_mb":                 {Min: bound(0)},
+	"log.retention_count":                      {Min: bound(0)},
+	"log.level":                                {Enum: []string{"debug", "info", "warn", "warning", "error"}},
+	"log.format":                               {Enum: []string{"text", "json"}},
+}
+
+// weekdayNames are the values accepted in workflow.schedule.weekdays.
+var weekdayNames = []string{
+	"sun", "mon", "tue", "wed", "thu", "fri", "sat",
+	"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday",
+}
+
+// envNamePattern matches valid environment variable names.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var activeHoursPattern = regexp.MustCompile(fieldRules["workflow.schedule.active_hours"].Pattern)
+
+// position is where a setting appears in the YAML source.
+type position struct {
+	line   int
+	column int
+}
+
+// parseLayer parses one config file and reports unknown fields and values of
+// the wrong type with their line and column. It also returns the position of
+// every setting so that semantic problems can point at it.
+func parseLayer(content []byte) (*yaml.Node, map[string]position, []Problem, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, nil, nil, err
+	}
+
+	positions := make(map[string]position)
+	var problems []Problem
+	if len(root.Content) == 0 {
+		return nil, positions, nil, nil
+	}
+	document := root.Content[0]
+	checkNode(document, reflect.TypeOf(Config{}), "", positions, &problems)
+	return document, positions, problems, nil
+}
+
+// decodeLenient decodes node into cfg. The values of the wrong type are
+// already reported by parseLayer; the rest is decoded so that it can be
+// validated too.
+func decodeLenient(node *yaml.Node, cfg *Config) error {
+	var typeErr *yaml.TypeError
+	if err := node.Decode(cfg); err != nil && !errors.As(err, &typeErr) {
+		return err
+	}
+	return nil
+}
+
+// checkNode compares a YAML node with the Go type it is decoded into.
+func checkNode(node *yaml.Node, t reflect.Type, field string, positions map[string]position, problems *[]Problem) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if field != "" {
+		positions[field] = position{line: node.Line, column: node.Column}
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	problem := func(message string) {
+		*problems = append(*problems, Problem{Field: field, Line: node.Line, Column: node.Column, Message: message})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			problem("expected a mapping")
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := joinField(field, key.Value)
+			structField, ok := fields[key.Value]
+			if !ok {
+				message := "unknown field"
+				if suggestion := closestName(key.Value, fields); suggestion != "" {
+					message += fmt.Sprintf(" (did you mean %s?)", suggestion)
+				}
+				*problems = append(*problems, Problem{Field: child, Line: key.Line, Column: key.Column, Message: message})
+				continue
+			}
+			checkNode(value, structField.Type, child, positions, problems)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			problem("expected a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkNode(node.Content[i+1], t.Elem(), joinField(field, node.Content[i].Value), positions, problems)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			problem("expected a list")
+			return
+		}
+		for i, item := range node.Content {
+			checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", field, i), positions, problems)
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			problem(fmt.Sprintf("expected %s value", typeName(t)))
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			problem(fmt.Sprintf("cannot use %q as %s value", node.Value, typeName(t)))
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of a struct to its fields.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// closestName returns the known key within two edits of name, if any.
+func closestName(name string, fields map[string]reflect.StructField) string {
+	best, bestDistance := "", 3
+	for candidate := range fields {
+		if d := editDistance(name, candidate); d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return "integer"
+	case reflect.Float64, reflect.Float32:
+		return "number"
+	default:
+		return t.Kind().String()
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// Validate checks the semantics of a loaded config and returns every problem
+// found. Problems carry no position; Load adds them from the YAML source.
+func Validate(cfg *Config) []Problem {
+	var problems []Problem
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, Problem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	walkFields(reflect.ValueOf(*cfg), "", "", func(field, rulePath string, v reflect.Value) {
+		rule, ok := fieldRules[rulePath]
+		if !ok {
+			return
+		}
+		if message := rule.check(v); message != "" {
+			add(field, "%s", message)
+		}
+	})
+
+	schedule := cfg.Workflow.Schedule
+	if schedule.ActiveHours != "" && activeHoursPattern.MatchString(schedule.ActiveHours) && !validActiveHours(schedule.ActiveHours) {
+		add("workflow.schedule.active_hours", "invalid time in %q", schedule.ActiveHours)
+	}
+	if schedule.Timezone != "" {
+		if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+			add("workflow.schedule.timezone", "unknown time zone %q", schedule.Timezone)
+		}
+	}
+
+	if cfg.Slack.NotificationsEnabled && cfg.Slack.WebhookURL == "" {
+		add("slack.webhook_url", "is required when slack.notifications_enabled is true")
+	}
+	if url := cfg.Slack.WebhookURL; url != "" && !strings.Contains(url, "${") &&
+		!strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		add("slack.webhook_url", "must be an http(s) URL")
+	}
+
+	if cfg.Metrics.Listen != "" {
+		if _, port, err := net.SplitHostPort(cfg.Metrics.Listen); err != nil {
+			add("metrics.listen", "must be host:port, got %q", cfg.Metrics.Listen)
+		} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			add("metrics.listen", "invalid port %q", port)
+		}
+	}
+
+	for _, phase := range phaseNames {
+		validatePhaseCommand("phase."+phase, cfg.Phase.ForPhase(phase), true, add)
+	}
+	for _, name := range sortedProfileNames(cfg.Profiles) {
+		for _, phase := range phaseNames {
+			validatePhaseCommand(fmt.Sprintf("profiles.%s.%s", name, phase), cfg.Profiles[name].ForPhase(phase), false, add)
+		}
+	}
+
+	return problems
+}
+
+// validatePhaseCommand checks one phase command. Profiles override only the
+// fields they set, so they may leave the command empty.
+func validatePhaseCommand(field string, command PhaseCommand, requireCommand bool, add func(field, format string, args ...interface{})) {
+	if requireCommand && command.Command == "" &&
+		(len(command.Options) > 0 || command.Parameter != "" || len(command.Env) > 0 || command.Workdir != "") {
+		add(field+".command", "is required when other fields of the phase are set")
+	}
+	for name := range command.Env {
+		if !envNamePattern.MatchString(name) {
+			add(field+".env."+name, "invalid environment variable name")
+		}
+	}
+}
+
+// check returns why v violates the rule, or "" when it does not.
+func (r fieldRule) check(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if len(r.Enum) > 0 && s != "" && !containsString(r.Enum, strings.ToLower(s)) {
+			return fmt.Sprintf("must be one of %s, got %q", strings.Join(r.Enum, ", "), s)
+		}
+		if r.Pattern != "" && !regexp.MustCompile(r.Pattern).MatchString(s) {
+			return fmt.Sprintf("must be %s, got %q", r.Format, s)
+		}
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		var n float64
+		if v.Kind() == reflect.Float64 {
+			n = v.Float()
+		} else {
+			n = float64(v.Int())
+		}
+		if r.Min != nil && n < *r.Min {
+			return fmt.Sprintf("must be at least %v, got %v", *r.Min, n)
+		}
+		if r.Max != nil && n > *r.Max {
+			return fmt.Sprintf("must be at most %v, got %v", *r.Max, n)
+		}
+	}
+	return ""
+}
+
+// walkFields calls fn for every field of the config with its YAML path and
+// the path used to look up its rule.
+func walkFields(v reflect.Value, field, rulePath string, fn func(field, rulePath string, v reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			walkFields(v.Field(i), joinField(field, name), joinField(rulePath, name), fn)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			walkFields(v.MapIndex(key), joinField(field, key.String()), joinField(rulePath, "*"), fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkFields(v.Index(i), fmt.Sprintf("%s[%d]", field, i), rulePath+".*", fn)
+		}
+	default:
+		fn(field, rulePath, v)
+	}
+}
+
+// validActiveHours reports whether s is a HH:MM-HH:MM range of valid times.
+func validActiveHours(s string) bool {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return false
+	}
+	for _, part := range parts {
+		if _, err := time.Parse("15:04", strings.TrimSpace(part)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedProfileNames(profiles map[string]PhaseConfig) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func loadYAML(t *testing.T, content string) (*Config, error) {
+	t.Helper()
+	return Load(writeConfig(t, content))
+}
+
+func validationProblems(t *testing.T, err error) []Problem {
+	t.Helper()
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "expected a ValidationError, got %v", err)
+	return validationErr.Problems
+}
+
+func TestLoad_StrictDecoding(t *testing.T) {
+	t.Run("reports unknown keys with a suggestion", func(t *testing.T) {
+		path := writeConfig(t, "github:\n  repository: owner/repo\nworkflow:\n  intervall: 5\n")
+		_, err := Load(path)
+
+		problems := validationProblems(t, err)
+		require.Len(t, problems, 1)
+		assert.Equal(t, Problem{Field: "workflow.intervall", Source: path, Line: 4, Column: 3, Message: "unknown field (did you mean interval?)"}, problems[0])
+	})
+
+	t.Run("reports values of the wrong type", func(t *testing.T) {
+		path := writeConfig(t, "workflow:\n  interval: soon\n  use_tmux: maybe\nphase:\n  plan:\n    options: -v\n")
+		_, err := Load(path)
+
+		problems := validationProblems(t, err)
+		require.Len(t, problems, 3)
+		assert.Equal(t, Problem{Field: "workflow.interval", Source: path, Line: 2, Column: 13, Message: `cannot use "soon" as integer value`}, problems[0])
+		assert.Equal(t, "workflow.use_tmux", problems[1].Field)
+		assert.Equal(t, Problem{Field: "phase.plan.options", Source: path, Line: 6, Column: 14, Message: "expected a list"}, problems[2])
+	})
+
+	t.Run("includes the YAML syntax error", func(t *testing.T) {
+		_, err := loadYAML(t, "github: [\n")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid YAML format: line")
+	})
+
+	t.Run("accepts profiles and phase env", func(t *testing.T) {
+		cfg, err := loadYAML(t, `github:
+  repository: owner/repo
+phase:
+  plan:
+    command: claude
+    env:
+      ISSUE: "{{.IssueNumber}}"
+profiles:
+  fast:
+    plan:
+      options: [--model, haiku]
+`)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--model", "haiku"}, cfg.Profiles["fast"].Plan.Options)
+	})
+}
+
+func TestLoad_SemanticValidation(t *testing.T) {
+	path := writeConfig(t, `github:
+  repository: owner
+  auth_method: ssh
+workflow:
+  interval: 7200
+  branch_update_method: merge
+  budget:
+    max_cost_per_day: -1
+  schedule:
+    active_hours: "25:00-18:00"
+    weekdays: [mon, funday]
+    timezone: Mars/Olympus
+slack:
+  notifications_enabled: true
+log:
+  level: loud
+  format: xml
+metrics:
+  listen: "9090"
+phase:
+  plan:
+    parameter: "{{.IssueNumber}}"
+    env:
+      BAD-NAME: x
+`)
+	_, err := Load(path)
+
+	problems := validationProblems(t, err)
+	fields := make(map[string]Problem)
+	for _, problem := range problems {
+		fields[problem.Field] = problem
+	}
+	assert.Equal(t, Problem{Field: "github.repository", Source: path, Line: 2, Column: 15, Message: `must be owner/repo, got "owner"`}, fields["github.repository"])
+	assert.Contains(t, fields["github.auth_method"].Message, "must be one of gh, env, token")
+	assert.Equal(t, `must be at most 3600, got 7200`, fields["workflow.interval"].Message)
+	assert.Contains(t, fields["workflow.branch_update_method"].Message, `got "merge"`)
+	assert.Equal(t, "must be at least 0, got -1", fields["workflow.budget.max_cost_per_day"].Message)
+	assert.Equal(t, `invalid time in "25:00-18:00"`, fields["workflow.schedule.active_hours"].Message)
+	assert.Equal(t, 11, fields["workflow.schedule.weekdays[1]"].Line)
+	assert.Contains(t, fields["workflow.schedule.timezone"].Message, "unknown time zone")
+	assert.Equal(t, Problem{Field: "slack.webhook_url", Message: "is required when slack.notifications_enabled is true"}, fields["slack.webhook_url"])
+	assert.Contains(t, fields["log.level"].Message, `got "loud"`)
+	assert.Contains(t, fields["log.format"].Message, `got "xml"`)
+	assert.Contains(t, fields["metrics.listen"].Message, "must be host:port")
+	assert.Contains(t, fields, "phase.plan.command")
+	assert.Contains(t, fields, "phase.plan.env.BAD-NAME")
+	assert.Len(t, problems, 14)
+
+	assert.Contains(t, err.Error(), "line 2, column 15: github.repository: must be owner/repo")
+}
+
+func TestValidate_GeneratedTemplate(t *testing.T) {
+	for _, name := range AgentPresetNames() {
+		preset, err := GetAgentPreset(name)
+		require.NoError(t, err)
+
+		_, err = loadYAML(t, GenerateTemplateWithOptions(&TemplateOptions{
+			Repository: "owner/repo",
+			LogLevel:   "info",
+			Phases:     preset.PhaseCommands(),
+		}))
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := JSONSchema()
+	require.NoError(t, err)
+
+	var parsed map[string]interface{}
+	require.NoError(t, json.Unmarshal(schema, &parsed))
+	assert.Equal(t, false, parsed["additionalProperties"])
+	workflow := parsed["properties"].(map[string]interface{})["workflow"].(map[string]interface{})
+	interval := workflow["properties"].(map[string]interface{})["interval"].(map[string]interface{})
+	assert.Equal(t, "integer", interval["type"])
+	assert.Equal(t, float64(1), interval["minimum"])
+
+	// docs/config.schema.json is regenerated with `make schema`
+	golden, err := os.ReadFile(filepath.Join("..", "..", "docs", "config.schema.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(golden), string(schema), "docs/config.schema.json is out of date; run `make schema`")
+}
@@ -39,6 +39,13 @@ const (
 	LabelRequiresChanges = "soba:requires-changes"
 	LabelRevising        = "soba:revising"
 	LabelLGTM            = "soba:lgtm"
+
+	// 状態を表さない制御用ラベル
+	LabelPaused       = "soba:paused"
+	LabelPriorityHigh = "soba:priority-high"
+
+	// LabelProfilePrefix はIssueのコマンドプロファイルを選ぶラベルの接頭辞
+	LabelProfilePrefix = "soba:profile:"
 )
 
 // PhaseDefinition はフェーズの完全な定義を表す
@@ -186,12 +193,21 @@ func GetNextActionForCompletion(executionLabel, completionLabel string) *NextAct
 	return nil
 }
 
+// IsControlLabel はワークフローの状態を表さないsobaラベルかチェック
+func IsControlLabel(label string) bool {
+	switch label {
+	case LabelLGTM, LabelPaused, LabelPriorityHigh:
+		return true
+	}
+	return strings.HasPrefix(label, LabelProfilePrefix)
+}
+
 // GetCurrentPhaseFromLabels はラベルリストから現在のフェーズを判定する
 func GetCurrentPhaseFromLabels(labels []string) (Phase, error) {
 	// soba:で始まるラベルを探す（LGTMは除く）
 	var sobaLabel string
 	for _, label := range labels {
-		if strings.HasPrefix(label, "soba:") && label != LabelLGTM {
+		if strings.HasPrefix(label, "soba:") && !IsControlLabel(label) {
 			if sobaLabel != "" {
 				// 複数のsobaラベルがある場合はエラー
 				return "", fmt.Errorf("multiple soba labels found")
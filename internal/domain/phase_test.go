@@ -102,6 +102,11 @@ func TestGetCurrentPhaseFromLabels(t *testing.T) {
 			expectedError: true,
 			errorContains: "no soba label found",
 		},
+		{
+			name:          "soba:profileラベルはフェーズの判定に使わない",
+			labels:        []string{"soba:profile:fast", "soba:ready"},
+			expectedPhase: domain.PhaseImplement,
+		},
 	}
 
 	for _, tt := range tests {
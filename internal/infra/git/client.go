@@ -167,6 +167,121 @@ func (c *Client) WorktreeExists(worktreePath string) bool {
 	return false
 }
 
+// BranchExists checks if a local branch exists
+func (c *Client) BranchExists(branchName string) (bool, error) {
+	if branchName == "" {
+		return false, NewGitError("rev-parse", "", "branch name is required", nil)
+	}
+
+	cmd := exec.Command("git", "-C", c.workDir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branchName)
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, nil
+		}
+		return false, NewGitError("rev-parse", branchName, "failed to verify branch", err)
+	}
+
+	return true, nil
+}
+
+// DeleteBranch deletes a local branch. With force, unmerged branches are deleted too
+func (c *Client) DeleteBranch(branchName string, force bool) error {
+	if branchName == "" {
+		return NewGitError("branch delete", "", "branch name is required", nil)
+	}
+
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+
+	cmd := exec.Command("git", "-C", c.workDir, "branch", flag, branchName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return NewGitError("branch delete", branchName, strings.TrimSpace(string(output)), err)
+	}
+
+	return nil
+}
+
+// Worktree describes an entry of `git worktree list`
+type Worktree struct {
+	Path     string
+	Head     string
+	Branch   string // short branch name, empty when detached
+	Prunable bool   // the worktree directory no longer exists
+}
+
+// ListWorktrees lists the worktrees of the repository, including the main worktree
+func (c *Client) ListWorktrees() ([]Worktree, error) {
+	cmd := exec.Command("git", "-C", c.workDir, "worktree", "list", "--porcelain")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, NewGitError("worktree list", c.workDir, "failed to list worktrees", err)
+	}
+
+	var worktrees []Worktree
+	var current *Worktree
+	for _, line := range strings.Split(string(output), "\n") {
+		switch {
+		case strings.HasPrefix(line, "worktree "):
+			if current != nil {
+				worktrees = append(worktrees, *current)
+			}
+			current = &Worktree{Path: strings.TrimPrefix(line, "worktree ")}
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "HEAD "):
+			current.Head = strings.TrimPrefix(line, "HEAD ")
+		case strings.HasPrefix(line, "branch "):
+			current.Branch = strings.TrimPrefix(strings.TrimPrefix(line, "branch "), "refs/heads/")
+		case strings.HasPrefix(line, "prunable"):
+			current.Prunable = true
+		}
+	}
+	if current != nil {
+		worktrees = append(worktrees, *current)
+	}
+
+	return worktrees, nil
+}
+
+// PruneWorktrees removes administrative data of worktrees whose directories are gone
+func (c *Client) PruneWorktrees() error {
+	cmd := exec.Command("git", "-C", c.workDir, "worktree", "prune")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return NewGitError("worktree prune", c.workDir, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// MergedBranches lists local branches matching pattern that are merged into baseBranch
+func (c *Client) MergedBranches(baseBranch, pattern string) ([]string, error) {
+	if baseBranch == "" {
+		return nil, NewGitError("branch --merged", "", "base branch is required", nil)
+	}
+
+	args := []string{"-C", c.workDir, "branch", "--merged", baseBranch, "--format=%(refname:short)"}
+	if pattern != "" {
+		args = append(args, "--list", pattern)
+	}
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, NewGitError("branch --merged", baseBranch, strings.TrimSpace(string(output)), err)
+	}
+
+	var branches []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if branch := strings.TrimSpace(line); branch != "" && branch != baseBranch {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
 // GetRemoteURL gets the URL of a remote repository
 func (c *Client) GetRemoteURL(remote string) (string, error) {
 	if remote == "" {
@@ -260,3 +375,109 @@ func (c *Client) GetRepository() (string, error) {
 
 	return fmt.Sprintf("%s/%s", owner, repo), nil
 }
+
+// RebaseWorktree rebases the branch checked out in a worktree onto the latest base branch
+// and force-pushes the result. On conflict the rebase is aborted and an error is returned.
+func (c *Client) RebaseWorktree(worktreePath, baseBranch string) error {
+	if worktreePath == "" {
+		return NewGitError("rebase", "", "worktree path is required", nil)
+	}
+	if !c.WorktreeExists(worktreePath) {
+		return NewGitError("rebase", worktreePath, "worktree not found", nil)
+	}
+
+	// Never rebase onto a stale base branch: the result is force-pushed
+	target, hasRemote, err := c.resolveBaseRef(worktreePath, baseBranch)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("git", "-C", worktreePath, "rebase", target)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// Leave the worktree clean for the next phase
+		abort := exec.Command("git", "-C", worktreePath, "rebase", "--abort")
+		_ = abort.Run()
+		return NewGitError("rebase", worktreePath, strings.TrimSpace(string(output)), err)
+	}
+
+	if !hasRemote {
+		return nil
+	}
+
+	cmd = exec.Command("git", "-C", worktreePath, "push", "--force-with-lease", "origin", "HEAD")
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		return NewGitError("push", worktreePath, strings.TrimSpace(string(output)), err)
+	}
+
+	return nil
+}
+
+// ConflictingFiles returns the files that would conflict when merging the base branch
+// into the branch checked out in a worktree
+func (c *Client) ConflictingFiles(worktreePath, baseBranch string) ([]string, error) {
+	if worktreePath == "" {
+		return nil, NewGitError("merge-tree", "", "worktree path is required", nil)
+	}
+	if !c.WorktreeExists(worktreePath) {
+		return nil, NewGitError("merge-tree", worktreePath, "worktree not found", nil)
+	}
+
+	target, _, err := c.resolveBaseRef(worktreePath, baseBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	// merge-tree exits with 1 when conflicts exist; the first line is the tree OID
+	cmd := exec.Command("git", "-C", worktreePath, "merge-tree", "--write-tree", "--name-only", "--no-messages", "HEAD", target)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, NewGitError("merge-tree", worktreePath, "failed to compute merge result", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	files := []string{}
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+
+	return files, nil
+}
+
+// resolveBaseRef fetches the base branch when an origin remote exists and returns
+// the ref to rebase or merge against and whether origin exists. It fails when
+// the fetch fails so that callers do not work against a stale base branch.
+func (c *Client) resolveBaseRef(worktreePath, baseBranch string) (string, bool, error) {
+	if baseBranch == "" {
+		baseBranch = "main"
+	}
+
+	cmd := exec.Command("git", "-C", worktreePath, "remote")
+	remoteOutput, err := cmd.Output()
+	if err != nil || !hasRemote(string(remoteOutput), "origin") {
+		return baseBranch, false, nil
+	}
+
+	cmd = exec.Command("git", "-C", worktreePath, "fetch", "origin", baseBranch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", true, NewGitError("fetch", worktreePath, strings.TrimSpace(string(output)), err)
+	}
+
+	return "origin/" + baseBranch, true, nil
+}
+
+// hasRemote reports whether the output of `git remote` lists name
+func hasRemote(remotes, name string) bool {
+	for _, remote := range strings.Split(remotes, "\n") {
+		if strings.TrimSpace(remote) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -646,3 +646,221 @@ func writeFile(t *testing.T, path string, content string) {
 		t.Fatalf("Failed to write file %s: %v", path, err)
 	}
 }
+
+func TestClient_RebaseWorktree(t *testing.T) {
+	t.Run("Rebase onto updated base branch", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		createTestRepository(t, tmpDir)
+
+		client, err := NewClient(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		worktreePath := filepath.Join(tmpDir, "worktrees", "issue-1")
+		if err := client.CreateWorktree(worktreePath, "soba/1", "main"); err != nil {
+			t.Fatalf("Failed to create worktree: %v", err)
+		}
+		writeFile(t, filepath.Join(worktreePath, "feature.txt"), "feature")
+		runCommand(t, worktreePath, "git", "add", ".")
+		runCommand(t, worktreePath, "git", "commit", "-m", "feature")
+
+		writeFile(t, filepath.Join(tmpDir, "base.txt"), "base")
+		runCommand(t, tmpDir, "git", "add", "base.txt")
+		runCommand(t, tmpDir, "git", "commit", "-m", "base change")
+
+		if err := client.RebaseWorktree(worktreePath, "main"); err != nil {
+			t.Fatalf("RebaseWorktree() error = %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(worktreePath, "base.txt")); err != nil {
+			t.Errorf("base change was not rebased into worktree: %v", err)
+		}
+	})
+
+	t.Run("Abort rebase on conflict", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		createTestRepository(t, tmpDir)
+
+		client, err := NewClient(tmpDir)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+
+		worktreePath := filepath.Join(tmpDir, "worktrees", "issue-2")
+		if err := client.CreateWorktree(worktreePath, "soba/2", "main"); err != nil {
+			t.Fatalf("Failed to create worktree: %v", err)
+		}
+		writeFile(t, filepath.Join(worktreePath, "README.md"), "feature")
+		runCommand(t, worktreePath, "git", "commit", "-am", "feature")
+
+		writeFile(t, filepath.Join(tmpDir, "README.md"), "base")
+		runCommand(t, tmpDir, "git", "commit", "-am", "base change")
+
+		if err := client.RebaseWorktree(worktreePath, "main"); err == nil {
+			t.Fatal("RebaseWorktree() expected error on conflict")
+		}
+
+		files, err := client.ConflictingFiles(worktreePath, "main")
+		if err != nil {
+			t.Fatalf("ConflictingFiles() error = %v", err)
+		}
+		if len(files) != 1 || files[0] != "README.md" {
+			t.Errorf("ConflictingFiles() = %v, want [README.md]", files)
+		}
+	})
+}
+
+func TestClient_RebaseWorktree_FetchFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestRepository(t, tmpDir)
+
+	client, err := NewClient(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	worktreePath := filepath.Join(tmpDir, "worktrees", "issue-3")
+	if err := client.CreateWorktree(worktreePath, "soba/3", "main"); err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+	writeFile(t, filepath.Join(worktreePath, "feature.txt"), "feature")
+	runCommand(t, worktreePath, "git", "add", ".")
+	runCommand(t, worktreePath, "git", "commit", "-m", "feature")
+	head := strings.TrimSpace(gitOutput(t, worktreePath, "rev-parse", "HEAD"))
+
+	// origin cannot be fetched: nothing is rebased or pushed
+	runCommand(t, tmpDir, "git", "remote", "add", "origin", filepath.Join(tmpDir, "missing"))
+	if err := client.RebaseWorktree(worktreePath, "main"); err == nil {
+		t.Fatal("RebaseWorktree() expected error when the base branch cannot be fetched")
+	}
+	if got := strings.TrimSpace(gitOutput(t, worktreePath, "rev-parse", "HEAD")); got != head {
+		t.Errorf("HEAD moved to %s, want %s", got, head)
+	}
+}
+
+func TestHasRemote(t *testing.T) {
+	tests := []struct {
+		remotes string
+		want    bool
+	}{
+		{"origin\n", true},
+		{"upstream\norigin\n", true},
+		{"upstream-origin\n", false},
+		{"origin-fork\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := hasRemote(tt.remotes, "origin"); got != tt.want {
+			t.Errorf("hasRemote(%q) = %v, want %v", tt.remotes, got, tt.want)
+		}
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %s failed: %v", strings.Join(args, " "), err)
+	}
+	return string(output)
+}
+
+func TestClient_DeleteBranch(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestRepository(t, tmpDir)
+	runCommand(t, tmpDir, "git", "branch", "soba/1")
+
+	client, err := NewClient(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	exists, err := client.BranchExists("soba/1")
+	if err != nil || !exists {
+		t.Fatalf("BranchExists() = %v, %v, want true", exists, err)
+	}
+
+	if err := client.DeleteBranch("soba/1", true); err != nil {
+		t.Fatalf("DeleteBranch() error = %v", err)
+	}
+
+	exists, err = client.BranchExists("soba/1")
+	if err != nil || exists {
+		t.Errorf("BranchExists() after delete = %v, %v, want false", exists, err)
+	}
+
+	if err := client.DeleteBranch("soba/1", true); err == nil {
+		t.Error("DeleteBranch() for missing branch should fail")
+	}
+}
+
+func TestClient_WorktreeMaintenance(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestRepository(t, tmpDir)
+
+	client, err := NewClient(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	kept := filepath.Join(tmpDir, "worktrees", "issue-1")
+	removed := filepath.Join(tmpDir, "worktrees", "issue-2")
+	if err := client.CreateWorktree(kept, "soba/1", "main"); err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+	if err := client.CreateWorktree(removed, "soba/2", "main"); err != nil {
+		t.Fatalf("CreateWorktree() error = %v", err)
+	}
+
+	// Delete a worktree directory behind git's back
+	if err := os.RemoveAll(removed); err != nil {
+		t.Fatalf("Failed to remove worktree directory: %v", err)
+	}
+
+	worktrees, err := client.ListWorktrees()
+	if err != nil {
+		t.Fatalf("ListWorktrees() error = %v", err)
+	}
+	if len(worktrees) != 3 {
+		t.Fatalf("ListWorktrees() returned %d entries, want 3", len(worktrees))
+	}
+	if worktrees[0].Branch != "main" {
+		t.Errorf("main worktree branch = %q, want main", worktrees[0].Branch)
+	}
+	if worktrees[1].Branch != "soba/1" || worktrees[1].Prunable {
+		t.Errorf("unexpected worktree entry: %+v", worktrees[1])
+	}
+	if worktrees[2].Branch != "soba/2" || !worktrees[2].Prunable {
+		t.Errorf("missing worktree should be prunable: %+v", worktrees[2])
+	}
+
+	if err := client.PruneWorktrees(); err != nil {
+		t.Fatalf("PruneWorktrees() error = %v", err)
+	}
+	worktrees, _ = client.ListWorktrees()
+	if len(worktrees) != 2 {
+		t.Errorf("ListWorktrees() after prune returned %d entries, want 2", len(worktrees))
+	}
+
+	// soba/2 points at main and is therefore merged; soba/1 is checked out but merged too
+	merged, err := client.MergedBranches("main", "soba/*")
+	if err != nil {
+		t.Fatalf("MergedBranches() error = %v", err)
+	}
+	if strings.Join(merged, ",") != "soba/1,soba/2" {
+		t.Errorf("MergedBranches() = %v, want [soba/1 soba/2]", merged)
+	}
+
+	// A branch with its own commit is not merged
+	writeFile(t, filepath.Join(kept, "feature.txt"), "feature")
+	runCommand(t, kept, "git", "add", ".")
+	runCommand(t, kept, "git", "commit", "-m", "Feature")
+	merged, _ = client.MergedBranches("main", "soba/*")
+	if strings.Join(merged, ",") != "soba/2" {
+		t.Errorf("MergedBranches() = %v, want [soba/2]", merged)
+	}
+}
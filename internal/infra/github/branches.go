@@ -0,0 +1,62 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/douhashi/soba/internal/infra"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// DeleteBranch はリモートのブランチを削除する
+// ブランチが既に存在しない場合はエラーとしない
+func (c *ClientImpl) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	// バリデーション
+	if owner == "" {
+		return infra.NewGitHubAPIError(0, "", "owner is required")
+	}
+	if repo == "" {
+		return infra.NewGitHubAPIError(0, "", "repo is required")
+	}
+	if branch == "" {
+		return infra.NewGitHubAPIError(0, "", "branch is required")
+	}
+
+	// HTTPリクエストの作成（ブランチ名の"/"はパス区切りとして扱われる）
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/git/refs/heads/%s", c.baseURL, owner, repo, (&url.URL{Path: branch}).EscapedPath())
+	req, err := http.NewRequestWithContext(ctx, "DELETE", apiURL, nil)
+	if err != nil {
+		return infra.WrapInfraError(err, "failed to create request")
+	}
+
+	// リクエスト実行
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 既に削除済みの場合は422（Reference does not exist）が返る
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity {
+		c.logger.Debug(ctx, "Branch already deleted",
+			logging.Field{Key: "owner", Value: owner},
+			logging.Field{Key: "repo", Value: repo},
+			logging.Field{Key: "branch", Value: branch},
+		)
+		return nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return c.parseErrorResponse(resp)
+	}
+
+	c.logger.Info(ctx, "Deleted remote branch",
+		logging.Field{Key: "owner", Value: owner},
+		logging.Field{Key: "repo", Value: repo},
+		logging.Field{Key: "branch", Value: branch},
+	)
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestDeleteBranch(t *testing.T) {
+	newClient := func(url string) *ClientImpl {
+		return &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       url,
+			logger:        logging.NewMockLogger(),
+		}
+	}
+
+	t.Run("ブランチを削除できる", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/git/refs/heads/soba/12", r.URL.Path)
+			assert.Equal(t, "DELETE", r.Method)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		require.NoError(t, newClient(server.URL).DeleteBranch(context.Background(), "owner", "repo", "soba/12"))
+	})
+
+	t.Run("削除済みのブランチはエラーにしない", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"Reference does not exist"}`))
+		}))
+		defer server.Close()
+
+		require.NoError(t, newClient(server.URL).DeleteBranch(context.Background(), "owner", "repo", "soba/12"))
+	})
+
+	t.Run("権限がない場合はエラー", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+		}))
+		defer server.Close()
+
+		require.Error(t, newClient(server.URL).DeleteBranch(context.Background(), "owner", "repo", "soba/12"))
+	})
+}
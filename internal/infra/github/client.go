@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/douhashi/soba/internal/infra"
+	"github.com/douhashi/soba/internal/infra/metrics"
 	"github.com/douhashi/soba/pkg/logging"
 )
 
@@ -83,9 +86,15 @@ func (c *ClientImpl) doRequest(ctx context.Context, req *http.Request) (*http.Re
 
 	// リクエスト実行
 	resp, err := c.httpClient.Do(req)
+	endpoint := endpointLabel(req.URL.Path)
 	if err != nil {
+		metrics.ObserveGitHubRequest(req.Method, endpoint, "error")
 		return nil, infra.WrapInfraError(err, "failed to execute HTTP request")
 	}
+	metrics.ObserveGitHubRequest(req.Method, endpoint, strconv.Itoa(resp.StatusCode))
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		metrics.SetGitHubRateLimitRemaining(remaining)
+	}
 
 	// レスポンス情報をログ出力
 	c.logger.Debug(ctx, "GitHub API response",
@@ -96,6 +105,40 @@ func (c *ClientImpl) doRequest(ctx context.Context, req *http.Request) (*http.Re
 	return resp, nil
 }
 
+// endpointLabel はメトリクス用に、リクエストパスのowner・repo・番号・ラベル名・ブランチ名をプレースホルダーに置き換える
+func endpointLabel(path string) string {
+	index := strings.Index(path, "/repos/")
+	if index < 0 {
+		return path
+	}
+	segments := strings.Split(strings.Trim(path[index:], "/"), "/")
+	if len(segments) < 3 {
+		return "/" + strings.Join(segments, "/")
+	}
+
+	result := []string{"repos", "{owner}", "{repo}"}
+	rest := segments[3:]
+	for i, segment := range rest {
+		switch {
+		case i > 0 && rest[i-1] == "heads":
+			// ブランチ名はスラッシュを含むため残りをまとめる
+			return "/" + strings.Join(append(result, "{branch}"), "/")
+		case i > 1 && rest[i-1] == "labels" && rest[i-2] == "{number}":
+			segment = "{name}"
+		case isNumber(segment):
+			segment = "{number}"
+		}
+		rest[i] = segment
+		result = append(result, segment)
+	}
+	return "/" + strings.Join(result, "/")
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
 // parseErrorResponse はエラーレスポンスを解析する
 func (c *ClientImpl) parseErrorResponse(resp *http.Response) error {
 	body, err := io.ReadAll(resp.Body)
@@ -121,3 +164,36 @@ func (c *ClientImpl) parseErrorResponse(resp *http.Response) error {
 
 	return infra.NewGitHubAPIError(resp.StatusCode, resp.Request.URL.String(), errResp.Message)
 }
+
+// GetTokenScopes はリポジトリにアクセスし、トークンに付与されたOAuthスコープを返す
+// fine-grained tokenやGitHub Appのトークンはスコープを返さないため、reportedはfalseになる
+func (c *ClientImpl) GetTokenScopes(ctx context.Context, owner, repo string) (scopes []string, reported bool, err error) {
+	url := fmt.Sprintf("%s/repos/%s/%s", c.baseURL, owner, repo)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, false, infra.WrapInfraError(err, "failed to create request")
+	}
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, false, c.parseErrorResponse(resp)
+	}
+
+	header, ok := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]
+	if !ok {
+		return nil, false, nil
+	}
+	for _, value := range header {
+		for _, scope := range strings.Split(value, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes, true, nil
+}
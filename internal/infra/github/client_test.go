@@ -1,6 +1,7 @@
 package github
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/douhashi/soba/internal/infra/metrics"
 	"github.com/douhashi/soba/pkg/logging"
 )
 
@@ -217,3 +219,91 @@ func TestClient(t *testing.T) {
 		})
 	})
 }
+
+func TestClient_RequestMetrics(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4321")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&mockTokenProvider{token: "test-token"}, &ClientOptions{
+		BaseURL: server.URL,
+		Logger:  logging.NewMockLogger(),
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/repos/test/repo/issues/7/comments", nil)
+	require.NoError(t, err)
+	resp, err := client.doRequest(ctx, req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	var out bytes.Buffer
+	metrics.Default().WriteText(&out)
+	assert.Contains(t, out.String(), `soba_github_api_requests_total{method="GET",endpoint="/repos/{owner}/{repo}/issues/{number}/comments",status="404"}`)
+	assert.Contains(t, out.String(), "soba_github_rate_limit_remaining 4321\n")
+}
+
+func TestClient_GetTokenScopes(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		setHeader    bool
+		wantScopes   []string
+		wantReported bool
+	}{
+		{name: "classic token", header: "repo, workflow", setHeader: true, wantScopes: []string{"repo", "workflow"}, wantReported: true},
+		{name: "classic token without scopes", header: "", setHeader: true, wantReported: true},
+		{name: "fine-grained token", wantReported: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/repos/owner/repo", r.URL.Path)
+				if tt.setHeader {
+					w.Header().Set("X-OAuth-Scopes", tt.header)
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(&MockTokenProvider{token: "test-token"}, &ClientOptions{
+				BaseURL: server.URL,
+				Logger:  logging.NewMockLogger(),
+			})
+			require.NoError(t, err)
+
+			scopes, reported, err := client.GetTokenScopes(context.Background(), "owner", "repo")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantScopes, scopes)
+			assert.Equal(t, tt.wantReported, reported)
+		})
+	}
+}
+
+func TestEndpointLabel(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"issues list", "/repos/douhashi/soba/issues", "/repos/{owner}/{repo}/issues"},
+		{"issue number", "/repos/douhashi/soba/issues/12", "/repos/{owner}/{repo}/issues/{number}"},
+		{"issue label name", "/repos/douhashi/soba/issues/12/labels/soba:todo", "/repos/{owner}/{repo}/issues/{number}/labels/{name}"},
+		{"repository labels", "/repos/douhashi/soba/labels", "/repos/{owner}/{repo}/labels"},
+		{"review comments", "/repos/douhashi/soba/pulls/3/reviews/99/comments", "/repos/{owner}/{repo}/pulls/{number}/reviews/{number}/comments"},
+		{"branch ref with slashes", "/repos/douhashi/soba/git/refs/heads/soba/12", "/repos/{owner}/{repo}/git/refs/heads/{branch}"},
+		{"enterprise prefix", "/api/v3/repos/douhashi/soba/pulls/3/merge", "/repos/{owner}/{repo}/pulls/{number}/merge"},
+		{"non repository path", "/user", "/user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, endpointLabel(tt.path))
+		})
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/douhashi/soba/internal/infra"
 )
@@ -46,7 +49,7 @@ func (c *ClientImpl) CreateComment(ctx context.Context, owner, repo string, issu
 // ListComments はIssueのコメント一覧を取得する
 func (c *ClientImpl) ListComments(ctx context.Context, owner, repo string, issueNumber int, opts *ListCommentsOptions) ([]IssueComment, error) {
 	// HTTPリクエストの作成
-	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, owner, repo, issueNumber)
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments%s", c.baseURL, owner, repo, issueNumber, buildCommentsQuery(opts))
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, infra.WrapInfraError(err, "failed to create request")
@@ -72,3 +75,98 @@ func (c *ClientImpl) ListComments(ctx context.Context, owner, repo string, issue
 
 	return comments, nil
 }
+
+// repositoryCommentsMaxPages はリポジトリ全体のコメント取得時に辿る最大ページ数
+const repositoryCommentsMaxPages = 5
+
+// ListRepositoryComments はリポジトリ内の全Issue・PRのコメントを取得する
+func (c *ClientImpl) ListRepositoryComments(ctx context.Context, owner, repo string, opts *ListCommentsOptions) ([]IssueComment, error) {
+	if owner == "" {
+		return nil, infra.NewGitHubAPIError(0, "", "owner is required")
+	}
+	if repo == "" {
+		return nil, infra.NewGitHubAPIError(0, "", "repo is required")
+	}
+
+	pageOpts := ListCommentsOptions{PerPage: 100}
+	if opts != nil {
+		pageOpts = *opts
+	}
+	if pageOpts.Page == 0 {
+		pageOpts.Page = 1
+	}
+
+	var comments []IssueComment
+	for i := 0; i < repositoryCommentsMaxPages; i++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/issues/comments%s", c.baseURL, owner, repo, buildCommentsQuery(&pageOpts))
+		var pageComments []IssueComment
+		hasNext, err := c.getJSONPage(ctx, url, &pageComments)
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, pageComments...)
+		if !hasNext {
+			break
+		}
+		pageOpts.Page++
+	}
+
+	return comments, nil
+}
+
+// CreateCommentReaction はIssueコメントにリアクションを付ける
+// contentは+1, -1, laugh, confused, heart, hooray, rocket, eyesのいずれか
+func (c *ClientImpl) CreateCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	reqBody, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return infra.WrapInfraError(err, "failed to marshal request body")
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%d/reactions", c.baseURL, owner, repo, commentID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return infra.WrapInfraError(err, "failed to create request")
+	}
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 既に同じリアクションがある場合は200が返る
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return c.parseErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// buildCommentsQuery はコメント一覧取得時のクエリ文字列を構築する
+func buildCommentsQuery(opts *ListCommentsOptions) string {
+	if opts == nil {
+		return ""
+	}
+
+	params := url.Values{}
+	if opts.Sort != "" {
+		params.Set("sort", opts.Sort)
+	}
+	if opts.Direction != "" {
+		params.Set("direction", opts.Direction)
+	}
+	if opts.Since != nil {
+		params.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if opts.Page > 0 {
+		params.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		params.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + params.Encode()
+}
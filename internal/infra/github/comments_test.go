@@ -0,0 +1,83 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestListRepositoryComments(t *testing.T) {
+	t.Run("since以降のコメントを取得できる", func(t *testing.T) {
+		since := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/issues/comments", r.URL.Path)
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, "2025-01-02T03:04:05Z", r.URL.Query().Get("since"))
+			assert.Equal(t, "asc", r.URL.Query().Get("direction"))
+			w.Write([]byte(`[{"id":1,"body":"/soba retry","author_association":"MEMBER","issue_url":"https://api.github.com/repos/owner/repo/issues/42","user":{"login":"alice"}}]`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		comments, err := client.ListRepositoryComments(context.Background(), "owner", "repo", &ListCommentsOptions{
+			Sort:      "updated",
+			Direction: "asc",
+			Since:     &since,
+			PerPage:   100,
+		})
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "MEMBER", comments[0].AuthorAssociation)
+		assert.Equal(t, 42, comments[0].IssueNumber())
+	})
+
+	t.Run("ownerが空の場合はエラー", func(t *testing.T) {
+		client := &ClientImpl{logger: logging.NewMockLogger()}
+		_, err := client.ListRepositoryComments(context.Background(), "", "repo", nil)
+		require.Error(t, err)
+	})
+}
+
+func TestCreateCommentReaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/owner/repo/issues/comments/99/reactions", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "+1", body["content"])
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1,"content":"+1"}`))
+	}))
+	defer server.Close()
+
+	client := &ClientImpl{
+		httpClient:    http.DefaultClient,
+		tokenProvider: newMockTokenProvider("test-token"),
+		baseURL:       server.URL,
+		logger:        logging.NewMockLogger(),
+	}
+
+	require.NoError(t, client.CreateCommentReaction(context.Background(), "owner", "repo", 99, "+1"))
+}
+
+func TestIssueComment_IssueNumber(t *testing.T) {
+	assert.Equal(t, 7, IssueComment{IssueURL: "https://api.github.com/repos/owner/repo/issues/7"}.IssueNumber())
+	assert.Equal(t, 0, IssueComment{}.IssueNumber())
+	assert.Equal(t, 0, IssueComment{IssueURL: "https://api.github.com/repos/owner/repo/issues/abc"}.IssueNumber())
+}
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -122,6 +123,91 @@ func (c *ClientImpl) ListOpenIssues(ctx context.Context, owner, repo string, opt
 	return issues, hasNext, nil
 }
 
+// GetIssue は指定されたIssueを取得する
+func (c *ClientImpl) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*Issue, error) {
+	// バリデーション
+	if owner == "" {
+		return nil, infra.NewGitHubAPIError(0, "", "owner is required")
+	}
+	if repo == "" {
+		return nil, infra.NewGitHubAPIError(0, "", "repo is required")
+	}
+	if issueNumber <= 0 {
+		return nil, infra.NewGitHubAPIError(0, "", "invalid issue number")
+	}
+
+	// HTTPリクエストの作成
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/issues/%d", c.baseURL, owner, repo, issueNumber)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, infra.WrapInfraError(err, "failed to create request")
+	}
+
+	// リクエストの実行
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// エラーレスポンスの処理
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseErrorResponse(resp)
+	}
+
+	// レスポンスのパース
+	var issue Issue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, infra.WrapInfraError(err, "failed to decode response")
+	}
+
+	return &issue, nil
+}
+
+// CloseIssue は指定されたIssueを完了としてクローズする
+func (c *ClientImpl) CloseIssue(ctx context.Context, owner, repo string, issueNumber int) error {
+	// バリデーション
+	if owner == "" {
+		return infra.NewGitHubAPIError(0, "", "owner is required")
+	}
+	if repo == "" {
+		return infra.NewGitHubAPIError(0, "", "repo is required")
+	}
+	if issueNumber <= 0 {
+		return infra.NewGitHubAPIError(0, "", "invalid issue number")
+	}
+
+	// リクエストボディの作成
+	reqBody, err := json.Marshal(map[string]string{
+		"state":        "closed",
+		"state_reason": "completed",
+	})
+	if err != nil {
+		return infra.WrapInfraError(err, "failed to marshal request body")
+	}
+
+	// HTTPリクエストの作成
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/issues/%d", c.baseURL, owner, repo, issueNumber)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", apiURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return infra.WrapInfraError(err, "failed to create request")
+	}
+
+	// リクエストの実行
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// エラーレスポンスの処理
+	if resp.StatusCode != http.StatusOK {
+		return c.parseErrorResponse(resp)
+	}
+
+	return nil
+}
+
 // buildIssuesURL はIssue取得用のURLを構築する
 func (c *ClientImpl) buildIssuesURL(owner, repo string, opts *ListIssuesOptions) string {
 	baseURL := fmt.Sprintf("%s/repos/%s/%s/issues", c.baseURL, owner, repo)
@@ -232,3 +232,48 @@ func TestIssue(t *testing.T) {
 		})
 	})
 }
+
+func TestGetAndCloseIssue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Issueを取得できる", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/issues/5", r.URL.Path)
+			assert.Equal(t, "GET", r.Method)
+			w.Write([]byte(`{"number":5,"state":"open","title":"Issue 5"}`))
+		}))
+		defer server.Close()
+
+		client, err := NewClient(&mockTokenProvider{token: "test-token"}, &ClientOptions{
+			BaseURL: server.URL,
+			Logger:  logging.NewMockLogger(),
+		})
+		require.NoError(t, err)
+
+		issue, err := client.GetIssue(ctx, "owner", "repo", 5)
+		require.NoError(t, err)
+		assert.Equal(t, "open", issue.State)
+	})
+
+	t.Run("Issueを完了としてクローズできる", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/issues/5", r.URL.Path)
+			assert.Equal(t, "PATCH", r.Method)
+
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "closed", body["state"])
+			assert.Equal(t, "completed", body["state_reason"])
+			w.Write([]byte(`{"number":5,"state":"closed"}`))
+		}))
+		defer server.Close()
+
+		client, err := NewClient(&mockTokenProvider{token: "test-token"}, &ClientOptions{
+			BaseURL: server.URL,
+			Logger:  logging.NewMockLogger(),
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, client.CloseIssue(ctx, "owner", "repo", 5))
+	})
+}
@@ -52,22 +52,73 @@ func (c *ClientImpl) CreateLabel(ctx context.Context, owner, repo string, reques
 	return &label, nil
 }
 
+// labelsMaxPages はラベル一覧取得時に辿る最大ページ数
+const labelsMaxPages = 10
+
 // ListLabels はリポジトリのラベル一覧を取得する
 func (c *ClientImpl) ListLabels(ctx context.Context, owner, repo string) ([]Label, error) {
+	labels := []Label{}
+	for page := 1; page <= labelsMaxPages; page++ {
+		// HTTPリクエストの作成
+		url := fmt.Sprintf("%s/repos/%s/%s/labels?per_page=100&page=%d", c.baseURL, owner, repo, page)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, infra.WrapInfraError(err, "failed to create request")
+		}
+
+		// リクエスト実行（リトライ付き）
+		retryClient := NewRetryableClient(&RetryOptions{
+			Logger: c.logger,
+		})
+		resp, err := retryClient.DoWithRetry(ctx, func() (*http.Response, error) {
+			return c.doRequest(ctx, req)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// レスポンスの処理
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			err := c.parseErrorResponse(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		// レスポンスのパース
+		var pageLabels []Label
+		err = json.NewDecoder(resp.Body).Decode(&pageLabels)
+		hasNext := c.hasNextPage(resp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, infra.WrapInfraError(err, "failed to decode response")
+		}
+
+		labels = append(labels, pageLabels...)
+		if !hasNext {
+			break
+		}
+	}
+
+	return labels, nil
+}
+
+// UpdateLabel は既存のラベルの色と説明を更新する
+func (c *ClientImpl) UpdateLabel(ctx context.Context, owner, repo, name string, request CreateLabelRequest) (*Label, error) {
+	// リクエストボディの作成
+	reqBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, infra.WrapInfraError(err, "failed to marshal request body")
+	}
+
 	// HTTPリクエストの作成
-	url := fmt.Sprintf("%s/repos/%s/%s/labels", c.baseURL, owner, repo)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	url := fmt.Sprintf("%s/repos/%s/%s/labels/%s", c.baseURL, owner, repo, name)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, infra.WrapInfraError(err, "failed to create request")
 	}
 
-	// リクエスト実行（リトライ付き）
-	retryClient := NewRetryableClient(&RetryOptions{
-		Logger: c.logger,
-	})
-	resp, err := retryClient.DoWithRetry(ctx, func() (*http.Response, error) {
-		return c.doRequest(ctx, req)
-	})
+	// リクエスト実行
+	resp, err := c.doRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -79,12 +130,12 @@ func (c *ClientImpl) ListLabels(ctx context.Context, owner, repo string) ([]Labe
 	}
 
 	// レスポンスのパース
-	var labels []Label
-	if err := json.NewDecoder(resp.Body).Decode(&labels); err != nil {
+	var label Label
+	if err := json.NewDecoder(resp.Body).Decode(&label); err != nil {
 		return nil, infra.WrapInfraError(err, "failed to decode response")
 	}
 
-	return labels, nil
+	return &label, nil
 }
 
 // AddLabelToIssue はIssueにラベルを追加する
@@ -266,5 +317,15 @@ func GetSobaLabels() []CreateLabelRequest {
 			Color:       "ff6347",
 			Description: "Claude applying requested changes",
 		},
+		{
+			Name:        "soba:paused",
+			Color:       "bfd4f2",
+			Description: "Paused, soba will not start the next phase",
+		},
+		{
+			Name:        "soba:priority-high",
+			Color:       "b60205",
+			Description: "Picked from the todo queue before other issues",
+		},
 	}
 }
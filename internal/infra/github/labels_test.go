@@ -3,6 +3,7 @@ package github
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -237,11 +238,71 @@ func TestClient_ListLabels(t *testing.T) {
 	}
 }
 
+func TestClient_ListLabels_Pagination(t *testing.T) {
+	var pages []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if page == "1" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/test-owner/test-repo/labels?per_page=100&page=2>; rel="next"`, server.URL))
+			w.Write([]byte(`[{"id": 1, "name": "bug"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 2, "name": "soba:todo"}]`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&MockTokenProvider{token: "test-token"}, &ClientOptions{
+		BaseURL: server.URL,
+		Logger:  logging.NewMockLogger(),
+	})
+	require.NoError(t, err)
+
+	labels, err := client.ListLabels(context.Background(), "test-owner", "test-repo")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"1", "2"}, pages)
+	assert.Equal(t, []Label{{ID: 1, Name: "bug"}, {ID: 2, Name: "soba:todo"}}, labels)
+}
+
+func TestClient_UpdateLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/repos/test-owner/test-repo/labels/soba:ready", r.URL.Path)
+
+		var body CreateLabelRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "0e8a16", body.Color)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 1, "name": "soba:ready", "color": "0e8a16"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&MockTokenProvider{token: "test-token"}, &ClientOptions{
+		BaseURL: server.URL,
+		Logger:  logging.NewMockLogger(),
+	})
+	require.NoError(t, err)
+
+	label, err := client.UpdateLabel(context.Background(), "test-owner", "test-repo", "soba:ready", CreateLabelRequest{
+		Name:  "soba:ready",
+		Color: "0e8a16",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0e8a16", label.Color)
+}
+
 func TestGetSobaLabels(t *testing.T) {
 	labels := GetSobaLabels()
 
-	// 10個のラベルが定義されていることを確認
-	assert.Len(t, labels, 10)
+	// 12個のラベルが定義されていることを確認
+	assert.Len(t, labels, 12)
 
 	// 各ラベルの内容を検証
 	expectedLabels := map[string]struct {
@@ -258,6 +319,8 @@ func TestGetSobaLabels(t *testing.T) {
 		"soba:done":             {"0e8a16", "Review approved, ready to merge"},
 		"soba:requires-changes": {"d93f0b", "Review requested modifications"},
 		"soba:revising":         {"ff6347", "Claude applying requested changes"},
+		"soba:paused":           {"bfd4f2", "Paused, soba will not start the next phase"},
+		"soba:priority-high":    {"b60205", "Picked from the todo queue before other issues"},
 	}
 
 	for _, label := range labels {
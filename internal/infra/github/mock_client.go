@@ -99,6 +99,11 @@ func (m *MockClient) MergePullRequest(ctx context.Context, owner, repo string, n
 	return args.Get(0).(*MergeResponse), args.Error(1)
 }
 
+func (m *MockClient) UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, expectedHeadSHA string) error {
+	args := m.Called(ctx, owner, repo, number, expectedHeadSHA)
+	return args.Error(0)
+}
+
 // Comment関連のモック実装
 func (m *MockClient) CreateComment(ctx context.Context, owner, repo string, issueNumber int, body string) error {
 	args := m.Called(ctx, owner, repo, issueNumber, body)
@@ -112,3 +117,61 @@ func (m *MockClient) ListComments(ctx context.Context, owner, repo string, issue
 	}
 	return args.Get(0).([]IssueComment), args.Error(1)
 }
+
+func (m *MockClient) ListRepositoryComments(ctx context.Context, owner, repo string, opts *ListCommentsOptions) ([]IssueComment, error) {
+	args := m.Called(ctx, owner, repo, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]IssueComment), args.Error(1)
+}
+
+func (m *MockClient) CreateCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	args := m.Called(ctx, owner, repo, commentID, content)
+	return args.Error(0)
+}
+
+// Timeline関連のモック実装
+func (m *MockClient) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]TimelineEvent, error) {
+	args := m.Called(ctx, owner, repo, issueNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]TimelineEvent), args.Error(1)
+}
+
+func (m *MockClient) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*Issue, error) {
+	args := m.Called(ctx, owner, repo, issueNumber)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Issue), args.Error(1)
+}
+
+func (m *MockClient) CloseIssue(ctx context.Context, owner, repo string, issueNumber int) error {
+	args := m.Called(ctx, owner, repo, issueNumber)
+	return args.Error(0)
+}
+
+// Branch関連のモック実装
+func (m *MockClient) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	args := m.Called(ctx, owner, repo, branch)
+	return args.Error(0)
+}
+
+// Review関連のモック実装
+func (m *MockClient) ListPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]PullRequestReview, error) {
+	args := m.Called(ctx, owner, repo, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]PullRequestReview), args.Error(1)
+}
+
+func (m *MockClient) ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]ReviewComment, error) {
+	args := m.Called(ctx, owner, repo, number, reviewID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ReviewComment), args.Error(1)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/douhashi/soba/internal/infra"
 	"github.com/douhashi/soba/pkg/logging"
@@ -196,6 +197,60 @@ func (c *ClientImpl) MergePullRequest(ctx context.Context, owner, repo string, n
 	return &mergeResp, nil
 }
 
+// UpdatePullRequestBranch はPRのheadブランチにベースブランチの最新の変更を取り込む
+// expectedHeadSHAが指定された場合、headがそのSHAと異なればGitHubが更新を拒否する
+func (c *ClientImpl) UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, expectedHeadSHA string) error {
+	// バリデーション
+	if owner == "" {
+		return infra.NewGitHubAPIError(0, "", "owner is required")
+	}
+	if repo == "" {
+		return infra.NewGitHubAPIError(0, "", "repo is required")
+	}
+	if number <= 0 {
+		return infra.NewGitHubAPIError(0, "", "invalid pull request number")
+	}
+
+	// URLの構築
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/update-branch", c.baseURL, owner, repo, number)
+
+	// リクエストボディの作成
+	requestBody := map[string]string{}
+	if expectedHeadSHA != "" {
+		requestBody["expected_head_sha"] = expectedHeadSHA
+	}
+	body, err := json.Marshal(requestBody)
+	if err != nil {
+		return infra.WrapInfraError(err, "failed to marshal request body")
+	}
+
+	// HTTPリクエストの作成
+	httpReq, err := http.NewRequestWithContext(ctx, "PUT", apiURL, bytes.NewBuffer(body))
+	if err != nil {
+		return infra.WrapInfraError(err, "failed to create request")
+	}
+
+	// リクエストの実行
+	resp, err := c.doRequest(ctx, httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 更新はGitHub側で非同期に行われるため202が返る
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return c.parseErrorResponse(resp)
+	}
+
+	c.logger.Info(ctx, "Pull request branch update requested",
+		logging.Field{Key: "number", Value: number},
+		logging.Field{Key: "owner", Value: owner},
+		logging.Field{Key: "repo", Value: repo},
+	)
+
+	return nil
+}
+
 // buildPullRequestsURL はPR一覧取得用のURLを構築する
 func (c *ClientImpl) buildPullRequestsURL(owner, repo string, opts *ListPullRequestsOptions) string {
 	baseURL := fmt.Sprintf("%s/repos/%s/%s/pulls", c.baseURL, owner, repo)
@@ -231,6 +286,5 @@ func (c *ClientImpl) hasNextPage(resp *http.Response) bool {
 	}
 
 	// Linkヘッダーに "rel=\"next\"" が含まれていれば次のページがある
-	// 簡易的な実装
-	return len(linkHeader) > 0 && (len(linkHeader) > 0 && linkHeader != "")
+	return strings.Contains(linkHeader, `rel="next"`)
 }
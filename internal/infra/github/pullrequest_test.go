@@ -196,3 +196,62 @@ func TestGetPullRequest(t *testing.T) {
 		assert.Equal(t, "clean", pr.MergeableState)
 	})
 }
+
+func TestUpdatePullRequestBranch(t *testing.T) {
+	t.Run("update-branch APIを呼び出せる", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/pulls/10/update-branch", r.URL.Path)
+			assert.Equal(t, "PUT", r.Method)
+
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "abc123", body["expected_head_sha"])
+
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"message":"Updating pull request branch."}`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		err := client.UpdatePullRequestBranch(context.Background(), "owner", "repo", 10, "abc123")
+		require.NoError(t, err)
+	})
+
+	t.Run("headが更新されていた場合はエラーを返す", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"expected head sha didn't match current head ref."}`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		err := client.UpdatePullRequestBranch(context.Background(), "owner", "repo", 10, "abc123")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected head sha")
+	})
+}
+
+func TestHasNextPage(t *testing.T) {
+	client := &ClientImpl{}
+
+	resp := &http.Response{Header: http.Header{}}
+	assert.False(t, client.hasNextPage(resp))
+
+	resp.Header.Set("Link", `<https://api.github.com/repos/o/r/pulls?page=1>; rel="prev"`)
+	assert.False(t, client.hasNextPage(resp))
+
+	resp.Header.Set("Link", `<https://api.github.com/repos/o/r/pulls?page=2>; rel="next"`)
+	assert.True(t, client.hasNextPage(resp))
+}
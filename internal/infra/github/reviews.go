@@ -0,0 +1,91 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/douhashi/soba/internal/infra"
+)
+
+// reviewsMaxPages はレビュー取得時に辿る最大ページ数
+const reviewsMaxPages = 10
+
+// ListPullRequestReviews はPRのレビュー一覧を古い順に取得する
+func (c *ClientImpl) ListPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]PullRequestReview, error) {
+	if err := validatePullRequestRef(owner, repo, number); err != nil {
+		return nil, err
+	}
+
+	var reviews []PullRequestReview
+	for page := 1; page <= reviewsMaxPages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews?per_page=100&page=%d", c.baseURL, owner, repo, number, page)
+		var pageReviews []PullRequestReview
+		hasNext, err := c.getJSONPage(ctx, url, &pageReviews)
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, pageReviews...)
+		if !hasNext {
+			break
+		}
+	}
+
+	return reviews, nil
+}
+
+// ListReviewComments はレビューに含まれるインラインコメントを取得する
+func (c *ClientImpl) ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]ReviewComment, error) {
+	if err := validatePullRequestRef(owner, repo, number); err != nil {
+		return nil, err
+	}
+	if reviewID <= 0 {
+		return nil, infra.NewGitHubAPIError(0, "", "invalid review id")
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d/comments?per_page=100", c.baseURL, owner, repo, number, reviewID)
+	var comments []ReviewComment
+	if _, err := c.getJSONPage(ctx, url, &comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func validatePullRequestRef(owner, repo string, number int) error {
+	if owner == "" {
+		return infra.NewGitHubAPIError(0, "", "owner is required")
+	}
+	if repo == "" {
+		return infra.NewGitHubAPIError(0, "", "repo is required")
+	}
+	if number <= 0 {
+		return infra.NewGitHubAPIError(0, "", "invalid pull request number")
+	}
+	return nil
+}
+
+// getJSONPage はGETリクエストの応答をoutにデコードし、次のページがあるかを返す
+func (c *ClientImpl) getJSONPage(ctx context.Context, url string, out interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, infra.WrapInfraError(err, "failed to create request")
+	}
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, c.parseErrorResponse(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, infra.WrapInfraError(err, "failed to decode response")
+	}
+	return c.hasNextPage(resp), nil
+}
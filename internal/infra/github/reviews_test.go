@@ -0,0 +1,96 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestListPullRequestReviews(t *testing.T) {
+	t.Run("レビュー一覧を複数ページ取得できる", func(t *testing.T) {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/pulls/7/reviews", r.URL.Path)
+			assert.Equal(t, "GET", r.Method)
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/pulls/7/reviews?page=2>; rel="next"`, server.URL))
+				w.Write([]byte(`[{"id":1,"user":{"login":"alice","type":"User"},"state":"CHANGES_REQUESTED","commit_id":"abc","body":"please fix"}]`))
+				return
+			}
+			w.Write([]byte(`[{"id":2,"user":{"login":"alice","type":"User"},"state":"APPROVED","commit_id":"def","submitted_at":"2025-01-02T03:04:05Z"}]`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		reviews, err := client.ListPullRequestReviews(context.Background(), "owner", "repo", 7)
+		require.NoError(t, err)
+		require.Len(t, reviews, 2)
+		assert.Equal(t, "CHANGES_REQUESTED", reviews[0].State)
+		assert.Equal(t, "alice", reviews[0].User.Login)
+		assert.Equal(t, "User", reviews[0].User.Type)
+		assert.Equal(t, "abc", reviews[0].CommitID)
+		assert.Equal(t, "APPROVED", reviews[1].State)
+		require.NotNil(t, reviews[1].SubmittedAt)
+	})
+
+	t.Run("不正なPR番号はエラー", func(t *testing.T) {
+		client := &ClientImpl{logger: logging.NewMockLogger()}
+		_, err := client.ListPullRequestReviews(context.Background(), "owner", "repo", 0)
+		require.Error(t, err)
+	})
+}
+
+func TestListReviewComments(t *testing.T) {
+	t.Run("レビューのインラインコメントを取得できる", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/pulls/7/reviews/11/comments", r.URL.Path)
+			w.Write([]byte(`[{"id":100,"path":"main.go","line":42,"body":"nil check"}]`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		comments, err := client.ListReviewComments(context.Background(), "owner", "repo", 7, 11)
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "main.go", comments[0].Path)
+		require.NotNil(t, comments[0].Line)
+		assert.Equal(t, 42, *comments[0].Line)
+	})
+
+	t.Run("エラーレスポンスを返す", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		_, err := client.ListReviewComments(context.Background(), "owner", "repo", 7, 11)
+		require.Error(t, err)
+	})
+}
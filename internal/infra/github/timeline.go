@@ -0,0 +1,66 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/douhashi/soba/internal/infra"
+)
+
+// timelineMaxPages はタイムライン取得時に辿る最大ページ数
+const timelineMaxPages = 10
+
+// ListIssueTimeline はIssue（またはPR）のタイムラインイベントを取得する
+func (c *ClientImpl) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]TimelineEvent, error) {
+	// バリデーション
+	if owner == "" {
+		return nil, infra.NewGitHubAPIError(0, "", "owner is required")
+	}
+	if repo == "" {
+		return nil, infra.NewGitHubAPIError(0, "", "repo is required")
+	}
+	if issueNumber <= 0 {
+		return nil, infra.NewGitHubAPIError(0, "", "invalid issue number")
+	}
+
+	var events []TimelineEvent
+	for page := 1; page <= timelineMaxPages; page++ {
+		// HTTPリクエストの作成
+		url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/timeline?per_page=100&page=%d", c.baseURL, owner, repo, issueNumber, page)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, infra.WrapInfraError(err, "failed to create request")
+		}
+
+		// リクエスト実行
+		resp, err := c.doRequest(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		// レスポンスの処理
+		if resp.StatusCode != http.StatusOK {
+			err := c.parseErrorResponse(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		// レスポンスのパース
+		var pageEvents []TimelineEvent
+		err = json.NewDecoder(resp.Body).Decode(&pageEvents)
+		hasNext := c.hasNextPage(resp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, infra.WrapInfraError(err, "failed to decode response")
+		}
+
+		events = append(events, pageEvents...)
+		if !hasNext {
+			break
+		}
+	}
+
+	return events, nil
+}
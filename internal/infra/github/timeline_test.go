@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestListIssueTimeline(t *testing.T) {
+	t.Run("cross-referencedイベントを取得できる", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/repos/owner/repo/issues/12/timeline", r.URL.Path)
+			assert.Equal(t, "GET", r.Method)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[
+				{"event":"labeled","label":{"name":"soba:todo"}},
+				{"event":"cross-referenced","source":{"type":"issue","issue":{"number":34,"state":"closed","pull_request":{"url":"https://api.github.com/repos/owner/repo/pulls/34","merged_at":"2026-10-10T09:00:00Z"}}}},
+				{"event":"closed","commit_id":"4f2a9c1"}
+			]`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		events, err := client.ListIssueTimeline(context.Background(), "owner", "repo", 12)
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		assert.Equal(t, "soba:todo", events[0].Label.Name)
+		require.NotNil(t, events[1].Source)
+		assert.Equal(t, 34, events[1].Source.Issue.Number)
+		assert.True(t, events[1].Source.Issue.IsPullRequest())
+		require.NotNil(t, events[1].Source.Issue.PullRequest.MergedAt)
+		assert.Equal(t, "4f2a9c1", events[2].CommitID)
+	})
+
+	t.Run("複数ページを辿る", func(t *testing.T) {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/issues/12/timeline?page=2>; rel="next"`, server.URL))
+				w.Write([]byte(`[{"event":"labeled"}]`))
+				return
+			}
+			w.Write([]byte(`[{"event":"closed"}]`))
+		}))
+		defer server.Close()
+
+		client := &ClientImpl{
+			httpClient:    http.DefaultClient,
+			tokenProvider: newMockTokenProvider("test-token"),
+			baseURL:       server.URL,
+			logger:        logging.NewMockLogger(),
+		}
+
+		events, err := client.ListIssueTimeline(context.Background(), "owner", "repo", 12)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "closed", events[1].Event)
+	})
+
+	t.Run("不正なIssue番号はエラー", func(t *testing.T) {
+		client := &ClientImpl{logger: logging.NewMockLogger()}
+		_, err := client.ListIssueTimeline(context.Background(), "owner", "repo", 0)
+		require.Error(t, err)
+	})
+}
@@ -1,6 +1,10 @@
 package github
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Issue はGitHub IssueのAPI応答を表す
 type Issue struct {
@@ -17,6 +21,21 @@ type Issue struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	ClosedAt  *time.Time `json:"closed_at"`
+
+	// PullRequest はIssueがPRである場合のみ設定される
+	PullRequest *IssuePullRequest `json:"pull_request,omitempty"`
+}
+
+// IssuePullRequest はIssue APIの応答に含まれるPRへの参照を表す
+type IssuePullRequest struct {
+	URL      string     `json:"url"`
+	HTMLURL  string     `json:"html_url"`
+	MergedAt *time.Time `json:"merged_at,omitempty"`
+}
+
+// IsPullRequest はIssueがPRであるかを返す
+func (i Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
 }
 
 // Label はGitHub Labelを表す
@@ -32,6 +51,7 @@ type User struct {
 	ID      int64  `json:"id"`
 	Login   string `json:"login"`
 	HTMLURL string `json:"html_url"`
+	Type    string `json:"type"` // User, Bot, Organization
 }
 
 // ListIssuesOptions はIssue一覧取得時のオプション
@@ -65,21 +85,30 @@ func (e ErrorResponse) Error() string {
 
 // PullRequest はGitHub Pull Requestを表す
 type PullRequest struct {
-	ID             int64      `json:"id"`
-	Number         int        `json:"number"`
-	Title          string     `json:"title"`
-	Body           string     `json:"body"`
-	State          string     `json:"state"` // open, closed
-	URL            string     `json:"url"`
-	HTMLURL        string     `json:"html_url"`
-	Labels         []Label    `json:"labels"`
-	User           User       `json:"user"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
-	ClosedAt       *time.Time `json:"closed_at"`
-	MergedAt       *time.Time `json:"merged_at"`
-	Mergeable      bool       `json:"mergeable"`
-	MergeableState string     `json:"mergeable_state"` // clean, dirty, unknown, etc.
+	ID             int64             `json:"id"`
+	Number         int               `json:"number"`
+	Title          string            `json:"title"`
+	Body           string            `json:"body"`
+	State          string            `json:"state"` // open, closed
+	URL            string            `json:"url"`
+	HTMLURL        string            `json:"html_url"`
+	Labels         []Label           `json:"labels"`
+	User           User              `json:"user"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	ClosedAt       *time.Time        `json:"closed_at"`
+	MergedAt       *time.Time        `json:"merged_at"`
+	Mergeable      bool              `json:"mergeable"`
+	MergeableState string            `json:"mergeable_state"` // clean, dirty, behind, unknown, etc.
+	Head           PullRequestBranch `json:"head"`
+	Base           PullRequestBranch `json:"base"`
+}
+
+// PullRequestBranch はPRのhead/baseブランチ情報を表す
+type PullRequestBranch struct {
+	Label string `json:"label"`
+	Ref   string `json:"ref"`
+	SHA   string `json:"sha"`
 }
 
 // ListPullRequestsOptions はPR一覧取得時のオプション
@@ -109,11 +138,27 @@ type MergeResponse struct {
 
 // IssueComment はIssueコメントを表す
 type IssueComment struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	User      User      `json:"user"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                int64     `json:"id"`
+	Body              string    `json:"body"`
+	User              User      `json:"user"`
+	AuthorAssociation string    `json:"author_association"` // OWNER, MEMBER, COLLABORATOR, CONTRIBUTOR, NONE, etc.
+	IssueURL          string    `json:"issue_url"`
+	HTMLURL           string    `json:"html_url"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// IssueNumber はコメントが投稿されたIssue（またはPR）の番号を返す
+func (c IssueComment) IssueNumber() int {
+	idx := strings.LastIndex(c.IssueURL, "/")
+	if idx < 0 {
+		return 0
+	}
+	number, err := strconv.Atoi(c.IssueURL[idx+1:])
+	if err != nil {
+		return 0
+	}
+	return number
 }
 
 // ListCommentsOptions はコメント一覧取得時のオプション
@@ -124,3 +169,41 @@ type ListCommentsOptions struct {
 	Page      int
 	PerPage   int
 }
+
+// TimelineEvent はIssue/PRのタイムラインイベントを表す
+type TimelineEvent struct {
+	ID        int64           `json:"id"`
+	Event     string          `json:"event"` // cross-referenced, labeled, unlabeled, closed, etc.
+	Actor     *User           `json:"actor,omitempty"`
+	Label     *Label          `json:"label,omitempty"`
+	Source    *TimelineSource `json:"source,omitempty"`
+	CommitID  string          `json:"commit_id,omitempty"` // closedイベントでは、Issueを閉じたコミット
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TimelineSource はcross-referencedイベントの参照元を表す
+type TimelineSource struct {
+	Type  string `json:"type"`
+	Issue *Issue `json:"issue,omitempty"`
+}
+
+// PullRequestReview はPRのレビューを表す
+type PullRequestReview struct {
+	ID          int64      `json:"id"`
+	User        User       `json:"user"`
+	Body        string     `json:"body"`
+	State       string     `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED, PENDING
+	CommitID    string     `json:"commit_id"`
+	HTMLURL     string     `json:"html_url"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
+
+// ReviewComment はPRレビューのインラインコメントを表す
+type ReviewComment struct {
+	ID      int64  `json:"id"`
+	Path    string `json:"path"`
+	Line    *int   `json:"line"`
+	Body    string `json:"body"`
+	User    User   `json:"user"`
+	HTMLURL string `json:"html_url"`
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// ComponentStatus はgoroutine1つの稼働状況
+type ComponentStatus struct {
+	Alive     bool       `json:"alive"`
+	StartedAt time.Time  `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Health はデーモンが起動したgoroutineの稼働状況を保持する
+type Health struct {
+	mu         sync.Mutex
+	components map[string]*ComponentStatus
+	now        func() time.Time
+}
+
+// NewHealth は新しいHealthを作成する
+func NewHealth() *Health {
+	return &Health{
+		components: make(map[string]*ComponentStatus),
+		now:        time.Now,
+	}
+}
+
+// Run はfnをnameのgoroutineとして実行し、戻るまで稼働中として記録する
+func (h *Health) Run(name string, fn func() error) error {
+	h.started(name)
+	err := fn()
+	h.stopped(name, err)
+	return err
+}
+
+func (h *Health) started(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.components[name] = &ComponentStatus{Alive: true, StartedAt: h.now()}
+}
+
+func (h *Health) stopped(name string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status, ok := h.components[name]
+	if !ok {
+		status = &ComponentStatus{}
+		h.components[name] = status
+	}
+	stoppedAt := h.now()
+	status.Alive = false
+	status.StoppedAt = &stoppedAt
+	if err != nil {
+		status.Error = err.Error()
+	}
+}
+
+// Snapshot は全goroutineの稼働状況と、全て稼働中かどうかを返す
+// 1つも起動していない場合は稼働中とみなさない
+func (h *Health) Snapshot() (map[string]ComponentStatus, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshot := make(map[string]ComponentStatus, len(h.components))
+	healthy := len(h.components) > 0
+	for name, status := range h.components {
+		snapshot[name] = *status
+		if !status.Alive {
+			healthy = false
+		}
+	}
+	return snapshot, healthy
+}
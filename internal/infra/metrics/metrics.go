@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 時間の分布に使うバケット（秒）
+var (
+	watchCycleBuckets    = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	phaseDurationBuckets = []float64{60, 300, 600, 1200, 1800, 3600, 7200, 14400}
+)
+
+var (
+	defaultRegistry = NewRegistry()
+
+	watchCycleDuration = defaultRegistry.NewHistogramVec("soba_watch_cycle_duration_seconds",
+		"Duration of watch cycles.", watchCycleBuckets, "watcher")
+	watchCycleErrors = defaultRegistry.NewCounterVec("soba_watch_cycle_errors_total",
+		"Watch cycles that failed.", "watcher")
+	githubRequests = defaultRegistry.NewCounterVec("soba_github_api_requests_total",
+		"GitHub API requests by endpoint and response status.", "method", "endpoint", "status")
+	githubRateLimitRemaining = defaultRegistry.NewGaugeVec("soba_github_rate_limit_remaining",
+		"Requests remaining in the current GitHub API rate limit window.")
+	phaseExecutions = defaultRegistry.NewCounterVec("soba_phase_executions_total",
+		"Phase executions started by soba, by result.", "phase", "result")
+	phaseDuration = defaultRegistry.NewHistogramVec("soba_phase_duration_seconds",
+		"Time from starting a phase command until its execution label is removed.", phaseDurationBuckets, "phase", "outcome")
+	queueDepth = defaultRegistry.NewGaugeVec("soba_queue_depth",
+		"Issues waiting in soba:todo.")
+	activeIssues = defaultRegistry.NewGaugeVec("soba_active_issues",
+		"Issues soba is working on.")
+	merges = defaultRegistry.NewCounterVec("soba_pull_requests_merged_total",
+		"Pull requests merged by soba.")
+	slackFailures = defaultRegistry.NewCounterVec("soba_slack_notification_failures_total",
+		"Slack notifications that could not be sent.", "template")
+
+	phaseStarts   = make(map[string]time.Time) // Issue番号とフェーズごとのコマンド開始時刻
+	phaseStartsMu sync.Mutex
+)
+
+// Default はsobaの計測値を保持するRegistryを返す
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// ObserveWatchCycle は監視サイクル1回の所要時間と失敗を記録する
+func ObserveWatchCycle(watcher string, duration time.Duration, err error) {
+	watchCycleDuration.Observe(duration.Seconds(), watcher)
+	if err != nil {
+		watchCycleErrors.Inc(watcher)
+	}
+}
+
+// ObserveGitHubRequest はGitHub APIの呼び出しを記録する。通信に失敗した場合のstatusは"error"
+func ObserveGitHubRequest(method, endpoint, status string) {
+	githubRequests.Inc(method, endpoint, status)
+}
+
+// SetGitHubRateLimitRemaining はレート制限の残り回数を記録する
+func SetGitHubRateLimitRemaining(remaining int) {
+	githubRateLimitRemaining.Set(float64(remaining))
+}
+
+// PhaseStarted はフェーズの実行結果を記録し、成功した場合は所要時間の計測を始める
+func PhaseStarted(issueNumber int, phase string, err error) {
+	if err != nil {
+		phaseExecutions.Inc(phase, "error")
+		return
+	}
+	phaseExecutions.Inc(phase, "started")
+
+	phaseStartsMu.Lock()
+	defer phaseStartsMu.Unlock()
+	phaseStarts[phaseKey(issueNumber, phase)] = time.Now()
+}
+
+// PhaseFinished はフェーズの実行ラベルが外れたときに所要時間を記録する
+// このプロセスで開始していないフェーズは記録しない
+func PhaseFinished(issueNumber int, phase, outcome string) {
+	phaseStartsMu.Lock()
+	key := phaseKey(issueNumber, phase)
+	started, ok := phaseStarts[key]
+	delete(phaseStarts, key)
+	phaseStartsMu.Unlock()
+
+	if ok {
+		phaseDuration.Observe(time.Since(started).Seconds(), phase, outcome)
+	}
+}
+
+func phaseKey(issueNumber int, phase string) string {
+	return fmt.Sprintf("%d/%s", issueNumber, phase)
+}
+
+// SetQueueDepth はsoba:todoで待っているIssue数を記録する
+func SetQueueDepth(n int) {
+	queueDepth.Set(float64(n))
+}
+
+// SetActiveIssues は処理中のIssue数を記録する
+func SetActiveIssues(n int) {
+	activeIssues.Set(float64(n))
+}
+
+// IncMerges はsobaがマージしたPRを数える
+func IncMerges() {
+	merges.Inc()
+}
+
+// IncSlackFailures は送信できなかったSlack通知を数える
+func IncSlackFailures(template string) {
+	slackFailures.Inc(template)
+}
@@ -0,0 +1,257 @@
+// Package metrics はデーモンの状態をPrometheusのテキスト形式で公開する
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metric はRegistryに登録できる計測値
+type metric interface {
+	write(w io.Writer)
+}
+
+// Registry は計測値を保持し、Prometheusのテキスト形式で書き出す
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry は空のRegistryを作成する
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText は登録順に全ての計測値を書き出す
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// family はラベルの組ごとに値を持つ計測値の共通部分
+type family struct {
+	mu         sync.Mutex
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+}
+
+func (f *family) key(labelValues []string) string {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("metric %s: expected %d label values, got %d", f.name, len(f.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+func (f *family) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// formatLabels はラベルを{name="value",...}の形に整形する。extraは末尾に追加する組
+func formatLabels(names, values []string, extra ...string) string {
+	pairs := make([]string, 0, len(names)+len(extra)/2)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// sample はラベルの組と値
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// ValueVec はラベルの組ごとの値を持つカウンターまたはゲージ
+type ValueVec struct {
+	family
+	values map[string]*sample
+}
+
+// NewCounterVec は単調増加する値を登録する
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *ValueVec {
+	return r.newValueVec("counter", name, help, labelNames)
+}
+
+// NewGaugeVec は増減する値を登録する
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *ValueVec {
+	return r.newValueVec("gauge", name, help, labelNames)
+}
+
+func (r *Registry) newValueVec(kind, name, help string, labelNames []string) *ValueVec {
+	v := &ValueVec{
+		family: family{name: name, help: help, kind: kind, labelNames: labelNames},
+		values: make(map[string]*sample),
+	}
+	r.register(v)
+	return v
+}
+
+func (v *ValueVec) sample(labelValues []string) *sample {
+	key := v.key(labelValues)
+	s, ok := v.values[key]
+	if !ok {
+		s = &sample{labels: append([]string(nil), labelValues...)}
+		v.values[key] = s
+	}
+	return s
+}
+
+// Add はラベルの組の値にdeltaを加える
+func (v *ValueVec) Add(delta float64, labelValues ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sample(labelValues).value += delta
+}
+
+// Inc はラベルの組の値に1を加える
+func (v *ValueVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Set はラベルの組の値を設定する
+func (v *ValueVec) Set(value float64, labelValues ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.sample(labelValues).value = value
+}
+
+// Value はラベルの組の現在の値を返す
+func (v *ValueVec) Value(labelValues ...string) float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.values[v.key(labelValues)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (v *ValueVec) write(w io.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.writeHeader(w)
+	for _, key := range sortedKeys(v.values) {
+		s := v.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labelNames, s.labels), formatValue(s.value))
+	}
+}
+
+// histogramSample はラベルの組ごとのヒストグラム
+type histogramSample struct {
+	labels []string
+	counts []uint64 // bucketsの各上限以下の観測数（累積ではない）
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec はラベルの組ごとに観測値の分布を持つ
+type HistogramVec struct {
+	family
+	buckets []float64
+	values  map[string]*histogramSample
+}
+
+// NewHistogramVec は観測値の分布を登録する。bucketsは昇順の上限値
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		family:  family{name: name, help: help, kind: "histogram", labelNames: labelNames},
+		buckets: buckets,
+		values:  make(map[string]*histogramSample),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe はラベルの組に観測値を1つ加える
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := h.key(labelValues)
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSample{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = s
+	}
+	for i, upper := range h.buckets {
+		if value <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+// Count はラベルの組の観測数を返す
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.values[h.key(labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.values) {
+		s := h.values[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labelNames, s.labels, "le", formatValue(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labelNames, s.labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labelNames, s.labels), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labelNames, s.labels), s.count)
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	t.Run("カウンターとゲージをラベルの組ごとに書き出す", func(t *testing.T) {
+		registry := NewRegistry()
+		requests := registry.NewCounterVec("test_requests_total", "Requests.", "method", "status")
+		depth := registry.NewGaugeVec("test_depth", "Depth.")
+
+		requests.Inc("GET", "200")
+		requests.Inc("GET", "200")
+		requests.Add(3, "POST", "500")
+		depth.Set(4)
+
+		var out bytes.Buffer
+		registry.WriteText(&out)
+
+		assert.Equal(t, `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",status="200"} 2
+test_requests_total{method="POST",status="500"} 3
+# HELP test_depth Depth.
+# TYPE test_depth gauge
+test_depth 4
+`, out.String())
+	})
+
+	t.Run("ヒストグラムは累積のバケットと合計を書き出す", func(t *testing.T) {
+		registry := NewRegistry()
+		durations := registry.NewHistogramVec("test_duration_seconds", "Durations.", []float64{1, 5}, "phase")
+
+		durations.Observe(0.5, "plan")
+		durations.Observe(3, "plan")
+		durations.Observe(10, "plan")
+
+		var out bytes.Buffer
+		registry.WriteText(&out)
+
+		assert.Equal(t, `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{phase="plan",le="1"} 1
+test_duration_seconds_bucket{phase="plan",le="5"} 2
+test_duration_seconds_bucket{phase="plan",le="+Inf"} 3
+test_duration_seconds_sum{phase="plan"} 13.5
+test_duration_seconds_count{phase="plan"} 3
+`, out.String())
+	})
+
+	t.Run("ラベルの値はエスケープする", func(t *testing.T) {
+		registry := NewRegistry()
+		failures := registry.NewCounterVec("test_failures_total", "Failures.", "template")
+		failures.Inc(`a"b`)
+
+		var out bytes.Buffer
+		registry.WriteText(&out)
+
+		assert.Contains(t, out.String(), `test_failures_total{template="a\"b"} 1`)
+	})
+
+	t.Run("ラベルの数が合わない場合はpanicする", func(t *testing.T) {
+		registry := NewRegistry()
+		requests := registry.NewCounterVec("test_requests_total", "Requests.", "method")
+
+		assert.Panics(t, func() { requests.Inc("GET", "200") })
+	})
+}
+
+func TestObserveWatchCycle(t *testing.T) {
+	beforeCycles := watchCycleDuration.Count("test_watcher")
+	beforeErrors := watchCycleErrors.Value("test_watcher")
+
+	ObserveWatchCycle("test_watcher", time.Second, nil)
+	ObserveWatchCycle("test_watcher", time.Second, errors.New("failed"))
+
+	assert.Equal(t, beforeCycles+2, watchCycleDuration.Count("test_watcher"))
+	assert.Equal(t, beforeErrors+1, watchCycleErrors.Value("test_watcher"))
+}
+
+func TestPhaseDuration(t *testing.T) {
+	t.Run("開始したフェーズの終了時に所要時間を記録する", func(t *testing.T) {
+		before := phaseDuration.Count("plan", "completed")
+		beforeStarted := phaseExecutions.Value("plan", "started")
+
+		PhaseStarted(101, "plan", nil)
+		PhaseFinished(101, "plan", "completed")
+
+		assert.Equal(t, before+1, phaseDuration.Count("plan", "completed"))
+		assert.Equal(t, beforeStarted+1, phaseExecutions.Value("plan", "started"))
+	})
+
+	t.Run("開始を記録していないフェーズは所要時間を記録しない", func(t *testing.T) {
+		before := phaseDuration.Count("implement", "stopped")
+
+		PhaseFinished(102, "implement", "stopped")
+
+		assert.Equal(t, before, phaseDuration.Count("implement", "stopped"))
+	})
+
+	t.Run("開始に失敗したフェーズはerrorとして数え、所要時間を記録しない", func(t *testing.T) {
+		before := phaseDuration.Count("review", "completed")
+		beforeErrors := phaseExecutions.Value("review", "error")
+
+		PhaseStarted(103, "review", errors.New("tmux failed"))
+		PhaseFinished(103, "review", "completed")
+
+		assert.Equal(t, beforeErrors+1, phaseExecutions.Value("review", "error"))
+		assert.Equal(t, before, phaseDuration.Count("review", "completed"))
+	})
+}
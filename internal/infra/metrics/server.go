@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Server は/metricsと/healthzを提供するHTTPサーバー
+type Server struct {
+	registry *Registry
+	health   *Health
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewServer は新しいServerを作成する
+func NewServer(registry *Registry, health *Health) *Server {
+	s := &Server{
+		registry: registry,
+		health:   health,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler はルーティング済みのハンドラーを返す
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// Listen はaddrで待ち受けを開始する。ポートが使えない場合はここでエラーを返す
+func (s *Server) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	return nil
+}
+
+// Addr は待ち受け中のアドレスを返す
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Serve はctxがキャンセルされるまでリクエストを処理する
+func (s *Server) Serve(ctx context.Context) error {
+	if s.listener == nil {
+		return errors.New("metrics server is not listening")
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = s.server.Shutdown(shutdownCtx)
+	}()
+
+	if err := s.server.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.registry.WriteText(w)
+}
+
+// healthResponse は/healthzの応答
+type healthResponse struct {
+	Status   string                     `json:"status"`
+	Watchers map[string]ComponentStatus `json:"watchers"`
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	watchers, healthy := s.health.Snapshot()
+	response := healthResponse{Status: "ok", Watchers: watchers}
+	code := http.StatusOK
+	if !healthy {
+		response.Status = "unhealthy"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth_Run(t *testing.T) {
+	t.Run("実行中は稼働中、戻った後は停止として記録する", func(t *testing.T) {
+		health := NewHealth()
+		release := make(chan struct{})
+		done := make(chan error)
+
+		started := make(chan struct{})
+		go func() {
+			done <- health.Run("issue_watcher", func() error {
+				close(started)
+				<-release
+				return errors.New("watch failed")
+			})
+		}()
+		<-started
+
+		snapshot, healthy := health.Snapshot()
+		assert.True(t, healthy)
+		assert.True(t, snapshot["issue_watcher"].Alive)
+
+		close(release)
+		require.EqualError(t, <-done, "watch failed")
+
+		snapshot, healthy = health.Snapshot()
+		assert.False(t, healthy)
+		assert.False(t, snapshot["issue_watcher"].Alive)
+		assert.Equal(t, "watch failed", snapshot["issue_watcher"].Error)
+		assert.NotNil(t, snapshot["issue_watcher"].StoppedAt)
+	})
+
+	t.Run("何も起動していない場合は稼働中とみなさない", func(t *testing.T) {
+		_, healthy := NewHealth().Snapshot()
+		assert.False(t, healthy)
+	})
+}
+
+func TestServer(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewGaugeVec("test_queue_depth", "Queue depth.").Set(2)
+	health := NewHealth()
+	handler := NewServer(registry, health).Handler()
+
+	t.Run("/metricsはテキスト形式で計測値を返す", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, rec.Body.String(), "test_queue_depth 2\n")
+	})
+
+	t.Run("/healthzは全goroutineが稼働中なら200を返す", func(t *testing.T) {
+		health.started("issue_watcher")
+		health.started("pr_watcher")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var body healthResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "ok", body.Status)
+		assert.Len(t, body.Watchers, 2)
+	})
+
+	t.Run("/healthzは停止したgoroutineがあれば503を返す", func(t *testing.T) {
+		health.stopped("pr_watcher", nil)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		var body healthResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "unhealthy", body.Status)
+		assert.False(t, body.Watchers["pr_watcher"].Alive)
+		assert.True(t, body.Watchers["issue_watcher"].Alive)
+	})
+}
+
+func TestServer_Serve(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := NewServer(NewRegistry(), NewHealth())
+	require.NoError(t, server.Listen("127.0.0.1:0"))
+
+	done := make(chan error)
+	go func() { done <- server.Serve(ctx) }()
+
+	resp, err := http.Get("http://" + server.Addr() + "/healthz")
+	require.NoError(t, err)
+	_, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
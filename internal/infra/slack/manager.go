@@ -7,12 +7,15 @@ import (
 	"time"
 
 	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/metrics"
 	"github.com/douhashi/soba/pkg/logging"
 )
 
 var (
-	instance Manager
-	once     sync.Once
+	// mu guards instance, which the watchers read while a config reload replaces it
+	mu          sync.RWMutex
+	instance    Manager
+	initialized bool
 )
 
 // SlackManager implements Manager interface
@@ -24,56 +27,80 @@ type SlackManager struct {
 	templateManager TemplateManager
 }
 
-// Initialize initializes the global Slack manager based on config
+// Initialize initializes the global Slack manager based on config.
+// Only the first call takes effect; use Reload to replace the manager.
 func Initialize(cfg *config.Config, logger logging.Logger) {
-	once.Do(func() {
-		if !cfg.Slack.NotificationsEnabled || cfg.Slack.WebhookURL == "" {
-			// Use NoOpManager when Slack is disabled
-			instance = &NoOpManager{}
-			logger.Info(context.Background(), "Slack notifications disabled")
-			return
-		}
+	mu.RLock()
+	done := initialized
+	mu.RUnlock()
+	if done {
+		return
+	}
 
-		// Check if GitHub repository is configured
-		if cfg.GitHub.Repository == "" {
-			logger.Warn(context.Background(), "GitHub repository not configured, falling back to NoOp manager")
-			instance = &NoOpManager{}
-			return
-		}
+	manager := newManager(cfg, logger)
+	mu.Lock()
+	defer mu.Unlock()
+	if !initialized {
+		instance, initialized = manager, true
+	}
+}
+
+// newManager builds a Slack manager from config
+func newManager(cfg *config.Config, logger logging.Logger) Manager {
+	if !cfg.Slack.NotificationsEnabled || cfg.Slack.WebhookURL == "" {
+		// Use NoOpManager when Slack is disabled
+		logger.Info(context.Background(), "Slack notifications disabled")
+		return &NoOpManager{}
+	}
+
+	// Check if GitHub repository is configured
+	if cfg.GitHub.Repository == "" {
+		logger.Warn(context.Background(), "GitHub repository not configured, falling back to NoOp manager")
+		return &NoOpManager{}
+	}
 
-		// Use default timeout of 30 seconds
-		timeout := 30 * time.Second
-		client := NewClient(cfg.Slack.WebhookURL, timeout)
+	// Use default timeout of 30 seconds
+	timeout := 30 * time.Second
+	client := NewClient(cfg.Slack.WebhookURL, timeout)
 
-		// Initialize template manager with embedded templates
-		templateManager := NewTemplateManagerWithFS(logger, config.GetSlackTemplatesFS())
+	// Initialize template manager with embedded templates
+	templateManager := NewTemplateManagerWithFS(logger, config.GetSlackTemplatesFS())
+	if err := templateManager.LoadTemplates(); err != nil {
+		// Fallback to filesystem templates
+		templateManager = NewTemplateManager(logger)
 		if err := templateManager.LoadTemplates(); err != nil {
-			// Fallback to filesystem templates
-			templateManager = NewTemplateManager(logger)
-			if err := templateManager.LoadTemplates(); err != nil {
-				logger.Warn(context.Background(), "Failed to load Slack templates, falling back to NoOp manager",
-					logging.Field{Key: "error", Value: err.Error()},
-				)
-				instance = &NoOpManager{}
-				return
-			}
+			logger.Warn(context.Background(), "Failed to load Slack templates, falling back to NoOp manager",
+				logging.Field{Key: "error", Value: err.Error()},
+			)
+			return &NoOpManager{}
 		}
+	}
 
-		instance = &SlackManager{
-			client:          client,
-			config:          cfg.Slack,
-			githubConfig:    cfg.GitHub,
-			logger:          logger,
-			templateManager: templateManager,
-		}
-		logger.Info(context.Background(), "Slack notifications enabled with block templates",
-			logging.Field{Key: "repository", Value: cfg.GitHub.Repository},
-		)
-	})
+	logger.Info(context.Background(), "Slack notifications enabled with block templates",
+		logging.Field{Key: "repository", Value: cfg.GitHub.Repository},
+	)
+	return &SlackManager{
+		client:          client,
+		config:          cfg.Slack,
+		githubConfig:    cfg.GitHub,
+		logger:          logger,
+		templateManager: templateManager,
+	}
+}
+
+// Reload replaces the global Slack manager with one built from a reloaded config.
+// Notifications sent meanwhile use the previous manager.
+func Reload(cfg *config.Config, logger logging.Logger) {
+	manager := newManager(cfg, logger)
+	mu.Lock()
+	defer mu.Unlock()
+	instance, initialized = manager, true
 }
 
 // GetManager returns the global Slack manager instance
 func GetManager() Manager {
+	mu.RLock()
+	defer mu.RUnlock()
 	if instance == nil {
 		// Return NoOpManager if not initialized
 		return &NoOpManager{}
@@ -81,10 +108,19 @@ func GetManager() Manager {
 	return instance
 }
 
+// Disable replaces the global manager with a NoOpManager so that nothing is sent,
+// e.g. during a dry run
+func Disable() {
+	mu.Lock()
+	defer mu.Unlock()
+	instance, initialized = &NoOpManager{}, true
+}
+
 // Reset resets the singleton (for testing)
 func Reset() {
-	instance = nil
-	once = sync.Once{}
+	mu.Lock()
+	defer mu.Unlock()
+	instance, initialized = nil, false
 }
 
 // Template data structures
@@ -124,6 +160,7 @@ func (s *SlackManager) sendBlockMessage(templateName string, data interface{}) {
 	go func() {
 		blockData, err := s.templateManager.RenderTemplate(templateName, data)
 		if err != nil {
+			metrics.IncSlackFailures(templateName)
 			s.logger.Warn(context.Background(), "Failed to render Slack template",
 				logging.Field{Key: "template", Value: templateName},
 				logging.Field{Key: "error", Value: err.Error()},
@@ -132,6 +169,7 @@ func (s *SlackManager) sendBlockMessage(templateName string, data interface{}) {
 		}
 
 		if err := s.client.SendBlockMessage(blockData); err != nil {
+			metrics.IncSlackFailures(templateName)
 			s.logger.Warn(context.Background(), "Failed to send Slack block notification",
 				logging.Field{Key: "template", Value: templateName},
 				logging.Field{Key: "error", Value: err.Error()},
@@ -3,6 +3,7 @@ package slack
 import (
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -163,6 +164,61 @@ func TestGetManagerWithoutInitialization(t *testing.T) {
 	manager.Notify("Test notification")
 }
 
+func TestDisable(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Disable()
+	assert.False(t, IsEnabled())
+
+	// Initialize after Disable keeps notifications disabled
+	cfg := &config.Config{
+		GitHub: config.GitHubConfig{Repository: "owner/repo"},
+		Slack: config.SlackConfig{
+			WebhookURL:           "https://hooks.slack.com/services/test",
+			NotificationsEnabled: true,
+		},
+	}
+	Initialize(cfg, logging.NewMockLogger())
+	assert.False(t, IsEnabled())
+}
+
+func TestReload(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	disabled := &config.Config{}
+	enabled := &config.Config{
+		GitHub: config.GitHubConfig{Repository: "owner/repo"},
+		Slack: config.SlackConfig{
+			WebhookURL:           "https://hooks.slack.com/services/test",
+			NotificationsEnabled: true,
+		},
+	}
+	Initialize(disabled, logging.NewMockLogger())
+	assert.False(t, IsEnabled())
+
+	// Reloading while notifications are read from other goroutines must not race
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = GetManager().IsEnabled()
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		Reload(enabled, logging.NewMockLogger())
+	}
+	wg.Wait()
+
+	assert.True(t, IsEnabled())
+	Reload(disabled, logging.NewMockLogger())
+	assert.False(t, IsEnabled())
+}
+
 func TestSlackManagerSingleton(t *testing.T) {
 	// Reset singleton for testing
 	Reset()
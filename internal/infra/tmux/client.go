@@ -32,6 +32,10 @@ type TmuxClient interface {
 
 	// コマンド送信
 	SendCommand(sessionName, windowName string, paneIndex int, command string) error
+
+	// ペイン出力の取得
+	CapturePane(sessionName, windowName string, paneIndex int) (string, error)
+	PipePane(sessionName, windowName string, paneIndex int, logPath string) error
 }
 
 // Client はTmuxClientインターフェースの具象実装
@@ -366,6 +370,60 @@ func (c *Client) SendCommand(sessionName, windowName string, paneIndex int, comm
 	return nil
 }
 
+// CapturePane は指定されたペインのスクロールバックを含む全履歴をテキストで取得する
+func (c *Client) CapturePane(sessionName, windowName string, paneIndex int) (string, error) {
+	if !c.SessionExists(sessionName) {
+		return "", ErrSessionNotFound
+	}
+
+	exists, err := c.WindowExists(sessionName, windowName)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", ErrWindowNotFound
+	}
+
+	target := fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex)
+	// -S - で履歴の先頭から取得し、-J で折り返された行を結合する
+	// #nosec G204 - tmuxコマンドは信頼できる入力のみを使用
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-J", "-S", "-", "-t", target)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", NewTmuxError("capture_pane", fmt.Sprintf("failed to capture pane %d in window '%s' of session '%s'", paneIndex, windowName, sessionName), err)
+	}
+
+	return string(output), nil
+}
+
+// PipePane は指定されたペインの出力をlogPathのファイルに追記し続ける
+// 既にパイプが開いている場合は何もしない
+func (c *Client) PipePane(sessionName, windowName string, paneIndex int, logPath string) error {
+	if !c.SessionExists(sessionName) {
+		return ErrSessionNotFound
+	}
+
+	exists, err := c.WindowExists(sessionName, windowName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrWindowNotFound
+	}
+
+	target := fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex)
+	// tmuxはシェル経由でコマンドを起動するため、パスはシングルクォートで囲む
+	pipeCommand := "cat >> '" + strings.ReplaceAll(logPath, "'", `'\''`) + "'"
+	// #nosec G204 - tmuxコマンドは信頼できる入力のみを使用
+	cmd := exec.Command("tmux", "pipe-pane", "-o", "-t", target, pipeCommand)
+	err = cmd.Run()
+	if err != nil {
+		return NewTmuxError("pipe_pane", fmt.Sprintf("failed to pipe pane %d in window '%s' of session '%s'", paneIndex, windowName, sessionName), err)
+	}
+
+	return nil
+}
+
 // isProtectedSession は指定されたセッションが削除から保護されているかを判定する
 // sobaプレフィックスのセッションは誤削除を防ぐため保護される（テスト用を除く）
 func isProtectedSession(sessionName string) bool {
@@ -3,7 +3,9 @@ package tmux
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -391,6 +393,59 @@ func TestTmuxClient_SendCommand(t *testing.T) {
 	}
 }
 
+func TestTmuxClient_PipeAndCapturePane(t *testing.T) {
+	checkTmuxAvailable(t)
+	client := NewClient()
+	sessionName := "soba-test-capture"
+	windowName := "test-window"
+	logPath := filepath.Join(t.TempDir(), "pane.log")
+
+	if !client.SessionExists(sessionName) {
+		err := client.CreateSession(sessionName)
+		if err != nil {
+			t.Fatalf("Failed to create test session: %v", err)
+		}
+	}
+	defer client.DeleteSession(sessionName)
+
+	err := client.CreateWindow(sessionName, windowName)
+	if err != nil {
+		t.Fatalf("Failed to create test window: %v", err)
+	}
+
+	// ウィンドウが完全に準備されるまで設定に従って待機
+	time.Sleep(getCommandDelay())
+
+	firstPaneIndex, err := client.GetFirstPaneIndex(sessionName, windowName)
+	if err != nil {
+		t.Fatalf("Failed to get first pane index: %v", err)
+	}
+
+	if err := client.PipePane(sessionName, windowName, firstPaneIndex, logPath); err != nil {
+		t.Fatalf("PipePane() error = %v", err)
+	}
+	if err := client.SendCommand(sessionName, windowName, firstPaneIndex, "echo soba-capture-marker"); err != nil {
+		t.Fatalf("SendCommand() error = %v", err)
+	}
+	time.Sleep(getCommandDelay())
+
+	output, err := client.CapturePane(sessionName, windowName, firstPaneIndex)
+	if err != nil {
+		t.Fatalf("CapturePane() error = %v", err)
+	}
+	if !strings.Contains(output, "soba-capture-marker") {
+		t.Errorf("CapturePane() output does not contain marker: %q", output)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read pipe log: %v", err)
+	}
+	if !strings.Contains(string(data), "soba-capture-marker") {
+		t.Errorf("pipe log does not contain marker: %q", string(data))
+	}
+}
+
 // generateSessionName関数は削除されたため、このテストも削除
 
 func TestIsProtectedSession(t *testing.T) {
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/slack"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// WorkLedgerPath は予算の集計に使うフェーズ実行とキュー投入の記録
+const WorkLedgerPath = ".soba/usage/ledger.jsonl"
+
+const (
+	ledgerKindPhase   = "phase"
+	ledgerKindEnqueue = "enqueue"
+)
+
+// ledgerEntry はフェーズの開始またはIssueのキュー投入を1件記録する
+type ledgerEntry struct {
+	Time  time.Time `json:"time"`
+	Kind  string    `json:"kind"`
+	Issue int       `json:"issue"`
+	Phase string    `json:"phase,omitempty"`
+}
+
+// recordLedgerEntry は台帳に1件追記する
+func recordLedgerEntry(path, kind string, issueNumber int, phase string, now time.Time) error {
+	return appendJSONLine(path, ledgerEntry{Time: now, Kind: kind, Issue: issueNumber, Phase: phase})
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// scheduleLocation はスケジュールと予算の日・月の区切りに使うタイムゾーンを返す
+func scheduleLocation(schedule config.ScheduleConfig) (*time.Location, error) {
+	if schedule.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule timezone %q: %w", schedule.Timezone, err)
+	}
+	return loc, nil
+}
+
+// parseActiveHours は"HH:MM-HH:MM"を0時からの分数の組に変換する
+func parseActiveHours(activeHours string) (int, int, error) {
+	parts := strings.Split(activeHours, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid active_hours %q: expected HH:MM-HH:MM", activeHours)
+	}
+
+	var minutes [2]int
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid active_hours %q: expected HH:MM-HH:MM", activeHours)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	return minutes[0], minutes[1], nil
+}
+
+// withinSchedule は現在時刻が新しいフェーズを開始してよい時間帯か判定する
+func withinSchedule(schedule config.ScheduleConfig, now time.Time) (bool, error) {
+	loc, err := scheduleLocation(schedule)
+	if err != nil {
+		return false, err
+	}
+	now = now.In(loc)
+
+	if len(schedule.Weekdays) > 0 {
+		active := false
+		for _, name := range schedule.Weekdays {
+			weekday, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return false, fmt.Errorf("invalid schedule weekday %q", name)
+			}
+			if weekday == now.Weekday() {
+				active = true
+			}
+		}
+		if !active {
+			return false, nil
+		}
+	}
+
+	if schedule.ActiveHours == "" {
+		return true, nil
+	}
+	start, end, err := parseActiveHours(schedule.ActiveHours)
+	if err != nil {
+		return false, err
+	}
+	minute := now.Hour()*60 + now.Minute()
+	switch {
+	case start == end:
+		return true, nil
+	case start < end:
+		return minute >= start && minute < end, nil
+	default:
+		// 日付をまたぐ時間帯
+		return minute >= start || minute < end, nil
+	}
+}
+
+// WorkGuard は予算とスケジュールに基づき、新しいIssueをキューに入れてよいか判定する
+type WorkGuard struct {
+	mu         sync.Mutex
+	config     *config.Config
+	ledgerPath string
+	usagePath  string
+	logger     logging.Logger
+	now        func() time.Time
+	notify     func(text string)
+	notified   map[string]bool // 通知済みの上限（期間ごと）
+	dryRun     *DryRunRecorder // nilでなければ台帳に書き込まない
+}
+
+// NewWorkGuard は新しいWorkGuardを作成する
+func NewWorkGuard(cfg *config.Config, logger logging.Logger) *WorkGuard {
+	return &WorkGuard{
+		config:     cfg,
+		ledgerPath: WorkLedgerPath,
+		usagePath:  UsageLogPath,
+		logger:     logger,
+		now:        time.Now,
+		notify:     slack.Notify,
+		notified:   make(map[string]bool),
+	}
+}
+
+// Configure は判定に使う設定を更新する
+func (g *WorkGuard) Configure(cfg *config.Config) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.config = cfg
+}
+
+// SetLogger はロガーを設定する
+func (g *WorkGuard) SetLogger(logger logging.Logger) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.logger = logger
+}
+
+// budgetUsage は日・月ごとの実績
+type budgetUsage struct {
+	phaseRuns int
+	issues    int
+	cost      float64
+}
+
+// EnqueueBlockReason は新しいIssueをキューに入れられない理由を返す。入れてよい場合は空文字を返す
+// 予算の上限に達したときは、上限と期間ごとに一度だけSlackへ通知する
+func (g *WorkGuard) EnqueueBlockReason(ctx context.Context) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.config == nil {
+		return ""
+	}
+
+	now := g.now()
+	schedule := g.config.Workflow.Schedule
+	active, err := withinSchedule(schedule, now)
+	if err != nil {
+		g.logger.Error(ctx, "Invalid schedule, not enqueuing new issues", logging.Field{Key: "error", Value: err.Error()})
+		return err.Error()
+	}
+	if !active {
+		return "outside the active schedule"
+	}
+
+	reason, key, err := g.budgetExceeded(now)
+	if err != nil {
+		g.logger.Warn(ctx, "Failed to check budget", logging.Field{Key: "error", Value: err.Error()})
+		return ""
+	}
+	if reason == "" {
+		return ""
+	}
+
+	if !g.notified[key] {
+		g.notified[key] = true
+		g.notify(fmt.Sprintf("soba stopped enqueuing new issues: %s. Issues in progress will finish.", reason))
+	}
+	return reason
+}
+
+// budgetExceeded は上限に達した予算の説明と通知済みかどうかの判定に使うキーを返す
+func (g *WorkGuard) budgetExceeded(now time.Time) (string, string, error) {
+	budget := g.config.Workflow.Budget
+	if budget == (config.BudgetConfig{}) {
+		return "", "", nil
+	}
+
+	loc, err := scheduleLocation(g.config.Workflow.Schedule)
+	if err != nil {
+		return "", "", err
+	}
+	now = now.In(loc)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+
+	day, month, err := g.collectUsage(dayStart, monthStart)
+	if err != nil {
+		return "", "", err
+	}
+
+	dayKey := dayStart.Format("2006-01-02")
+	monthKey := monthStart.Format("2006-01")
+	checks := []struct {
+		name     string
+		exceeded bool
+		detail   string
+		period   string
+	}{
+		{"max_phase_runs_per_day", budget.MaxPhaseRunsPerDay > 0 && day.phaseRuns >= budget.MaxPhaseRunsPerDay,
+			fmt.Sprintf("%d of %d phase runs today", day.phaseRuns, budget.MaxPhaseRunsPerDay), dayKey},
+		{"max_phase_runs_per_month", budget.MaxPhaseRunsPerMonth > 0 && month.phaseRuns >= budget.MaxPhaseRunsPerMonth,
+			fmt.Sprintf("%d of %d phase runs this month", month.phaseRuns, budget.MaxPhaseRunsPerMonth), monthKey},
+		{"max_issues_per_day", budget.MaxIssuesPerDay > 0 && day.issues >= budget.MaxIssuesPerDay,
+			fmt.Sprintf("%d of %d issues started today", day.issues, budget.MaxIssuesPerDay), dayKey},
+		{"max_issues_per_month", budget.MaxIssuesPerMonth > 0 && month.issues >= budget.MaxIssuesPerMonth,
+			fmt.Sprintf("%d of %d issues started this month", month.issues, budget.MaxIssuesPerMonth), monthKey},
+		{"max_cost_per_day", budget.MaxCostPerDay > 0 && day.cost >= budget.MaxCostPerDay,
+			fmt.Sprintf("$%.2f of $%.2f spent today", day.cost, budget.MaxCostPerDay), dayKey},
+		{"max_cost_per_month", budget.MaxCostPerMonth > 0 && month.cost >= budget.MaxCostPerMonth,
+			fmt.Sprintf("$%.2f of $%.2f spent this month", month.cost, budget.MaxCostPerMonth), monthKey},
+	}
+	for _, check := range checks {
+		if check.exceeded {
+			return fmt.Sprintf("%s reached (%s)", check.name, check.detail), check.name + ":" + check.period, nil
+		}
+	}
+	return "", "", nil
+}
+
+// collectUsage は台帳と使用量ファイルから今日と今月の実績を集計する
+func (g *WorkGuard) collectUsage(dayStart, monthStart time.Time) (budgetUsage, budgetUsage, error) {
+	var day, month budgetUsage
+
+	entries, err := readJSONLines[ledgerEntry](g.ledgerPath)
+	if err != nil {
+		return day, month, err
+	}
+	for _, entry := range entries {
+		if entry.Time.Before(monthStart) {
+			continue
+		}
+		inDay := !entry.Time.Before(dayStart)
+		switch entry.Kind {
+		case ledgerKindPhase:
+			month.phaseRuns++
+			if inDay {
+				day.phaseRuns++
+			}
+		case ledgerKindEnqueue:
+			month.issues++
+			if inDay {
+				day.issues++
+			}
+		}
+	}
+
+	records, err := LoadUsageRecords(g.usagePath)
+	if err != nil {
+		return day, month, err
+	}
+	for _, record := range records {
+		if record.Time.Before(monthStart) {
+			continue
+		}
+		month.cost += record.CostUSD
+		if !record.Time.Before(dayStart) {
+			day.cost += record.CostUSD
+		}
+	}
+	return day, month, nil
+}
+
+// SetDryRun はドライランを設定する
+func (g *WorkGuard) SetDryRun(recorder *DryRunRecorder) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dryRun = recorder
+}
+
+// RecordEnqueue はIssueをキューに入れたことを台帳に記録する
+// 途中で上限を設定しても集計できるよう、上限がなくても記録する
+func (g *WorkGuard) RecordEnqueue(ctx context.Context, issueNumber int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.dryRun != nil {
+		g.dryRun.Record("record issue #%d in the budget ledger", issueNumber)
+		return
+	}
+	if err := recordLedgerEntry(g.ledgerPath, ledgerKindEnqueue, issueNumber, "", g.now()); err != nil {
+		g.logger.Warn(ctx, "Failed to record enqueued issue",
+			logging.Field{Key: "error", Value: err.Error()},
+			logging.Field{Key: "issue", Value: issueNumber},
+		)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestWithinSchedule(t *testing.T) {
+	// 2025-01-06は月曜日
+	at := func(day, hour, minute int) time.Time { return time.Date(2025, 1, day, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		schedule config.ScheduleConfig
+		now      time.Time
+		expected bool
+	}{
+		{"指定がなければ常に活動時間", config.ScheduleConfig{}, at(5, 3, 0), true},
+		{"活動時間内", config.ScheduleConfig{ActiveHours: "09:00-18:00", Timezone: "UTC"}, at(6, 9, 0), true},
+		{"終了時刻は含まない", config.ScheduleConfig{ActiveHours: "09:00-18:00", Timezone: "UTC"}, at(6, 18, 0), false},
+		{"日付をまたぐ時間帯の深夜", config.ScheduleConfig{ActiveHours: "22:00-06:00", Timezone: "UTC"}, at(6, 2, 30), true},
+		{"日付をまたぐ時間帯の日中", config.ScheduleConfig{ActiveHours: "22:00-06:00", Timezone: "UTC"}, at(6, 12, 0), false},
+		{"曜日が含まれない", config.ScheduleConfig{Weekdays: []string{"mon", "Tue"}, Timezone: "UTC"}, at(5, 12, 0), false},
+		{"曜日が含まれる", config.ScheduleConfig{Weekdays: []string{"mon", "Tue"}, Timezone: "UTC"}, at(7, 12, 0), true},
+		{"タイムゾーンで判定する", config.ScheduleConfig{ActiveHours: "09:00-18:00", Timezone: "Asia/Tokyo"}, at(6, 1, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := withinSchedule(tt.schedule, tt.now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, active)
+		})
+	}
+
+	t.Run("不正な設定はエラー", func(t *testing.T) {
+		for _, schedule := range []config.ScheduleConfig{
+			{ActiveHours: "9-18"},
+			{Weekdays: []string{"funday"}},
+			{Timezone: "Nowhere/City"},
+		} {
+			_, err := withinSchedule(schedule, at(6, 12, 0))
+			assert.Error(t, err, "%+v", schedule)
+		}
+	})
+}
+
+func newTestWorkGuard(t *testing.T, workflow config.WorkflowConfig, now time.Time) (*WorkGuard, *[]string) {
+	t.Helper()
+	var notifications []string
+	dir := t.TempDir()
+	guard := NewWorkGuard(&config.Config{Workflow: workflow}, logging.NewMockLogger())
+	guard.ledgerPath = filepath.Join(dir, "ledger.jsonl")
+	guard.usagePath = filepath.Join(dir, "usage.jsonl")
+	guard.now = func() time.Time { return now }
+	guard.notify = func(text string) { notifications = append(notifications, text) }
+	return guard, &notifications
+}
+
+// chdirTemp はテスト中の作業ディレクトリを一時ディレクトリにし、
+// 既定の台帳.soba/usage/ledger.jsonlをソースツリーに書かないようにする
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(oldWd) })
+}
+
+func TestWorkGuard_EnqueueBlockReason(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	lastMonth := now.AddDate(0, -1, 0)
+	utc := config.ScheduleConfig{Timezone: "UTC"}
+
+	t.Run("上限がなければキューに入れてよい", func(t *testing.T) {
+		guard, notifications := newTestWorkGuard(t, config.WorkflowConfig{Schedule: utc}, now)
+		assert.Empty(t, guard.EnqueueBlockReason(context.Background()))
+		assert.Empty(t, *notifications)
+	})
+
+	t.Run("1日のフェーズ実行数の上限に達したら止めて一度だけ通知する", func(t *testing.T) {
+		guard, notifications := newTestWorkGuard(t, config.WorkflowConfig{
+			Schedule: utc,
+			Budget:   config.BudgetConfig{MaxPhaseRunsPerDay: 2},
+		}, now)
+		require.NoError(t, recordLedgerEntry(guard.ledgerPath, ledgerKindPhase, 1, "plan", yesterday))
+		require.NoError(t, recordLedgerEntry(guard.ledgerPath, ledgerKindPhase, 1, "implement", now.Add(-time.Hour)))
+		assert.Empty(t, guard.EnqueueBlockReason(context.Background()))
+
+		require.NoError(t, recordLedgerEntry(guard.ledgerPath, ledgerKindPhase, 1, "review", now))
+		reason := guard.EnqueueBlockReason(context.Background())
+		assert.Equal(t, "max_phase_runs_per_day reached (2 of 2 phase runs today)", reason)
+		assert.NotEmpty(t, guard.EnqueueBlockReason(context.Background()))
+
+		require.Len(t, *notifications, 1)
+		assert.Contains(t, (*notifications)[0], "max_phase_runs_per_day reached")
+		assert.Contains(t, (*notifications)[0], "Issues in progress will finish")
+	})
+
+	t.Run("1ヶ月に始めたIssue数の上限", func(t *testing.T) {
+		guard, _ := newTestWorkGuard(t, config.WorkflowConfig{
+			Schedule: utc,
+			Budget:   config.BudgetConfig{MaxIssuesPerMonth: 2},
+		}, now)
+		guard.RecordEnqueue(context.Background(), 1)
+		require.NoError(t, recordLedgerEntry(guard.ledgerPath, ledgerKindEnqueue, 2, "", lastMonth))
+		assert.Empty(t, guard.EnqueueBlockReason(context.Background()))
+
+		guard.RecordEnqueue(context.Background(), 3)
+		assert.Contains(t, guard.EnqueueBlockReason(context.Background()), "max_issues_per_month")
+	})
+
+	t.Run("記録された使用量でコストの上限を判定する", func(t *testing.T) {
+		guard, _ := newTestWorkGuard(t, config.WorkflowConfig{
+			Schedule: utc,
+			Budget:   config.BudgetConfig{MaxCostPerDay: 5, MaxCostPerMonth: 20},
+		}, now)
+		require.NoError(t, AppendUsageRecord(guard.usagePath, UsageRecord{Time: yesterday, CostUSD: 16}))
+		require.NoError(t, AppendUsageRecord(guard.usagePath, UsageRecord{Time: now, CostUSD: 3}))
+		assert.Empty(t, guard.EnqueueBlockReason(context.Background()))
+
+		require.NoError(t, AppendUsageRecord(guard.usagePath, UsageRecord{Time: now, CostUSD: 1.5}))
+		assert.Equal(t, "max_cost_per_month reached ($20.50 of $20.00 spent this month)", guard.EnqueueBlockReason(context.Background()))
+	})
+
+	t.Run("スケジュール外は通知せずに止める", func(t *testing.T) {
+		guard, notifications := newTestWorkGuard(t, config.WorkflowConfig{
+			Schedule: config.ScheduleConfig{ActiveHours: "09:00-11:00", Timezone: "UTC"},
+		}, now)
+		assert.Equal(t, "outside the active schedule", guard.EnqueueBlockReason(context.Background()))
+		assert.Empty(t, *notifications)
+	})
+
+	t.Run("上限がない場合もキュー投入を記録し、後から設定した上限に数える", func(t *testing.T) {
+		guard, _ := newTestWorkGuard(t, config.WorkflowConfig{Schedule: utc}, now)
+		guard.RecordEnqueue(context.Background(), 1)
+		assert.FileExists(t, guard.ledgerPath)
+		assert.Empty(t, guard.EnqueueBlockReason(context.Background()))
+
+		guard.config.Workflow.Budget = config.BudgetConfig{MaxIssuesPerDay: 1}
+		assert.NotEmpty(t, guard.EnqueueBlockReason(context.Background()))
+	})
+}
+
+func TestQueueManager_EnqueueNextIssue_WorkGuard(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	issues := []github.Issue{{Number: 5, Labels: []github.Label{{Name: "soba:todo"}}}}
+
+	t.Run("上限に達したらキューに入れない", func(t *testing.T) {
+		mockClient := new(MockQueueGitHubClient)
+		guard, _ := newTestWorkGuard(t, config.WorkflowConfig{
+			Schedule: config.ScheduleConfig{Timezone: "UTC"},
+			Budget:   config.BudgetConfig{MaxIssuesPerDay: 1},
+		}, now)
+		guard.RecordEnqueue(context.Background(), 4)
+		queueManager := NewQueueManager(mockClient, "owner", "repo")
+		queueManager.SetWorkGuard(guard)
+
+		require.NoError(t, queueManager.EnqueueNextIssue(context.Background(), issues))
+		mockClient.AssertNotCalled(t, "RemoveLabelFromIssue", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("キューに入れたIssueを記録する", func(t *testing.T) {
+		mockClient := new(MockQueueGitHubClient)
+		mockClient.On("RemoveLabelFromIssue", mock.Anything, "owner", "repo", 5, "soba:todo").Return(nil)
+		mockClient.On("AddLabelToIssue", mock.Anything, "owner", "repo", 5, "soba:queued").Return(nil)
+		guard, _ := newTestWorkGuard(t, config.WorkflowConfig{
+			Schedule: config.ScheduleConfig{Timezone: "UTC"},
+			Budget:   config.BudgetConfig{MaxIssuesPerDay: 2},
+		}, now)
+		queueManager := NewQueueManager(mockClient, "owner", "repo")
+		queueManager.SetWorkGuard(guard)
+
+		require.NoError(t, queueManager.EnqueueNextIssue(context.Background(), issues))
+
+		mockClient.AssertExpectations(t)
+		entries, err := readJSONLines[ledgerEntry](guard.ledgerPath)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, ledgerEntry{Time: now, Kind: ledgerKindEnqueue, Issue: 5}, entries[0])
+	})
+}
+
+func TestWorkflowExecutor_ExecutePhase_OutsideSchedule(t *testing.T) {
+	mockTmux := new(MockTmuxClient)
+	mockWorkspace := new(MockWorkspaceManager)
+	mockProcessor := new(MockIssueProcessorUpdater)
+	executor := NewWorkflowExecutor(mockTmux, mockWorkspace, mockProcessor, logging.NewMockLogger())
+
+	// 今日以外の曜日のみを活動日にする
+	tomorrow := time.Now().UTC().Add(24 * time.Hour).Weekday()
+	cfg := &config.Config{
+		Workflow: config.WorkflowConfig{
+			Schedule: config.ScheduleConfig{
+				Weekdays: []string{tomorrow.String()},
+				Timezone: "UTC",
+			},
+		},
+	}
+
+	err := executor.ExecutePhase(context.Background(), cfg, 7, "implement")
+
+	require.NoError(t, err)
+	mockProcessor.AssertNotCalled(t, "UpdateLabels", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockTmux.AssertNotCalled(t, "SendCommand", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
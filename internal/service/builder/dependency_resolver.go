@@ -20,6 +20,7 @@ type DependencyResolver struct {
 	logFactory   *logging.Factory
 	logger       logging.Logger
 	errorHandler ErrorHandler
+	decorator    ClientDecorator
 }
 
 // NewDependencyResolver creates a new dependency resolver with new logging
@@ -93,6 +94,12 @@ func (r *DependencyResolver) ResolveClients(ctx context.Context) (*ResolvedClien
 
 	// Slack notifications now handled by singleton SlackManager (initialized in app.go)
 
+	if r.decorator != nil {
+		r.logger.Info(ctx, "Decorating clients")
+		clients.GitHubClient = r.decorator.DecorateGitHubClient(clients.GitHubClient)
+		clients.TmuxClient = r.decorator.DecorateTmuxClient(clients.TmuxClient)
+	}
+
 	r.logger.Info(ctx, "Client dependencies resolved successfully")
 	return clients, nil
 }
@@ -135,6 +142,7 @@ func (r *DependencyResolver) ResolveServices(ctx context.Context, clients *Resol
 	// Set the issue processor on the workflow executor
 	workflowExecutor.SetIssueProcessor(issueProcessor)
 	r.logger.Info(ctx, "Successfully set IssueProcessor on WorkflowExecutor")
+	workflowExecutor.SetGitHubClient(clients.GitHubClient)
 
 	// Parse repository for owner and repo (needed for multiple services)
 	owner, repo := parseRepository(r.config.GitHub.Repository)
@@ -170,12 +178,16 @@ func (r *DependencyResolver) ResolveServices(ctx context.Context, clients *Resol
 	r.logger.Info(ctx, "Queue manager set to IssueWatcher")
 	services.IssueWatcher.SetProcessor(issueProcessor)
 	services.IssueWatcher.SetWorkflowExecutor(workflowExecutor)
+	services.IssueWatcher.SetTmuxClient(clients.TmuxClient)
 
 	r.logger.Debug(ctx, "Creating PR watcher")
 	services.PRWatcher = serviceFactory.CreatePRWatcher(
 		clients.GitHubClient,
 		r.config,
 	)
+	services.PRWatcher.SetGitClient(r.gitClient(clients))
+	services.PRWatcher.SetWorkspaceManager(workspace)
+	services.PRWatcher.SetTmuxClient(clients.TmuxClient)
 
 	// Phase 6: Create cleanup service
 	r.logger.Debug(ctx, "Creating cleanup service")
@@ -188,6 +200,7 @@ func (r *DependencyResolver) ResolveServices(ctx context.Context, clients *Resol
 		r.config.Workflow.ClosedIssueCleanupEnabled,
 		time.Duration(r.config.Workflow.ClosedIssueCleanupInterval)*time.Second,
 	)
+	services.CleanupService.SetWorktreeCollector(r.gitClient(clients), r.config)
 
 	r.logger.Info(ctx, "Service dependencies resolved successfully")
 	return services, nil
@@ -202,10 +215,18 @@ func (r *DependencyResolver) createWorkspaceManager(clients *ResolvedClients) Gi
 
 	return serviceFactory.CreateGitWorkspaceManager(
 		r.config,
-		clients.GitClient,
+		r.gitClient(clients),
 	)
 }
 
+// gitClient returns the Git client handed to services, decorated if a decorator is set
+func (r *DependencyResolver) gitClient(clients *ResolvedClients) interface{} {
+	if r.decorator != nil {
+		return r.decorator.DecorateGitClient(clients.GitClient)
+	}
+	return clients.GitClient
+}
+
 // parseRepository parses repository string to owner and repo
 func parseRepository(repository string) (string, string) {
 	parts := strings.Split(repository, "/")
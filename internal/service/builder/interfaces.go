@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/git"
 	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/tmux"
 )
 
 // GitWorkspaceManager manages Git workspaces for issues
@@ -31,6 +33,7 @@ type IssueProcessorUpdater interface {
 type WorkflowExecutor interface {
 	ExecutePhase(ctx context.Context, cfg *config.Config, issueNumber int, phase interface{}) error
 	SetIssueProcessor(processor IssueProcessorUpdater)
+	SetGitHubClient(client interface{})
 }
 
 // IssueWatcher watches for issue changes
@@ -40,12 +43,24 @@ type IssueWatcher interface {
 	SetQueueManager(manager interface{})
 	SetLogger(logger interface{})
 	SetWorkflowExecutor(executor WorkflowExecutor)
+	SetTmuxClient(tmuxClient interface{})
 }
 
 // PRWatcher watches for PR changes
 type PRWatcher interface {
 	Start(ctx context.Context) error
 	SetLogger(logger interface{})
+	SetGitClient(gitClient interface{})
+	SetWorkspaceManager(workspace GitWorkspaceManager)
+	SetTmuxClient(tmuxClient interface{})
+}
+
+// ClientDecorator wraps resolved clients before services are created,
+// e.g. to record mutations instead of executing them in a dry run
+type ClientDecorator interface {
+	DecorateGitHubClient(client GitHubClientInterface) GitHubClientInterface
+	DecorateGitClient(client *git.Client) interface{}
+	DecorateTmuxClient(client tmux.TmuxClient) tmux.TmuxClient
 }
 
 // ErrorHandler handles various errors in the system
@@ -62,6 +77,7 @@ type ErrorHandler interface {
 type ClosedIssueCleanupService interface {
 	Start(ctx context.Context) error
 	Configure(owner, repo, sessionName string, enabled bool, interval interface{})
+	SetWorktreeCollector(gitClient interface{}, cfg *config.Config)
 }
 
 // DaemonService provides daemon functionality
@@ -107,10 +123,11 @@ type TmuxWindow struct {
 
 // IssueStatus represents an issue's processing status
 type IssueStatus struct {
-	Number int      `json:"number"`
-	Title  string   `json:"title"`
-	Labels []string `json:"labels"`
-	State  string   `json:"state"`
+	Number      int      `json:"number"`
+	Title       string   `json:"title"`
+	Labels      []string `json:"labels"`
+	State       string   `json:"state"`
+	PullRequest int      `json:"pull_request,omitempty"`
 }
 
 // GitClientInterface defines Git client interface
@@ -132,4 +149,5 @@ type GitHubClientInterface interface {
 	ListPullRequests(ctx context.Context, owner, repo string, opts *github.ListPullRequestsOptions) ([]github.PullRequest, bool, error)
 	GetPullRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, bool, error)
 	MergePullRequest(ctx context.Context, owner, repo string, number int, req *github.MergeRequest) (*github.MergeResponse, error)
+	ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error)
 }
@@ -17,6 +17,7 @@ type ServiceBuilder struct {
 	errorHandler ErrorHandler
 	resolver     *DependencyResolver
 	clients      *Clients
+	decorator    ClientDecorator
 	cliLogLevel  string // CLI log level flag
 	verbose      bool   // CLI verbose flag
 }
@@ -50,6 +51,12 @@ func (b *ServiceBuilder) WithErrorHandler(handler ErrorHandler) *ServiceBuilder
 	return b
 }
 
+// WithClientDecorator sets a decorator applied to the resolved clients
+func (b *ServiceBuilder) WithClientDecorator(decorator ClientDecorator) *ServiceBuilder {
+	b.decorator = decorator
+	return b
+}
+
 // WithCLILogLevel sets CLI log level
 func (b *ServiceBuilder) WithCLILogLevel(level string) *ServiceBuilder {
 	b.cliLogLevel = level
@@ -77,6 +84,7 @@ func (b *ServiceBuilder) Build(ctx context.Context) (DaemonService, error) {
 
 	// Create dependency resolver with new logging
 	resolver := NewDependencyResolver(b.config, b.workDir, b.logFactory, b.errorHandler)
+	resolver.decorator = b.decorator
 
 	// Resolve clients
 	clients, err := resolver.ResolveClients(ctx)
@@ -7,7 +7,9 @@ import (
 
 	"github.com/douhashi/soba/internal/config"
 	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/git"
 	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/tmux"
 	"github.com/douhashi/soba/internal/service/builder"
 	"github.com/douhashi/soba/pkg/logging"
 )
@@ -66,6 +68,12 @@ func (a *WorkflowExecutorAdapter) SetIssueProcessor(processor builder.IssueProce
 	}
 }
 
+func (a *WorkflowExecutorAdapter) SetGitHubClient(client interface{}) {
+	if c, ok := client.(GitHubClientInterface); ok && c != nil {
+		a.WorkflowExecutor.SetGitHubClient(c)
+	}
+}
+
 // IssueWatcherAdapter adapts IssueWatcher to builder interface
 type IssueWatcherAdapter struct {
 	*IssueWatcher
@@ -97,6 +105,12 @@ func (a *IssueWatcherAdapter) SetWorkflowExecutor(executor builder.WorkflowExecu
 	}
 }
 
+func (a *IssueWatcherAdapter) SetTmuxClient(tmuxClient interface{}) {
+	if client, ok := tmuxClient.(tmux.TmuxClient); ok {
+		a.IssueWatcher.SetTmuxClient(client)
+	}
+}
+
 // PRWatcherAdapter adapts PRWatcher to builder interface
 type PRWatcherAdapter struct {
 	*PRWatcher
@@ -112,6 +126,27 @@ func (a *PRWatcherAdapter) SetLogger(log interface{}) {
 	}
 }
 
+func (a *PRWatcherAdapter) SetGitClient(gitClient interface{}) {
+	if gc, ok := gitClient.(*git.Client); ok && gc != nil {
+		a.PRWatcher.SetGitClient(gc)
+	}
+	if gc, ok := gitClient.(*dryRunGitClient); ok {
+		a.PRWatcher.SetGitClient(gc)
+	}
+}
+
+func (a *PRWatcherAdapter) SetWorkspaceManager(workspace builder.GitWorkspaceManager) {
+	if adapter, ok := workspace.(*GitWorkspaceManagerAdapter); ok {
+		a.PRWatcher.SetWorkspaceManager(adapter.GitWorkspaceManager)
+	}
+}
+
+func (a *PRWatcherAdapter) SetTmuxClient(tmuxClient interface{}) {
+	if tc, ok := tmuxClient.(tmux.TmuxClient); ok && tc != nil {
+		a.PRWatcher.SetTmuxClient(tc)
+	}
+}
+
 // ClosedIssueCleanupServiceAdapter adapts ClosedIssueCleanupService to builder interface
 type ClosedIssueCleanupServiceAdapter struct {
 	*ClosedIssueCleanupService
@@ -127,6 +162,26 @@ func (a *ClosedIssueCleanupServiceAdapter) Configure(owner, repo, sessionName st
 	}
 }
 
+func (a *ClosedIssueCleanupServiceAdapter) SetWorktreeCollector(gitClient interface{}, cfg *config.Config) {
+	var gc WorktreeGitClient
+	switch c := gitClient.(type) {
+	case *git.Client:
+		if c == nil {
+			return
+		}
+		gc = c
+	case *dryRunGitClient:
+		gc = c
+	default:
+		return
+	}
+	var issues WorktreeIssueReader
+	if a.githubClient != nil {
+		issues = a.githubClient
+	}
+	a.ClosedIssueCleanupService.SetWorktreeCollector(NewWorktreeCollector(gc, issues, cfg))
+}
+
 // DaemonServiceAdapter adapts daemonService to builder interface
 type DaemonServiceAdapter struct {
 	*daemonService
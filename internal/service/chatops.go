@@ -0,0 +1,483 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/tmux"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// chatOpsPrefix はコメント中のsobaコマンドの接頭辞
+const chatOpsPrefix = "/soba"
+
+// コマンドへの応答に使うリアクション
+const (
+	reactionAccepted = "+1"
+	reactionRejected = "-1"
+	reactionFailed   = "confused"
+)
+
+// chatOpsCommand はコメントから解析したコマンドを表す
+type chatOpsCommand struct {
+	Name string
+	Args []string
+}
+
+// String はコマンドをコメントに書かれた形式で返す
+func (c chatOpsCommand) String() string {
+	return strings.TrimSpace(chatOpsPrefix + " " + strings.Join(append([]string{c.Name}, c.Args...), " "))
+}
+
+// chatOpsTarget はコマンドの対象となるIssueとPRを表す
+type chatOpsTarget struct {
+	issueNumber int
+	prNumber    int
+	labels      []string
+}
+
+// ChatOpsHandler はIssue・PRコメントに書かれた/sobaコマンドを処理する
+type ChatOpsHandler struct {
+	client    GitHubClientInterface
+	tmux      tmux.TmuxClient
+	linker    *IssueLinker
+	logger    logging.Logger
+	startedAt time.Time
+	since     time.Time
+	seen      map[int64]time.Time // 処理済みコメントIDと更新日時
+}
+
+// NewChatOpsHandler は新しいChatOpsHandlerを作成する
+// 作成時点より前に投稿されたコメントは処理しない
+func NewChatOpsHandler(client GitHubClientInterface, cfg *config.Config) *ChatOpsHandler {
+	owner, repo := "", ""
+	if parts := strings.Split(cfg.GitHub.Repository, "/"); len(parts) == 2 {
+		owner, repo = parts[0], parts[1]
+	}
+	log := logging.NewMockLogger()
+	linker := NewIssueLinker(client, owner, repo)
+	linker.SetLogger(log)
+
+	now := time.Now()
+	return &ChatOpsHandler{
+		client:    client,
+		linker:    linker,
+		logger:    log,
+		startedAt: now,
+		since:     now,
+		seen:      make(map[int64]time.Time),
+	}
+}
+
+// SetLogger はロガーを設定する
+func (h *ChatOpsHandler) SetLogger(log logging.Logger) {
+	h.logger = log
+	h.linker.SetLogger(log)
+}
+
+// SetTmuxClient は実行中のフェーズを止めるためのtmuxクライアントを設定する
+func (h *ChatOpsHandler) SetTmuxClient(tmuxClient tmux.TmuxClient) {
+	h.tmux = tmuxClient
+}
+
+// ProcessComments は前回以降に投稿されたコメントのコマンドを実行する
+func (h *ChatOpsHandler) ProcessComments(ctx context.Context, cfg *config.Config) error {
+	if !cfg.Workflow.ChatOps.Enabled {
+		return nil
+	}
+	parts := strings.Split(cfg.GitHub.Repository, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid repository configuration: %s", cfg.GitHub.Repository)
+	}
+	owner, repo := parts[0], parts[1]
+
+	since := h.since
+	comments, err := h.client.ListRepositoryComments(ctx, owner, repo, &github.ListCommentsOptions{
+		Sort:      "updated",
+		Direction: "asc",
+		Since:     &since,
+		PerPage:   100,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	for _, comment := range comments {
+		if comment.UpdatedAt.After(h.since) {
+			h.since = comment.UpdatedAt
+		}
+		if _, ok := h.seen[comment.ID]; ok || comment.CreatedAt.Before(h.startedAt) {
+			continue
+		}
+		h.seen[comment.ID] = comment.UpdatedAt
+
+		commands := parseChatOpsCommands(comment.Body)
+		if len(commands) == 0 {
+			continue
+		}
+		h.handleComment(ctx, cfg, owner, repo, comment, commands)
+	}
+
+	// sinceより古い処理済みコメントは再取得されないため忘れる
+	for id, updatedAt := range h.seen {
+		if updatedAt.Before(h.since.Add(-time.Hour)) {
+			delete(h.seen, id)
+		}
+	}
+	return nil
+}
+
+// handleComment は1つのコメントに含まれるコマンドを順に実行し、結果をリアクションで返す
+func (h *ChatOpsHandler) handleComment(ctx context.Context, cfg *config.Config, owner, repo string, comment github.IssueComment, commands []chatOpsCommand) {
+	number := comment.IssueNumber()
+	if number == 0 {
+		return
+	}
+
+	if !isChatOpsAuthorized(cfg.Workflow.ChatOps, comment) {
+		h.logger.Warn(ctx, "Ignoring soba command from unauthorized user",
+			logging.Field{Key: "issue", Value: number},
+			logging.Field{Key: "user", Value: comment.User.Login},
+			logging.Field{Key: "association", Value: comment.AuthorAssociation},
+		)
+		h.react(ctx, owner, repo, comment.ID, reactionRejected)
+		return
+	}
+
+	target, err := h.resolveTarget(ctx, owner, repo, number)
+	var failures []string
+	if err != nil {
+		failures = append(failures, err.Error())
+	} else {
+		for _, command := range commands {
+			h.logger.Info(ctx, "Executing soba command",
+				logging.Field{Key: "issue", Value: target.issueNumber},
+				logging.Field{Key: "command", Value: command.String()},
+				logging.Field{Key: "user", Value: comment.User.Login},
+			)
+			if err := h.execute(ctx, cfg, owner, repo, target, command); err != nil {
+				h.logger.Warn(ctx, "Soba command failed",
+					logging.Field{Key: "issue", Value: target.issueNumber},
+					logging.Field{Key: "command", Value: command.String()},
+					logging.Field{Key: "error", Value: err.Error()},
+				)
+				failures = append(failures, fmt.Sprintf("`%s`: %s", command, err.Error()))
+			}
+		}
+	}
+
+	if len(failures) == 0 {
+		h.react(ctx, owner, repo, comment.ID, reactionAccepted)
+		return
+	}
+
+	h.react(ctx, owner, repo, comment.ID, reactionFailed)
+	body := fmt.Sprintf("@%s soba could not run the command:\n\n- %s\n\n%s",
+		comment.User.Login, strings.Join(failures, "\n- "), chatOpsUsage)
+	if err := h.client.CreateComment(ctx, owner, repo, number, body); err != nil {
+		h.logger.Warn(ctx, "Failed to reply to soba command",
+			logging.Field{Key: "issue", Value: number},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+}
+
+// CancelIssue は/soba cancelと同じく、実行中のフェーズを止めてIssueからsobaのラベルを外す
+func (h *ChatOpsHandler) CancelIssue(ctx context.Context, cfg *config.Config, number int) error {
+	parts := strings.Split(cfg.GitHub.Repository, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid repository configuration: %s", cfg.GitHub.Repository)
+	}
+	owner, repo := parts[0], parts[1]
+
+	target, err := h.resolveTarget(ctx, owner, repo, number)
+	if err != nil {
+		return err
+	}
+	h.logger.Info(ctx, "Cancelling issue", logging.Field{Key: "issue", Value: target.issueNumber})
+	return h.execute(ctx, cfg, owner, repo, target, chatOpsCommand{Name: "cancel"})
+}
+
+// resolveTarget はコメントされたIssue/PRから操作対象のIssueを求める
+func (h *ChatOpsHandler) resolveTarget(ctx context.Context, owner, repo string, number int) (*chatOpsTarget, error) {
+	issue, err := h.client.GetIssue(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get #%d", number)
+	}
+
+	target := &chatOpsTarget{issueNumber: number}
+	if issue.IsPullRequest() {
+		pr, _, err := h.client.GetPullRequest(ctx, owner, repo, number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pull request #%d", number)
+		}
+		target.prNumber = number
+		target.issueNumber = h.linker.IssueForPullRequest(ctx, *pr)
+		if target.issueNumber == 0 {
+			return nil, fmt.Errorf("pull request #%d is not linked to a soba issue", number)
+		}
+		if issue, err = h.client.GetIssue(ctx, owner, repo, target.issueNumber); err != nil {
+			return nil, fmt.Errorf("failed to get #%d", target.issueNumber)
+		}
+	}
+
+	for _, label := range issue.Labels {
+		target.labels = append(target.labels, label.Name)
+	}
+	return target, nil
+}
+
+// execute はコマンドに対応するラベル変更とエグゼキューター操作を行う
+func (h *ChatOpsHandler) execute(ctx context.Context, cfg *config.Config, owner, repo string, target *chatOpsTarget, command chatOpsCommand) error {
+	state := workflowStateLabel(target.labels)
+
+	switch command.Name {
+	case "retry":
+		phase := domain.GetPhaseByExecutionLabel(state)
+		if phase == nil {
+			if state == "" {
+				return fmt.Errorf("issue #%d is not managed by soba", target.issueNumber)
+			}
+			return fmt.Errorf("nothing to retry, issue #%d is waiting in %s", target.issueNumber, state)
+		}
+		h.stopPhase(ctx, cfg, target.issueNumber)
+		return h.replaceState(ctx, owner, repo, target, state, phase.TriggerLabel)
+
+	case "skip-plan":
+		switch state {
+		case "", domain.LabelTodo, domain.LabelQueued, domain.LabelPlanning:
+		default:
+			return fmt.Errorf("planning already finished for issue #%d", target.issueNumber)
+		}
+		if state == domain.LabelPlanning {
+			h.stopPhase(ctx, cfg, target.issueNumber)
+		}
+		return h.replaceState(ctx, owner, repo, target, state, domain.LabelReady)
+
+	case "pause":
+		return h.addLabel(ctx, owner, repo, target, domain.LabelPaused)
+
+	case "resume":
+		return h.removeLabel(ctx, owner, repo, target, domain.LabelPaused)
+
+	case "cancel":
+		h.stopPhase(ctx, cfg, target.issueNumber)
+		labels := append([]string(nil), target.labels...)
+		// プロファイル・優先度・一時停止などの制御ラベルは残す
+		for _, label := range labels {
+			if strings.HasPrefix(label, "soba:") && !domain.IsControlLabel(label) {
+				if err := h.removeLabel(ctx, owner, repo, target, label); err != nil {
+					return err
+				}
+			}
+		}
+		return h.withdrawApproval(ctx, owner, repo, target)
+
+	case "rerun":
+		if len(command.Args) != 1 {
+			return fmt.Errorf("specify the phase to rerun")
+		}
+		phase, ok := domain.PhaseDefinitions[command.Args[0]]
+		if !ok || phase.ExecutionType != domain.ExecutionTypeCommand {
+			return fmt.Errorf("unknown phase %q", command.Args[0])
+		}
+		if domain.GetPhaseByExecutionLabel(state) != nil {
+			h.stopPhase(ctx, cfg, target.issueNumber)
+		}
+		if err := h.withdrawApproval(ctx, owner, repo, target); err != nil {
+			return err
+		}
+		return h.replaceState(ctx, owner, repo, target, state, phase.TriggerLabel)
+
+	case "priority":
+		if len(command.Args) != 1 {
+			return fmt.Errorf("specify high or normal")
+		}
+		switch command.Args[0] {
+		case "high":
+			return h.addLabel(ctx, owner, repo, target, domain.LabelPriorityHigh)
+		case "normal", "low":
+			return h.removeLabel(ctx, owner, repo, target, domain.LabelPriorityHigh)
+		}
+		return fmt.Errorf("unknown priority %q", command.Args[0])
+	}
+
+	return fmt.Errorf("unknown command %q", command.Name)
+}
+
+// replaceState は状態ラベルを置き換える
+func (h *ChatOpsHandler) replaceState(ctx context.Context, owner, repo string, target *chatOpsTarget, from, to string) error {
+	if from == to {
+		return nil
+	}
+	if from != "" {
+		if err := h.removeLabel(ctx, owner, repo, target, from); err != nil {
+			return err
+		}
+	}
+	return h.addLabel(ctx, owner, repo, target, to)
+}
+
+func (h *ChatOpsHandler) addLabel(ctx context.Context, owner, repo string, target *chatOpsTarget, label string) error {
+	for _, existing := range target.labels {
+		if existing == label {
+			return nil
+		}
+	}
+	if err := h.client.AddLabelToIssue(ctx, owner, repo, target.issueNumber, label); err != nil {
+		return fmt.Errorf("failed to add %s", label)
+	}
+	target.labels = append(target.labels, label)
+	return nil
+}
+
+func (h *ChatOpsHandler) removeLabel(ctx context.Context, owner, repo string, target *chatOpsTarget, label string) error {
+	index := -1
+	for i, existing := range target.labels {
+		if existing == label {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+	if err := h.client.RemoveLabelFromIssue(ctx, owner, repo, target.issueNumber, label); err != nil {
+		return fmt.Errorf("failed to remove %s", label)
+	}
+	target.labels = append(target.labels[:index], target.labels[index+1:]...)
+	return nil
+}
+
+// withdrawApproval はIssueに紐づくPRのsoba:lgtmを外し、自動マージを止める
+func (h *ChatOpsHandler) withdrawApproval(ctx context.Context, owner, repo string, target *chatOpsTarget) error {
+	prNumber := target.prNumber
+	if prNumber == 0 {
+		prs, _, err := h.client.ListPullRequests(ctx, owner, repo, &github.ListPullRequestsOptions{
+			State:   "open",
+			Page:    1,
+			PerPage: 100,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list pull requests")
+		}
+		prNumber = h.linker.PullRequestForIssue(ctx, target.issueNumber, prs)
+	}
+	if prNumber == 0 {
+		return nil
+	}
+
+	// 付いていないラベルの削除は404になるため、エラーは無視する
+	if err := h.client.RemoveLabelFromIssue(ctx, owner, repo, prNumber, domain.LabelLGTM); err != nil {
+		h.logger.Debug(ctx, "Failed to remove lgtm label",
+			logging.Field{Key: "pr", Value: prNumber},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+	return nil
+}
+
+// stopPhase は実行中のフェーズのtmuxウィンドウを削除する
+func (h *ChatOpsHandler) stopPhase(ctx context.Context, cfg *config.Config, issueNumber int) {
+	if h.tmux == nil {
+		return
+	}
+
+	sessionName := tmuxSessionName(cfg.GitHub.Repository)
+	windowName := fmt.Sprintf("issue-%d", issueNumber)
+	exists, err := h.tmux.WindowExists(sessionName, windowName)
+	if err != nil || !exists {
+		return
+	}
+	if err := h.tmux.DeleteWindow(sessionName, windowName); err != nil {
+		h.logger.Warn(ctx, "Failed to stop running phase",
+			logging.Field{Key: "session", Value: sessionName},
+			logging.Field{Key: "window", Value: windowName},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+}
+
+func (h *ChatOpsHandler) react(ctx context.Context, owner, repo string, commentID int64, content string) {
+	if err := h.client.CreateCommentReaction(ctx, owner, repo, commentID, content); err != nil {
+		h.logger.Warn(ctx, "Failed to react to soba command",
+			logging.Field{Key: "comment", Value: commentID},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+}
+
+// chatOpsUsage はコマンドが失敗した場合の返信に添える使い方
+const chatOpsUsage = "Available commands: `/soba retry`, `/soba skip-plan`, `/soba pause`, `/soba resume`, " +
+	"`/soba cancel`, `/soba rerun <plan|implement|review|revise>`, `/soba priority <high|normal>`"
+
+// parseChatOpsCommands はコメント本文の行頭に書かれた/sobaコマンドを取り出す
+// コードブロックと引用の中は無視する
+func parseChatOpsCommands(body string) []chatOpsCommand {
+	var commands []chatOpsCommand
+	inCodeBlock := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != chatOpsPrefix {
+			continue
+		}
+		commands = append(commands, chatOpsCommand{
+			Name: strings.ToLower(fields[1]),
+			Args: lowerAll(fields[2:]),
+		})
+	}
+	return commands
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, value := range values {
+		lowered = append(lowered, strings.ToLower(value))
+	}
+	return lowered
+}
+
+// isChatOpsAuthorized はコメント投稿者がコマンドを実行できるかを返す
+// allowed_usersが空の場合はリポジトリのオーナー・メンバー・コラボレーターを許可する
+func isChatOpsAuthorized(opts config.ChatOpsConfig, comment github.IssueComment) bool {
+	if comment.User.Type == "Bot" {
+		return false
+	}
+	if len(opts.AllowedUsers) > 0 {
+		for _, user := range opts.AllowedUsers {
+			if strings.EqualFold(user, comment.User.Login) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch comment.AuthorAssociation {
+	case "OWNER", "MEMBER", "COLLABORATOR":
+		return true
+	}
+	return false
+}
+
+// workflowStateLabel はラベルのうちワークフローの状態を表すsobaラベルを返す
+func workflowStateLabel(labels []string) string {
+	for _, label := range labels {
+		if strings.HasPrefix(label, "soba:") && !domain.IsControlLabel(label) {
+			return label
+		}
+	}
+	return ""
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+func TestParseChatOpsCommands(t *testing.T) {
+	t.Run("行頭の/sobaコマンドを取り出す", func(t *testing.T) {
+		commands := parseChatOpsCommands("Looks stuck.\n/soba retry\n  /soba Priority HIGH\n")
+		require.Len(t, commands, 2)
+		assert.Equal(t, "retry", commands[0].Name)
+		assert.Empty(t, commands[0].Args)
+		assert.Equal(t, "priority", commands[1].Name)
+		assert.Equal(t, []string{"high"}, commands[1].Args)
+	})
+
+	t.Run("コードブロック内と文中のコマンドは無視する", func(t *testing.T) {
+		commands := parseChatOpsCommands("Run `/soba retry` if needed\n```\n/soba cancel\n```\n/sobaretry\n/soba")
+		assert.Empty(t, commands)
+	})
+}
+
+func TestIsChatOpsAuthorized(t *testing.T) {
+	member := github.IssueComment{User: github.User{Login: "alice"}, AuthorAssociation: "MEMBER"}
+	outsider := github.IssueComment{User: github.User{Login: "mallory"}, AuthorAssociation: "NONE"}
+	bot := github.IssueComment{User: github.User{Login: "ci[bot]", Type: "Bot"}, AuthorAssociation: "MEMBER"}
+
+	t.Run("allowed_usersが空の場合はリポジトリのメンバーを許可する", func(t *testing.T) {
+		opts := config.ChatOpsConfig{Enabled: true}
+		assert.True(t, isChatOpsAuthorized(opts, member))
+		assert.False(t, isChatOpsAuthorized(opts, outsider))
+		assert.False(t, isChatOpsAuthorized(opts, bot))
+	})
+
+	t.Run("allowed_usersが指定された場合はそのユーザーのみ許可する", func(t *testing.T) {
+		opts := config.ChatOpsConfig{Enabled: true, AllowedUsers: []string{"Mallory"}}
+		assert.False(t, isChatOpsAuthorized(opts, member))
+		assert.True(t, isChatOpsAuthorized(opts, outsider))
+	})
+}
+
+func TestChatOpsHandler_ProcessComments(t *testing.T) {
+	cfg := &config.Config{
+		GitHub: config.GitHubConfig{Repository: "owner/repo"},
+		Workflow: config.WorkflowConfig{
+			ChatOps: config.ChatOpsConfig{Enabled: true},
+		},
+	}
+	newComment := func(id int64, number int, body string) github.IssueComment {
+		now := time.Now().Add(time.Minute)
+		return github.IssueComment{
+			ID:                id,
+			Body:              body,
+			User:              github.User{Login: "alice"},
+			AuthorAssociation: "OWNER",
+			IssueURL:          fmt.Sprintf("https://api.github.com/repos/owner/repo/issues/%d", number),
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+	}
+
+	t.Run("retryは実行中のフェーズを止めてトリガーラベルに戻す", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			issueLabels:  map[int][]string{7: {domain.LabelDoing}},
+			repoComments: []github.IssueComment{newComment(1, 7, "/soba retry")},
+		}
+		tmuxClient := new(StatusMockTmuxClient)
+		tmuxClient.On("WindowExists", "soba-owner-repo", "issue-7").Return(true, nil)
+		tmuxClient.On("DeleteWindow", "soba-owner-repo", "issue-7").Return(nil)
+
+		handler := NewChatOpsHandler(mockClient, cfg)
+		handler.SetTmuxClient(tmuxClient)
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+
+		tmuxClient.AssertExpectations(t)
+		assert.Equal(t, []string{domain.LabelDoing}, mockClient.removedLabels[7])
+		assert.Equal(t, []string{domain.LabelReady}, mockClient.addedLabels[7])
+		assert.Equal(t, []string{"+1"}, mockClient.reactions[1])
+		assert.Empty(t, mockClient.comments)
+
+		// 同じコメントは二度処理しない
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+		assert.Equal(t, []string{"+1"}, mockClient.reactions[1])
+	})
+
+	t.Run("PRのコメントはリンクされたIssueに適用する", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			prs: []github.PullRequest{
+				{Number: 20, Head: github.PullRequestBranch{Ref: "soba/7"}, Labels: []github.Label{{Name: domain.LabelLGTM}}},
+			},
+			issueLabels:  map[int][]string{7: {domain.LabelDone}},
+			repoComments: []github.IssueComment{newComment(2, 20, "/soba rerun review")},
+		}
+
+		handler := NewChatOpsHandler(mockClient, cfg)
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+
+		assert.Equal(t, []string{domain.LabelLGTM}, mockClient.removedLabels[20])
+		assert.Equal(t, []string{domain.LabelDone}, mockClient.removedLabels[7])
+		assert.Equal(t, []string{domain.LabelReviewRequested}, mockClient.addedLabels[7])
+		assert.Equal(t, []string{"+1"}, mockClient.reactions[2])
+	})
+
+	t.Run("pause・priority・skip-planはラベルを付け替える", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			issueLabels:  map[int][]string{3: {domain.LabelTodo}},
+			repoComments: []github.IssueComment{newComment(3, 3, "/soba pause\n/soba priority high\n/soba skip-plan")},
+		}
+
+		handler := NewChatOpsHandler(mockClient, cfg)
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+
+		assert.Equal(t, []string{domain.LabelPaused, domain.LabelPriorityHigh, domain.LabelReady}, mockClient.addedLabels[3])
+		assert.Equal(t, []string{domain.LabelTodo}, mockClient.removedLabels[3])
+	})
+
+	t.Run("cancelはワークフローの状態ラベルを外し制御ラベルは残す", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			issueLabels:  map[int][]string{4: {"bug", domain.LabelReviewing, domain.LabelPaused, domain.LabelPriorityHigh, domain.LabelProfilePrefix + "fast"}},
+			repoComments: []github.IssueComment{newComment(4, 4, "/soba cancel")},
+		}
+
+		handler := NewChatOpsHandler(mockClient, cfg)
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+
+		assert.Equal(t, []string{domain.LabelReviewing}, mockClient.removedLabels[4])
+		assert.Empty(t, mockClient.addedLabels[4])
+	})
+
+	t.Run("失敗したコマンドには理由を返信する", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			issueLabels:  map[int][]string{5: {domain.LabelReady}},
+			repoComments: []github.IssueComment{newComment(5, 5, "/soba retry\n/soba dance")},
+		}
+
+		handler := NewChatOpsHandler(mockClient, cfg)
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+
+		assert.Equal(t, []string{"confused"}, mockClient.reactions[5])
+		require.Len(t, mockClient.comments, 1)
+		assert.Equal(t, 5, mockClient.comments[0].number)
+		assert.Contains(t, mockClient.comments[0].body, "nothing to retry")
+		assert.Contains(t, mockClient.comments[0].body, `unknown command "dance"`)
+	})
+
+	t.Run("権限のないユーザーのコマンドは実行しない", func(t *testing.T) {
+		comment := newComment(6, 6, "/soba cancel")
+		comment.AuthorAssociation = "NONE"
+		mockClient := &MockGitHubClientForPR{
+			issueLabels:  map[int][]string{6: {domain.LabelDoing}},
+			repoComments: []github.IssueComment{comment},
+		}
+
+		handler := NewChatOpsHandler(mockClient, cfg)
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+
+		assert.Empty(t, mockClient.removedLabels[6])
+		assert.Equal(t, []string{"-1"}, mockClient.reactions[6])
+	})
+
+	t.Run("起動前のコメントと無効設定は処理しない", func(t *testing.T) {
+		old := newComment(7, 7, "/soba pause")
+		old.CreatedAt = time.Now().Add(-time.Hour)
+		mockClient := &MockGitHubClientForPR{
+			issueLabels:  map[int][]string{7: {domain.LabelDoing}},
+			repoComments: []github.IssueComment{old, newComment(8, 7, "/soba pause")},
+		}
+
+		handler := NewChatOpsHandler(mockClient, cfg)
+		disabled := *cfg
+		disabled.Workflow.ChatOps.Enabled = false
+		require.NoError(t, handler.ProcessComments(context.Background(), &disabled))
+		assert.Empty(t, mockClient.reactions)
+
+		require.NoError(t, handler.ProcessComments(context.Background(), cfg))
+		assert.Empty(t, mockClient.reactions[7])
+		assert.Equal(t, []string{"+1"}, mockClient.reactions[8])
+	})
+}
@@ -11,16 +11,19 @@ import (
 	"github.com/douhashi/soba/pkg/logging"
 )
 
-// ClosedIssueCleanupService は閉じたIssueに対応するtmuxウィンドウを削除するサービス
+// ClosedIssueCleanupService は閉じたIssueに対応するtmuxウィンドウとworktreeを削除するサービス
 type ClosedIssueCleanupService struct {
 	githubClient *github.ClientImpl
 	tmuxClient   tmux.TmuxClient
+	worktrees    *WorktreeCollector
 	owner        string
 	repo         string
 	sessionName  string
 	enabled      bool
 	interval     time.Duration
 	log          logging.Logger
+	control      *DaemonControl // 制御APIから参照される状態
+	history      *EventHistory  // Issueごとのイベント履歴
 }
 
 // NewClosedIssueCleanupService は新しいClosedIssueCleanupServiceを作成する
@@ -53,9 +56,30 @@ func NewClosedIssueCleanupService(
 func (s *ClosedIssueCleanupService) SetLogger(logger logging.Logger) {
 	if logger != nil {
 		s.log = logger
+		if s.worktrees != nil {
+			s.worktrees.SetLogger(logger)
+		}
+	}
+}
+
+// SetWorktreeCollector はworktreeの整理に使うWorktreeCollectorを設定する
+func (s *ClosedIssueCleanupService) SetWorktreeCollector(collector *WorktreeCollector) {
+	s.worktrees = collector
+	if collector != nil && s.log != nil {
+		collector.SetLogger(s.log)
 	}
 }
 
+// SetControl は制御APIから参照される状態を設定する
+func (s *ClosedIssueCleanupService) SetControl(control *DaemonControl) {
+	s.control = control
+}
+
+// SetHistory はイベント履歴の記録先を設定する
+func (s *ClosedIssueCleanupService) SetHistory(history *EventHistory) {
+	s.history = history
+}
+
 // Configure は設定を更新する
 func (s *ClosedIssueCleanupService) Configure(owner, repo, sessionName string, enabled bool, interval time.Duration) {
 	s.owner = owner
@@ -85,7 +109,7 @@ func (s *ClosedIssueCleanupService) Start(ctx context.Context) error {
 	defer ticker.Stop()
 
 	// 最初の実行
-	if err := s.cleanupOnce(ctx); err != nil {
+	if err := timedCycle(s.control, "closed_issue_cleanup", func() error { return s.cleanupOnce(ctx) }); err != nil {
 		if s.log != nil {
 			s.log.Error(ctx, "Failed to cleanup closed issues", logging.Field{Key: "error", Value: err})
 		}
@@ -99,7 +123,7 @@ func (s *ClosedIssueCleanupService) Start(ctx context.Context) error {
 			}
 			return ctx.Err()
 		case <-ticker.C:
-			if err := s.cleanupOnce(ctx); err != nil {
+			if err := timedCycle(s.control, "closed_issue_cleanup", func() error { return s.cleanupOnce(ctx) }); err != nil {
 				if s.log != nil {
 					s.log.Error(ctx, "Failed to cleanup closed issues", logging.Field{Key: "error", Value: err})
 				}
@@ -115,6 +139,9 @@ func (s *ClosedIssueCleanupService) cleanupOnce(ctx context.Context) error {
 		s.log.Info(ctx, "Starting cleanup of closed issues")
 	}
 
+	// worktreeの整理はtmuxの有無に関わらず実行する
+	defer s.collectWorktrees(ctx)
+
 	// githubClientがnilの場合はスキップ
 	if s.githubClient == nil {
 		s.logCleanupCompleted(ctx, 0)
@@ -213,9 +240,39 @@ func (s *ClosedIssueCleanupService) deleteWindowForIssue(ctx context.Context, is
 			logging.Field{Key: "window", Value: windowName},
 			logging.Field{Key: "issue", Value: issue.Number})
 	}
+	s.history.Record(ctx, HistoryEvent{Issue: issue.Number, Type: HistoryCleanedUp, Detail: "killed tmux window of closed issue"})
 	return true
 }
 
+// collectWorktrees はクローズ済みIssueのworktreeや不要なブランチを削除する
+func (s *ClosedIssueCleanupService) collectWorktrees(ctx context.Context) {
+	if s.worktrees == nil {
+		return
+	}
+
+	result, err := s.worktrees.Prune(ctx, false)
+	if err != nil {
+		if s.log != nil {
+			s.log.Error(ctx, "Failed to collect worktrees", logging.Field{Key: "error", Value: err})
+		}
+		return
+	}
+
+	for _, removed := range result.Removed {
+		s.history.Record(ctx, HistoryEvent{
+			Issue:  removed.IssueNumber,
+			Type:   HistoryCleanedUp,
+			Detail: fmt.Sprintf("removed worktree %s (%s)", removed.Path, removed.Reason),
+		})
+	}
+
+	if s.log != nil && (len(result.Removed) > 0 || len(result.DeletedBranches) > 0) {
+		s.log.Info(ctx, "Collected worktrees",
+			logging.Field{Key: "removed_count", Value: len(result.Removed)},
+			logging.Field{Key: "deleted_branches", Value: result.DeletedBranches})
+	}
+}
+
 // logCleanupCompleted はクリーンアップ完了のログを出力する
 func (s *ClosedIssueCleanupService) logCleanupCompleted(ctx context.Context, deletedCount int) {
 	if s.log != nil {
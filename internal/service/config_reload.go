@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/slack"
+	"github.com/douhashi/soba/pkg/app"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// configReloadDelay は設定ファイルの変更を検知してから読み込むまでの待ち時間
+// エディタは保存時に複数のイベントを発生させるため、落ち着いてから読み込む
+const configReloadDelay = 500 * time.Millisecond
+
+// restartRequiredFields は再起動しないと反映できない設定。配下の項目も含む
+var restartRequiredFields = []string{
+	"github.repository",
+	"github.token",
+	"github.auth_method",
+	"git.worktree_base_path",
+	"workflow.use_tmux",
+	"workflow.closed_issue_cleanup_enabled",
+	"workflow.closed_issue_cleanup_interval",
+	"log.output_path",
+	"log.format",
+	"log.retention_count",
+	"metrics",
+}
+
+// restartRequired は変更のうち再起動が必要な項目を返す
+func restartRequired(changes []config.Change) []string {
+	var fields []string
+	for _, change := range changes {
+		for _, field := range restartRequiredFields {
+			if change.Field == field || strings.HasPrefix(change.Field, field+".") {
+				fields = append(fields, change.Field)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// reloadConfigFrom は設定ファイルを読み、IssueWatcherとPRWatcherにそれぞれのgoroutineで反映する
+// 再起動が必要な項目が変わった場合は何も反映せずにエラーを返す
+// グローバル設定、プロファイル、環境変数のレイヤーは起動時と同じものを重ねる
+func (d *daemonService) reloadConfigFrom(ctx context.Context, path string) error {
+	layers := app.ConfigLayers()
+	layers.Project = path
+	cfg, origins, err := config.LoadLayers(layers)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// 制御API、SIGHUP、ファイルの変更による再読み込みを順に処理する
+	d.reloadMu.Lock()
+	defer d.reloadMu.Unlock()
+
+	var changes []config.Change
+	if current := d.currentConfig(); current != nil {
+		changes = config.Diff(current, cfg)
+		if len(changes) == 0 {
+			d.logger.Debug(ctx, "Config unchanged", logging.Field{Key: "path", Value: path})
+			return nil
+		}
+		if fields := restartRequired(changes); len(fields) > 0 {
+			return fmt.Errorf("changes to %s require a restart; run `soba stop` and `soba start` to apply them", strings.Join(fields, ", "))
+		}
+	}
+
+	if d.watcher != nil {
+		if err := d.watcher.Do(ctx, func(loopCtx context.Context) { d.configureIssueWatcher(loopCtx, cfg) }); err != nil {
+			return err
+		}
+	}
+	if d.prWatcher != nil {
+		if err := d.prWatcher.Do(ctx, func(context.Context) { d.configurePRWatcher(cfg) }); err != nil {
+			return err
+		}
+	}
+	d.setConfig(cfg)
+	d.applyGlobalConfig(cfg, origins, changes)
+
+	for _, change := range changes {
+		d.logger.Info(ctx, "Config changed",
+			logging.Field{Key: "field", Value: change.Field},
+			logging.Field{Key: "old", Value: change.Old},
+			logging.Field{Key: "new", Value: change.New},
+		)
+	}
+	d.logger.Info(ctx, "Config reloaded",
+		logging.Field{Key: "path", Value: path},
+		logging.Field{Key: "changes", Value: len(changes)},
+	)
+	return nil
+}
+
+// applyGlobalConfig はログレベルとSlack通知に再読み込みした設定を反映する
+func (d *daemonService) applyGlobalConfig(cfg *config.Config, origins config.Origins, changes []config.Change) {
+	if !app.IsInitialized() {
+		return
+	}
+	app.ApplyConfig(cfg, origins)
+
+	// ドライランではSlack通知を無効にしたままにする
+	if d.dryRun != nil {
+		return
+	}
+	for _, change := range changes {
+		if strings.HasPrefix(change.Field, "slack.") {
+			slack.Reload(cfg, app.LogFactory().CreateComponentLogger("slack"))
+			return
+		}
+	}
+}
+
+// watchConfig は設定ファイルの変更とSIGHUPを受けて設定を再読み込みする。ctxがキャンセルされると停止する
+// グローバル設定とプロファイルのファイルも監視する
+func (d *daemonService) watchConfig(ctx context.Context, path string) {
+	layers := app.ConfigLayers()
+	layers.Project = path
+	files := make(map[string]bool)
+	for _, file := range layers.Files() {
+		files[filepath.Clean(file)] = true
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	// エディタはファイルを置き換えて保存することがあるため、ディレクトリを監視する
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		err = watcher.Add(filepath.Dir(path))
+		for file := range files {
+			// プロジェクト以外のディレクトリは存在しないことがあるため、失敗しても続行する
+			if dir := filepath.Dir(file); dir != filepath.Dir(path) {
+				_ = watcher.Add(dir)
+			}
+		}
+	}
+	if err != nil {
+		d.logger.Warn(ctx, "Failed to watch the config file, reload it with SIGHUP or soba ctl reload",
+			logging.Field{Key: "path", Value: path},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	} else {
+		events = watcher.Events
+		watchErrors = watcher.Errors
+	}
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			d.reloadConfigOn(ctx, path, "SIGHUP")
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if files[filepath.Clean(event.Name)] && !event.Has(fsnotify.Chmod) {
+				pending = time.After(configReloadDelay)
+			}
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+			d.logger.Warn(ctx, "Config file watch error", logging.Field{Key: "error", Value: err.Error()})
+		case <-pending:
+			pending = nil
+			// 削除された場合は既定値を読み込まず、書き直されるのを待つ
+			if _, err := os.Stat(path); err != nil {
+				d.logger.Warn(ctx, "Config file is missing, keeping the current config", logging.Field{Key: "path", Value: path})
+				continue
+			}
+			d.reloadConfigOn(ctx, path, "file change")
+		}
+	}
+}
+
+// reloadConfigOn は再読み込みを実行し、失敗した場合は現在の設定のまま続行する
+func (d *daemonService) reloadConfigOn(ctx context.Context, path, trigger string) {
+	ctx, cancel := context.WithTimeout(ctx, controlRequestTimeout)
+	defer cancel()
+	if err := d.reloadConfigFrom(ctx, path); err != nil {
+		d.logger.Error(ctx, "Config reload rejected, keeping the current config",
+			logging.Field{Key: "trigger", Value: trigger},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+}
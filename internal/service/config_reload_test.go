@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// newReloadTestDaemon は設定ファイルを読み込み済みのデーモンと、要求を処理するPRWatcherのループを用意する
+func newReloadTestDaemon(t *testing.T, content string) (*daemonService, *PRWatcher, *logging.MockLogger, string) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0600))
+	cfg, err := config.Load(configPath)
+	require.NoError(t, err)
+
+	logger := logging.NewMockLogger()
+	watcher := NewPRWatcher(nil, cfg)
+	watcher.SetLogger(logger)
+	d := &daemonService{logger: logger, control: NewDaemonControl(), prWatcher: watcher}
+	d.setConfig(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case request := <-watcher.requests:
+				request(ctx)
+			}
+		}
+	}()
+	return d, watcher, logger, configPath
+}
+
+const reloadTestConfig = "github:\n  repository: owner/repo\nworkflow:\n  interval: 20\n"
+
+func TestDaemonService_ReloadConfig(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("安全な変更を反映して差分をログに出す", func(t *testing.T) {
+		d, watcher, logger, configPath := newReloadTestDaemon(t, reloadTestConfig)
+		require.NoError(t, os.WriteFile(configPath, []byte(reloadTestConfig+"  auto_merge_enabled: true\nphase:\n  plan:\n    command: codex\n"), 0600))
+
+		require.NoError(t, d.reloadConfigFrom(ctx, configPath))
+
+		assert.True(t, watcher.config.Workflow.AutoMergeEnabled)
+		assert.Equal(t, "codex", d.currentConfig().Phase.Plan.Command)
+		var changed []string
+		for _, message := range logger.Messages {
+			if message.Message == "Config changed" {
+				changed = append(changed, message.Fields["field"].(string))
+			}
+		}
+		assert.Equal(t, []string{"phase.plan.command", "workflow.auto_merge_enabled"}, changed)
+	})
+
+	t.Run("再起動が必要な変更は反映しない", func(t *testing.T) {
+		d, watcher, _, configPath := newReloadTestDaemon(t, reloadTestConfig)
+		before := d.currentConfig()
+		require.NoError(t, os.WriteFile(configPath, []byte("github:\n  repository: other/repo\nworkflow:\n  interval: 5\ngit:\n  worktree_base_path: /tmp/worktrees\n"), 0600))
+
+		err := d.reloadConfigFrom(ctx, configPath)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "changes to git.worktree_base_path, github.repository require a restart")
+		assert.Same(t, before, d.currentConfig())
+		assert.Equal(t, 20*time.Second, watcher.interval)
+	})
+
+	t.Run("不正な設定は反映しない", func(t *testing.T) {
+		d, _, _, configPath := newReloadTestDaemon(t, reloadTestConfig)
+		before := d.currentConfig()
+		require.NoError(t, os.WriteFile(configPath, []byte(reloadTestConfig+"  intervall: 5\n"), 0600))
+
+		err := d.reloadConfigFrom(ctx, configPath)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "workflow.intervall: unknown field")
+		assert.Same(t, before, d.currentConfig())
+	})
+}
+
+func TestDaemonService_WatchConfig(t *testing.T) {
+	d, watcher, logger, configPath := newReloadTestDaemon(t, reloadTestConfig)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.watchConfig(ctx, configPath)
+	// 監視を始める前の書き込みを避ける
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(configPath, []byte("github:\n  repository: owner/repo\nworkflow:\n  interval: 7\n"), 0600))
+
+	assert.Eventually(t, func() bool {
+		return d.currentConfig().Workflow.Interval == 7
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, 7*time.Second, watcher.interval)
+	assert.True(t, logger.HasMessage("Config reloaded"))
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+// ControlSocketPath はデーモンが制御APIを提供するUnixソケット
+const ControlSocketPath = ".soba/soba.sock"
+
+// ControlStatus は制御APIの/v1/statusが返すデーモンの状態
+type ControlStatus struct {
+	PID          int                      `json:"pid"`
+	StartedAt    time.Time                `json:"started_at"`
+	Uptime       string                   `json:"uptime"`
+	LogFile      string                   `json:"log_file,omitempty"`
+	Paused       bool                     `json:"paused"`
+	Draining     bool                     `json:"draining"`
+	CurrentIssue int                      `json:"current_issue,omitempty"`
+	Issues       []ControlIssue           `json:"issues"`
+	Watchers     map[string]WatcherStatus `json:"watchers"`
+}
+
+// ControlIssue はsobaが扱っているIssue1件の状態
+type ControlIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Phase  string `json:"phase,omitempty"`
+	Paused bool   `json:"paused,omitempty"`
+	Pane   string `json:"pane,omitempty"` // 実行中のtmuxペイン（session:window.pane）
+}
+
+// WatcherStatus は監視goroutine1つの稼働状況と直近のサイクル
+type WatcherStatus struct {
+	Alive             bool       `json:"alive"`
+	LastCycleAt       *time.Time `json:"last_cycle_at,omitempty"`
+	LastCycleDuration string     `json:"last_cycle_duration,omitempty"`
+	Errors            int        `json:"errors"`
+	LastError         string     `json:"last_error,omitempty"`
+	LastErrorAt       *time.Time `json:"last_error_at,omitempty"`
+}
+
+// ControlResponse は制御APIの操作の結果
+type ControlResponse struct {
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DaemonControl は制御APIから操作・参照されるデーモンの状態を保持する
+// nilの場合は何もしない
+type DaemonControl struct {
+	mu           sync.Mutex
+	startedAt    time.Time
+	logFile      string
+	paused       bool
+	draining     bool
+	currentIssue int
+	issues       []ControlIssue
+	cycles       map[string]*WatcherStatus
+	stopReason   string
+	stop         context.CancelFunc
+	now          func() time.Time
+}
+
+// NewDaemonControl は新しいDaemonControlを作成する
+func NewDaemonControl() *DaemonControl {
+	return &DaemonControl{
+		startedAt: time.Now(),
+		cycles:    make(map[string]*WatcherStatus),
+		now:       time.Now,
+	}
+}
+
+// setStop はデーモンを止める関数を設定する
+func (c *DaemonControl) setStop(stop context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stop = stop
+}
+
+// setLogFile はstatusで返すログファイルのパスを設定する
+func (c *DaemonControl) setLogFile(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logFile = path
+}
+
+// RequestStop は監視を止めてデーモンを終了させる
+func (c *DaemonControl) RequestStop(reason string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopReason == "" {
+		c.stopReason = reason
+	}
+	if c.stop != nil {
+		c.stop()
+	}
+}
+
+// StopReason は停止を要求された理由を返す。要求されていない場合は空文字
+func (c *DaemonControl) StopReason() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stopReason
+}
+
+// SetPaused は新しいIssueのキュー投入とフェーズの開始を止める・再開する
+func (c *DaemonControl) SetPaused(paused bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = paused
+}
+
+// Paused は一時停止中かどうかを返す
+func (c *DaemonControl) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// StartDrain は新しいIssueのキュー投入を止め、進行中のIssueが全て終わったらデーモンを終了させる
+func (c *DaemonControl) StartDrain() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining = true
+}
+
+// Draining はドレイン中かどうかを返す
+func (c *DaemonControl) Draining() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.draining
+}
+
+// recordCycle は監視サイクル1回の結果を記録する
+func (c *DaemonControl) recordCycle(watcher string, duration time.Duration, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status, ok := c.cycles[watcher]
+	if !ok {
+		status = &WatcherStatus{}
+		c.cycles[watcher] = status
+	}
+	now := c.now()
+	status.LastCycleAt = &now
+	status.LastCycleDuration = duration.Round(time.Millisecond).String()
+	if err != nil {
+		status.Errors++
+		status.LastError = err.Error()
+		status.LastErrorAt = &now
+	}
+}
+
+// publishIssues はIssueWatcherが取得したIssueの状態を記録する
+func (c *DaemonControl) publishIssues(issues []github.Issue, currentIssue *int) {
+	if c == nil {
+		return
+	}
+
+	published := make([]ControlIssue, 0, len(issues))
+	for _, issue := range issues {
+		var labels []string
+		paused := false
+		for _, label := range issue.Labels {
+			labels = append(labels, label.Name)
+			if label.Name == domain.LabelPaused {
+				paused = true
+			}
+		}
+		item := ControlIssue{
+			Number: issue.Number,
+			Title:  issue.Title,
+			State:  workflowStateLabel(labels),
+			Paused: paused,
+		}
+		if phase, err := domain.GetCurrentPhaseFromLabels(labels); err == nil {
+			item.Phase = string(phase)
+		}
+		published = append(published, item)
+	}
+	sort.Slice(published, func(i, j int) bool { return published[i].Number < published[j].Number })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.issues = published
+	c.currentIssue = 0
+	if currentIssue != nil {
+		c.currentIssue = *currentIssue
+	}
+}
+
+// Status は現在の状態を返す。稼働状況はhealthから、ペインはpaneForから補う
+func (c *DaemonControl) Status(alive map[string]bool, paneFor func(issueNumber int) string) ControlStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := ControlStatus{
+		PID:          os.Getpid(),
+		StartedAt:    c.startedAt,
+		Uptime:       c.now().Sub(c.startedAt).Round(time.Second).String(),
+		LogFile:      c.logFile,
+		Paused:       c.paused,
+		Draining:     c.draining,
+		CurrentIssue: c.currentIssue,
+		Issues:       make([]ControlIssue, 0, len(c.issues)),
+		Watchers:     make(map[string]WatcherStatus),
+	}
+	for _, issue := range c.issues {
+		if paneFor != nil && domain.GetPhaseByExecutionLabel(issue.State) != nil {
+			issue.Pane = paneFor(issue.Number)
+		}
+		status.Issues = append(status.Issues, issue)
+	}
+	for name, cycle := range c.cycles {
+		status.Watchers[name] = *cycle
+	}
+	for name, ok := range alive {
+		watcher := status.Watchers[name]
+		watcher.Alive = ok
+		status.Watchers[name] = watcher
+	}
+	return status
+}
+
+// watchLoop は監視goroutineへの即時実行と操作の要求を受け付ける
+type watchLoop struct {
+	trigger  chan struct{}
+	requests chan func(context.Context)
+}
+
+func newWatchLoop() watchLoop {
+	return watchLoop{
+		trigger:  make(chan struct{}, 1),
+		requests: make(chan func(context.Context)),
+	}
+}
+
+// Trigger は次のサイクルをすぐに実行させる。既に要求済みの場合は何もしない
+func (l watchLoop) Trigger() {
+	select {
+	case l.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Do はfnを監視goroutineで実行し、終わるまで待つ
+// サイクルの実行中は、そのサイクルが終わってから実行される
+func (l watchLoop) Do(ctx context.Context, fn func(context.Context)) error {
+	done := make(chan struct{})
+	select {
+	case l.requests <- func(loopCtx context.Context) {
+		defer close(done)
+		fn(loopCtx)
+	}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// controlClientTimeout は制御APIへの1回のリクエストの上限
+// 操作が監視サイクルの終了を待つ場合があるため、サーバー側の上限より少し長くする
+const controlClientTimeout = controlRequestTimeout + 10*time.Second
+
+// ControlClient はデーモンの制御APIのクライアント
+type ControlClient struct {
+	socketPath string
+	httpClient *http.Client
+}
+
+// NewControlClient はsocketPathのデーモンに接続するクライアントを作成する
+func NewControlClient(socketPath string) *ControlClient {
+	return &ControlClient{
+		socketPath: socketPath,
+		httpClient: &http.Client{
+			Timeout: controlClientTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Available はデーモンが制御APIに応答するかを返す
+func (c *ControlClient) Available(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	_, err := c.Status(ctx)
+	return err == nil
+}
+
+// Status はデーモンの状態を返す
+func (c *ControlClient) Status(ctx context.Context) (*ControlStatus, error) {
+	var status ControlStatus
+	if err := c.do(ctx, http.MethodGet, "/v1/status", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Watch は監視サイクルをすぐに実行させる
+func (c *ControlClient) Watch(ctx context.Context) (string, error) {
+	return c.post(ctx, "/v1/watch")
+}
+
+// Pause は新しいIssueのキュー投入とフェーズの開始を止める
+func (c *ControlClient) Pause(ctx context.Context) (string, error) {
+	return c.post(ctx, "/v1/pause")
+}
+
+// Resume は一時停止を解除する
+func (c *ControlClient) Resume(ctx context.Context) (string, error) {
+	return c.post(ctx, "/v1/resume")
+}
+
+// CancelIssue はIssueの実行中のフェーズを止め、sobaのラベルを外す
+func (c *ControlClient) CancelIssue(ctx context.Context, issueNumber int) (string, error) {
+	return c.post(ctx, fmt.Sprintf("/v1/issues/%d/cancel", issueNumber))
+}
+
+// Reload は設定ファイルを読み直させる
+func (c *ControlClient) Reload(ctx context.Context) (string, error) {
+	return c.post(ctx, "/v1/reload")
+}
+
+// Drain は新しいIssueの受け付けを止め、進行中のIssueが終わったらデーモンを終了させる
+func (c *ControlClient) Drain(ctx context.Context) (string, error) {
+	return c.post(ctx, "/v1/drain")
+}
+
+// Stop はデーモンを終了させる
+func (c *ControlClient) Stop(ctx context.Context) (string, error) {
+	return c.post(ctx, "/v1/stop")
+}
+
+func (c *ControlClient) post(ctx context.Context, path string) (string, error) {
+	var response ControlResponse
+	if err := c.do(ctx, http.MethodPost, path, &response); err != nil {
+		return "", err
+	}
+	return response.Message, nil
+}
+
+func (c *ControlClient) do(ctx context.Context, method, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, "http://soba"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach soba control API at %s: %w", c.socketPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var response ControlResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err == nil && response.Error != "" {
+			return fmt.Errorf("%s", response.Error)
+		}
+		return fmt.Errorf("soba control API returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/pkg/app"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// controlRequestTimeout は監視goroutineでの操作を待つ上限
+const controlRequestTimeout = 2 * time.Minute
+
+// setConfig は現在watchersに反映している設定を記録する
+func (d *daemonService) setConfig(cfg *config.Config) {
+	d.configMu.Lock()
+	defer d.configMu.Unlock()
+	d.config = cfg
+}
+
+func (d *daemonService) currentConfig() *config.Config {
+	d.configMu.Lock()
+	defer d.configMu.Unlock()
+	return d.config
+}
+
+// controlSocketAddress はUnixソケットのパス長の上限を避けるため、可能ならカレントディレクトリからの相対パスを返す
+func controlSocketAddress(workDir string) string {
+	path := filepath.Join(workDir, ControlSocketPath)
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, path); err == nil && len(rel) < len(path) {
+			return rel
+		}
+	}
+	return path
+}
+
+// startControlServer は制御APIをUnixソケットで提供する。ctxがキャンセルされると停止し、ソケットを削除する
+// 起動できない場合は警告を出して続行する（CLIはPIDファイルによる操作に戻る）
+func (d *daemonService) startControlServer(ctx context.Context) {
+	address := controlSocketAddress(d.workDir)
+
+	// 前回のデーモンが残したソケットを削除する。応答がある場合は別のデーモンが使用中
+	if _, err := os.Stat(address); err == nil {
+		if NewControlClient(address).Available(ctx) {
+			d.logger.Warn(ctx, "Control socket is used by another soba process, control API disabled",
+				logging.Field{Key: "socket", Value: address},
+			)
+			return
+		}
+		_ = os.Remove(address)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(address), 0755); err != nil {
+		d.logger.Warn(ctx, "Failed to create control socket directory", logging.Field{Key: "error", Value: err.Error()})
+		return
+	}
+	listener, err := net.Listen("unix", address)
+	if err != nil {
+		d.logger.Warn(ctx, "Failed to start control API",
+			logging.Field{Key: "socket", Value: address},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return
+	}
+	if err := os.Chmod(address, 0600); err != nil {
+		d.logger.Warn(ctx, "Failed to restrict control socket permissions", logging.Field{Key: "error", Value: err.Error()})
+	}
+
+	server := &http.Server{
+		Handler:           d.controlHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+		_ = os.Remove(address)
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			d.logger.Error(ctx, "Control API stopped", logging.Field{Key: "error", Value: err.Error()})
+		}
+	}()
+
+	d.logger.Info(ctx, "Control API started", logging.Field{Key: "socket", Value: address})
+}
+
+// controlHandler は制御APIのルーティングを返す
+func (d *daemonService) controlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", d.handleControlStatus)
+	mux.HandleFunc("POST /v1/watch", d.handleControlWatch)
+	mux.HandleFunc("POST /v1/pause", d.handleControlPause)
+	mux.HandleFunc("POST /v1/resume", d.handleControlResume)
+	mux.HandleFunc("POST /v1/issues/{number}/cancel", d.handleControlCancel)
+	mux.HandleFunc("POST /v1/reload", d.handleControlReload)
+	mux.HandleFunc("POST /v1/drain", d.handleControlDrain)
+	mux.HandleFunc("POST /v1/stop", d.handleControlStop)
+	return mux
+}
+
+func writeControlJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeControlError(w http.ResponseWriter, code int, err error) {
+	writeControlJSON(w, code, ControlResponse{Error: err.Error()})
+}
+
+func (d *daemonService) handleControlStatus(w http.ResponseWriter, r *http.Request) {
+	alive := make(map[string]bool)
+	if d.health != nil {
+		watchers, _ := d.health.Snapshot()
+		for name, status := range watchers {
+			alive[name] = status.Alive
+		}
+	}
+	writeControlJSON(w, http.StatusOK, d.control.Status(alive, d.paneFor))
+}
+
+// paneFor はIssueのフェーズを実行しているtmuxペインを返す
+func (d *daemonService) paneFor(issueNumber int) string {
+	cfg := d.currentConfig()
+	if d.tmux == nil || cfg == nil {
+		return ""
+	}
+	sessionName := d.generateSessionName(cfg.GitHub.Repository)
+	windowName := fmt.Sprintf("issue-%d", issueNumber)
+	if exists, err := d.tmux.WindowExists(sessionName, windowName); err != nil || !exists {
+		return ""
+	}
+	paneIndex, err := d.tmux.GetLastPaneIndex(sessionName, windowName)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex)
+}
+
+func (d *daemonService) handleControlWatch(w http.ResponseWriter, r *http.Request) {
+	if d.watcher != nil {
+		d.watcher.Trigger()
+	}
+	if d.prWatcher != nil {
+		d.prWatcher.Trigger()
+	}
+	writeControlJSON(w, http.StatusAccepted, ControlResponse{Message: "watch cycle triggered"})
+}
+
+func (d *daemonService) handleControlPause(w http.ResponseWriter, r *http.Request) {
+	d.control.SetPaused(true)
+	d.logger.Info(r.Context(), "Paused by control API")
+	writeControlJSON(w, http.StatusOK, ControlResponse{Message: "paused"})
+}
+
+func (d *daemonService) handleControlResume(w http.ResponseWriter, r *http.Request) {
+	d.control.SetPaused(false)
+	d.logger.Info(r.Context(), "Resumed by control API")
+	if d.watcher != nil {
+		d.watcher.Trigger()
+	}
+	writeControlJSON(w, http.StatusOK, ControlResponse{Message: "resumed"})
+}
+
+func (d *daemonService) handleControlCancel(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil || number <= 0 {
+		writeControlError(w, http.StatusBadRequest, fmt.Errorf("invalid issue number %q", r.PathValue("number")))
+		return
+	}
+	if d.watcher == nil {
+		writeControlError(w, http.StatusServiceUnavailable, fmt.Errorf("issue watcher is not running"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), controlRequestTimeout)
+	defer cancel()
+	var cancelErr error
+	if err := d.watcher.Do(ctx, func(loopCtx context.Context) {
+		cancelErr = d.watcher.chatOps.CancelIssue(loopCtx, d.watcher.config, number)
+	}); err != nil {
+		writeControlError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if cancelErr != nil {
+		writeControlError(w, http.StatusUnprocessableEntity, cancelErr)
+		return
+	}
+	writeControlJSON(w, http.StatusOK, ControlResponse{Message: fmt.Sprintf("issue #%d cancelled", number)})
+}
+
+func (d *daemonService) handleControlReload(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), controlRequestTimeout)
+	defer cancel()
+	if err := d.reloadConfig(ctx); err != nil {
+		writeControlError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeControlJSON(w, http.StatusOK, ControlResponse{Message: "config reloaded"})
+}
+
+// reloadConfig は起動時に読んだ設定ファイルを読み直す
+func (d *daemonService) reloadConfig(ctx context.Context) error {
+	path := app.ConfigPath()
+	if path == "" {
+		return fmt.Errorf("config file path is unknown")
+	}
+	return d.reloadConfigFrom(ctx, path)
+}
+
+func (d *daemonService) handleControlDrain(w http.ResponseWriter, r *http.Request) {
+	d.control.StartDrain()
+	d.logger.Info(r.Context(), "Draining by control API")
+	if d.watcher != nil {
+		d.watcher.Trigger()
+	}
+	writeControlJSON(w, http.StatusAccepted, ControlResponse{Message: "draining, soba stops after issues in progress finish"})
+}
+
+func (d *daemonService) handleControlStop(w http.ResponseWriter, r *http.Request) {
+	d.logger.Info(r.Context(), "Stop requested by control API")
+	writeControlJSON(w, http.StatusAccepted, ControlResponse{Message: "stopping"})
+	d.control.RequestStop("requested")
+}
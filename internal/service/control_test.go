@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestDaemonControl_NilIsNoop(t *testing.T) {
+	var control *DaemonControl
+
+	assert.NotPanics(t, func() {
+		control.SetPaused(true)
+		control.StartDrain()
+		control.RequestStop("requested")
+		control.recordCycle("issue_watcher", time.Second, errors.New("boom"))
+		control.publishIssues(nil, nil)
+	})
+	assert.False(t, control.Paused())
+	assert.False(t, control.Draining())
+	assert.Empty(t, control.StopReason())
+}
+
+func TestDaemonControl_Status(t *testing.T) {
+	control := NewDaemonControl()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	control.startedAt = now.Add(-90 * time.Second)
+	control.now = func() time.Time { return now }
+
+	control.recordCycle("issue_watcher", 1500*time.Millisecond, nil)
+	control.recordCycle("issue_watcher", 200*time.Millisecond, errors.New("rate limited"))
+	current := 12
+	control.publishIssues([]github.Issue{
+		{Number: 12, Title: "Implement", Labels: []github.Label{{Name: "soba:doing"}}},
+		{Number: 3, Title: "Plan", Labels: []github.Label{{Name: "soba:planning"}, {Name: "soba:paused"}}},
+	}, &current)
+	control.SetPaused(true)
+
+	status := control.Status(map[string]bool{"issue_watcher": true, "pr_watcher": false}, func(issueNumber int) string {
+		return fmt.Sprintf("soba-repo:issue-%d.1", issueNumber)
+	})
+
+	assert.Equal(t, os.Getpid(), status.PID)
+	assert.Equal(t, "1m30s", status.Uptime)
+	assert.True(t, status.Paused)
+	assert.Equal(t, 12, status.CurrentIssue)
+
+	require.Len(t, status.Issues, 2)
+	assert.Equal(t, 3, status.Issues[0].Number)
+	assert.Equal(t, "soba:planning", status.Issues[0].State)
+	assert.Equal(t, "plan", status.Issues[0].Phase)
+	assert.True(t, status.Issues[0].Paused)
+	assert.Equal(t, "soba-repo:issue-3.1", status.Issues[0].Pane)
+	assert.Equal(t, 12, status.Issues[1].Number)
+	assert.Equal(t, "implement", status.Issues[1].Phase)
+
+	issueWatcher := status.Watchers["issue_watcher"]
+	assert.True(t, issueWatcher.Alive)
+	assert.Equal(t, "200ms", issueWatcher.LastCycleDuration)
+	assert.Equal(t, 1, issueWatcher.Errors)
+	assert.Equal(t, "rate limited", issueWatcher.LastError)
+	assert.False(t, status.Watchers["pr_watcher"].Alive)
+}
+
+func TestDaemonControl_RequestStop(t *testing.T) {
+	control := NewDaemonControl()
+	ctx, cancel := context.WithCancel(context.Background())
+	control.setStop(cancel)
+
+	control.RequestStop("drained")
+	control.RequestStop("requested")
+
+	assert.Error(t, ctx.Err())
+	assert.Equal(t, "drained", control.StopReason())
+}
+
+func TestWatchLoop_Trigger(t *testing.T) {
+	loop := newWatchLoop()
+	loop.Trigger()
+	loop.Trigger()
+
+	assert.Len(t, loop.trigger, 1)
+}
+
+func TestWatchLoop_Do(t *testing.T) {
+	loop := newWatchLoop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case request := <-loop.requests:
+				request(ctx)
+			}
+		}
+	}()
+
+	ran := false
+	require.NoError(t, loop.Do(context.Background(), func(context.Context) { ran = true }))
+	assert.True(t, ran)
+
+	t.Run("times out when the loop is busy", func(t *testing.T) {
+		busy := newWatchLoop()
+		timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer timeoutCancel()
+
+		err := busy.Do(timeoutCtx, func(context.Context) {})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// startTestControlServer はdaemonServiceの制御APIを短いパスのUnixソケットで起動する
+func startTestControlServer(t *testing.T, d *daemonService) *ControlClient {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "soba")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "soba.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := &http.Server{Handler: d.controlHandler()}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return NewControlClient(socketPath)
+}
+
+func TestControlAPI(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("status, pause, resume and drain", func(t *testing.T) {
+		d := &daemonService{logger: logging.NewMockLogger(), control: NewDaemonControl()}
+		client := startTestControlServer(t, d)
+
+		assert.True(t, client.Available(ctx))
+
+		_, err := client.Pause(ctx)
+		require.NoError(t, err)
+		status, err := client.Status(ctx)
+		require.NoError(t, err)
+		assert.True(t, status.Paused)
+
+		_, err = client.Resume(ctx)
+		require.NoError(t, err)
+		assert.False(t, d.control.Paused())
+
+		_, err = client.Drain(ctx)
+		require.NoError(t, err)
+		assert.True(t, d.control.Draining())
+
+		_, err = client.Watch(ctx)
+		require.NoError(t, err)
+	})
+
+	t.Run("stop cancels the watchers", func(t *testing.T) {
+		d := &daemonService{logger: logging.NewMockLogger(), control: NewDaemonControl()}
+		stopCtx, cancel := context.WithCancel(ctx)
+		d.control.setStop(cancel)
+		client := startTestControlServer(t, d)
+
+		_, err := client.Stop(ctx)
+		require.NoError(t, err)
+		assert.Error(t, stopCtx.Err())
+		assert.Equal(t, "requested", d.control.StopReason())
+	})
+
+	t.Run("cancel rejects invalid input and missing watcher", func(t *testing.T) {
+		d := &daemonService{logger: logging.NewMockLogger(), control: NewDaemonControl()}
+		client := startTestControlServer(t, d)
+
+		_, err := client.post(ctx, "/v1/issues/abc/cancel")
+		assert.ErrorContains(t, err, "invalid issue number")
+
+		_, err = client.CancelIssue(ctx, 5)
+		assert.ErrorContains(t, err, "issue watcher is not running")
+	})
+
+	t.Run("reload applies the config to the watchers", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "config.yml")
+		require.NoError(t, os.WriteFile(configPath, []byte("github:\n  repository: owner/repo\nworkflow:\n  interval: 42\n"), 0600))
+
+		watcher := NewPRWatcher(nil, &config.Config{Workflow: config.WorkflowConfig{Interval: 10}})
+		watcher.SetLogger(logging.NewMockLogger())
+		d := &daemonService{logger: logging.NewMockLogger(), control: NewDaemonControl(), prWatcher: watcher}
+
+		loopCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			for {
+				select {
+				case <-loopCtx.Done():
+					return
+				case request := <-watcher.requests:
+					request(loopCtx)
+				}
+			}
+		}()
+
+		require.NoError(t, d.reloadConfigFrom(ctx, configPath))
+		assert.Equal(t, 42*time.Second, watcher.interval)
+		assert.Equal(t, "owner/repo", d.currentConfig().GitHub.Repository)
+	})
+
+	t.Run("unreachable socket", func(t *testing.T) {
+		client := NewControlClient(filepath.Join(t.TempDir(), "missing.sock"))
+		assert.False(t, client.Available(ctx))
+	})
+}
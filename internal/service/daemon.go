@@ -8,13 +8,16 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/douhashi/soba/internal/config"
 	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/metrics"
 	"github.com/douhashi/soba/internal/infra/tmux"
 	"github.com/douhashi/soba/internal/service/builder"
+	"github.com/douhashi/soba/pkg/app"
 	"github.com/douhashi/soba/pkg/errors"
 	"github.com/douhashi/soba/pkg/logging"
 )
@@ -43,6 +46,12 @@ type daemonService struct {
 	closedIssueCleanupService *ClosedIssueCleanupService
 	tmux                      tmux.TmuxClient
 	logger                    logging.Logger
+	health                    *metrics.Health // watchersのgoroutineの稼働状況
+	control                   *DaemonControl  // 制御APIから参照・操作される状態
+	configMu                  sync.Mutex
+	config                    *config.Config  // 現在watchersに反映している設定
+	reloadMu                  sync.Mutex      // 設定の再読み込みを1つずつ処理する
+	dryRun                    *DryRunRecorder // nilでなければ変更操作を記録するだけにする
 }
 
 // init initializes the service factory
@@ -246,12 +255,15 @@ func (d *daemonService) StartForeground(ctx context.Context, cfg *config.Config)
 		return err
 	}
 
+	d.control = NewDaemonControl()
+	d.control.setLogFile(logPath)
+
 	// watchers設定と起動（共通処理を使用）
 	return d.configureAndStartWatchers(ctx, cfg)
 }
 
-// configureAndStartWatchers はwatchersの設定と起動を行う共通処理
-func (d *daemonService) configureAndStartWatchers(ctx context.Context, cfg *config.Config) error {
+// configureIssueWatcher はIssueWatcherとQueueManagerに設定を反映する
+func (d *daemonService) configureIssueWatcher(ctx context.Context, cfg *config.Config) {
 	// IssueWatcherに設定を反映
 	if d.watcher != nil {
 		d.watcher.config = cfg
@@ -281,17 +293,56 @@ func (d *daemonService) configureAndStartWatchers(ctx context.Context, cfg *conf
 		}
 	}
 
-	// PRWatcherに設定を反映
+}
+
+// configurePRWatcher はPRWatcherに設定を反映する
+func (d *daemonService) configurePRWatcher(cfg *config.Config) {
 	if d.prWatcher != nil {
 		d.prWatcher.config = cfg
 		d.prWatcher.interval = time.Duration(cfg.Workflow.Interval) * time.Second
 		d.prWatcher.SetLogger(d.logger)
 	}
+}
+
+// configureAndStartWatchers はwatchersの設定と起動を行う共通処理
+// 制御APIから停止を要求された場合はnilを返す
+func (d *daemonService) configureAndStartWatchers(ctx context.Context, cfg *config.Config) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if d.control == nil {
+		d.control = NewDaemonControl()
+	}
+	d.control.setStop(cancel)
+	d.setConfig(cfg)
+
+	// ドライランでは実行していない操作を履歴に残さない
+	var history *EventHistory
+	if d.dryRun == nil {
+		history = NewEventHistory(filepath.Join(d.workDir, HistoryDir), d.logger)
+	}
+
+	d.configureIssueWatcher(ctx, cfg)
+	if d.watcher != nil {
+		d.watcher.SetControl(d.control)
+		d.watcher.SetHistory(history)
+		d.watcher.SetDryRun(d.dryRun)
+	}
+	d.configurePRWatcher(cfg)
+	if d.prWatcher != nil {
+		d.prWatcher.SetControl(d.control)
+		d.prWatcher.SetHistory(history)
+	}
 
 	// ClosedIssueCleanupServiceを設定
 	if d.closedIssueCleanupService != nil && cfg.GitHub.Repository != "" {
 		// ロガーを設定
 		d.closedIssueCleanupService.SetLogger(d.logger)
+		d.closedIssueCleanupService.SetControl(d.control)
+		d.closedIssueCleanupService.SetHistory(history)
+		if d.closedIssueCleanupService.worktrees != nil {
+			d.closedIssueCleanupService.worktrees.SetConfig(cfg)
+		}
 
 		parts := strings.Split(cfg.GitHub.Repository, "/")
 		if len(parts) == 2 {
@@ -305,13 +356,27 @@ func (d *daemonService) configureAndStartWatchers(ctx context.Context, cfg *conf
 		}
 	}
 
+	if d.health == nil {
+		d.health = metrics.NewHealth()
+	}
+	// ドライランは稼働中のデーモンと並行して動かせるよう、メトリクスのポートと制御ソケットを使わない
+	if d.dryRun == nil {
+		if err := d.startMetricsServer(ctx, cfg); err != nil {
+			return err
+		}
+		d.startControlServer(ctx)
+	}
+	if path := app.ConfigPath(); path != "" {
+		go d.watchConfig(ctx, path)
+	}
+
 	// IssueWatcher、PRWatcher、ClosedIssueCleanupServiceを並行して起動
 	errCh := make(chan error, 3)
 
 	// IssueWatcherを起動
 	go func() {
 		if d.watcher != nil {
-			errCh <- d.watcher.Start(ctx)
+			errCh <- d.health.Run("issue_watcher", func() error { return d.watcher.Start(ctx) })
 		} else {
 			errCh <- nil
 		}
@@ -320,7 +385,7 @@ func (d *daemonService) configureAndStartWatchers(ctx context.Context, cfg *conf
 	// PRWatcherを起動
 	go func() {
 		if d.prWatcher != nil {
-			errCh <- d.prWatcher.Start(ctx)
+			errCh <- d.health.Run("pr_watcher", func() error { return d.prWatcher.Start(ctx) })
 		} else {
 			errCh <- nil
 		}
@@ -333,7 +398,13 @@ func (d *daemonService) configureAndStartWatchers(ctx context.Context, cfg *conf
 				logging.Field{Key: "enabled", Value: d.closedIssueCleanupService.enabled},
 				logging.Field{Key: "interval", Value: d.closedIssueCleanupService.interval},
 			)
-			errCh <- d.closedIssueCleanupService.Start(ctx)
+			run := func() error { return d.closedIssueCleanupService.Start(ctx) }
+			if d.closedIssueCleanupService.enabled {
+				errCh <- d.health.Run("closed_issue_cleanup", run)
+			} else {
+				// 無効な場合はすぐに戻るため、稼働状況には含めない
+				errCh <- run()
+			}
 		} else {
 			d.logger.Debug(ctx, "ClosedIssueCleanupService is nil, skipping")
 			errCh <- nil
@@ -343,10 +414,51 @@ func (d *daemonService) configureAndStartWatchers(ctx context.Context, cfg *conf
 	// どれかがエラーで終了したら全体を終了
 	for i := 0; i < 3; i++ {
 		if err := <-errCh; err != nil {
+			// 制御APIから止めた場合、キャンセルによる終了はエラーとしない
+			if err == context.Canceled && d.control.StopReason() != "" {
+				continue
+			}
 			return err
 		}
 	}
 
+	if reason := d.control.StopReason(); reason != "" {
+		d.logger.Info(ctx, "Watchers stopped by control API", logging.Field{Key: "reason", Value: reason})
+	}
+	return nil
+}
+
+// timedCycle は監視サイクル1回を実行し、所要時間と失敗をメトリクスと制御APIの状態に記録する
+func timedCycle(control *DaemonControl, watcher string, cycle func() error) error {
+	start := time.Now()
+	err := cycle()
+	metrics.ObserveWatchCycle(watcher, time.Since(start), err)
+	control.recordCycle(watcher, time.Since(start), err)
+	return err
+}
+
+// startMetricsServer は設定が有効な場合に/metricsと/healthzのHTTPリスナーを起動する
+// ctxがキャンセルされると停止する
+func (d *daemonService) startMetricsServer(ctx context.Context, cfg *config.Config) error {
+	if !cfg.Metrics.Enabled {
+		return nil
+	}
+
+	server := metrics.NewServer(metrics.Default(), d.health)
+	if err := server.Listen(cfg.Metrics.Listen); err != nil {
+		d.logger.Error(ctx, "Failed to start metrics server",
+			logging.Field{Key: "listen", Value: cfg.Metrics.Listen},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return errors.WrapInternal(err, "failed to start metrics server")
+	}
+
+	d.logger.Info(ctx, "Metrics server started", logging.Field{Key: "listen", Value: server.Addr()})
+	go func() {
+		if err := server.Serve(ctx); err != nil {
+			d.logger.Error(ctx, "Metrics server stopped", logging.Field{Key: "error", Value: err.Error()})
+		}
+	}()
 	return nil
 }
 
@@ -411,8 +523,38 @@ func (d *daemonService) StartDaemon(ctx context.Context, cfg *config.Config) err
 		logging.Field{Key: "logFile", Value: logPath},
 	)
 
+	d.control = NewDaemonControl()
+	d.control.setLogFile(logPath)
+
 	// watchers設定と起動（共通処理を使用）
-	return d.configureAndStartWatchers(ctx, cfg)
+	if err := d.configureAndStartWatchers(ctx, cfg); err != nil {
+		return err
+	}
+
+	// 制御APIから止めた場合は、soba stopと同じくtmuxセッションとPIDファイルを片付ける
+	if d.control.StopReason() != "" {
+		d.cleanupAfterStop(ctx, cfg.GitHub.Repository)
+	}
+	return nil
+}
+
+// cleanupAfterStop はデーモン自身が終了する前にtmuxセッションとPIDファイルを削除する
+func (d *daemonService) cleanupAfterStop(ctx context.Context, repository string) {
+	sessionName := d.generateSessionName(repository)
+	if d.tmux != nil && d.tmux.SessionExists(sessionName) {
+		if err := d.tmux.KillSession(sessionName); err != nil {
+			d.logger.Warn(ctx, "Failed to kill tmux session",
+				logging.Field{Key: "session", Value: sessionName},
+				logging.Field{Key: "error", Value: err},
+			)
+		}
+	}
+	if err := d.removePIDFile(); err != nil {
+		d.logger.Warn(ctx, "Failed to remove PID file",
+			logging.Field{Key: "error", Value: err},
+		)
+	}
+	d.logger.Info(ctx, "Daemon stopped successfully")
 }
 
 // forkAndExit forks a child process and exits the parent
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/douhashi/soba/internal/config"
 	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/metrics"
 	"github.com/douhashi/soba/pkg/app"
 	"github.com/douhashi/soba/pkg/logging"
 )
@@ -449,6 +451,47 @@ func TestDaemonService_ConfigureAndStartWatchers_WithNilClosedIssueCleanupServic
 	}
 }
 
+func TestDaemonService_StartMetricsServer(t *testing.T) {
+	t.Run("無効な場合は待ち受けない", func(t *testing.T) {
+		service := &daemonService{logger: logging.NewMockLogger(), health: metrics.NewHealth()}
+		cfg := &config.Config{Metrics: config.MetricsConfig{Enabled: false, Listen: "invalid"}}
+
+		assert.NoError(t, service.startMetricsServer(context.Background(), cfg))
+	})
+
+	t.Run("待ち受けできないアドレスはエラーを返す", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		service := &daemonService{logger: logging.NewMockLogger(), health: metrics.NewHealth()}
+		cfg := &config.Config{Metrics: config.MetricsConfig{Enabled: true, Listen: listener.Addr().String()}}
+
+		err = service.startMetricsServer(context.Background(), cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to start metrics server")
+	})
+}
+
+func TestDaemonService_ConfigureAndStartWatchers_Health(t *testing.T) {
+	t.Run("無効なクリーンアップサービスは稼働状況に含めない", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		service := &daemonService{
+			closedIssueCleanupService: &ClosedIssueCleanupService{},
+			logger:                    logging.NewMockLogger(),
+		}
+		cfg := &config.Config{GitHub: config.GitHubConfig{Repository: "douhashi/soba"}}
+
+		require.NoError(t, service.configureAndStartWatchers(ctx, cfg))
+
+		watchers, healthy := service.health.Snapshot()
+		assert.Empty(t, watchers)
+		assert.False(t, healthy)
+	})
+}
+
 func TestDaemonService_Stop(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -31,6 +31,9 @@ func (f *DefaultServiceFactory) CreateGitWorkspaceManager(cfg *config.Config, gi
 	if gc, ok := gitClient.(*git.Client); ok {
 		return &GitWorkspaceManagerAdapter{NewGitWorkspaceManager(cfg, gc)}
 	}
+	if gc, ok := gitClient.(*dryRunGitClient); ok {
+		return &GitWorkspaceManagerAdapter{NewGitWorkspaceManager(cfg, gc)}
+	}
 	return &GitWorkspaceManagerAdapter{NewMockGitWorkspaceManager()}
 }
 
@@ -101,6 +104,10 @@ func (f *DefaultServiceFactory) CreatePRWatcher(githubClient builder.GitHubClien
 
 // CreateClosedIssueCleanupService creates cleanup service
 func (f *DefaultServiceFactory) CreateClosedIssueCleanupService(githubClient builder.GitHubClientInterface, tmuxClient tmux.TmuxClient, owner, repo, sessionName string, enabled bool, interval time.Duration) builder.ClosedIssueCleanupService {
+	// The cleanup service only reads issues from GitHub, so a dry-run client can be unwrapped
+	if dryRun, ok := githubClient.(*dryRunGitHubClient); ok {
+		githubClient = dryRun.GitHubClientInterface
+	}
 	// Type assert to *github.ClientImpl which is what NewClosedIssueCleanupService expects
 	if impl, ok := githubClient.(*github.ClientImpl); ok {
 		return &ClosedIssueCleanupServiceAdapter{NewClosedIssueCleanupService(impl, tmuxClient, owner, repo, sessionName, enabled, interval)}
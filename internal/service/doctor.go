@@ -0,0 +1,616 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+// 診断結果の状態
+const (
+	DoctorPass = "pass"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+)
+
+// minGitVersion はgit worktree removeを使うために必要なgitのバージョン
+var minGitVersion = [2]int{2, 17}
+
+// versionPattern はコマンドのバージョン表記からメジャー・マイナーバージョンを取り出す
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// slackWebhookPattern はSlackのIncoming WebhookのURLにマッチする
+var slackWebhookPattern = regexp.MustCompile(`^https://hooks\.slack\.com/services/[A-Za-z0-9]+/[A-Za-z0-9]+/[A-Za-z0-9]+$`)
+
+// DoctorGitHubClient は診断に必要なGitHub操作のインターフェース
+type DoctorGitHubClient interface {
+	GetTokenScopes(ctx context.Context, owner, repo string) ([]string, bool, error)
+	ListLabels(ctx context.Context, owner, repo string) ([]github.Label, error)
+	CreateLabel(ctx context.Context, owner, repo string, request github.CreateLabelRequest) (*github.Label, error)
+	UpdateLabel(ctx context.Context, owner, repo, name string, request github.CreateLabelRequest) (*github.Label, error)
+}
+
+// DoctorResult は1つの診断項目の結果
+type DoctorResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+	Fixable bool   `json:"fixable,omitempty"` // --fixで安全に修正できる
+	Fixed   bool   `json:"fixed,omitempty"`
+
+	fix func(ctx context.Context) (string, error)
+}
+
+// Doctor はsobaが依存する環境とリポジトリの設定を診断する
+type Doctor struct {
+	configPath    string
+	config        *config.Config
+	configErr     error
+	workDir       string
+	tokenProvider github.TokenProvider
+	github        DoctorGitHubClient
+	templates     *config.ClaudeCommandsManager
+
+	// テスト用に差し替え可能にする
+	runCommand func(ctx context.Context, name string, args ...string) ([]byte, error)
+	lookPath   func(file string) (string, error)
+}
+
+// NewDoctor は新しいDoctorを作成する
+// clientがnilの場合、GitHubに関する診断は失敗として報告する
+// 設定はlayersのグローバル設定、プロファイル、環境変数も重ねて読み込む
+func NewDoctor(layers config.Layers, workDir string, tokenProvider github.TokenProvider, client DoctorGitHubClient) *Doctor {
+	configPath := layers.Project
+	d := &Doctor{
+		configPath:    configPath,
+		workDir:       workDir,
+		tokenProvider: tokenProvider,
+		github:        client,
+		templates:     config.GetClaudeCommandsManager(),
+		runCommand:    runDoctorCommand,
+		lookPath:      exec.LookPath,
+	}
+
+	// 設定ファイルがない・読めない場合も既定値で他の項目を診断する
+	cfg, _, err := config.LoadLayers(layers)
+	if err != nil {
+		d.configErr = err
+		cfg = &config.Config{Git: config.GitConfig{WorktreeBasePath: config.DefaultWorktreeBasePath, BaseBranch: "main"}}
+	} else if _, err := os.Stat(configPath); err != nil {
+		d.configErr = err
+	}
+	d.config = cfg
+	return d
+}
+
+// Run はすべての項目を診断する
+// fixがtrueの場合、安全に修正できる問題を修正してから結果を返す
+func (d *Doctor) Run(ctx context.Context, fix bool) []DoctorResult {
+	results := []DoctorResult{
+		d.checkConfig(),
+		d.checkTmux(ctx),
+		d.checkGit(ctx),
+		d.checkGitWorktree(ctx),
+		d.checkBaseBranch(ctx),
+		d.checkWorktreePath(),
+		d.checkAgent(),
+		d.checkTemplates(),
+	}
+
+	token := d.checkToken(ctx)
+	results = append(results, token)
+	if token.Status == DoctorFail {
+		results = append(results,
+			skippedResult("token scopes", "no GitHub token"),
+			skippedResult("labels", "no GitHub token"),
+		)
+	} else {
+		results = append(results, d.checkTokenScopes(ctx), d.checkLabels(ctx))
+	}
+	results = append(results, d.checkSlack())
+
+	for i := range results {
+		results[i].Fixable = results[i].fix != nil
+		if fix && results[i].fix != nil {
+			d.applyFix(ctx, &results[i])
+		}
+	}
+	return results
+}
+
+// applyFix は診断結果の修正を実行し、結果を更新する
+func (d *Doctor) applyFix(ctx context.Context, result *DoctorResult) {
+	message, err := result.fix(ctx)
+	if err != nil {
+		result.Message = fmt.Sprintf("%s (fix failed: %v)", result.Message, err)
+		return
+	}
+	result.Status = DoctorPass
+	result.Message = message
+	result.Hint = ""
+	result.Fixed = true
+}
+
+func (d *Doctor) checkConfig() DoctorResult {
+	path := d.configPath
+	if rel, err := filepath.Rel(d.workDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+
+	result := DoctorResult{Name: "config"}
+	var validationErr *config.ValidationError
+	switch {
+	case errors.As(d.configErr, &validationErr):
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("%d problems found in %s", len(validationErr.Problems), path)
+		result.Hint = "run `soba config validate` for details"
+	case os.IsNotExist(d.configErr):
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("%s not found", path)
+		result.Hint = "run `soba init` in the repository root"
+	case d.configErr != nil:
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("failed to load %s: %v", path, d.configErr)
+		result.Hint = "fix the YAML syntax in the config file"
+	default:
+		if _, _, err := splitRepository(d.config.GitHub.Repository); err != nil {
+			result.Status = DoctorFail
+			result.Message = "github.repository is not set to owner/repo"
+			result.Hint = "set github.repository in " + path
+			return result
+		}
+		// 古い形式の設定ファイルは動作するが、新しい設定が既定値で補われない
+		if content, err := os.ReadFile(d.configPath); err == nil {
+			if migration, err := config.Migrate(content); err == nil && (len(migration.Changes) > 0 || len(migration.Unresolved) > 0) {
+				result.Status = DoctorWarn
+				result.Message = fmt.Sprintf("%s uses config version %d (current %d)", path, migration.From, config.CurrentConfigVersion)
+				result.Hint = "run `soba config migrate` to upgrade it; `--dry-run` lists the changes"
+				return result
+			}
+		}
+		result.Status = DoctorPass
+		result.Message = fmt.Sprintf("%s (repository %s)", path, d.config.GitHub.Repository)
+	}
+	return result
+}
+
+func (d *Doctor) checkTmux(ctx context.Context) DoctorResult {
+	result := DoctorResult{Name: "tmux"}
+	output, err := d.runCommand(ctx, "tmux", "-V")
+	if err != nil {
+		result.Status = DoctorFail
+		result.Message = "tmux not found"
+		result.Hint = "install tmux, e.g. `brew install tmux` or `apt install tmux`"
+		return result
+	}
+	result.Status = DoctorPass
+	result.Message = strings.TrimSpace(string(output))
+	return result
+}
+
+func (d *Doctor) checkGit(ctx context.Context) DoctorResult {
+	result := DoctorResult{Name: "git"}
+	output, err := d.runCommand(ctx, "git", "--version")
+	if err != nil {
+		result.Status = DoctorFail
+		result.Message = "git not found"
+		result.Hint = "install git " + formatVersion(minGitVersion) + " or later"
+		return result
+	}
+
+	version := strings.TrimSpace(string(output))
+	major, minor, ok := parseVersion(version)
+	switch {
+	case !ok:
+		result.Status = DoctorWarn
+		result.Message = fmt.Sprintf("cannot parse the git version from %q", version)
+	case major < minGitVersion[0] || (major == minGitVersion[0] && minor < minGitVersion[1]):
+		result.Status = DoctorFail
+		result.Message = version
+		result.Hint = "soba needs git " + formatVersion(minGitVersion) + " or later for `git worktree remove`"
+	default:
+		result.Status = DoctorPass
+		result.Message = version
+	}
+	return result
+}
+
+func (d *Doctor) checkGitWorktree(ctx context.Context) DoctorResult {
+	result := DoctorResult{Name: "git worktree"}
+	if output, err := d.runCommand(ctx, "git", "-C", d.workDir, "worktree", "list"); err != nil {
+		result.Status = DoctorFail
+		result.Message = strings.TrimSpace(fmt.Sprintf("git worktree list failed: %v %s", err, output))
+		result.Hint = "run soba in the root of a git repository"
+		return result
+	}
+	result.Status = DoctorPass
+	result.Message = "git worktree is available"
+	return result
+}
+
+func (d *Doctor) checkBaseBranch(ctx context.Context) DoctorResult {
+	branch := d.config.Git.BaseBranch
+	result := DoctorResult{Name: "base branch"}
+	for _, ref := range []string{"refs/heads/" + branch, "refs/remotes/origin/" + branch} {
+		if _, err := d.runCommand(ctx, "git", "-C", d.workDir, "rev-parse", "--verify", "--quiet", ref); err == nil {
+			result.Status = DoctorPass
+			result.Message = fmt.Sprintf("%s exists", strings.TrimPrefix(ref, "refs/"))
+			return result
+		}
+	}
+	result.Status = DoctorFail
+	result.Message = fmt.Sprintf("branch %s not found locally or on origin", branch)
+	result.Hint = "set git.base_branch to the branch pull requests are merged into, or run `git fetch origin`"
+	return result
+}
+
+func (d *Doctor) checkWorktreePath() DoctorResult {
+	path := d.config.Git.WorktreeBasePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(d.workDir, path)
+	}
+	result := DoctorResult{Name: "worktree path"}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		result.Status = DoctorWarn
+		result.Message = fmt.Sprintf("%s does not exist yet", d.config.Git.WorktreeBasePath)
+		result.Hint = "soba creates it on first use; `soba doctor --fix` creates it now"
+		result.fix = func(ctx context.Context) (string, error) {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("created %s", d.config.Git.WorktreeBasePath), nil
+		}
+		return result
+	}
+	if err != nil || !info.IsDir() {
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("%s is not a directory", d.config.Git.WorktreeBasePath)
+		result.Hint = "set git.worktree_base_path to a writable directory"
+		return result
+	}
+
+	probe, err := os.CreateTemp(path, ".soba-doctor-*")
+	if err != nil {
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("%s is not writable", d.config.Git.WorktreeBasePath)
+		result.Hint = "fix the directory permissions or set git.worktree_base_path"
+		return result
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	result.Status = DoctorPass
+	result.Message = fmt.Sprintf("%s is writable", d.config.Git.WorktreeBasePath)
+	return result
+}
+
+func (d *Doctor) checkAgent() DoctorResult {
+	result := DoctorResult{Name: "agent"}
+	commands := d.agentCommands()
+	if len(commands) == 0 {
+		result.Status = DoctorWarn
+		result.Message = "no phase commands are configured"
+		result.Hint = "set phase.<name>.command, e.g. with `soba init --agent claude`"
+		return result
+	}
+
+	var found, missing []string
+	for _, command := range commands {
+		if _, err := d.lookPath(command); err != nil {
+			missing = append(missing, command)
+			continue
+		}
+		found = append(found, command)
+	}
+	if len(missing) > 0 {
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("not found on PATH: %s", strings.Join(missing, ", "))
+		result.Hint = "install the agent CLI or fix phase.<name>.command"
+		return result
+	}
+	result.Status = DoctorPass
+	result.Message = fmt.Sprintf("found on PATH: %s", strings.Join(found, ", "))
+	return result
+}
+
+// agentCommands はフェーズとプロファイルに設定されたコマンドを重複なく返す
+// テンプレートを含むコマンドは実行時まで決まらないため除く
+func (d *Doctor) agentCommands() []string {
+	seen := make(map[string]bool)
+	add := func(phases config.PhaseConfig) {
+		for _, phase := range commandPhases() {
+			command := phases.ForPhase(phase).Command
+			if command == "" || strings.Contains(command, "{{") {
+				continue
+			}
+			seen[command] = true
+		}
+	}
+	add(d.config.Phase)
+	for _, profile := range d.config.Profiles {
+		add(profile)
+	}
+
+	commands := make([]string, 0, len(seen))
+	for command := range seen {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
+
+func (d *Doctor) checkTemplates() DoctorResult {
+	preset, _ := config.GetAgentPreset(config.DefaultAgent)
+	dir := filepath.Join(d.workDir, preset.PromptDir)
+	result := DoctorResult{Name: "command templates"}
+
+	usesClaude := false
+	for _, command := range d.agentCommands() {
+		if filepath.Base(command) == preset.Command {
+			usesClaude = true
+		}
+	}
+	if !usesClaude {
+		result.Status = DoctorPass
+		result.Message = "skipped: the phase commands do not use " + preset.Command
+		return result
+	}
+
+	statuses, err := d.templates.Status(dir)
+	if err != nil {
+		result.Status = DoctorFail
+		result.Message = err.Error()
+		return result
+	}
+
+	var missing, outdated, conflicts, customized []string
+	for _, status := range statuses {
+		switch status.State {
+		case config.TemplateMissing:
+			missing = append(missing, status.Name)
+		case config.TemplateOutdated, config.TemplateNeedsMerge, config.TemplateUntracked:
+			outdated = append(outdated, status.Name)
+		case config.TemplateConflict:
+			conflicts = append(conflicts, status.Name)
+		case config.TemplateCustomized:
+			customized = append(customized, status.Name)
+		}
+	}
+
+	relDir := preset.PromptDir
+	switch {
+	case len(missing) > 0:
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("missing in %s: %s", relDir, strings.Join(missing, ", "))
+		result.Hint = "`soba doctor --fix` installs the missing templates"
+		result.fix = func(ctx context.Context) (string, error) {
+			// 既存のファイルは上書きしない
+			if err := d.templates.CopyTemplates(dir); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("installed %s in %s", strings.Join(missing, ", "), relDir), nil
+		}
+	case len(conflicts) > 0:
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("unresolved conflict markers in %s: %s", relDir, strings.Join(conflicts, ", "))
+		result.Hint = "resolve the conflicts left by `soba templates upgrade`"
+	case len(outdated) > 0:
+		result.Status = DoctorWarn
+		result.Message = fmt.Sprintf("differ from this soba version in %s: %s", relDir, strings.Join(outdated, ", "))
+		result.Hint = "`soba templates diff` shows the differences; `soba templates upgrade` upgrades them and merges your changes"
+	default:
+		result.Status = DoctorPass
+		result.Message = fmt.Sprintf("%d templates in %s are up to date", len(statuses), relDir)
+		if len(customized) > 0 {
+			result.Message += fmt.Sprintf(" (customized: %s)", strings.Join(customized, ", "))
+		}
+	}
+	return result
+}
+
+func (d *Doctor) checkToken(ctx context.Context) DoctorResult {
+	result := DoctorResult{Name: "github token"}
+	if d.tokenProvider == nil {
+		result.Status = DoctorFail
+		result.Message = "no token provider"
+		return result
+	}
+	if _, err := d.tokenProvider.GetToken(ctx); err != nil {
+		result.Status = DoctorFail
+		result.Message = "no GitHub token from `gh auth token` or GITHUB_TOKEN"
+		result.Hint = "run `gh auth login` or export GITHUB_TOKEN"
+		return result
+	}
+	result.Status = DoctorPass
+	result.Message = "token available"
+	return result
+}
+
+func (d *Doctor) checkTokenScopes(ctx context.Context) DoctorResult {
+	result := DoctorResult{Name: "token scopes"}
+	owner, repo, err := splitRepository(d.config.GitHub.Repository)
+	if err != nil {
+		return skippedResult(result.Name, "github.repository is not set")
+	}
+	if d.github == nil {
+		result.Status = DoctorFail
+		result.Message = "GitHub client is not available"
+		return result
+	}
+
+	scopes, reported, err := d.github.GetTokenScopes(ctx, owner, repo)
+	if err != nil {
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("cannot access %s: %v", d.config.GitHub.Repository, err)
+		result.Hint = "check github.repository and that the token can read the repository"
+		return result
+	}
+	if !reported {
+		result.Status = DoctorPass
+		result.Message = "the token does not report scopes (fine-grained or app token); repository access works"
+		return result
+	}
+
+	has := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		has[scope] = true
+	}
+	switch {
+	case has["repo"]:
+		result.Status = DoctorPass
+		result.Message = "scopes: " + strings.Join(scopes, ", ")
+	case has["public_repo"]:
+		result.Status = DoctorWarn
+		result.Message = "only public_repo: soba cannot work on private repositories"
+		result.Hint = "run `gh auth refresh -s repo`"
+	default:
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("missing the repo scope (scopes: %s)", strings.Join(scopes, ", "))
+		result.Hint = "run `gh auth refresh -s repo`"
+	}
+	return result
+}
+
+func (d *Doctor) checkLabels(ctx context.Context) DoctorResult {
+	result := DoctorResult{Name: "labels"}
+	owner, repo, err := splitRepository(d.config.GitHub.Repository)
+	if err != nil {
+		return skippedResult(result.Name, "github.repository is not set")
+	}
+	if d.github == nil {
+		result.Status = DoctorFail
+		result.Message = "GitHub client is not available"
+		return result
+	}
+
+	labels, err := d.github.ListLabels(ctx, owner, repo)
+	if err != nil {
+		result.Status = DoctorFail
+		result.Message = fmt.Sprintf("failed to list labels: %v", err)
+		return result
+	}
+	existing := make(map[string]github.Label, len(labels))
+	for _, label := range labels {
+		existing[label.Name] = label
+	}
+
+	var missing, recolor []github.CreateLabelRequest
+	for _, want := range github.GetSobaLabels() {
+		label, ok := existing[want.Name]
+		switch {
+		case !ok:
+			missing = append(missing, want)
+		case !strings.EqualFold(label.Color, want.Color):
+			recolor = append(recolor, want)
+		}
+	}
+
+	if len(missing) == 0 && len(recolor) == 0 {
+		result.Status = DoctorPass
+		result.Message = fmt.Sprintf("all %d soba labels exist", len(github.GetSobaLabels()))
+		return result
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		result.Status = DoctorFail
+		problems = append(problems, "missing "+labelRequestNames(missing))
+	} else {
+		result.Status = DoctorWarn
+	}
+	if len(recolor) > 0 {
+		problems = append(problems, "wrong color "+labelRequestNames(recolor))
+	}
+	result.Message = strings.Join(problems, "; ")
+	result.Hint = "`soba doctor --fix` creates missing labels and resets their colors"
+	result.fix = func(ctx context.Context) (string, error) {
+		for _, label := range missing {
+			if _, err := d.github.CreateLabel(ctx, owner, repo, label); err != nil {
+				return "", fmt.Errorf("create %s: %w", label.Name, err)
+			}
+		}
+		for _, label := range recolor {
+			if _, err := d.github.UpdateLabel(ctx, owner, repo, label.Name, label); err != nil {
+				return "", fmt.Errorf("update %s: %w", label.Name, err)
+			}
+		}
+		return fmt.Sprintf("created %d and updated %d labels", len(missing), len(recolor)), nil
+	}
+	return result
+}
+
+func (d *Doctor) checkSlack() DoctorResult {
+	slack := d.config.Slack
+	result := DoctorResult{Name: "slack webhook"}
+	switch {
+	case slack.WebhookURL == "" && slack.NotificationsEnabled:
+		result.Status = DoctorFail
+		result.Message = "slack.notifications_enabled is set but slack.webhook_url is empty"
+		result.Hint = "set slack.webhook_url or disable notifications"
+	case slack.WebhookURL == "":
+		result.Status = DoctorPass
+		result.Message = "not configured"
+	case strings.Contains(slack.WebhookURL, "${"):
+		result.Status = DoctorFail
+		result.Message = "slack.webhook_url references an environment variable that is not set"
+		result.Hint = "export the variable before starting soba"
+	case !slackWebhookPattern.MatchString(slack.WebhookURL):
+		result.Status = DoctorWarn
+		result.Message = "slack.webhook_url does not look like https://hooks.slack.com/services/..."
+		result.Hint = "copy the Incoming Webhook URL from the Slack app settings"
+	case !slack.NotificationsEnabled:
+		result.Status = DoctorPass
+		result.Message = "webhook configured, notifications disabled"
+	default:
+		result.Status = DoctorPass
+		result.Message = "webhook configured"
+	}
+	return result
+}
+
+// skippedResult は前提となる項目が失敗したため診断しなかった項目の結果
+func skippedResult(name, reason string) DoctorResult {
+	return DoctorResult{Name: name, Status: DoctorWarn, Message: "skipped: " + reason}
+}
+
+// labelRequestNames はラベル名をカンマ区切りで返す
+func labelRequestNames(labels []github.CreateLabelRequest) string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseVersion はバージョン表記から最初のメジャー・マイナーバージョンを取り出す
+func parseVersion(version string) (int, int, bool) {
+	match := versionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return 0, 0, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	return major, minor, true
+}
+
+func formatVersion(version [2]int) string {
+	return fmt.Sprintf("%d.%d", version[0], version[1])
+}
+
+// runDoctorCommand はコマンドを実行し、標準出力と標準エラーを返す
+func runDoctorCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
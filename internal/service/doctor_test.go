@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+type doctorTokenProvider struct {
+	err error
+}
+
+func (p *doctorTokenProvider) GetToken(ctx context.Context) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	return "token", nil
+}
+
+type fakeDoctorGitHubClient struct {
+	scopes   []string
+	reported bool
+	labels   []github.Label
+	created  []string
+	updated  []string
+}
+
+func (c *fakeDoctorGitHubClient) GetTokenScopes(ctx context.Context, owner, repo string) ([]string, bool, error) {
+	return c.scopes, c.reported, nil
+}
+
+func (c *fakeDoctorGitHubClient) ListLabels(ctx context.Context, owner, repo string) ([]github.Label, error) {
+	return c.labels, nil
+}
+
+func (c *fakeDoctorGitHubClient) CreateLabel(ctx context.Context, owner, repo string, request github.CreateLabelRequest) (*github.Label, error) {
+	c.created = append(c.created, request.Name)
+	return &github.Label{Name: request.Name, Color: request.Color}, nil
+}
+
+func (c *fakeDoctorGitHubClient) UpdateLabel(ctx context.Context, owner, repo, name string, request github.CreateLabelRequest) (*github.Label, error) {
+	c.updated = append(c.updated, name)
+	return &github.Label{Name: name, Color: request.Color}, nil
+}
+
+// allSobaLabels は正しい色のsobaラベルをすべて返す
+func allSobaLabels() []github.Label {
+	var labels []github.Label
+	for _, label := range github.GetSobaLabels() {
+		labels = append(labels, github.Label{Name: label.Name, Color: label.Color})
+	}
+	return labels
+}
+
+// newTestDoctor は問題のない環境を想定したDoctorを作成する
+func newTestDoctor(t *testing.T, configYAML string, client *fakeDoctorGitHubClient) (*Doctor, string) {
+	workDir := t.TempDir()
+	configPath := filepath.Join(workDir, ".soba", "config.yml")
+	if configYAML != "" {
+		require.NoError(t, os.MkdirAll(filepath.Dir(configPath), 0755))
+		require.NoError(t, os.WriteFile(configPath, []byte(configYAML), 0600))
+	}
+
+	d := NewDoctor(config.Layers{Project: configPath}, workDir, &doctorTokenProvider{}, client)
+	d.runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		switch name + " " + strings.Join(args, " ") {
+		case "tmux -V":
+			return []byte("tmux 3.4\n"), nil
+		case "git --version":
+			return []byte("git version 2.43.0\n"), nil
+		case "git -C " + workDir + " worktree list",
+			"git -C " + workDir + " rev-parse --verify --quiet refs/remotes/origin/main":
+			return nil, nil
+		}
+		return nil, errors.New("exit status 1")
+	}
+	d.lookPath = func(file string) (string, error) {
+		if file == "claude" {
+			return "/usr/local/bin/claude", nil
+		}
+		return "", errors.New("not found")
+	}
+	return d, workDir
+}
+
+const doctorTestConfig = `version: 2
+github:
+  repository: owner/repo
+phase:
+  plan:
+    command: claude
+  implement:
+    command: claude
+`
+
+func doctorResult(t *testing.T, results []DoctorResult, name string) DoctorResult {
+	for _, result := range results {
+		if result.Name == name {
+			return result
+		}
+	}
+	t.Fatalf("no result for %s", name)
+	return DoctorResult{}
+}
+
+func TestDoctor_Run(t *testing.T) {
+	t.Run("問題がなければすべてpass", func(t *testing.T) {
+		client := &fakeDoctorGitHubClient{scopes: []string{"repo", "workflow"}, reported: true, labels: allSobaLabels()}
+		d, workDir := newTestDoctor(t, doctorTestConfig, client)
+		require.NoError(t, config.GetClaudeCommandsManager().CopyTemplates(filepath.Join(workDir, ".claude", "commands", "soba")))
+		require.NoError(t, os.MkdirAll(filepath.Join(workDir, config.DefaultWorktreeBasePath), 0755))
+
+		results := d.Run(context.Background(), false)
+
+		for _, result := range results {
+			assert.Equal(t, DoctorPass, result.Status, "%s: %s", result.Name, result.Message)
+		}
+		assert.Equal(t, "tmux 3.4", doctorResult(t, results, "tmux").Message)
+		assert.Equal(t, "remotes/origin/main exists", doctorResult(t, results, "base branch").Message)
+	})
+
+	t.Run("カスタマイズしただけのテンプレートはpass", func(t *testing.T) {
+		d, workDir := newTestDoctor(t, doctorTestConfig, &fakeDoctorGitHubClient{})
+		templateDir := filepath.Join(workDir, ".claude", "commands", "soba")
+		require.NoError(t, config.GetClaudeCommandsManager().CopyTemplates(templateDir))
+		require.NoError(t, os.WriteFile(filepath.Join(templateDir, "review.md"), []byte("customized"), 0644))
+
+		result := doctorResult(t, d.Run(context.Background(), false), "command templates")
+
+		assert.Equal(t, DoctorPass, result.Status)
+		assert.Equal(t, "4 templates in .claude/commands/soba are up to date (customized: review.md)", result.Message)
+	})
+
+	t.Run("設定ファイルがない場合はfail", func(t *testing.T) {
+		d, _ := newTestDoctor(t, "", &fakeDoctorGitHubClient{})
+
+		result := doctorResult(t, d.Run(context.Background(), false), "config")
+
+		assert.Equal(t, DoctorFail, result.Status)
+		assert.Contains(t, result.Hint, "soba init")
+	})
+
+	t.Run("設定が不正な場合はconfig validateを案内する", func(t *testing.T) {
+		d, _ := newTestDoctor(t, doctorTestConfig+"workflow:\n  intervall: 5\n", &fakeDoctorGitHubClient{})
+
+		result := doctorResult(t, d.Run(context.Background(), false), "config")
+
+		assert.Equal(t, DoctorFail, result.Status)
+		assert.Equal(t, "1 problems found in .soba/config.yml", result.Message)
+		assert.Contains(t, result.Hint, "soba config validate")
+	})
+
+	t.Run("古い形式の設定はconfig migrateを案内する", func(t *testing.T) {
+		d, _ := newTestDoctor(t, strings.TrimPrefix(doctorTestConfig, "version: 2\n"), &fakeDoctorGitHubClient{})
+
+		result := doctorResult(t, d.Run(context.Background(), false), "config")
+
+		assert.Equal(t, DoctorWarn, result.Status)
+		assert.Equal(t, ".soba/config.yml uses config version 1 (current 2)", result.Message)
+		assert.Contains(t, result.Hint, "soba config migrate")
+	})
+
+	t.Run("依存コマンドの問題を報告する", func(t *testing.T) {
+		d, _ := newTestDoctor(t, doctorTestConfig+"  review:\n    command: codex\n", &fakeDoctorGitHubClient{reported: false, labels: allSobaLabels()})
+		base := d.runCommand
+		d.runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			switch name {
+			case "tmux":
+				return nil, errors.New("executable file not found")
+			case "git":
+				if len(args) == 1 {
+					return []byte("git version 2.11.0"), nil
+				}
+			}
+			return base(ctx, name, args...)
+		}
+
+		results := d.Run(context.Background(), false)
+
+		assert.Equal(t, DoctorFail, doctorResult(t, results, "tmux").Status)
+		assert.Equal(t, DoctorFail, doctorResult(t, results, "git").Status)
+		agent := doctorResult(t, results, "agent")
+		assert.Equal(t, DoctorFail, agent.Status)
+		assert.Equal(t, "not found on PATH: codex", agent.Message)
+		assert.Equal(t, DoctorPass, doctorResult(t, results, "token scopes").Status)
+	})
+
+	t.Run("トークンがない場合はGitHubの項目を飛ばす", func(t *testing.T) {
+		d, _ := newTestDoctor(t, doctorTestConfig, &fakeDoctorGitHubClient{})
+		d.tokenProvider = &doctorTokenProvider{err: errors.New("no token")}
+
+		results := d.Run(context.Background(), false)
+
+		assert.Equal(t, DoctorFail, doctorResult(t, results, "github token").Status)
+		assert.Equal(t, "skipped: no GitHub token", doctorResult(t, results, "labels").Message)
+	})
+
+	t.Run("repoスコープがない場合はfail", func(t *testing.T) {
+		d, _ := newTestDoctor(t, doctorTestConfig, &fakeDoctorGitHubClient{scopes: []string{"read:org"}, reported: true, labels: allSobaLabels()})
+
+		result := doctorResult(t, d.Run(context.Background(), false), "token scopes")
+
+		assert.Equal(t, DoctorFail, result.Status)
+		assert.Contains(t, result.Hint, "gh auth refresh -s repo")
+	})
+
+	t.Run("Slackのwebhook URLの形式を確認する", func(t *testing.T) {
+		d, _ := newTestDoctor(t, doctorTestConfig+"slack:\n  webhook_url: https://example.com/hook\n  notifications_enabled: true\n", &fakeDoctorGitHubClient{})
+
+		result := doctorResult(t, d.Run(context.Background(), false), "slack webhook")
+
+		assert.Equal(t, DoctorWarn, result.Status)
+	})
+}
+
+func TestDoctor_RunFix(t *testing.T) {
+	labels := allSobaLabels()
+	var existing []github.Label
+	for _, label := range labels {
+		switch label.Name {
+		case "soba:paused":
+			continue
+		case "soba:ready":
+			label.Color = "000000"
+		}
+		existing = append(existing, label)
+	}
+	client := &fakeDoctorGitHubClient{reported: false, labels: existing}
+	d, workDir := newTestDoctor(t, doctorTestConfig, client)
+	templateDir := filepath.Join(workDir, ".claude", "commands", "soba")
+	require.NoError(t, os.MkdirAll(templateDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(templateDir, "plan.md"), []byte("customized"), 0644))
+
+	results := d.Run(context.Background(), false)
+	labelResult := doctorResult(t, results, "labels")
+	assert.Equal(t, DoctorFail, labelResult.Status)
+	assert.Equal(t, "missing soba:paused; wrong color soba:ready", labelResult.Message)
+	assert.True(t, labelResult.Fixable)
+	assert.Equal(t, DoctorWarn, doctorResult(t, results, "worktree path").Status)
+	assert.Equal(t, DoctorFail, doctorResult(t, results, "command templates").Status)
+	assert.Empty(t, client.created)
+
+	results = d.Run(context.Background(), true)
+
+	labelResult = doctorResult(t, results, "labels")
+	assert.Equal(t, DoctorPass, labelResult.Status)
+	assert.True(t, labelResult.Fixed)
+	assert.Equal(t, []string{"soba:paused"}, client.created)
+	assert.Equal(t, []string{"soba:ready"}, client.updated)
+	assert.DirExists(t, filepath.Join(workDir, config.DefaultWorktreeBasePath))
+	assert.True(t, doctorResult(t, results, "command templates").Fixed)
+
+	// カスタマイズしたテンプレートは上書きしない
+	plan, err := os.ReadFile(filepath.Join(templateDir, "plan.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "customized", string(plan))
+	// plan.mdはマニフェスト以前から変わっていないので、カスタマイズとして扱う
+	templateResult := doctorResult(t, d.Run(context.Background(), false), "command templates")
+	assert.Equal(t, DoctorPass, templateResult.Status)
+	assert.Contains(t, templateResult.Message, "(customized: plan.md)")
+}
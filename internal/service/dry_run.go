@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/git"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/tmux"
+	"github.com/douhashi/soba/internal/service/builder"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// dryRunCommentWidth は出力するコメント本文の最大文字数
+const dryRunCommentWidth = 80
+
+// DryRunRecorder はドライランで実行する代わりに記録した操作を出力する
+// ラベルが実際には変わらないため同じ操作が毎サイクル繰り返されるが、出力は初回だけにする
+type DryRunRecorder struct {
+	mu   sync.Mutex
+	out  io.Writer
+	seen map[string]bool
+	now  func() time.Time
+}
+
+// NewDryRunRecorder はoutに操作を出力するDryRunRecorderを作成する
+func NewDryRunRecorder(out io.Writer) *DryRunRecorder {
+	return &DryRunRecorder{
+		out:  out,
+		seen: make(map[string]bool),
+		now:  time.Now,
+	}
+}
+
+// Record は実行しなかった操作を1行出力する
+func (r *DryRunRecorder) Record(format string, args ...interface{}) {
+	action := fmt.Sprintf(format, args...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen[action] {
+		return
+	}
+	r.seen[action] = true
+	fmt.Fprintf(r.out, "[dry-run] %s %s\n", r.now().Format("15:04:05"), action)
+}
+
+// DecorateGitHubClient はGitHubへの変更操作を記録するクライアントで包む
+func (r *DryRunRecorder) DecorateGitHubClient(client builder.GitHubClientInterface) builder.GitHubClientInterface {
+	if c, ok := client.(GitHubClientInterface); ok && c != nil {
+		return &dryRunGitHubClient{GitHubClientInterface: c, recorder: r}
+	}
+	return client
+}
+
+// DecorateGitClient はworktreeとブランチの変更操作を記録するクライアントで包む
+func (r *DryRunRecorder) DecorateGitClient(client *git.Client) interface{} {
+	if client == nil {
+		return client
+	}
+	return &dryRunGitClient{Client: client, recorder: r}
+}
+
+// DecorateTmuxClient はtmuxへの変更操作を記録するクライアントで包む
+func (r *DryRunRecorder) DecorateTmuxClient(client tmux.TmuxClient) tmux.TmuxClient {
+	return &dryRunTmuxClient{
+		TmuxClient: client,
+		recorder:   r,
+		sessions:   make(map[string]bool),
+		windows:    make(map[string]bool),
+	}
+}
+
+// NewDryRunDaemonService は変更操作をoutに出力するだけのデーモンサービスを作成する
+// 監視とキューの判定は実際のGitHubのデータに対して行う
+func NewDryRunDaemonService(cfg *config.Config, logFactory *logging.Factory, out io.Writer) (DaemonService, error) {
+	recorder := NewDryRunRecorder(out)
+	service, err := builder.NewServiceBuilder(logFactory).
+		WithConfig(cfg).
+		WithClientDecorator(recorder).
+		Build(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build dry-run daemon service: %w", err)
+	}
+
+	adapter, ok := service.(*DaemonServiceAdapter)
+	if !ok {
+		return nil, fmt.Errorf("unexpected daemon service type: %T", service)
+	}
+	adapter.dryRun = recorder
+	return adapter, nil
+}
+
+// dryRunGitHubClient は読み取りをそのまま行い、変更操作は記録だけする
+type dryRunGitHubClient struct {
+	GitHubClientInterface
+	recorder *DryRunRecorder
+}
+
+func (c *dryRunGitHubClient) AddLabelToIssue(ctx context.Context, owner, repo string, issueNumber int, label string) error {
+	c.recorder.Record("add label %s to issue #%d", label, issueNumber)
+	return nil
+}
+
+func (c *dryRunGitHubClient) RemoveLabelFromIssue(ctx context.Context, owner, repo string, issueNumber int, label string) error {
+	c.recorder.Record("remove label %s from issue #%d", label, issueNumber)
+	return nil
+}
+
+func (c *dryRunGitHubClient) UpdateIssueLabels(ctx context.Context, owner, repo string, issueNumber int, labels []string) error {
+	c.recorder.Record("set labels of issue #%d to [%s]", issueNumber, strings.Join(labels, ", "))
+	return nil
+}
+
+func (c *dryRunGitHubClient) MergePullRequest(ctx context.Context, owner, repo string, number int, req *github.MergeRequest) (*github.MergeResponse, error) {
+	method := "merge"
+	if req != nil && req.MergeMethod != "" {
+		method = req.MergeMethod
+	}
+	c.recorder.Record("merge PR #%d (%s)", number, method)
+	return &github.MergeResponse{Merged: true, Message: "dry run"}, nil
+}
+
+func (c *dryRunGitHubClient) UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, expectedHeadSHA string) error {
+	c.recorder.Record("update the branch of PR #%d with the base branch", number)
+	return nil
+}
+
+func (c *dryRunGitHubClient) CreateComment(ctx context.Context, owner, repo string, issueNumber int, body string) error {
+	summary := strings.Join(strings.Fields(body), " ")
+	if len(summary) > dryRunCommentWidth {
+		summary = summary[:dryRunCommentWidth-3] + "..."
+	}
+	c.recorder.Record("comment on issue #%d: %s", issueNumber, summary)
+	return nil
+}
+
+func (c *dryRunGitHubClient) CloseIssue(ctx context.Context, owner, repo string, issueNumber int) error {
+	c.recorder.Record("close issue #%d", issueNumber)
+	return nil
+}
+
+func (c *dryRunGitHubClient) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	c.recorder.Record("delete remote branch %s", branch)
+	return nil
+}
+
+func (c *dryRunGitHubClient) CreateCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	c.recorder.Record("react %s to comment %d", content, commentID)
+	return nil
+}
+
+// dryRunGitClient はworktreeとブランチの状態を実際に読み、変更操作は記録だけする
+type dryRunGitClient struct {
+	*git.Client
+	recorder *DryRunRecorder
+}
+
+func (c *dryRunGitClient) CreateWorktree(worktreePath, branchName, baseBranch string) error {
+	c.recorder.Record("create worktree %s on branch %s from %s", worktreePath, branchName, baseBranch)
+	return nil
+}
+
+func (c *dryRunGitClient) RemoveWorktree(worktreePath string) error {
+	c.recorder.Record("remove worktree %s", worktreePath)
+	return nil
+}
+
+func (c *dryRunGitClient) UpdateBaseBranch(branch string) error {
+	c.recorder.Record("update base branch %s", branch)
+	return nil
+}
+
+func (c *dryRunGitClient) DeleteBranch(branchName string, force bool) error {
+	c.recorder.Record("delete local branch %s", branchName)
+	return nil
+}
+
+func (c *dryRunGitClient) PruneWorktrees() error {
+	c.recorder.Record("prune stale worktree entries")
+	return nil
+}
+
+func (c *dryRunGitClient) RebaseWorktree(worktreePath, baseBranch string) error {
+	c.recorder.Record("rebase worktree %s onto %s", worktreePath, baseBranch)
+	return nil
+}
+
+// dryRunTmuxClient はtmuxの変更操作を記録だけする
+// 作成したことにしたセッションとウィンドウは存在するものとして扱い、後続の処理を続けられるようにする
+type dryRunTmuxClient struct {
+	tmux.TmuxClient
+	recorder *DryRunRecorder
+	mu       sync.Mutex
+	sessions map[string]bool
+	windows  map[string]bool
+}
+
+func (c *dryRunTmuxClient) simulated(set map[string]bool, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return set[key]
+}
+
+func (c *dryRunTmuxClient) setSimulated(set map[string]bool, key string, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if exists {
+		set[key] = true
+	} else {
+		delete(set, key)
+	}
+}
+
+func (c *dryRunTmuxClient) CreateSession(sessionName string) error {
+	c.recorder.Record("create tmux session %s", sessionName)
+	c.setSimulated(c.sessions, sessionName, true)
+	return nil
+}
+
+func (c *dryRunTmuxClient) DeleteSession(sessionName string) error {
+	c.recorder.Record("delete tmux session %s", sessionName)
+	c.setSimulated(c.sessions, sessionName, false)
+	return nil
+}
+
+func (c *dryRunTmuxClient) KillSession(sessionName string) error {
+	c.recorder.Record("kill tmux session %s", sessionName)
+	c.setSimulated(c.sessions, sessionName, false)
+	return nil
+}
+
+func (c *dryRunTmuxClient) SessionExists(sessionName string) bool {
+	return c.simulated(c.sessions, sessionName) || c.TmuxClient.SessionExists(sessionName)
+}
+
+func (c *dryRunTmuxClient) CreateWindow(sessionName, windowName string) error {
+	c.recorder.Record("create tmux window %s:%s", sessionName, windowName)
+	c.setSimulated(c.windows, sessionName+":"+windowName, true)
+	return nil
+}
+
+func (c *dryRunTmuxClient) DeleteWindow(sessionName, windowName string) error {
+	c.recorder.Record("delete tmux window %s:%s", sessionName, windowName)
+	c.setSimulated(c.windows, sessionName+":"+windowName, false)
+	return nil
+}
+
+func (c *dryRunTmuxClient) WindowExists(sessionName, windowName string) (bool, error) {
+	if c.simulated(c.windows, sessionName+":"+windowName) {
+		return true, nil
+	}
+	return c.TmuxClient.WindowExists(sessionName, windowName)
+}
+
+func (c *dryRunTmuxClient) CreatePane(sessionName, windowName string) error {
+	c.recorder.Record("create a pane in tmux window %s:%s", sessionName, windowName)
+	return nil
+}
+
+func (c *dryRunTmuxClient) DeletePane(sessionName, windowName string, paneIndex int) error {
+	c.recorder.Record("delete pane %s:%s.%d", sessionName, windowName, paneIndex)
+	return nil
+}
+
+// ResizePanes はレイアウトの調整だけなので記録しない
+func (c *dryRunTmuxClient) ResizePanes(sessionName, windowName string) error {
+	return nil
+}
+
+// 作成したことにしたウィンドウは1ペインだけを持つものとして扱う
+func (c *dryRunTmuxClient) GetPaneCount(sessionName, windowName string) (int, error) {
+	if c.simulated(c.windows, sessionName+":"+windowName) {
+		return 1, nil
+	}
+	return c.TmuxClient.GetPaneCount(sessionName, windowName)
+}
+
+func (c *dryRunTmuxClient) GetFirstPaneIndex(sessionName, windowName string) (int, error) {
+	if c.simulated(c.windows, sessionName+":"+windowName) {
+		return 0, nil
+	}
+	return c.TmuxClient.GetFirstPaneIndex(sessionName, windowName)
+}
+
+func (c *dryRunTmuxClient) GetLastPaneIndex(sessionName, windowName string) (int, error) {
+	if c.simulated(c.windows, sessionName+":"+windowName) {
+		return 0, nil
+	}
+	return c.TmuxClient.GetLastPaneIndex(sessionName, windowName)
+}
+
+func (c *dryRunTmuxClient) SendCommand(sessionName, windowName string, paneIndex int, command string) error {
+	c.recorder.Record("send to pane %s:%s.%d: %s", sessionName, windowName, paneIndex, strings.TrimSpace(command))
+	return nil
+}
+
+func (c *dryRunTmuxClient) CapturePane(sessionName, windowName string, paneIndex int) (string, error) {
+	if c.simulated(c.windows, sessionName+":"+windowName) {
+		return "", nil
+	}
+	return c.TmuxClient.CapturePane(sessionName, windowName, paneIndex)
+}
+
+// PipePane はペイン出力のログを始めるだけなので記録しない
+func (c *dryRunTmuxClient) PipePane(sessionName, windowName string, paneIndex int, logPath string) error {
+	return nil
+}
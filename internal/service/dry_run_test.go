@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func newTestDryRunRecorder() (*DryRunRecorder, *bytes.Buffer) {
+	var out bytes.Buffer
+	recorder := NewDryRunRecorder(&out)
+	recorder.now = func() time.Time { return time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC) }
+	return recorder, &out
+}
+
+func TestDryRunRecorder_Record(t *testing.T) {
+	recorder, out := newTestDryRunRecorder()
+
+	recorder.Record("add label %s to issue #%d", "soba:queued", 12)
+	recorder.Record("add label %s to issue #%d", "soba:queued", 12)
+	recorder.Record("close issue #%d", 12)
+
+	assert.Equal(t, "[dry-run] 09:30:00 add label soba:queued to issue #12\n[dry-run] 09:30:00 close issue #12\n", out.String())
+}
+
+func TestDryRunGitHubClient(t *testing.T) {
+	recorder, out := newTestDryRunRecorder()
+	mutate := func(ctx context.Context, owner, repo string, issueNumber int, label string) error {
+		t.Fatalf("mutation must not reach GitHub")
+		return nil
+	}
+	inner := &MockGitHubClient{
+		addLabelFunc:    mutate,
+		removeLabelFunc: mutate,
+		getIssueLabelsFunc: func(ctx context.Context, owner, repo string, issueNumber int) ([]github.Label, error) {
+			return []github.Label{{Name: "soba:todo"}}, nil
+		},
+	}
+	client := recorder.DecorateGitHubClient(inner).(*dryRunGitHubClient)
+	ctx := context.Background()
+
+	labels, err := client.GetIssueLabels(ctx, "owner", "repo", 7)
+	require.NoError(t, err)
+	assert.Equal(t, []github.Label{{Name: "soba:todo"}}, labels)
+
+	require.NoError(t, client.RemoveLabelFromIssue(ctx, "owner", "repo", 7, "soba:todo"))
+	require.NoError(t, client.AddLabelToIssue(ctx, "owner", "repo", 7, "soba:queued"))
+	require.NoError(t, client.CreateComment(ctx, "owner", "repo", 7, "Plan\n\n"+strings.Repeat("x", 100)))
+	resp, err := client.MergePullRequest(ctx, "owner", "repo", 8, &github.MergeRequest{MergeMethod: "squash"})
+	require.NoError(t, err)
+	assert.True(t, resp.Merged)
+
+	output := out.String()
+	assert.Contains(t, output, "remove label soba:todo from issue #7")
+	assert.Contains(t, output, "add label soba:queued to issue #7")
+	assert.Contains(t, output, "comment on issue #7: Plan xxx")
+	assert.Contains(t, output, "...\n")
+	assert.Contains(t, output, "merge PR #8 (squash)")
+}
+
+func TestDryRunGitClient(t *testing.T) {
+	recorder, out := newTestDryRunRecorder()
+	client := &dryRunGitClient{recorder: recorder}
+
+	require.NoError(t, client.UpdateBaseBranch("main"))
+	require.NoError(t, client.CreateWorktree(".git/soba/worktrees/issue-3", "soba/3", "main"))
+	require.NoError(t, client.RebaseWorktree(".git/soba/worktrees/issue-3", "main"))
+	require.NoError(t, client.RemoveWorktree(".git/soba/worktrees/issue-3"))
+	require.NoError(t, client.DeleteBranch("soba/3", true))
+
+	output := out.String()
+	assert.Contains(t, output, "update base branch main")
+	assert.Contains(t, output, "create worktree .git/soba/worktrees/issue-3 on branch soba/3 from main")
+	assert.Contains(t, output, "rebase worktree .git/soba/worktrees/issue-3 onto main")
+	assert.Contains(t, output, "remove worktree .git/soba/worktrees/issue-3")
+	assert.Contains(t, output, "delete local branch soba/3")
+}
+
+func TestDryRunTmuxClient(t *testing.T) {
+	recorder, out := newTestDryRunRecorder()
+	inner := new(MockTmuxClient)
+	inner.On("SessionExists", "soba-owner-repo").Return(false)
+	inner.On("WindowExists", "soba-owner-repo", "issue-5").Return(false, nil)
+	client := recorder.DecorateTmuxClient(inner)
+
+	assert.False(t, client.SessionExists("soba-owner-repo"))
+	require.NoError(t, client.CreateSession("soba-owner-repo"))
+	assert.True(t, client.SessionExists("soba-owner-repo"))
+
+	exists, err := client.WindowExists("soba-owner-repo", "issue-5")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// 作成したことにしたウィンドウでは後続の操作を続けられる
+	require.NoError(t, client.CreateWindow("soba-owner-repo", "issue-5"))
+	exists, err = client.WindowExists("soba-owner-repo", "issue-5")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	paneIndex, err := client.GetLastPaneIndex("soba-owner-repo", "issue-5")
+	require.NoError(t, err)
+	require.NoError(t, client.SendCommand("soba-owner-repo", "issue-5", paneIndex, "claude \"/soba:plan 5\"\n"))
+
+	assert.Equal(t, strings.Join([]string{
+		"[dry-run] 09:30:00 create tmux session soba-owner-repo",
+		"[dry-run] 09:30:00 create tmux window soba-owner-repo:issue-5",
+		`[dry-run] 09:30:00 send to pane soba-owner-repo:issue-5.0: claude "/soba:plan 5"`,
+		"",
+	}, "\n"), out.String())
+	inner.AssertExpectations(t)
+}
+
+func TestWorkGuard_RecordEnqueueDryRun(t *testing.T) {
+	recorder, out := newTestDryRunRecorder()
+	guard, _ := newTestWorkGuard(t, config.WorkflowConfig{
+		Budget: config.BudgetConfig{MaxIssuesPerDay: 5},
+	}, time.Now())
+	guard.SetDryRun(recorder)
+
+	guard.RecordEnqueue(context.Background(), 4)
+
+	_, err := os.Stat(guard.ledgerPath)
+	assert.True(t, os.IsNotExist(err))
+	assert.Contains(t, out.String(), "record issue #4 in the budget ledger")
+}
+
+func TestIssueWatcher_SetDryRun(t *testing.T) {
+	recorder, _ := newTestDryRunRecorder()
+	executor := NewWorkflowExecutor(new(MockTmuxClient), nil, nil, logging.NewMockLogger()).(*workflowExecutor)
+	watcher := NewIssueWatcher(&MockGitHubClient{}, &config.Config{})
+	watcher.SetWorkflowExecutor(executor)
+
+	watcher.SetDryRun(recorder)
+
+	assert.Same(t, recorder, watcher.guard.dryRun)
+	assert.Same(t, recorder, executor.dryRun)
+	assert.Nil(t, executor.transcripts)
+}
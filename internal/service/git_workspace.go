@@ -14,7 +14,7 @@ type GitWorkspaceManager interface {
 	// PrepareWorkspace は指定されたissue番号に対応するワークスペースを準備する
 	PrepareWorkspace(issueNumber int) error
 
-	// CleanupWorkspace は指定されたissue番号に対応するワークスペースをクリーンアップする
+	// CleanupWorkspace は指定されたissue番号に対応するワークスペースとローカルブランチを削除する
 	CleanupWorkspace(issueNumber int) error
 }
 
@@ -24,6 +24,8 @@ type GitClient interface {
 	RemoveWorktree(worktreePath string) error
 	UpdateBaseBranch(branch string) error
 	WorktreeExists(worktreePath string) bool
+	BranchExists(branchName string) (bool, error)
+	DeleteBranch(branchName string, force bool) error
 }
 
 // gitWorkspaceManager は GitWorkspaceManager の実装
@@ -68,23 +70,31 @@ func (g *gitWorkspaceManager) PrepareWorkspace(issueNumber int) error {
 	return nil
 }
 
-// CleanupWorkspace は指定されたissue番号に対応するワークスペースをクリーンアップする
+// CleanupWorkspace は指定されたissue番号に対応するワークスペースとローカルブランチを削除する
 func (g *gitWorkspaceManager) CleanupWorkspace(issueNumber int) error {
 	if issueNumber <= 0 {
 		return errors.New("invalid issue number")
 	}
 
 	worktreePath := g.getWorkspacePath(issueNumber)
+	branchName := g.getBranchName(issueNumber)
 
-	// Check if worktree exists
-	if !g.gitClient.WorktreeExists(worktreePath) {
-		// Worktree does not exist, nothing to cleanup
-		return nil
+	// Remove worktree if it exists
+	if g.gitClient.WorktreeExists(worktreePath) {
+		if err := g.gitClient.RemoveWorktree(worktreePath); err != nil {
+			return fmt.Errorf("failed to remove worktree: %w", err)
+		}
 	}
 
-	// Remove worktree
-	if err := g.gitClient.RemoveWorktree(worktreePath); err != nil {
-		return fmt.Errorf("failed to remove worktree: %w", err)
+	// Remove the issue branch; squash merges leave it unmerged, so force is required
+	exists, err := g.gitClient.BranchExists(branchName)
+	if err != nil {
+		return fmt.Errorf("failed to check branch: %w", err)
+	}
+	if exists {
+		if err := g.gitClient.DeleteBranch(branchName, true); err != nil {
+			return fmt.Errorf("failed to delete branch: %w", err)
+		}
 	}
 
 	return nil
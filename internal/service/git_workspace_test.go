@@ -36,6 +36,16 @@ func (m *mockGitClient) WorktreeExists(worktreePath string) bool {
 	return args.Bool(0)
 }
 
+func (m *mockGitClient) BranchExists(branchName string) (bool, error) {
+	args := m.Called(branchName)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockGitClient) DeleteBranch(branchName string, force bool) error {
+	args := m.Called(branchName, force)
+	return args.Error(0)
+}
+
 func TestNewGitWorkspaceManager(t *testing.T) {
 	cfg := &config.Config{
 		Git: config.GitConfig{
@@ -143,6 +153,8 @@ func TestGitWorkspaceManager_CleanupWorkspace(t *testing.T) {
 				expectedPath := filepath.Join(".git/soba/worktrees", "issue-33")
 				mc.On("WorktreeExists", expectedPath).Return(true)
 				mc.On("RemoveWorktree", expectedPath).Return(nil)
+				mc.On("BranchExists", "soba/33").Return(true, nil)
+				mc.On("DeleteBranch", "soba/33", true).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -152,9 +164,32 @@ func TestGitWorkspaceManager_CleanupWorkspace(t *testing.T) {
 			setupMocks: func(mc *mockGitClient) {
 				expectedPath := filepath.Join(".git/soba/worktrees", "issue-33")
 				mc.On("WorktreeExists", expectedPath).Return(false)
+				mc.On("BranchExists", "soba/33").Return(false, nil)
 			},
 			wantErr: false,
 		},
+		{
+			name:        "Only the branch remains",
+			issueNumber: 33,
+			setupMocks: func(mc *mockGitClient) {
+				expectedPath := filepath.Join(".git/soba/worktrees", "issue-33")
+				mc.On("WorktreeExists", expectedPath).Return(false)
+				mc.On("BranchExists", "soba/33").Return(true, nil)
+				mc.On("DeleteBranch", "soba/33", true).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:        "Failed to delete branch",
+			issueNumber: 33,
+			setupMocks: func(mc *mockGitClient) {
+				expectedPath := filepath.Join(".git/soba/worktrees", "issue-33")
+				mc.On("WorktreeExists", expectedPath).Return(false)
+				mc.On("BranchExists", "soba/33").Return(true, nil)
+				mc.On("DeleteBranch", "soba/33", true).Return(assert.AnError)
+			},
+			wantErr: true,
+		},
 		{
 			name:        "Failed to remove worktree",
 			issueNumber: 33,
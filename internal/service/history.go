@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// HistoryDir はIssueごとのイベント履歴を追記するディレクトリ
+const HistoryDir = ".soba/history"
+
+// 履歴に記録するイベントの種類
+const (
+	HistoryEnqueued          = "enqueued"
+	HistoryPhaseStarted      = "phase_started"
+	HistoryCommandDispatched = "command_dispatched"
+	HistoryPhaseFinished     = "phase_finished"
+	HistoryLabelChanged      = "label_changed"
+	HistoryFailed            = "failed"
+	HistoryMerged            = "merged"
+	HistoryCleanedUp         = "cleaned_up"
+)
+
+// HistoryEvent はsobaがIssueに対して行ったこと、または観測したこと1件
+type HistoryEvent struct {
+	Time    time.Time `json:"time"`
+	Issue   int       `json:"issue"`
+	Type    string    `json:"type"`
+	Phase   string    `json:"phase,omitempty"`
+	Added   []string  `json:"added,omitempty"`
+	Removed []string  `json:"removed,omitempty"`
+	Command string    `json:"command,omitempty"`
+	PR      int       `json:"pr,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+}
+
+// EventHistory はイベントをIssueごとのJSON Linesファイルに追記する
+// ログファイルとは別に保存するため、再起動やログのローテーション後も残る
+// nilの場合は何もしない
+type EventHistory struct {
+	dir    string
+	logger logging.Logger
+	now    func() time.Time
+}
+
+// NewEventHistory はdirにイベントを記録するEventHistoryを作成する
+func NewEventHistory(dir string, logger logging.Logger) *EventHistory {
+	if logger == nil {
+		logger = logging.NewMockLogger()
+	}
+	return &EventHistory{
+		dir:    dir,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// historyFile はIssueの履歴ファイルのパスを返す
+func historyFile(dir string, issueNumber int) string {
+	return filepath.Join(dir, fmt.Sprintf("issue-%d.jsonl", issueNumber))
+}
+
+// Record はイベントを追記する。記録に失敗しても処理は続けるため、警告を出すだけにする
+func (h *EventHistory) Record(ctx context.Context, event HistoryEvent) {
+	if h == nil || event.Issue <= 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = h.now()
+	}
+
+	if err := appendJSONLine(historyFile(h.dir, event.Issue), event); err != nil {
+		h.logger.Warn(ctx, "Failed to record history event",
+			logging.Field{Key: "error", Value: err.Error()},
+			logging.Field{Key: "issue", Value: event.Issue},
+			logging.Field{Key: "type", Value: event.Type},
+		)
+	}
+}
+
+// RecordFirstSeen は履歴のないIssueに付いていたsoba:todoを、初めて観測した時刻に付いたものとして記録する
+// レポートのキュー待ち時間とリードタイムの起点になる。再起動のたびに記録しないよう、履歴のあるIssueでは何もしない
+func (h *EventHistory) RecordFirstSeen(ctx context.Context, issue github.Issue) {
+	if h == nil || issue.Number <= 0 {
+		return
+	}
+	hasTodo := false
+	for _, label := range issue.Labels {
+		hasTodo = hasTodo || label.Name == domain.LabelTodo
+	}
+	if !hasTodo {
+		return
+	}
+	if _, err := os.Stat(historyFile(h.dir, issue.Number)); !os.IsNotExist(err) {
+		return
+	}
+	h.Record(ctx, HistoryEvent{Issue: issue.Number, Type: HistoryLabelChanged, Added: []string{domain.LabelTodo}, Detail: "first seen"})
+}
+
+// LoadIssueHistory はIssueの履歴を時刻順に読み込む。記録がない場合は空を返す
+func LoadIssueHistory(dir string, issueNumber int) ([]HistoryEvent, error) {
+	events, err := readJSONLines[HistoryEvent](historyFile(dir, issueNumber))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events, nil
+}
+
+// labelChangeEvent は変更前後のラベルから付いたラベルと外れたラベルを求める
+// sobaのラベル以外も含める。変化がない場合はfalseを返す
+func labelChangeEvent(previous, current github.Issue) (HistoryEvent, bool) {
+	before := make(map[string]bool, len(previous.Labels))
+	for _, label := range previous.Labels {
+		before[label.Name] = true
+	}
+	after := make(map[string]bool, len(current.Labels))
+	for _, label := range current.Labels {
+		after[label.Name] = true
+	}
+
+	event := HistoryEvent{Issue: current.Number, Type: HistoryLabelChanged}
+	for _, label := range current.Labels {
+		if !before[label.Name] {
+			event.Added = append(event.Added, label.Name)
+		}
+	}
+	for _, label := range previous.Labels {
+		if !after[label.Name] {
+			event.Removed = append(event.Removed, label.Name)
+		}
+	}
+	return event, len(event.Added) > 0 || len(event.Removed) > 0
+}
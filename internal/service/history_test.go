@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestEventHistory_RecordAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	history := NewEventHistory(dir, logging.NewMockLogger())
+	base := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	history.now = func() time.Time { return base }
+
+	ctx := context.Background()
+	history.Record(ctx, HistoryEvent{Issue: 7, Type: HistoryPhaseStarted, Phase: "plan", Time: base.Add(time.Minute)})
+	history.Record(ctx, HistoryEvent{Issue: 7, Type: HistoryEnqueued})
+	history.Record(ctx, HistoryEvent{Issue: 8, Type: HistoryEnqueued})
+	history.Record(ctx, HistoryEvent{Issue: 0, Type: HistoryMerged})
+
+	events, err := LoadIssueHistory(dir, 7)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, HistoryEnqueued, events[0].Type)
+	assert.Equal(t, base, events[0].Time)
+	assert.Equal(t, HistoryPhaseStarted, events[1].Type)
+	assert.Equal(t, "plan", events[1].Phase)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	t.Run("missing history is empty", func(t *testing.T) {
+		events, err := LoadIssueHistory(dir, 99)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
+
+func TestEventHistory_NilIsNoop(t *testing.T) {
+	var history *EventHistory
+	assert.NotPanics(t, func() {
+		history.Record(context.Background(), HistoryEvent{Issue: 1, Type: HistoryEnqueued})
+	})
+}
+
+func TestEventHistory_RecordFirstSeen(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	history := NewEventHistory(dir, logging.NewMockLogger())
+	base := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	history.now = func() time.Time { return base }
+	ctx := context.Background()
+	todo := github.Issue{Number: 7, Labels: []github.Label{{Name: "bug"}, {Name: "soba:todo"}}}
+
+	t.Run("初めて見たsoba:todoのIssueはラベルが付いたものとして記録する", func(t *testing.T) {
+		history.RecordFirstSeen(ctx, todo)
+
+		events, err := LoadIssueHistory(dir, 7)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, HistoryEvent{Time: base, Issue: 7, Type: HistoryLabelChanged, Added: []string{"soba:todo"}, Detail: "first seen"}, events[0])
+	})
+
+	t.Run("履歴のあるIssueは再起動後に記録し直さない", func(t *testing.T) {
+		history.now = func() time.Time { return base.Add(time.Hour) }
+		history.RecordFirstSeen(ctx, todo)
+
+		events, err := LoadIssueHistory(dir, 7)
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("soba:todoのないIssueは記録しない", func(t *testing.T) {
+		history.RecordFirstSeen(ctx, github.Issue{Number: 8, Labels: []github.Label{{Name: "soba:planning"}}})
+
+		events, err := LoadIssueHistory(dir, 8)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("キュー待ち時間とリードタイムをsoba:todoから測る", func(t *testing.T) {
+		history.Record(ctx, HistoryEvent{Time: base.Add(20 * time.Minute), Issue: 7, Type: HistoryEnqueued})
+		history.Record(ctx, HistoryEvent{Time: base.Add(2 * time.Hour), Issue: 7, Type: HistoryMerged, PR: 9})
+
+		timelines, err := LoadHistoryTimelines(dir)
+		require.NoError(t, err)
+		report := BuildWorkflowReport(timelines, ReportSourceHistory, base.Add(-time.Hour), base.Add(3*time.Hour))
+
+		assert.Equal(t, 1, report.QueueWait.Count)
+		assert.Equal(t, 20*time.Minute, report.QueueWait.Mean)
+		assert.Equal(t, 1, report.LeadTime.Count)
+		assert.Equal(t, 2*time.Hour, report.LeadTime.Mean)
+	})
+}
+
+func TestLabelChangeEvent(t *testing.T) {
+	previous := github.Issue{Number: 3, Labels: []github.Label{{Name: "soba:doing"}, {Name: "bug"}}}
+	current := github.Issue{Number: 3, Labels: []github.Label{{Name: "soba:review-requested"}, {Name: "bug"}}}
+
+	event, changed := labelChangeEvent(previous, current)
+	require.True(t, changed)
+	assert.Equal(t, 3, event.Issue)
+	assert.Equal(t, HistoryLabelChanged, event.Type)
+	assert.Equal(t, []string{"soba:review-requested"}, event.Added)
+	assert.Equal(t, []string{"soba:doing"}, event.Removed)
+
+	_, changed = labelChangeEvent(current, current)
+	assert.False(t, changed)
+}
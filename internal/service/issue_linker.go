@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// TimelineReader はIssue/PRのタイムラインを取得するインターフェース
+type TimelineReader interface {
+	ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error)
+}
+
+// closingKeywordPattern はPR本文中のIssueを閉じるキーワードにマッチする
+// https://docs.github.com/en/issues/tracking-your-work-with-issues/linking-a-pull-request-to-an-issue
+var closingKeywordPattern = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s*:?\s+#(\d+)\b`)
+
+// titleIssuePattern はPRタイトル中の"(#N)"にマッチする
+var titleIssuePattern = regexp.MustCompile(`\(#(\d+)\)`)
+
+// IssueLinkSource はPRとIssueを紐付けた根拠
+type IssueLinkSource string
+
+const (
+	// LinkSourceBranch はsoba/Nのheadブランチによる紐付け
+	LinkSourceBranch IssueLinkSource = "branch"
+	// LinkSourceBody はPR本文のCloses #Nなどのキーワードによる紐付け
+	LinkSourceBody IssueLinkSource = "body"
+	// LinkSourceTimeline はcross-referencedイベントによる紐付け
+	LinkSourceTimeline IssueLinkSource = "timeline"
+	// LinkSourceTitle はPRタイトルの(#N)による紐付け
+	LinkSourceTitle IssueLinkSource = "title"
+)
+
+// IssueLink はPRに紐づくIssueとその根拠
+type IssueLink struct {
+	Issue  int
+	Source IssueLinkSource
+}
+
+// Trusted はIssueのクローズやラベル変更など、Issueを変更する操作に使える紐付けかを返す
+// タイムラインやタイトルの参照は単に言及しているだけの可能性があるため信頼しない
+func (l IssueLink) Trusted() bool {
+	return l.Issue > 0 && (l.Source == LinkSourceBranch || l.Source == LinkSourceBody)
+}
+
+// IssueLinker はPRと関連するIssueを紐付ける
+// 解決した結果はキャッシュし、同じPRに対するAPI呼び出しを繰り返さない
+type IssueLinker struct {
+	timeline TimelineReader
+	owner    string
+	repo     string
+	logger   logging.Logger
+
+	mu      sync.RWMutex
+	byPR    map[int]IssueLink // PR番号 -> Issue
+	byIssue map[int]int       // Issue番号 -> PR番号
+	misses  map[int]string    // Issueが見つからなかったPR番号 -> そのときのhead SHA
+}
+
+// NewIssueLinker は新しいIssueLinkerを作成する
+// timelineがnilの場合はタイムラインによる解決を行わない
+func NewIssueLinker(timeline TimelineReader, owner, repo string) *IssueLinker {
+	return &IssueLinker{
+		timeline: timeline,
+		owner:    owner,
+		repo:     repo,
+		logger:   logging.NewMockLogger(),
+		byPR:     make(map[int]IssueLink),
+		byIssue:  make(map[int]int),
+		misses:   make(map[int]string),
+	}
+}
+
+// SetLogger はロガーを設定する
+func (l *IssueLinker) SetLogger(log logging.Logger) {
+	l.logger = log
+}
+
+// IssueForPullRequest はPRに紐づくIssue番号を返す。見つからない場合は0を返す
+func (l *IssueLinker) IssueForPullRequest(ctx context.Context, pr github.PullRequest) int {
+	return l.LinkForPullRequest(ctx, pr).Issue
+}
+
+// TrustedIssueForPullRequest はheadブランチか本文のキーワードで紐づくIssue番号を返す
+// Issueを変更する操作にはこちらを使う。それ以外の紐付けの場合は0を返す
+func (l *IssueLinker) TrustedIssueForPullRequest(ctx context.Context, pr github.PullRequest) int {
+	link := l.LinkForPullRequest(ctx, pr)
+	if !link.Trusted() {
+		return 0
+	}
+	return link.Issue
+}
+
+// LinkForPullRequest はPRに紐づくIssueとその根拠を返す。見つからない場合はIssueが0になる
+// 解決順序: キャッシュ → headブランチ(soba/N) → 本文のキーワード(Closes #N) → タイムライン → タイトル(#N)
+// 見つからなかった結果もheadが変わるまでキャッシュする
+func (l *IssueLinker) LinkForPullRequest(ctx context.Context, pr github.PullRequest) IssueLink {
+	if link, ok := l.cachedLink(pr); ok {
+		return link
+	}
+
+	link := IssueLink{Issue: issueNumberFromBranch(pr.Head.Ref), Source: LinkSourceBranch}
+	if link.Issue == 0 {
+		link = IssueLink{Issue: issueNumberFromBody(pr.Body, pr.Number), Source: LinkSourceBody}
+	}
+	if link.Issue == 0 {
+		link = IssueLink{Issue: l.issueFromTimeline(ctx, pr.Number), Source: LinkSourceTimeline}
+	}
+	if link.Issue == 0 {
+		link = IssueLink{Issue: issueNumberFromTitle(pr.Title), Source: LinkSourceTitle}
+	}
+
+	if link.Issue == 0 {
+		l.logger.Debug(ctx, "No linked issue found for PR",
+			logging.Field{Key: "pr", Value: pr.Number},
+			logging.Field{Key: "head", Value: pr.Head.Ref},
+		)
+		l.mu.Lock()
+		l.misses[pr.Number] = pr.Head.SHA
+		l.mu.Unlock()
+		return IssueLink{}
+	}
+
+	l.logger.Debug(ctx, "Resolved linked issue for PR",
+		logging.Field{Key: "pr", Value: pr.Number},
+		logging.Field{Key: "issue", Value: link.Issue},
+		logging.Field{Key: "source", Value: string(link.Source)},
+	)
+	l.link(pr.Number, link)
+	return link
+}
+
+// PullRequestForIssue はIssueに紐づくPR番号を返す。見つからない場合は0を返す
+// prsに含まれるPRを優先し、見つからなければIssueのタイムラインを参照する
+func (l *IssueLinker) PullRequestForIssue(ctx context.Context, issueNumber int, prs []github.PullRequest) int {
+	if prNumber, ok := l.cachedPullRequest(issueNumber); ok {
+		return prNumber
+	}
+
+	for _, pr := range prs {
+		if l.IssueForPullRequest(ctx, pr) == issueNumber {
+			return pr.Number
+		}
+	}
+
+	prNumber := l.pullRequestFromTimeline(ctx, issueNumber)
+	if prNumber > 0 {
+		l.link(prNumber, IssueLink{Issue: issueNumber, Source: LinkSourceTimeline})
+	}
+	return prNumber
+}
+
+// link はPRとIssueの紐付けをキャッシュに登録する
+func (l *IssueLinker) link(prNumber int, link IssueLink) {
+	if prNumber <= 0 || link.Issue <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.byPR[prNumber] = link
+	l.byIssue[link.Issue] = prNumber
+	delete(l.misses, prNumber)
+}
+
+// Forget はPRの紐付けをキャッシュから削除する
+func (l *IssueLinker) Forget(prNumber int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if link, ok := l.byPR[prNumber]; ok {
+		delete(l.byIssue, link.Issue)
+	}
+	delete(l.byPR, prNumber)
+	delete(l.misses, prNumber)
+}
+
+// cachedLink はキャッシュ済みの紐付けを返す。同じheadで見つからなかったPRは空の紐付けを返す
+func (l *IssueLinker) cachedLink(pr github.PullRequest) (IssueLink, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if link, ok := l.byPR[pr.Number]; ok {
+		return link, true
+	}
+	if sha, ok := l.misses[pr.Number]; ok && sha == pr.Head.SHA {
+		return IssueLink{}, true
+	}
+	return IssueLink{}, false
+}
+
+func (l *IssueLinker) cachedPullRequest(issueNumber int) (int, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	prNumber, ok := l.byIssue[issueNumber]
+	return prNumber, ok
+}
+
+// issueFromTimeline はPRのタイムラインからPRを参照しているIssueを探す
+func (l *IssueLinker) issueFromTimeline(ctx context.Context, prNumber int) int {
+	events := l.listTimeline(ctx, prNumber)
+	// 最新の参照を優先する
+	for i := len(events) - 1; i >= 0; i-- {
+		issue := crossReferencedIssue(events[i])
+		if issue != nil && !issue.IsPullRequest() {
+			return issue.Number
+		}
+	}
+	return 0
+}
+
+// pullRequestFromTimeline はIssueのタイムラインからIssueを参照しているPRを探す
+func (l *IssueLinker) pullRequestFromTimeline(ctx context.Context, issueNumber int) int {
+	events := l.listTimeline(ctx, issueNumber)
+	// オープンなPRを優先し、なければ最新の参照を返す
+	latest := 0
+	for i := len(events) - 1; i >= 0; i-- {
+		issue := crossReferencedIssue(events[i])
+		if issue == nil || !issue.IsPullRequest() {
+			continue
+		}
+		if issue.State == "open" {
+			return issue.Number
+		}
+		if latest == 0 {
+			latest = issue.Number
+		}
+	}
+	return latest
+}
+
+func (l *IssueLinker) listTimeline(ctx context.Context, number int) []github.TimelineEvent {
+	if l.timeline == nil || l.owner == "" || l.repo == "" {
+		return nil
+	}
+	events, err := l.timeline.ListIssueTimeline(ctx, l.owner, l.repo, number)
+	if err != nil {
+		l.logger.Warn(ctx, "Failed to fetch timeline",
+			logging.Field{Key: "number", Value: number},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return nil
+	}
+	return events
+}
+
+// crossReferencedIssue はcross-referencedイベントの参照元を返す
+func crossReferencedIssue(event github.TimelineEvent) *github.Issue {
+	if event.Event != "cross-referenced" || event.Source == nil {
+		return nil
+	}
+	return event.Source.Issue
+}
+
+// issueNumberFromBranch はsoba/Nの形式のブランチ名からIssue番号を抽出する
+func issueNumberFromBranch(ref string) int {
+	if !strings.HasPrefix(ref, "soba/") {
+		return 0
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(ref, "soba/"))
+	if err != nil || number <= 0 {
+		return 0
+	}
+	return number
+}
+
+// issueNumberFromBody はPR本文のCloses/Fixes/Resolves #NからIssue番号を抽出する
+// PR自身の番号への参照は無視する
+func issueNumberFromBody(body string, prNumber int) int {
+	for _, match := range closingKeywordPattern.FindAllStringSubmatch(body, -1) {
+		number, err := strconv.Atoi(match[1])
+		if err == nil && number > 0 && number != prNumber {
+			return number
+		}
+	}
+	return 0
+}
+
+// issueNumberFromTitle はPRタイトルの"(#N)"からIssue番号を抽出する
+func issueNumberFromTitle(title string) int {
+	match := titleIssuePattern.FindStringSubmatch(title)
+	if match == nil {
+		return 0
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return number
+}
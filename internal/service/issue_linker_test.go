@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+// mockTimelineReader はIssueLinkerテスト用のタイムラインモック
+type mockTimelineReader struct {
+	timelines map[int][]github.TimelineEvent
+	calls     []int
+}
+
+func (m *mockTimelineReader) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error) {
+	m.calls = append(m.calls, issueNumber)
+	return m.timelines[issueNumber], nil
+}
+
+func crossReference(number int, state string, isPR bool) github.TimelineEvent {
+	issue := &github.Issue{Number: number, State: state}
+	if isPR {
+		issue.PullRequest = &github.IssuePullRequest{}
+	}
+	return github.TimelineEvent{
+		Event:  "cross-referenced",
+		Source: &github.TimelineSource{Type: "issue", Issue: issue},
+	}
+}
+
+func TestIssueLinker_IssueForPullRequest(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("headブランチからIssueを解決する", func(t *testing.T) {
+		timeline := &mockTimelineReader{}
+		linker := NewIssueLinker(timeline, "owner", "repo")
+
+		pr := github.PullRequest{Number: 10, Head: github.PullRequestBranch{Ref: "soba/42"}, Body: "Closes #7"}
+		assert.Equal(t, 42, linker.IssueForPullRequest(ctx, pr))
+		assert.Empty(t, timeline.calls)
+	})
+
+	t.Run("本文のキーワードからIssueを解決する", func(t *testing.T) {
+		linker := NewIssueLinker(nil, "owner", "repo")
+
+		pr := github.PullRequest{Number: 10, Head: github.PullRequestBranch{Ref: "feature/x"}, Body: "Some change\n\nfixes: #15"}
+		assert.Equal(t, 15, linker.IssueForPullRequest(ctx, pr))
+	})
+
+	t.Run("タイムラインのcross-referencedイベントから解決する", func(t *testing.T) {
+		timeline := &mockTimelineReader{timelines: map[int][]github.TimelineEvent{
+			10: {crossReference(20, "open", true), crossReference(8, "open", false)},
+		}}
+		linker := NewIssueLinker(timeline, "owner", "repo")
+
+		pr := github.PullRequest{Number: 10, Title: "Fix (#99)"}
+		assert.Equal(t, 8, linker.IssueForPullRequest(ctx, pr))
+	})
+
+	t.Run("最後の手段としてタイトルを参照する", func(t *testing.T) {
+		linker := NewIssueLinker(&mockTimelineReader{}, "owner", "repo")
+
+		pr := github.PullRequest{Number: 10, Title: "Implement feature (#99)"}
+		assert.Equal(t, 99, linker.IssueForPullRequest(ctx, pr))
+	})
+
+	t.Run("解決結果をキャッシュする", func(t *testing.T) {
+		timeline := &mockTimelineReader{timelines: map[int][]github.TimelineEvent{
+			10: {crossReference(8, "open", false)},
+		}}
+		linker := NewIssueLinker(timeline, "owner", "repo")
+
+		pr := github.PullRequest{Number: 10}
+		assert.Equal(t, 8, linker.IssueForPullRequest(ctx, pr))
+		assert.Equal(t, 8, linker.IssueForPullRequest(ctx, pr))
+		assert.Len(t, timeline.calls, 1)
+
+		linker.Forget(10)
+		assert.Equal(t, 8, linker.IssueForPullRequest(ctx, pr))
+		assert.Len(t, timeline.calls, 2)
+	})
+
+	t.Run("見つからない場合は0を返す", func(t *testing.T) {
+		linker := NewIssueLinker(&mockTimelineReader{}, "owner", "repo")
+		assert.Equal(t, 0, linker.IssueForPullRequest(ctx, github.PullRequest{Number: 10, Title: "No reference"}))
+	})
+}
+
+func TestIssueLinker_LinkForPullRequest(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ブランチと本文の紐付けだけを信頼する", func(t *testing.T) {
+		timeline := &mockTimelineReader{timelines: map[int][]github.TimelineEvent{
+			12: {crossReference(8, "open", false)},
+		}}
+		linker := NewIssueLinker(timeline, "owner", "repo")
+
+		tests := []struct {
+			pr      github.PullRequest
+			want    IssueLink
+			trusted bool
+		}{
+			{github.PullRequest{Number: 10, Head: github.PullRequestBranch{Ref: "soba/42"}}, IssueLink{Issue: 42, Source: LinkSourceBranch}, true},
+			{github.PullRequest{Number: 11, Body: "Closes #15"}, IssueLink{Issue: 15, Source: LinkSourceBody}, true},
+			{github.PullRequest{Number: 12}, IssueLink{Issue: 8, Source: LinkSourceTimeline}, false},
+			{github.PullRequest{Number: 13, Title: "Refactor parser (#99)"}, IssueLink{Issue: 99, Source: LinkSourceTitle}, false},
+		}
+		for _, tt := range tests {
+			link := linker.LinkForPullRequest(ctx, tt.pr)
+			assert.Equal(t, tt.want, link)
+			assert.Equal(t, tt.trusted, link.Trusted())
+			assert.Equal(t, tt.want.Issue, linker.IssueForPullRequest(ctx, tt.pr))
+			if tt.trusted {
+				assert.Equal(t, tt.want.Issue, linker.TrustedIssueForPullRequest(ctx, tt.pr))
+			} else {
+				assert.Zero(t, linker.TrustedIssueForPullRequest(ctx, tt.pr))
+			}
+		}
+	})
+
+	t.Run("見つからなかった結果はheadが変わるまでキャッシュする", func(t *testing.T) {
+		timeline := &mockTimelineReader{}
+		linker := NewIssueLinker(timeline, "owner", "repo")
+
+		pr := github.PullRequest{Number: 10, Title: "No reference", Head: github.PullRequestBranch{SHA: "sha1"}}
+		assert.Equal(t, 0, linker.IssueForPullRequest(ctx, pr))
+		assert.Equal(t, 0, linker.IssueForPullRequest(ctx, pr))
+		assert.Len(t, timeline.calls, 1)
+
+		pr.Head.SHA = "sha2"
+		assert.Equal(t, 0, linker.IssueForPullRequest(ctx, pr))
+		assert.Len(t, timeline.calls, 2)
+	})
+}
+
+func TestIssueLinker_PullRequestForIssue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("PR一覧から解決する", func(t *testing.T) {
+		timeline := &mockTimelineReader{}
+		linker := NewIssueLinker(timeline, "owner", "repo")
+
+		prs := []github.PullRequest{
+			{Number: 10, Head: github.PullRequestBranch{Ref: "soba/1"}},
+			{Number: 11, Head: github.PullRequestBranch{Ref: "soba/2"}},
+		}
+		assert.Equal(t, 11, linker.PullRequestForIssue(ctx, 2, prs))
+		assert.Empty(t, timeline.calls)
+	})
+
+	t.Run("Issueのタイムラインからオープンなプルリクエストを優先する", func(t *testing.T) {
+		timeline := &mockTimelineReader{timelines: map[int][]github.TimelineEvent{
+			5: {crossReference(30, "open", true), crossReference(31, "closed", true), crossReference(6, "open", false)},
+		}}
+		linker := NewIssueLinker(timeline, "owner", "repo")
+
+		assert.Equal(t, 30, linker.PullRequestForIssue(ctx, 5, nil))
+		// キャッシュされるため逆引きもできる
+		assert.Equal(t, 5, linker.IssueForPullRequest(ctx, github.PullRequest{Number: 30}))
+	})
+}
+
+func TestIssueNumberFromBranch(t *testing.T) {
+	assert.Equal(t, 42, issueNumberFromBranch("soba/42"))
+	assert.Equal(t, 0, issueNumberFromBranch("feature/42"))
+	assert.Equal(t, 0, issueNumberFromBranch("soba/abc"))
+	assert.Equal(t, 0, issueNumberFromBranch(""))
+}
+
+func TestIssueNumberFromBody(t *testing.T) {
+	assert.Equal(t, 12, issueNumberFromBody("Closes #12", 1))
+	assert.Equal(t, 12, issueNumberFromBody("This resolves #12.", 1))
+	assert.Equal(t, 12, issueNumberFromBody("FIXED #12", 1))
+	assert.Equal(t, 0, issueNumberFromBody("Related to #12", 1))
+	assert.Equal(t, 0, issueNumberFromBody("Closes #1", 1))
+	assert.Equal(t, 0, issueNumberFromBody("", 1))
+}
@@ -21,6 +21,16 @@ type GitHubClientInterface interface {
 	ListPullRequests(ctx context.Context, owner, repo string, opts *github.ListPullRequestsOptions) ([]github.PullRequest, bool, error)
 	GetPullRequest(ctx context.Context, owner, repo string, number int) (*github.PullRequest, bool, error)
 	MergePullRequest(ctx context.Context, owner, repo string, number int, req *github.MergeRequest) (*github.MergeResponse, error)
+	UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, expectedHeadSHA string) error
+	CreateComment(ctx context.Context, owner, repo string, issueNumber int, body string) error
+	ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error)
+	GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*github.Issue, error)
+	CloseIssue(ctx context.Context, owner, repo string, issueNumber int) error
+	DeleteBranch(ctx context.Context, owner, repo, branch string) error
+	ListPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]github.PullRequestReview, error)
+	ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]github.ReviewComment, error)
+	ListRepositoryComments(ctx context.Context, owner, repo string, opts *github.ListCommentsOptions) ([]github.IssueComment, error)
+	CreateCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error
 }
 
 type issueProcessor struct {
@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/douhashi/soba/internal/config"
 	"github.com/douhashi/soba/internal/domain"
 	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/metrics"
+	"github.com/douhashi/soba/internal/infra/tmux"
 	"github.com/douhashi/soba/pkg/logging"
 )
 
@@ -39,6 +43,14 @@ type IssueWatcher struct {
 	currentIssue     *int                    // 現在処理中のIssue番号（シングルライン処理用）
 	queueManager     *QueueManager           // キュー管理用マネージャー
 	workflowExecutor WorkflowExecutor        // ワークフロー実行用エグゼキューター
+	chatOps          *ChatOpsHandler         // コメントの/sobaコマンド処理
+	guard            *WorkGuard              // 予算とスケジュールによるキュー投入の制限
+	transcriptDir    string                  // ペイン出力ログの保存先
+	postedLogs       map[string]bool         // 末尾をコメント済みのペイン出力ログ
+	usageLogPath     string                  // フェーズ実行ごとの使用量の記録先
+	control          *DaemonControl          // 制御APIから参照・操作される状態
+	history          *EventHistory           // Issueごとのイベント履歴
+	watchLoop
 }
 
 // NewIssueWatcher は新しいIssueWatcherを作成する
@@ -51,18 +63,34 @@ func NewIssueWatcher(client GitHubClientInterface, cfg *config.Config) *IssueWat
 	// ロガーの初期化（テスト環境を考慮）
 	log := logging.NewMockLogger() // デフォルトでMockLogger使用
 
+	chatOps := NewChatOpsHandler(client, cfg)
+	chatOps.SetLogger(log)
+
 	return &IssueWatcher{
 		client:         client,
 		config:         cfg,
 		interval:       time.Duration(cfg.Workflow.Interval) * time.Second,
 		logger:         log,
 		previousIssues: make(map[int64]github.Issue),
+		chatOps:        chatOps,
+		guard:          NewWorkGuard(cfg, log),
+		transcriptDir:  TranscriptLogDir,
+		postedLogs:     make(map[string]bool),
+		usageLogPath:   UsageLogPath,
+		watchLoop:      newWatchLoop(),
 	}
 }
 
 // SetLogger はロガーを設定する（運用時用）
 func (w *IssueWatcher) SetLogger(log logging.Logger) {
 	w.logger = log
+	w.chatOps.SetLogger(log)
+	w.guard.SetLogger(log)
+}
+
+// SetTmuxClient は/sobaコマンドで実行中のフェーズを止めるためのtmuxクライアントを設定する
+func (w *IssueWatcher) SetTmuxClient(tmuxClient tmux.TmuxClient) {
+	w.chatOps.SetTmuxClient(tmuxClient)
 }
 
 // SetProcessor はIssueProcessorを設定する
@@ -73,6 +101,14 @@ func (w *IssueWatcher) SetProcessor(processor IssueProcessorInterface) {
 // SetQueueManager はQueueManagerを設定する
 func (w *IssueWatcher) SetQueueManager(qm *QueueManager) {
 	w.queueManager = qm
+	if qm != nil {
+		qm.SetWorkGuard(w.guard)
+	}
+}
+
+// SetControl は制御APIから参照・操作される状態を設定する
+func (w *IssueWatcher) SetControl(control *DaemonControl) {
+	w.control = control
 }
 
 // SetWorkflowExecutor はWorkflowExecutorを設定する
@@ -80,6 +116,25 @@ func (w *IssueWatcher) SetWorkflowExecutor(executor WorkflowExecutor) {
 	w.workflowExecutor = executor
 }
 
+// SetHistory はイベント履歴の記録先を設定する。QueueManagerとWorkflowExecutorにも反映する
+func (w *IssueWatcher) SetHistory(history *EventHistory) {
+	w.history = history
+	if w.queueManager != nil {
+		w.queueManager.SetHistory(history)
+	}
+	if w.workflowExecutor != nil {
+		w.workflowExecutor.SetHistory(history)
+	}
+}
+
+// SetDryRun はドライランを設定する。WorkGuardとWorkflowExecutorにも反映する
+func (w *IssueWatcher) SetDryRun(recorder *DryRunRecorder) {
+	w.guard.SetDryRun(recorder)
+	if w.workflowExecutor != nil {
+		w.workflowExecutor.SetDryRun(recorder)
+	}
+}
+
 // Start はIssue監視を開始する
 func (w *IssueWatcher) Start(ctx context.Context) error {
 	w.logger.Info(ctx, "Starting Issue watcher", logging.Field{Key: "interval", Value: w.interval})
@@ -88,7 +143,7 @@ func (w *IssueWatcher) Start(ctx context.Context) error {
 	defer ticker.Stop()
 
 	// 最初に一度実行
-	if err := w.watchOnce(ctx); err != nil {
+	if err := timedCycle(w.control, "issue_watcher", func() error { return w.watchOnce(ctx) }); err != nil {
 		w.logger.Error(ctx, "Initial watch failed", logging.Field{Key: "error", Value: err.Error()})
 	}
 
@@ -98,24 +153,59 @@ func (w *IssueWatcher) Start(ctx context.Context) error {
 			w.logger.Info(ctx, "Issue watcher stopped due to context cancellation")
 			return nil
 		case <-ticker.C:
-			if err := w.watchOnce(ctx); err != nil {
+			if err := timedCycle(w.control, "issue_watcher", func() error { return w.watchOnce(ctx) }); err != nil {
+				w.logger.Error(ctx, "Watch cycle failed", logging.Field{Key: "error", Value: err.Error()})
+			}
+		case <-w.trigger:
+			w.logger.Info(ctx, "Watch cycle triggered")
+			if err := timedCycle(w.control, "issue_watcher", func() error { return w.watchOnce(ctx) }); err != nil {
 				w.logger.Error(ctx, "Watch cycle failed", logging.Field{Key: "error", Value: err.Error()})
 			}
+		case request := <-w.requests:
+			request(ctx)
+			// 設定の再読み込みで間隔が変わった場合に備える
+			ticker.Reset(w.interval)
 		}
 	}
 }
 
 // watchOnce は一度だけIssue監視を実行する
 func (w *IssueWatcher) watchOnce(ctx context.Context) error {
+	if w.control.Paused() {
+		w.logger.Info(ctx, "Soba is paused, skipping watch cycle")
+		return nil
+	}
+
 	w.logger.Info(ctx, "Starting watch cycle")
+	w.guard.Configure(w.config)
+
+	// コメントの/sobaコマンドを先に反映し、同じサイクルのラベル判定に含める
+	if err := w.chatOps.ProcessComments(ctx, w.config); err != nil {
+		w.logger.Warn(ctx, "Failed to process soba commands", logging.Field{Key: "error", Value: err.Error()})
+	}
 
 	issues, err := w.fetchFilteredIssues(ctx)
 	if err != nil {
 		return err
 	}
+	_, active := recordIssueGauges(issues)
+	w.control.publishIssues(issues, w.currentIssue)
+
+	// ドレイン中は進行中のIssueが全て終わった時点でデーモンを止める
+	if w.control.Draining() && active == 0 {
+		w.logger.Info(ctx, "Drain completed, no issues in progress")
+		w.control.RequestStop("drained")
+		return nil
+	}
+
+	// 一時停止中のIssueは実行中のフェーズが終わった後、次のフェーズに進めない
+	// キュー管理には一時停止中のIssueも渡し、進行中のIssueとして数えさせる
+	runnable := w.excludePausedIssues(issues)
 
 	// 1. キュー管理（soba:todo → soba:queued）
-	if w.queueManager != nil {
+	if w.control.Draining() {
+		w.logger.Info(ctx, "Draining, not enqueuing new issues")
+	} else if w.queueManager != nil {
 		w.logger.Debug(ctx, "Calling QueueManager.EnqueueNextIssue")
 		if err := w.queueManager.EnqueueNextIssue(ctx, issues); err != nil {
 			w.logger.Error(ctx, "Failed to enqueue", logging.Field{Key: "error", Value: err.Error()})
@@ -125,13 +215,14 @@ func (w *IssueWatcher) watchOnce(ctx context.Context) error {
 	}
 
 	// 2. キューに入ったIssueの処理（soba:queued → plan実行）
-	w.processQueuedIssues(ctx, issues)
+	w.processQueuedIssues(ctx, runnable)
 
 	// 3. その他のワークフロー処理
-	issueToProcess := w.selectIssueForProcessing(issues)
+	issueToProcess := w.selectIssueForProcessing(runnable)
 
 	// 変更を検知してログ出力
-	w.detectAndLogChanges(issues)
+	w.detectAndLogChanges(ctx, issues)
+	w.reportExitedPhases(ctx, issues)
 
 	// 選択されたIssueを処理
 	if err := w.processSelectedIssue(ctx, issueToProcess); err != nil {
@@ -139,29 +230,152 @@ func (w *IssueWatcher) watchOnce(ctx context.Context) error {
 	}
 
 	// 自動フェーズ遷移を処理（queue以外）
-	w.handleAutoTransitions(ctx, issues)
+	w.handleAutoTransitions(ctx, runnable)
 
+	// 一時停止中のIssueもsoba statusに表示する
+	w.control.publishIssues(issues, w.currentIssue)
 	w.logger.Info(ctx, "Watch cycle completed")
 	return nil
 }
 
 // detectAndLogChanges は変更を検知してログ出力を行う
-func (w *IssueWatcher) detectAndLogChanges(issues []github.Issue) {
+func (w *IssueWatcher) detectAndLogChanges(ctx context.Context, issues []github.Issue) {
 	changes := w.detectChanges(issues)
 	if len(changes) == 0 {
 		return
 	}
 
-	w.logger.Info(context.Background(), "Detected issue changes", logging.Field{Key: "count", Value: len(changes)})
+	w.logger.Info(ctx, "Detected issue changes", logging.Field{Key: "count", Value: len(changes)})
 	for _, change := range changes {
 		w.logChange(change)
+		if change.Type == IssueChangeTypeNew {
+			w.history.RecordFirstSeen(ctx, change.Issue)
+		}
 		// PhaseStrategyが有効な場合は、フェーズ分析を行う
 		if change.Type == IssueChangeTypeLabelChanged {
+			if event, changed := labelChangeEvent(*change.Previous, change.Issue); changed {
+				w.history.Record(ctx, event)
+			}
 			w.analyzeAndLogPhaseTransition(change)
+			w.reportFinishedPhase(ctx, change)
+		}
+	}
+}
+
+// reportFinishedPhase は実行中ラベルが外れたフェーズの所要時間を記録し、ログ末尾とIssueの使用量の合計をコメントする
+// 完了ラベルが付いていればcompleted、それ以外で外れた場合はstoppedとして扱う
+func (w *IssueWatcher) reportFinishedPhase(ctx context.Context, change IssueChange) {
+	transcript := w.config.Workflow.Transcript
+	usage := w.config.Workflow.Usage
+	postTranscript := transcript.Enabled && transcript.CommentEnabled
+	postUsage := usage.Enabled && usage.CommentEnabled
+	if change.Previous == nil {
+		return
+	}
+
+	for _, label := range change.Previous.Labels {
+		phaseDef := domain.GetPhaseByExecutionLabel(label.Name)
+		if phaseDef == nil || phaseDef.ExecutionType != domain.ExecutionTypeCommand || w.hasLabel(change.Issue, label.Name) {
+			continue
+		}
+
+		outcome := "stopped"
+		for completionLabel := range phaseDef.CompletionLabels {
+			if w.hasLabel(change.Issue, completionLabel) {
+				outcome = "completed"
+				break
+			}
+		}
+		metrics.PhaseFinished(change.Issue.Number, phaseDef.Name, outcome)
+		w.history.Record(ctx, HistoryEvent{
+			Issue:  change.Issue.Number,
+			Type:   HistoryPhaseFinished,
+			Phase:  phaseDef.Name,
+			Detail: outcome,
+		})
+
+		if postTranscript {
+			w.postPhaseTranscript(ctx, change.Issue.Number, phaseDef.Name, outcome, time.Time{})
+		}
+		if postUsage {
+			if err := postUsageTotal(ctx, w.client, w.config, w.usageLogPath, change.Issue.Number); err != nil {
+				w.logger.Warn(ctx, "Failed to post usage total",
+					logging.Field{Key: "error", Value: err.Error()},
+					logging.Field{Key: "issue", Value: change.Issue.Number},
+				)
+			}
+		}
+	}
+}
+
+// reportExitedPhases は実行中ラベルが残ったままコマンドが終了したフェーズのログ末尾をコメントする
+// エージェントが異常終了した場合や完了ラベルを付けずに終わった場合に、ペインを開かずに原因を確認できるようにする
+func (w *IssueWatcher) reportExitedPhases(ctx context.Context, issues []github.Issue) {
+	transcript := w.config.Workflow.Transcript
+	if !transcript.Enabled || !transcript.CommentEnabled {
+		return
+	}
+
+	for _, issue := range issues {
+		for _, label := range issue.Labels {
+			phaseDef := domain.GetPhaseByExecutionLabel(label.Name)
+			if phaseDef == nil || phaseDef.ExecutionType != domain.ExecutionTypeCommand {
+				continue
+			}
+			path, err := latestTranscript(w.transcriptDir, issue.Number, phaseDef.Name)
+			if err != nil || w.postedLogs[path] {
+				continue
+			}
+			if exited, err := transcriptExited(path); err != nil || !exited {
+				continue
+			}
+			w.logger.Warn(ctx, "Phase command exited without finishing the phase",
+				logging.Field{Key: "issue", Value: issue.Number},
+				logging.Field{Key: "phase", Value: phaseDef.Name},
+			)
+			w.postPhaseTranscript(ctx, issue.Number, phaseDef.Name, "exited", time.Time{})
 		}
 	}
 }
 
+// postPhaseTranscript はフェーズの最新ログの末尾をIssueにコメントする
+// 同じログは一度だけコメントし、sinceより前に書かれたログは前回の実行のものとして扱わない
+func (w *IssueWatcher) postPhaseTranscript(ctx context.Context, issueNumber int, phase, outcome string, since time.Time) {
+	path, err := latestTranscript(w.transcriptDir, issueNumber, phase)
+	if errors.Is(err, os.ErrNotExist) {
+		// 記録を有効にする前に始まったフェーズ
+		return
+	}
+	if err == nil && !since.IsZero() {
+		// 更新時刻の精度はファイルシステムによって粗いため、秒単位で比べる
+		if info, statErr := os.Stat(path); statErr == nil && info.ModTime().Before(since.Truncate(time.Second)) {
+			return
+		}
+	}
+	if err == nil {
+		if w.postedLogs[path] {
+			return
+		}
+		w.postedLogs[path] = true
+		err = postTranscriptTail(ctx, w.client, w.config, path, issueNumber, phase, outcome)
+	}
+	if err != nil {
+		w.logger.Warn(ctx, "Failed to post phase transcript",
+			logging.Field{Key: "error", Value: err.Error()},
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "phase", Value: phase},
+		)
+	}
+}
+
+// reportFailedPhase は開始に失敗したフェーズのログ末尾をコメントする
+func (w *IssueWatcher) reportFailedPhase(ctx context.Context, issueNumber int, phase domain.Phase, startedAt time.Time) {
+	transcript := w.config.Workflow.Transcript
+	if transcript.Enabled && transcript.CommentEnabled {
+		w.postPhaseTranscript(ctx, issueNumber, string(phase), "failed", startedAt)
+	}
+}
+
 // processSelectedIssue は選択されたIssueを処理する
 func (w *IssueWatcher) processSelectedIssue(ctx context.Context, issueToProcess *github.Issue) error {
 	if issueToProcess == nil || w.workflowExecutor == nil {
@@ -186,12 +400,14 @@ func (w *IssueWatcher) processSelectedIssue(ctx context.Context, issueToProcess
 		logging.Field{Key: "issue", Value: issueToProcess.Number},
 		logging.Field{Key: "phase", Value: phaseToExecute},
 	)
+	startedAt := time.Now()
 	if err := w.workflowExecutor.ExecutePhase(ctx, w.config, issueToProcess.Number, phaseToExecute); err != nil {
 		w.logger.Error(ctx, "Failed to execute phase",
 			logging.Field{Key: "error", Value: err.Error()},
 			logging.Field{Key: "issue", Value: issueToProcess.Number},
 			logging.Field{Key: "phase", Value: phaseToExecute},
 		)
+		w.reportFailedPhase(ctx, issueToProcess.Number, phaseToExecute, startedAt)
 		return err
 	}
 
@@ -210,12 +426,14 @@ func (w *IssueWatcher) processQueuedIssues(ctx context.Context, issues []github.
 			w.logger.Info(ctx, "Processing queued issue", logging.Field{Key: "issue", Value: issue.Number})
 
 			// 明示的にplanフェーズを実行
+			startedAt := time.Now()
 			err := w.workflowExecutor.ExecutePhase(ctx, w.config, issue.Number, domain.PhasePlan)
 			if err != nil {
 				w.logger.Error(ctx, "Failed to execute plan phase",
 					logging.Field{Key: "error", Value: err.Error()},
 					logging.Field{Key: "issue", Value: issue.Number},
 				)
+				w.reportFailedPhase(ctx, issue.Number, domain.PhasePlan, startedAt)
 			}
 			break // シングルライン処理のため1つだけ処理
 		}
@@ -249,6 +467,41 @@ func (w *IssueWatcher) handleAutoTransitions(ctx context.Context, issues []githu
 	}
 }
 
+// recordIssueGauges はsoba:todoで待っているIssue数と処理中のIssue数をメトリクスに記録して返す
+// soba:doneのIssueはPRのマージを待っているだけなので処理中に数えない
+func recordIssueGauges(issues []github.Issue) (int, int) {
+	queued, active := 0, 0
+	for _, issue := range issues {
+		labels := make([]string, 0, len(issue.Labels))
+		for _, label := range issue.Labels {
+			labels = append(labels, label.Name)
+		}
+		phase, err := domain.GetCurrentPhaseFromLabels(labels)
+		if err != nil {
+			continue
+		}
+		switch {
+		case phase == domain.PhaseQueue:
+			queued++
+		case !containsLabel(labels, domain.LabelDone):
+			active++
+		}
+	}
+	metrics.SetQueueDepth(queued)
+	metrics.SetActiveIssues(active)
+	return queued, active
+}
+
+// containsLabel はラベルの一覧にlabelが含まれるか判定する
+func containsLabel(labels []string, label string) bool {
+	for _, name := range labels {
+		if name == label {
+			return true
+		}
+	}
+	return false
+}
+
 // fetchFilteredIssues はフィルタされたIssue一覧を取得する
 func (w *IssueWatcher) fetchFilteredIssues(ctx context.Context) ([]github.Issue, error) {
 	owner, repo := w.parseRepository()
@@ -294,6 +547,18 @@ func (w *IssueWatcher) fetchFilteredIssues(ctx context.Context) ([]github.Issue,
 	return filteredIssues, nil
 }
 
+// excludePausedIssues は実行中でない一時停止中のIssueを除外する
+func (w *IssueWatcher) excludePausedIssues(issues []github.Issue) []github.Issue {
+	var active []github.Issue
+	for _, issue := range issues {
+		if w.hasLabel(issue, domain.LabelPaused) && !w.isInProgressPhase(issue) {
+			continue
+		}
+		active = append(active, issue)
+	}
+	return active
+}
+
 // hasSobaLabel はIssueがsoba:で始まるラベルを持つかチェックする
 func (w *IssueWatcher) hasSobaLabel(issue github.Issue) bool {
 	for _, label := range issue.Labels {
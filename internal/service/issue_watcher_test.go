@@ -542,3 +542,94 @@ func TestIssueWatcher_WatchCycleLogs(t *testing.T) {
 		t.Error("expected 'Watch cycle completed' INFO log, but not found")
 	}
 }
+
+func TestIssueWatcher_PausedIssueBlocksQueue(t *testing.T) {
+	// 一時停止中でも進行中のIssueがあれば、次のsoba:todoをキューに入れない
+	tests := []struct {
+		name  string
+		label string
+	}{
+		{"ready", "soba:ready"},
+		{"review-requested", "soba:review-requested"},
+		{"requires-changes", "soba:requires-changes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := []github.Issue{
+				{ID: 1, Number: 1, State: "open", Labels: []github.Label{{Name: tt.label}, {Name: "soba:paused"}}},
+				{ID: 2, Number: 2, State: "open", Labels: []github.Label{{Name: "soba:todo"}}},
+			}
+			var added []string
+			client := &MockGitHubClient{
+				ListOpenIssuesFunc: func(ctx context.Context, owner, repo string, opts *github.ListIssuesOptions) ([]github.Issue, bool, error) {
+					return issues, false, nil
+				},
+				addLabelFunc: func(ctx context.Context, owner, repo string, issueNumber int, label string) error {
+					added = append(added, fmt.Sprintf("#%d %s", issueNumber, label))
+					return nil
+				},
+			}
+			cfg := &config.Config{GitHub: config.GitHubConfig{Repository: "owner/repo"}, Workflow: config.WorkflowConfig{Interval: 1}}
+			watcher := NewIssueWatcher(client, cfg)
+			watcher.SetQueueManager(NewQueueManager(client, "owner", "repo"))
+
+			if err := watcher.watchOnce(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(added) != 0 {
+				t.Errorf("expected nothing to be enqueued, got labels added: %v", added)
+			}
+		})
+	}
+}
+
+func TestIssueWatcher_DrainAndPublish(t *testing.T) {
+	newWatcher := func(issues []github.Issue) (*IssueWatcher, *DaemonControl) {
+		client := &MockGitHubClient{
+			ListOpenIssuesFunc: func(ctx context.Context, owner, repo string, opts *github.ListIssuesOptions) ([]github.Issue, bool, error) {
+				return issues, false, nil
+			},
+		}
+		cfg := &config.Config{GitHub: config.GitHubConfig{Repository: "owner/repo"}, Workflow: config.WorkflowConfig{Interval: 1}}
+		watcher := NewIssueWatcher(client, cfg)
+		control := NewDaemonControl()
+		control.StartDrain()
+		watcher.SetControl(control)
+		return watcher, control
+	}
+
+	t.Run("マージ待ちのsoba:doneだけが残ったらドレインを終える", func(t *testing.T) {
+		watcher, control := newWatcher([]github.Issue{
+			{ID: 1, Number: 1, State: "open", Labels: []github.Label{{Name: "soba:done"}}},
+			{ID: 2, Number: 2, State: "open", Labels: []github.Label{{Name: "soba:todo"}}},
+		})
+
+		if err := watcher.watchOnce(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if reason := control.StopReason(); reason != "drained" {
+			t.Errorf("expected the drain to finish, got stop reason %q", reason)
+		}
+	})
+
+	t.Run("一時停止中のIssueはドレインを止め、statusに表示する", func(t *testing.T) {
+		watcher, control := newWatcher([]github.Issue{
+			{ID: 1, Number: 1, State: "open", Labels: []github.Label{{Name: "soba:ready"}, {Name: "soba:paused"}}},
+		})
+
+		if err := watcher.watchOnce(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if reason := control.StopReason(); reason != "" {
+			t.Errorf("expected the drain to wait for the paused issue, got stop reason %q", reason)
+		}
+		issues := control.Status(nil, nil).Issues
+		if len(issues) != 1 || issues[0].Number != 1 || !issues[0].Paused {
+			t.Errorf("expected the paused issue to be published, got %+v", issues)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// appendJSONLine は値をJSONにしてファイルに1行追記する。ディレクトリがなければ作成する
+func appendJSONLine(path string, value interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return WrapServiceError(err, "failed to create directory for "+filepath.Base(path))
+	}
+
+	line, err := json.Marshal(value)
+	if err != nil {
+		return WrapServiceError(err, "failed to encode "+filepath.Base(path)+" entry")
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 - sobaが管理するファイルのみを開く
+	if err != nil {
+		return WrapServiceError(err, "failed to open "+filepath.Base(path))
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return WrapServiceError(err, "failed to write "+filepath.Base(path))
+	}
+	return nil
+}
+
+// readJSONLines はJSON Linesファイルを読み込む。ファイルがない場合は空を返し、壊れた行は読み飛ばす
+func readJSONLines[T any](path string) ([]T, error) {
+	file, err := os.Open(path) // #nosec G304 - sobaが管理するファイルのみを開く
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, WrapServiceError(err, "failed to open "+filepath.Base(path))
+	}
+	defer file.Close()
+
+	var values []T
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var value T
+		if err := json.Unmarshal(scanner.Bytes(), &value); err != nil {
+			continue
+		}
+		values = append(values, value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, WrapServiceError(err, "failed to read "+filepath.Base(path))
+	}
+	return values, nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// PhaseCommandData はフェーズコマンドのテンプレートに渡す変数
+type PhaseCommandData struct {
+	IssueNumber  int
+	IssueTitle   string
+	Labels       []string
+	Branch       string // soba/<issue-number>
+	WorktreePath string // worktreeの絶対パス
+	BaseBranch   string
+	PRNumber     int // 紐づくPRがない場合は0
+	Repository   string
+	Owner        string
+	Repo         string
+	Phase        string
+	Profile      string // soba:profile:<name>ラベルまたは本文のfront matterで選ばれたプロファイル
+}
+
+var (
+	// shellSafePattern はクォートせずにシェルへ渡せる文字列にマッチする
+	shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+	// envNamePattern は環境変数名として有効な文字列にマッチする
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	phaseCommandFuncs = template.FuncMap{
+		"join":  strings.Join,
+		"quote": shellQuote,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}
+)
+
+// shellQuote は文字列をPOSIXシェルの1つの引数として扱われるようにクォートする
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if shellSafePattern.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// renderPhaseTemplate はテンプレート文字列をdataで展開する
+func renderPhaseTemplate(name, text string, data PhaseCommandData) (string, error) {
+	if !strings.Contains(text, "{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(phaseCommandFuncs).Parse(config.LegacyPlaceholders.Replace(text))
+	if err != nil {
+		return "", fmt.Errorf("invalid template in %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// buildPhaseCommand はフェーズコマンドを展開し、シェルで実行する1行のコマンドを組み立てる
+// command・options・parameterはそれぞれ1つの引数としてクォートされ、空になったoptionは除外する
+// バージョン1の設定ファイルでは以前と同じく、command・optionsをそのまま連結し、parameterをダブルクォートで囲む
+func buildPhaseCommand(phaseCommand config.PhaseCommand, data PhaseCommandData, version int) (string, error) {
+	if phaseCommand.Command == "" {
+		return "", nil
+	}
+
+	quote := shellQuote
+	quoteParameter := shellQuote
+	if version < config.QuotedPhaseCommandsVersion {
+		quote = func(s string) string { return s }
+		quoteParameter = func(s string) string { return `"` + s + `"` }
+	}
+
+	command, err := renderPhaseTemplate("command", phaseCommand.Command, data)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, name := range sortedKeys(phaseCommand.Env) {
+		if !envNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid environment variable name %q", name)
+		}
+		value, err := renderPhaseTemplate("env."+name, phaseCommand.Env[name], data)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, name+"="+shellQuote(value))
+	}
+
+	parts = append(parts, quote(command))
+	for i, option := range phaseCommand.Options {
+		rendered, err := renderPhaseTemplate(fmt.Sprintf("options[%d]", i), option, data)
+		if err != nil {
+			return "", err
+		}
+		if rendered == "" {
+			continue
+		}
+		parts = append(parts, quote(rendered))
+	}
+
+	if phaseCommand.Parameter != "" {
+		parameter, err := renderPhaseTemplate("parameter", phaseCommand.Parameter, data)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, quoteParameter(parameter))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// sortedKeys はマップのキーを昇順で返す
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// phaseCommandData はテンプレートに渡す変数を集める
+// GitHubから取得できない値は空のまま続行する
+func (e *workflowExecutor) phaseCommandData(ctx context.Context, cfg *config.Config, issueNumber int, phase domain.Phase) PhaseCommandData {
+	data := PhaseCommandData{
+		IssueNumber:  issueNumber,
+		Branch:       fmt.Sprintf("soba/%d", issueNumber),
+		WorktreePath: filepath.Join(cfg.Git.WorktreeBasePath, "issue-"+strconv.Itoa(issueNumber)),
+		BaseBranch:   cfg.Git.BaseBranch,
+		Repository:   cfg.GitHub.Repository,
+		Phase:        string(phase),
+	}
+	if abs, err := filepath.Abs(data.WorktreePath); err == nil {
+		data.WorktreePath = abs
+	}
+	if parts := strings.Split(cfg.GitHub.Repository, "/"); len(parts) == 2 {
+		data.Owner, data.Repo = parts[0], parts[1]
+	}
+
+	if e.githubClient == nil || data.Owner == "" {
+		return data
+	}
+
+	issue, err := e.githubClient.GetIssue(ctx, data.Owner, data.Repo, issueNumber)
+	if err != nil {
+		e.logger.Warn(ctx, "Failed to fetch issue for phase command",
+			logging.Field{Key: "error", Value: err.Error()},
+			logging.Field{Key: "issue", Value: issueNumber},
+		)
+	} else {
+		data.IssueTitle = issue.Title
+		for _, label := range issue.Labels {
+			data.Labels = append(data.Labels, label.Name)
+		}
+		data.Profile = issueProfile(data.Labels, issue.Body)
+	}
+
+	// PRはimplementフェーズで作られるため、それ以降のフェーズでのみ探す
+	if phase == domain.PhaseReview || phase == domain.PhaseRevise {
+		prs, _, err := e.githubClient.ListPullRequests(ctx, data.Owner, data.Repo, &github.ListPullRequestsOptions{
+			State:   "open",
+			PerPage: 100,
+		})
+		if err != nil {
+			e.logger.Warn(ctx, "Failed to list pull requests for phase command",
+				logging.Field{Key: "error", Value: err.Error()},
+				logging.Field{Key: "issue", Value: issueNumber},
+			)
+			prs = nil
+		}
+		data.PRNumber = NewIssueLinker(e.githubClient, data.Owner, data.Repo).PullRequestForIssue(ctx, issueNumber, prs)
+	}
+
+	return data
+}
+
+// issueProfile はIssueのコマンドプロファイル名を返す。指定がない場合は空文字を返す
+// soba:profile:<name>ラベルを本文のfront matterより優先する
+func issueProfile(labels []string, body string) string {
+	for _, label := range labels {
+		if name := strings.TrimPrefix(label, domain.LabelProfilePrefix); name != label && name != "" {
+			return name
+		}
+	}
+	return frontMatterProfile(body)
+}
+
+// frontMatterProfile は本文先頭のYAML front matterからprofileの値を取り出す
+//
+//	---
+//	profile: thorough
+//	---
+func frontMatterProfile(body string) string {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	if !strings.HasPrefix(body, "---\n") {
+		return ""
+	}
+	rest := body[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return ""
+	}
+
+	var frontMatter struct {
+		Profile string `yaml:"profile"`
+	}
+	if err := yaml.Unmarshal([]byte(rest[:end]), &frontMatter); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(frontMatter.Profile)
+}
+
+// phaseWorkdir はコマンドを実行するディレクトリを返す。移動しない場合は空文字を返す
+func phaseWorkdir(cfg *config.Config, phaseCommand config.PhaseCommand, issueNumber int, phase domain.Phase, data PhaseCommandData) (string, error) {
+	if phaseCommand.Workdir != "" {
+		return renderPhaseTemplate("workdir", phaseCommand.Workdir, data)
+	}
+	if requiresWorktree(phase) {
+		return fmt.Sprintf("%s/issue-%d", cfg.Git.WorktreeBasePath, issueNumber), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "--dangerously-skip-permissions", shellQuote("--dangerously-skip-permissions"))
+	assert.Equal(t, "/soba:plan", shellQuote("/soba:plan"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, "'Fix the $HOME bug'", shellQuote("Fix the $HOME bug"))
+	assert.Equal(t, `'it'\''s "quoted"'`, shellQuote(`it's "quoted"`))
+}
+
+func TestBuildPhaseCommand(t *testing.T) {
+	data := PhaseCommandData{
+		IssueNumber:  42,
+		IssueTitle:   "Don't break `main`",
+		Labels:       []string{"soba:doing", "bug"},
+		Branch:       "soba/42",
+		WorktreePath: "/repo/.git/soba/worktrees/issue-42",
+		BaseBranch:   "main",
+		Repository:   "owner/repo",
+		Owner:        "owner",
+		Repo:         "repo",
+		Phase:        "implement",
+	}
+
+	t.Run("全てのフィールドでテンプレート変数を展開してクォートする", func(t *testing.T) {
+		command, err := buildPhaseCommand(config.PhaseCommand{
+			Command:   "./scripts/{{.Phase}}.sh",
+			Options:   []string{"--title={{.IssueTitle}}", "--labels={{join .Labels \",\"}}", "--base", "{{.BaseBranch}}"},
+			Parameter: "{{.Repository}}#{{.IssueNumber}} on {{.Branch}}",
+		}, data, config.QuotedPhaseCommandsVersion)
+
+		require.NoError(t, err)
+		assert.Equal(t, `./scripts/implement.sh '--title=Don'\''t break `+"`main`"+`' --labels=soba:doing,bug --base main 'owner/repo#42 on soba/42'`, command)
+	})
+
+	t.Run("空になったオプションは除外する", func(t *testing.T) {
+		command, err := buildPhaseCommand(config.PhaseCommand{
+			Command: "agent",
+			Options: []string{"{{if .PRNumber}}--pr={{.PRNumber}}{{end}}", "--issue={{.IssueNumber}}"},
+		}, data, config.QuotedPhaseCommandsVersion)
+
+		require.NoError(t, err)
+		assert.Equal(t, "agent --issue=42", command)
+	})
+
+	t.Run("envは変数名順にコマンドの前に付ける", func(t *testing.T) {
+		command, err := buildPhaseCommand(config.PhaseCommand{
+			Command: "claude",
+			Env: map[string]string{
+				"SOBA_WORKTREE": "{{.WorktreePath}}",
+				"SOBA_ISSUE":    "{{.IssueNumber}}",
+				"SOBA_TITLE":    "{{.IssueTitle}}",
+			},
+		}, data, config.QuotedPhaseCommandsVersion)
+
+		require.NoError(t, err)
+		assert.Equal(t, `SOBA_ISSUE=42 SOBA_TITLE='Don'\''t break `+"`main`"+`' SOBA_WORKTREE=/repo/.git/soba/worktrees/issue-42 claude`, command)
+	})
+
+	t.Run("バージョン1の設定ではcommandとoptionsをそのまま連結する", func(t *testing.T) {
+		command, err := buildPhaseCommand(config.PhaseCommand{
+			Command:   "npx claude",
+			Options:   []string{"--model opus", "{{if .PRNumber}}--pr={{.PRNumber}}{{end}}"},
+			Parameter: "/soba:plan {{issue-number}} in $SOBA_DIR",
+			Env:       map[string]string{"SOBA_TITLE": "{{.IssueTitle}}"},
+		}, data, 1)
+
+		require.NoError(t, err)
+		assert.Equal(t, `SOBA_TITLE='Don'\''t break `+"`main`"+`' npx claude --model opus "/soba:plan 42 in $SOBA_DIR"`, command)
+	})
+
+	t.Run("不正なテンプレートと環境変数名はエラー", func(t *testing.T) {
+		_, err := buildPhaseCommand(config.PhaseCommand{Command: "echo", Parameter: "{{.Unknown}}"}, data, config.QuotedPhaseCommandsVersion)
+		assert.ErrorContains(t, err, "parameter")
+
+		_, err = buildPhaseCommand(config.PhaseCommand{Command: "echo", Options: []string{"{{if}}"}}, data, config.QuotedPhaseCommandsVersion)
+		assert.ErrorContains(t, err, "options[0]")
+
+		_, err = buildPhaseCommand(config.PhaseCommand{Command: "echo", Env: map[string]string{"BAD-NAME": "x"}}, data, config.QuotedPhaseCommandsVersion)
+		assert.ErrorContains(t, err, "BAD-NAME")
+	})
+}
+
+func TestPhaseWorkdir(t *testing.T) {
+	cfg := &config.Config{Git: config.GitConfig{WorktreeBasePath: ".git/soba/worktrees"}}
+	data := PhaseCommandData{IssueNumber: 5, WorktreePath: "/repo/.git/soba/worktrees/issue-5"}
+
+	workdir, err := phaseWorkdir(cfg, config.PhaseCommand{}, 5, domain.PhaseImplement, data)
+	require.NoError(t, err)
+	assert.Equal(t, ".git/soba/worktrees/issue-5", workdir)
+
+	workdir, err = phaseWorkdir(cfg, config.PhaseCommand{}, 5, domain.PhaseReview, data)
+	require.NoError(t, err)
+	assert.Empty(t, workdir)
+
+	workdir, err = phaseWorkdir(cfg, config.PhaseCommand{Workdir: "{{.WorktreePath}}/web"}, 5, domain.PhaseReview, data)
+	require.NoError(t, err)
+	assert.Equal(t, "/repo/.git/soba/worktrees/issue-5/web", workdir)
+}
+
+func TestWorkflowExecutor_phaseCommandData(t *testing.T) {
+	cfg := &config.Config{
+		GitHub: config.GitHubConfig{Repository: "owner/repo"},
+		Git:    config.GitConfig{WorktreeBasePath: ".git/soba/worktrees", BaseBranch: "develop"},
+	}
+	mockClient := &MockGitHubClientForPR{
+		prs:         []github.PullRequest{{Number: 30, Head: github.PullRequestBranch{Ref: "soba/9"}}},
+		issueLabels: map[int][]string{9: {domain.LabelReviewing, "frontend"}},
+		issueTitles: map[int]string{9: "Add dark mode"},
+	}
+	executor := &workflowExecutor{githubClient: mockClient, logger: logging.NewMockLogger()}
+
+	t.Run("Issue・PR・リポジトリの情報を集める", func(t *testing.T) {
+		data := executor.phaseCommandData(context.Background(), cfg, 9, domain.PhaseReview)
+
+		assert.Equal(t, 9, data.IssueNumber)
+		assert.Equal(t, "Add dark mode", data.IssueTitle)
+		assert.Equal(t, []string{domain.LabelReviewing, "frontend"}, data.Labels)
+		assert.Equal(t, "soba/9", data.Branch)
+		assert.Equal(t, "develop", data.BaseBranch)
+		assert.Equal(t, 30, data.PRNumber)
+		assert.Equal(t, "owner", data.Owner)
+		assert.Equal(t, "repo", data.Repo)
+		assert.Equal(t, "review", data.Phase)
+		assert.True(t, filepath.IsAbs(data.WorktreePath))
+		assert.Equal(t, "issue-9", filepath.Base(data.WorktreePath))
+	})
+
+	t.Run("PRが作られる前のフェーズではPRを探さない", func(t *testing.T) {
+		data := executor.phaseCommandData(context.Background(), cfg, 9, domain.PhaseImplement)
+		assert.Zero(t, data.PRNumber)
+	})
+
+	t.Run("GitHubクライアントがない場合は設定から分かる値のみ", func(t *testing.T) {
+		data := (&workflowExecutor{logger: logging.NewMockLogger()}).phaseCommandData(context.Background(), cfg, 9, domain.PhaseReview)
+		assert.Empty(t, data.IssueTitle)
+		assert.Zero(t, data.PRNumber)
+		assert.Equal(t, "owner/repo", data.Repository)
+	})
+}
+
+func TestIssueProfile(t *testing.T) {
+	body := "---\r\nprofile: thorough\r\n---\r\n\r\n画面を追加する"
+
+	t.Run("本文のfront matterからプロファイルを選ぶ", func(t *testing.T) {
+		assert.Equal(t, "thorough", issueProfile([]string{domain.LabelReady}, body))
+	})
+
+	t.Run("ラベルをfront matterより優先する", func(t *testing.T) {
+		assert.Equal(t, "fast", issueProfile([]string{domain.LabelReady, domain.LabelProfilePrefix + "fast"}, body))
+	})
+
+	t.Run("指定がない場合は空文字", func(t *testing.T) {
+		assert.Empty(t, issueProfile(nil, "profile: thorough"))
+		assert.Empty(t, issueProfile(nil, "---\nprofile: thorough\n"))
+		assert.Empty(t, issueProfile([]string{domain.LabelProfilePrefix}, ""))
+	})
+}
+
+func TestWorkflowExecutor_getPhaseCommand(t *testing.T) {
+	cfg := &config.Config{
+		Phase: config.PhaseConfig{
+			Implement: config.PhaseCommand{
+				Command:   "claude",
+				Options:   []string{"--dangerously-skip-permissions"},
+				Parameter: "/soba:implement {{.IssueNumber}}",
+			},
+		},
+		Profiles: map[string]config.PhaseConfig{
+			"frontend": {
+				Implement: config.PhaseCommand{Workdir: "{{.WorktreePath}}/web"},
+			},
+		},
+	}
+	executor := &workflowExecutor{logger: logging.NewMockLogger()}
+
+	t.Run("プロファイルで設定された項目だけを上書きする", func(t *testing.T) {
+		phaseCommand := executor.getPhaseCommand(cfg, domain.PhaseImplement, "frontend")
+		assert.Equal(t, "claude", phaseCommand.Command)
+		assert.Equal(t, "/soba:implement {{.IssueNumber}}", phaseCommand.Parameter)
+		assert.Equal(t, "{{.WorktreePath}}/web", phaseCommand.Workdir)
+	})
+
+	t.Run("未定義のプロファイルとプロファイルなしは既定のコマンド", func(t *testing.T) {
+		assert.Equal(t, cfg.Phase.Implement, executor.getPhaseCommand(cfg, domain.PhaseImplement, "unknown"))
+		assert.Equal(t, cfg.Phase.Implement, executor.getPhaseCommand(cfg, domain.PhaseImplement, ""))
+	})
+
+	t.Run("コマンドのないフェーズは空", func(t *testing.T) {
+		assert.Empty(t, executor.getPhaseCommand(cfg, domain.PhaseQueue, "frontend").Command)
+	})
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/tmux"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// defaultPhaseRunPollInterval はtmuxで実行したフェーズの完了ラベルを確認する間隔
+const defaultPhaseRunPollInterval = 10 * time.Second
+
+// PhaseRunOptions はsoba runで1つのフェーズを実行する方法
+type PhaseRunOptions struct {
+	Phase    domain.Phase   // 空の場合はIssueのラベルから判定する
+	Terminal *PhaseTerminal // nilの場合はtmuxのウィンドウで実行する
+	NoLabels bool           // trueの場合はラベルを更新しない
+}
+
+// PhaseRunResult はフェーズの実行結果
+type PhaseRunResult struct {
+	Issue           int
+	Phase           domain.Phase
+	Window          string   // コマンドを送ったtmuxのウィンドウ（端末で実行した場合は空）
+	CompletionLabel string   // 実行後に付いた完了ラベル（付いていない場合は空）
+	Labels          []string // 最後に確認したIssueのラベル
+	initialLabels   map[string]bool
+}
+
+// PhaseRunner はデーモンを起動せずに1つのIssueの1つのフェーズを実行する
+type PhaseRunner struct {
+	client       GitHubClientInterface
+	tmux         tmux.TmuxClient
+	workspace    GitWorkspaceManager
+	logger       logging.Logger
+	history      *EventHistory
+	pollInterval time.Duration
+}
+
+// NewPhaseRunner は新しいPhaseRunnerを作成する
+func NewPhaseRunner(client GitHubClientInterface, tmuxClient tmux.TmuxClient, workspace GitWorkspaceManager, logger logging.Logger) *PhaseRunner {
+	if logger == nil {
+		logger = logging.NewMockLogger()
+	}
+	return &PhaseRunner{
+		client:       client,
+		tmux:         tmuxClient,
+		workspace:    workspace,
+		logger:       logger,
+		pollInterval: defaultPhaseRunPollInterval,
+	}
+}
+
+// SetHistory はイベント履歴の記録先を設定する
+func (r *PhaseRunner) SetHistory(history *EventHistory) {
+	r.history = history
+}
+
+// Run はフェーズをWorkflowExecutor.ExecutePhaseで実行する
+// 端末で実行した場合はコマンドの終了後に付いている完了ラベルを結果に含める
+func (r *PhaseRunner) Run(ctx context.Context, cfg *config.Config, issueNumber int, opts PhaseRunOptions) (*PhaseRunResult, error) {
+	owner, repo, err := splitRepository(cfg.GitHub.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err := r.client.GetIssue(ctx, owner, repo, issueNumber)
+	if err != nil {
+		return nil, WrapServiceError(err, "failed to get issue")
+	}
+	labels := labelNames(issue.Labels)
+
+	phase, err := resolveRunPhase(opts.Phase, labels, opts.NoLabels)
+	if err != nil {
+		return nil, fmt.Errorf("issue #%d: %w", issueNumber, err)
+	}
+
+	executor := NewWorkflowExecutor(r.tmux, r.workspace, nil, r.logger).(*workflowExecutor)
+	executor.SetGitHubClient(r.client)
+	executor.SetHistory(r.history)
+	executor.terminal = opts.Terminal
+	var processor IssueProcessorUpdater
+	if !opts.NoLabels {
+		processor = NewIssueProcessor(r.client, executor)
+		executor.SetIssueProcessor(processor)
+	}
+
+	// 明示的な実行なので活動時間帯を待たない
+	runCfg := *cfg
+	runCfg.Workflow.Schedule = config.ScheduleConfig{}
+
+	result := &PhaseRunResult{
+		Issue:         issueNumber,
+		Phase:         phase,
+		Labels:        labels,
+		initialLabels: make(map[string]bool, len(labels)),
+	}
+	for _, label := range labels {
+		result.initialLabels[label] = true
+	}
+	if opts.Terminal == nil {
+		result.Window = fmt.Sprintf("%s:issue-%d", executor.generateSessionName(cfg.GitHub.Repository), issueNumber)
+	}
+
+	if err := executor.ExecutePhase(ctx, &runCfg, issueNumber, phase); err != nil {
+		// 実行ラベルのままではデーモンも再実行しないため、トリガーラベルに戻す
+		if processor != nil {
+			if restoreErr := r.restoreTriggerLabel(ctx, processor, owner, repo, issueNumber, phase); restoreErr != nil {
+				phaseDef := domain.PhaseDefinitions[string(phase)]
+				return nil, fmt.Errorf("%w; failed to restore the labels (%v): replace %s with %s on issue #%d to run the phase again",
+					err, restoreErr, phaseDef.ExecutionLabel, phaseDef.TriggerLabel, issueNumber)
+			}
+		}
+		return nil, err
+	}
+
+	if opts.Terminal != nil {
+		if _, err := r.refresh(ctx, owner, repo, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// WaitForCompletion はtmuxで実行したフェーズに完了ラベルが付くまで待つ
+// ラベルを更新して実行した場合は、実行ラベルが完了ラベルなしで外れた時点でも終了する
+func (r *PhaseRunner) WaitForCompletion(ctx context.Context, cfg *config.Config, result *PhaseRunResult, labelsUpdated bool) error {
+	owner, repo, err := splitRepository(cfg.GitHub.Repository)
+	if err != nil {
+		return err
+	}
+	phaseDef := domain.PhaseDefinitions[string(result.Phase)]
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		labels, err := r.refresh(ctx, owner, repo, result)
+		if err != nil {
+			r.logger.Warn(ctx, "Failed to check issue labels",
+				logging.Field{Key: "error", Value: err.Error()},
+				logging.Field{Key: "issue", Value: result.Issue},
+			)
+			continue
+		}
+		if result.CompletionLabel != "" {
+			return nil
+		}
+		if labelsUpdated && !containsLabel(labels, phaseDef.ExecutionLabel) {
+			return nil
+		}
+	}
+}
+
+// restoreTriggerLabel は失敗したフェーズの実行ラベルをトリガーラベルに戻す
+// 実行ラベルが付いていない場合（ラベルの更新前に失敗した、エージェントが先に進めたなど）は何もしない
+func (r *PhaseRunner) restoreTriggerLabel(ctx context.Context, processor IssueProcessorUpdater, owner, repo string, issueNumber int, phase domain.Phase) error {
+	phaseDef := domain.PhaseDefinitions[string(phase)]
+	issueLabels, err := r.client.GetIssueLabels(ctx, owner, repo, issueNumber)
+	if err != nil {
+		return err
+	}
+	if !containsLabel(labelNames(issueLabels), phaseDef.ExecutionLabel) {
+		return nil
+	}
+	return processor.UpdateLabels(ctx, issueNumber, phaseDef.ExecutionLabel, phaseDef.TriggerLabel)
+}
+
+// refresh はIssueのラベルを取得し直し、新しく付いた完了ラベルを結果に反映する
+func (r *PhaseRunner) refresh(ctx context.Context, owner, repo string, result *PhaseRunResult) ([]string, error) {
+	issueLabels, err := r.client.GetIssueLabels(ctx, owner, repo, result.Issue)
+	if err != nil {
+		return nil, WrapServiceError(err, "failed to get issue labels")
+	}
+	labels := labelNames(issueLabels)
+	result.Labels = labels
+
+	phaseDef := domain.PhaseDefinitions[string(result.Phase)]
+	for _, label := range labels {
+		if _, ok := phaseDef.CompletionLabels[label]; ok && !result.initialLabels[label] {
+			result.CompletionLabel = label
+			break
+		}
+	}
+	return labels, nil
+}
+
+// resolveRunPhase は実行するフェーズを決める
+// ラベルを更新する場合は、Issueの状態がそのフェーズのトリガーラベルか実行ラベルであることを確認する
+func resolveRunPhase(requested domain.Phase, labels []string, noLabels bool) (domain.Phase, error) {
+	phase := requested
+	if phase == "" {
+		current, err := domain.GetCurrentPhaseFromLabels(labels)
+		if err != nil {
+			return "", fmt.Errorf("cannot determine the phase from the labels (%w), use --phase", err)
+		}
+		phase = current
+	}
+
+	phaseDef := domain.PhaseDefinitions[string(phase)]
+	if phaseDef == nil || phaseDef.ExecutionType != domain.ExecutionTypeCommand {
+		return "", fmt.Errorf("invalid phase: %s. Valid phases are: %s", phase, strings.Join(commandPhases(), ", "))
+	}
+
+	if !noLabels && !containsLabel(labels, phaseDef.TriggerLabel) && !containsLabel(labels, phaseDef.ExecutionLabel) {
+		return "", fmt.Errorf("the %s phase needs the %s label, use --no-labels to run it without updating labels", phase, phaseDef.TriggerLabel)
+	}
+	return phase, nil
+}
+
+// commandPhases はコマンドを実行するフェーズをワークフローの順に返す
+func commandPhases() []string {
+	var phases []string
+	for _, phase := range []domain.Phase{domain.PhasePlan, domain.PhaseImplement, domain.PhaseReview, domain.PhaseRevise} {
+		if def := domain.PhaseDefinitions[string(phase)]; def != nil && def.ExecutionType == domain.ExecutionTypeCommand {
+			phases = append(phases, string(phase))
+		}
+	}
+	return phases
+}
+
+// labelNames はラベルの名前だけを取り出す
+func labelNames(labels []github.Label) []string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+// splitRepository は"owner/repo"形式のリポジトリ名を分割する
+func splitRepository(repository string) (string, string, error) {
+	parts := strings.Split(repository, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid repository configuration: %q", repository)
+	}
+	return parts[0], parts[1], nil
+}
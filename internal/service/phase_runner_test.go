@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// phaseRunGitHubClient はIssueのラベルを保持し、ラベルの更新を反映するテスト用クライアント
+type phaseRunGitHubClient struct {
+	MockGitHubClient
+	labels []string
+}
+
+func newPhaseRunGitHubClient(labels ...string) *phaseRunGitHubClient {
+	c := &phaseRunGitHubClient{labels: labels}
+	c.updateIssueLabelsFunc = func(ctx context.Context, owner, repo string, issueNumber int, labels []string) error {
+		c.labels = labels
+		return nil
+	}
+	c.getIssueLabelsFunc = func(ctx context.Context, owner, repo string, issueNumber int) ([]github.Label, error) {
+		return c.issueLabels(), nil
+	}
+	return c
+}
+
+func (c *phaseRunGitHubClient) issueLabels() []github.Label {
+	labels := make([]github.Label, 0, len(c.labels))
+	for _, name := range c.labels {
+		labels = append(labels, github.Label{Name: name})
+	}
+	return labels
+}
+
+func (c *phaseRunGitHubClient) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*github.Issue, error) {
+	return &github.Issue{Number: issueNumber, State: "open", Labels: c.issueLabels()}, nil
+}
+
+func newPhaseRunConfig(t *testing.T, command string, parameter string) *config.Config {
+	cfg := &config.Config{}
+	cfg.GitHub.Repository = "owner/repo"
+	cfg.Phase.Plan = config.PhaseCommand{Command: command, Parameter: parameter, Workdir: t.TempDir()}
+	return cfg
+}
+
+func TestResolveRunPhase(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested domain.Phase
+		labels    []string
+		noLabels  bool
+		want      domain.Phase
+		wantErr   string
+	}{
+		{name: "ラベルからフェーズを判定する", labels: []string{"soba:queued"}, want: domain.PhasePlan},
+		{name: "実行中ラベルでも再実行できる", labels: []string{"soba:reviewing"}, want: domain.PhaseReview},
+		{name: "指定したフェーズのトリガーラベルがあれば実行する", requested: domain.PhaseRevise, labels: []string{"soba:requires-changes"}, want: domain.PhaseRevise},
+		{name: "トリガーラベルがない場合はエラー", requested: domain.PhaseImplement, labels: []string{"soba:queued"}, wantErr: "--no-labels"},
+		{name: "no-labelsならラベルを問わない", requested: domain.PhaseImplement, labels: nil, noLabels: true, want: domain.PhaseImplement},
+		{name: "ラベルなしでフェーズ未指定はエラー", labels: []string{"bug"}, wantErr: "use --phase"},
+		{name: "コマンドを実行しないフェーズはエラー", requested: domain.PhaseQueue, labels: []string{"soba:todo"}, wantErr: "plan, implement, review, revise"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phase, err := resolveRunPhase(tt.requested, tt.labels, tt.noLabels)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, phase)
+		})
+	}
+}
+
+func TestPhaseRunner_RunInTerminal(t *testing.T) {
+	chdirTemp(t)
+
+	t.Run("ラベルを更新してコマンドを端末で実行する", func(t *testing.T) {
+		client := newPhaseRunGitHubClient("soba:queued")
+		// フェーズのコマンドの代わりに完了ラベルを付ける
+		client.updateIssueLabelsFunc = func(ctx context.Context, owner, repo string, issueNumber int, labels []string) error {
+			client.labels = append(labels, domain.LabelReady)
+			return nil
+		}
+		runner := NewPhaseRunner(client, new(MockTmuxClient), nil, logging.NewMockLogger())
+		var out bytes.Buffer
+
+		result, err := runner.Run(context.Background(), newPhaseRunConfig(t, "echo", "planned"), 12, PhaseRunOptions{
+			Terminal: &PhaseTerminal{Stdout: &out, Stderr: &out},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.PhasePlan, result.Phase)
+		assert.Empty(t, result.Window)
+		assert.Equal(t, domain.LabelReady, result.CompletionLabel)
+		assert.NotContains(t, client.labels, "soba:queued")
+		assert.Equal(t, "planned\n", out.String())
+	})
+
+	t.Run("no-labelsではラベルを変更しない", func(t *testing.T) {
+		client := newPhaseRunGitHubClient()
+		runner := NewPhaseRunner(client, new(MockTmuxClient), nil, logging.NewMockLogger())
+		var out bytes.Buffer
+
+		result, err := runner.Run(context.Background(), newPhaseRunConfig(t, "echo", "planned"), 12, PhaseRunOptions{
+			Phase:    domain.PhasePlan,
+			Terminal: &PhaseTerminal{Stdout: &out, Stderr: &out},
+			NoLabels: true,
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, client.labels)
+		assert.Empty(t, result.CompletionLabel)
+	})
+
+	t.Run("コマンドが失敗した場合はトリガーラベルに戻してエラー", func(t *testing.T) {
+		client := newPhaseRunGitHubClient("bug", "soba:queued")
+		runner := NewPhaseRunner(client, new(MockTmuxClient), nil, logging.NewMockLogger())
+		var out bytes.Buffer
+
+		_, err := runner.Run(context.Background(), newPhaseRunConfig(t, "false", ""), 12, PhaseRunOptions{
+			Terminal: &PhaseTerminal{Stdout: &out, Stderr: &out},
+		})
+
+		require.Error(t, err)
+		assert.ElementsMatch(t, []string{"bug", domain.LabelQueued}, client.labels)
+	})
+
+	t.Run("エージェントが先に進めたラベルは戻さない", func(t *testing.T) {
+		client := newPhaseRunGitHubClient("soba:queued")
+		runner := NewPhaseRunner(client, new(MockTmuxClient), nil, logging.NewMockLogger())
+		var out bytes.Buffer
+
+		cfg := newPhaseRunConfig(t, "false", "")
+		client.getIssueLabelsFunc = func(ctx context.Context, owner, repo string, issueNumber int) ([]github.Label, error) {
+			// 実行ラベルに変えた後は、エージェントが完了ラベルを付けたものとする
+			if containsLabel(client.labels, domain.LabelPlanning) {
+				client.labels = []string{domain.LabelReady}
+			}
+			return client.issueLabels(), nil
+		}
+
+		_, err := runner.Run(context.Background(), cfg, 12, PhaseRunOptions{
+			Terminal: &PhaseTerminal{Stdout: &out, Stderr: &out},
+		})
+
+		require.Error(t, err)
+		assert.Equal(t, []string{domain.LabelReady}, client.labels)
+	})
+
+	t.Run("使用量を記録する設定でも端末ではラッパーを挟まない", func(t *testing.T) {
+		cfg := newPhaseRunConfig(t, "claude", "")
+		cfg.Workflow.Usage.Enabled = true
+		executor := NewWorkflowExecutor(new(MockTmuxClient), nil, nil, logging.NewMockLogger()).(*workflowExecutor)
+		executor.executable = "/usr/local/bin/soba"
+
+		command, _, err := executor.shellCommand(cfg, 12, domain.PhasePlan)
+		require.NoError(t, err)
+		assert.Contains(t, command, "cost record")
+
+		executor.terminal = &PhaseTerminal{}
+		command, _, err = executor.shellCommand(cfg, 12, domain.PhasePlan)
+		require.NoError(t, err)
+		assert.NotContains(t, command, "cost record")
+	})
+
+}
+
+func TestPhaseRunner_WaitForCompletion(t *testing.T) {
+	t.Run("完了ラベルが付くまで待つ", func(t *testing.T) {
+		client := newPhaseRunGitHubClient("soba:planning")
+		runner := NewPhaseRunner(client, new(MockTmuxClient), nil, logging.NewMockLogger())
+		runner.pollInterval = 5 * time.Millisecond
+		result := &PhaseRunResult{Issue: 3, Phase: domain.PhasePlan, initialLabels: map[string]bool{"soba:planning": true}}
+
+		polls := 0
+		client.getIssueLabelsFunc = func(ctx context.Context, owner, repo string, issueNumber int) ([]github.Label, error) {
+			polls++
+			if polls == 3 {
+				client.labels = []string{domain.LabelReady}
+			}
+			return client.issueLabels(), nil
+		}
+
+		require.NoError(t, runner.WaitForCompletion(context.Background(), newPhaseRunConfig(t, "claude", ""), result, true))
+		assert.Equal(t, domain.LabelReady, result.CompletionLabel)
+		assert.Equal(t, 3, polls)
+	})
+
+	t.Run("実行ラベルが外れたら完了ラベルなしで終了する", func(t *testing.T) {
+		client := newPhaseRunGitHubClient("bug")
+		runner := NewPhaseRunner(client, new(MockTmuxClient), nil, logging.NewMockLogger())
+		runner.pollInterval = 5 * time.Millisecond
+		result := &PhaseRunResult{Issue: 3, Phase: domain.PhasePlan, initialLabels: map[string]bool{}}
+
+		require.NoError(t, runner.WaitForCompletion(context.Background(), newPhaseRunConfig(t, "claude", ""), result, true))
+		assert.Empty(t, result.CompletionLabel)
+		assert.Equal(t, []string{"bug"}, result.Labels)
+	})
+
+	t.Run("キャンセルされたら待つのをやめる", func(t *testing.T) {
+		client := newPhaseRunGitHubClient("bug")
+		runner := NewPhaseRunner(client, new(MockTmuxClient), nil, logging.NewMockLogger())
+		runner.pollInterval = 5 * time.Millisecond
+		result := &PhaseRunResult{Issue: 3, Phase: domain.PhasePlan, initialLabels: map[string]bool{}}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := runner.WaitForCompletion(ctx, newPhaseRunConfig(t, "claude", ""), result, false)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, result.CompletionLabel)
+	})
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/tmux"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// PostMergeHandler はPRマージ後の後片付けを行う
+// 各ステップはworkflow.post_mergeの設定で個別に有効化され、失敗しても後続のステップは継続する
+type PostMergeHandler struct {
+	client    GitHubClientInterface
+	workspace GitWorkspaceManager
+	tmux      tmux.TmuxClient
+	logger    logging.Logger
+	history   *EventHistory
+}
+
+// NewPostMergeHandler は新しいPostMergeHandlerを作成する
+func NewPostMergeHandler(client GitHubClientInterface) *PostMergeHandler {
+	return &PostMergeHandler{
+		client: client,
+		logger: logging.NewMockLogger(),
+	}
+}
+
+// SetLogger はロガーを設定する
+func (h *PostMergeHandler) SetLogger(log logging.Logger) {
+	h.logger = log
+}
+
+// SetWorkspaceManager はworktreeの削除に使うGitWorkspaceManagerを設定する
+func (h *PostMergeHandler) SetWorkspaceManager(workspace GitWorkspaceManager) {
+	h.workspace = workspace
+}
+
+// SetHistory はイベント履歴の記録先を設定する
+func (h *PostMergeHandler) SetHistory(history *EventHistory) {
+	h.history = history
+}
+
+// SetTmuxClient はウィンドウの削除に使うtmuxクライアントを設定する
+func (h *PostMergeHandler) SetTmuxClient(tmuxClient tmux.TmuxClient) {
+	h.tmux = tmuxClient
+}
+
+// Handle はマージされたPRとそのIssueに対して後片付けを実行する
+// issueNumberが0の場合、Issueに関わるステップはスキップする
+func (h *PostMergeHandler) Handle(ctx context.Context, cfg *config.Config, pr github.PullRequest, mergeSHA string, issueNumber int) {
+	opts := cfg.Workflow.PostMerge
+	parts := strings.Split(cfg.GitHub.Repository, "/")
+	if len(parts) != 2 {
+		return
+	}
+	owner, repo := parts[0], parts[1]
+
+	var done []string
+	if opts.DeleteRemoteBranch && h.deleteRemoteBranch(ctx, owner, repo, pr, cfg.Git.BaseBranch) {
+		done = append(done, "deleted branch "+pr.Head.Ref)
+	}
+
+	if issueNumber <= 0 {
+		h.logger.Warn(ctx, "Merged PR has no linked issue, skipping issue cleanup",
+			logging.Field{Key: "pr", Value: pr.Number},
+		)
+		return
+	}
+
+	if opts.CloseIssue && h.closeIssue(ctx, owner, repo, pr, mergeSHA, issueNumber) {
+		done = append(done, "closed issue")
+	}
+
+	if opts.KillTmuxWindow && h.killWindow(ctx, cfg.GitHub.Repository, issueNumber) {
+		done = append(done, "killed tmux window")
+	}
+
+	if opts.RemoveWorktree && h.workspace != nil {
+		if err := h.workspace.CleanupWorkspace(issueNumber); err != nil {
+			h.logger.Warn(ctx, "Failed to remove worktree after merge",
+				logging.Field{Key: "issue", Value: issueNumber},
+				logging.Field{Key: "error", Value: err.Error()},
+			)
+		} else {
+			h.logger.Info(ctx, "Removed worktree and local branch after merge",
+				logging.Field{Key: "issue", Value: issueNumber},
+			)
+			done = append(done, "removed worktree")
+		}
+	}
+
+	if len(done) > 0 {
+		h.history.Record(ctx, HistoryEvent{
+			Issue:  issueNumber,
+			Type:   HistoryCleanedUp,
+			PR:     pr.Number,
+			Detail: strings.Join(done, ", "),
+		})
+	}
+}
+
+// deleteRemoteBranch はPRのheadブランチを削除する。削除した場合はtrueを返す
+func (h *PostMergeHandler) deleteRemoteBranch(ctx context.Context, owner, repo string, pr github.PullRequest, baseBranch string) bool {
+	branch := pr.Head.Ref
+	if branch == "" || branch == pr.Base.Ref || branch == baseBranch {
+		return false
+	}
+	// フォークからのPRのブランチは削除できない
+	if pr.Head.Label != "" && !strings.HasPrefix(pr.Head.Label, owner+":") {
+		return false
+	}
+
+	if err := h.client.DeleteBranch(ctx, owner, repo, branch); err != nil {
+		h.logger.Warn(ctx, "Failed to delete remote branch after merge",
+			logging.Field{Key: "pr", Value: pr.Number},
+			logging.Field{Key: "branch", Value: branch},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return false
+	}
+	return true
+}
+
+// closeIssue はGitHubが自動でクローズしなかったIssueをコメント付きでクローズする。クローズした場合はtrueを返す
+func (h *PostMergeHandler) closeIssue(ctx context.Context, owner, repo string, pr github.PullRequest, mergeSHA string, issueNumber int) bool {
+	issue, err := h.client.GetIssue(ctx, owner, repo, issueNumber)
+	if err != nil {
+		h.logger.Warn(ctx, "Failed to get issue after merge",
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return false
+	}
+	if issue.State == "closed" {
+		h.logger.Debug(ctx, "Issue already closed by GitHub",
+			logging.Field{Key: "issue", Value: issueNumber},
+		)
+		return false
+	}
+
+	if err := h.client.CreateComment(ctx, owner, repo, issueNumber, buildMergeSummaryComment(pr, mergeSHA)); err != nil {
+		h.logger.Warn(ctx, "Failed to post merge summary comment",
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+
+	if err := h.client.CloseIssue(ctx, owner, repo, issueNumber); err != nil {
+		h.logger.Warn(ctx, "Failed to close issue after merge",
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return false
+	}
+
+	h.logger.Info(ctx, "Closed issue after merge",
+		logging.Field{Key: "issue", Value: issueNumber},
+		logging.Field{Key: "pr", Value: pr.Number},
+	)
+	return true
+}
+
+// killWindow はIssueのtmuxウィンドウを削除する。削除した場合はtrueを返す
+func (h *PostMergeHandler) killWindow(ctx context.Context, repository string, issueNumber int) bool {
+	if h.tmux == nil {
+		return false
+	}
+
+	sessionName := tmuxSessionName(repository)
+	windowName := fmt.Sprintf("issue-%d", issueNumber)
+
+	exists, err := h.tmux.WindowExists(sessionName, windowName)
+	if err != nil || !exists {
+		return false
+	}
+
+	if err := h.tmux.DeleteWindow(sessionName, windowName); err != nil {
+		h.logger.Warn(ctx, "Failed to kill tmux window after merge",
+			logging.Field{Key: "session", Value: sessionName},
+			logging.Field{Key: "window", Value: windowName},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return false
+	}
+	return true
+}
+
+// buildMergeSummaryComment はIssueをクローズする際のコメント本文を生成する
+func buildMergeSummaryComment(pr github.PullRequest, mergeSHA string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("## Merged\n\nThis issue was resolved by #%d", pr.Number))
+	if pr.Title != "" {
+		b.WriteString(fmt.Sprintf(" (%s)", pr.Title))
+	}
+	b.WriteString(".\n")
+	if mergeSHA != "" {
+		b.WriteString(fmt.Sprintf("\nMerge commit: %s\n", mergeSHA))
+	}
+	return b.String()
+}
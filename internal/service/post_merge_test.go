@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+// recordingWorkspaceManager はクリーンアップ対象を記録するGitWorkspaceManager
+type recordingWorkspaceManager struct {
+	cleaned []int
+}
+
+func (m *recordingWorkspaceManager) PrepareWorkspace(issueNumber int) error {
+	return nil
+}
+
+func (m *recordingWorkspaceManager) CleanupWorkspace(issueNumber int) error {
+	m.cleaned = append(m.cleaned, issueNumber)
+	return nil
+}
+
+func newPostMergeConfig(opts config.PostMergeConfig) *config.Config {
+	return &config.Config{
+		GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+		Git:      config.GitConfig{BaseBranch: "main"},
+		Workflow: config.WorkflowConfig{PostMerge: opts},
+	}
+}
+
+func TestPostMergeHandler_Handle(t *testing.T) {
+	ctx := context.Background()
+	pr := github.PullRequest{
+		Number: 20,
+		Title:  "Add feature",
+		Head:   github.PullRequestBranch{Ref: "soba/7", Label: "owner:soba/7"},
+		Base:   github.PullRequestBranch{Ref: "main"},
+	}
+	allSteps := config.PostMergeConfig{
+		CloseIssue:         true,
+		DeleteRemoteBranch: true,
+		RemoveWorktree:     true,
+		KillTmuxWindow:     true,
+	}
+
+	t.Run("全てのステップを実行する", func(t *testing.T) {
+		client := &MockGitHubClientForPR{}
+		workspace := &recordingWorkspaceManager{}
+		tmuxClient := new(StatusMockTmuxClient)
+		tmuxClient.On("WindowExists", "soba-owner-repo", "issue-7").Return(true, nil)
+		tmuxClient.On("DeleteWindow", "soba-owner-repo", "issue-7").Return(nil)
+
+		handler := NewPostMergeHandler(client)
+		handler.SetWorkspaceManager(workspace)
+		handler.SetTmuxClient(tmuxClient)
+		handler.Handle(ctx, newPostMergeConfig(allSteps), pr, "abc123", 7)
+
+		assert.Equal(t, []string{"soba/7"}, client.deletedRefs)
+		assert.Equal(t, []int{7}, client.closedIssues)
+		require.Len(t, client.comments, 1)
+		assert.Equal(t, 7, client.comments[0].number)
+		assert.Contains(t, client.comments[0].body, "#20")
+		assert.Contains(t, client.comments[0].body, "abc123")
+		assert.Equal(t, []int{7}, workspace.cleaned)
+		tmuxClient.AssertExpectations(t)
+	})
+
+	t.Run("GitHubがクローズ済みのIssueにはコメントしない", func(t *testing.T) {
+		client := &MockGitHubClientForPR{issueStates: map[int]string{7: "closed"}}
+
+		handler := NewPostMergeHandler(client)
+		handler.Handle(ctx, newPostMergeConfig(config.PostMergeConfig{CloseIssue: true}), pr, "abc123", 7)
+
+		assert.Empty(t, client.closedIssues)
+		assert.Empty(t, client.comments)
+	})
+
+	t.Run("無効化されたステップは実行しない", func(t *testing.T) {
+		client := &MockGitHubClientForPR{}
+		workspace := &recordingWorkspaceManager{}
+		tmuxClient := new(StatusMockTmuxClient)
+
+		handler := NewPostMergeHandler(client)
+		handler.SetWorkspaceManager(workspace)
+		handler.SetTmuxClient(tmuxClient)
+		handler.Handle(ctx, newPostMergeConfig(config.PostMergeConfig{}), pr, "abc123", 7)
+
+		assert.Empty(t, client.deletedRefs)
+		assert.Empty(t, client.closedIssues)
+		assert.Empty(t, workspace.cleaned)
+		tmuxClient.AssertNotCalled(t, "WindowExists", mock.Anything, mock.Anything)
+	})
+
+	t.Run("フォークのブランチとIssue不明の場合は対象外", func(t *testing.T) {
+		client := &MockGitHubClientForPR{}
+		workspace := &recordingWorkspaceManager{}
+		forkPR := pr
+		forkPR.Head.Label = "someone:soba/7"
+
+		handler := NewPostMergeHandler(client)
+		handler.SetWorkspaceManager(workspace)
+		handler.Handle(ctx, newPostMergeConfig(allSteps), forkPR, "abc123", 0)
+
+		assert.Empty(t, client.deletedRefs)
+		assert.Empty(t, client.closedIssues)
+		assert.Empty(t, workspace.cleaned)
+	})
+}
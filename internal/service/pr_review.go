@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// GitHubのレビュー状態
+const (
+	reviewStateApproved         = "APPROVED"
+	reviewStateChangesRequested = "CHANGES_REQUESTED"
+	reviewStateDismissed        = "DISMISSED"
+)
+
+// reviewSummary はレビュアーごとの最新のレビュー状態を集計したもの
+type reviewSummary struct {
+	approvals      []github.PullRequestReview
+	changeRequests []github.PullRequestReview
+}
+
+// summarizeReviews は人間のレビュアーごとに最新の承認・修正要求を集計する
+// PR作成者（soba自身）とBotのレビュー、コメントのみのレビューは対象外
+func summarizeReviews(reviews []github.PullRequestReview, author string) reviewSummary {
+	latest := make(map[string]github.PullRequestReview)
+	var reviewers []string
+	for _, review := range reviews {
+		login := review.User.Login
+		if login == "" || login == author || review.User.Type == "Bot" {
+			continue
+		}
+		switch review.State {
+		case reviewStateApproved, reviewStateChangesRequested, reviewStateDismissed:
+		default:
+			continue
+		}
+		if _, ok := latest[login]; !ok {
+			reviewers = append(reviewers, login)
+		}
+		latest[login] = review
+	}
+	sort.Strings(reviewers)
+
+	var summary reviewSummary
+	for _, login := range reviewers {
+		review := latest[login]
+		switch review.State {
+		case reviewStateApproved:
+			summary.approvals = append(summary.approvals, review)
+		case reviewStateChangesRequested:
+			summary.changeRequests = append(summary.changeRequests, review)
+		}
+	}
+	return summary
+}
+
+// reviewGateEnabled は人間のレビューを参照する設定かを返す
+func (w *PRWatcher) reviewGateEnabled() bool {
+	opts := w.config.Workflow.HumanReview
+	return opts.RequestChanges || opts.RequiredApprovals > 0
+}
+
+// applyHumanReviews は人間のレビューをIssue/PRのラベルに反映する
+// 戻り値のPRにはsoba:lgtmの付与が反映される。falseを返した場合はマージを保留する
+func (w *PRWatcher) applyHumanReviews(ctx context.Context, pr github.PullRequest) (github.PullRequest, bool) {
+	opts := w.config.Workflow.HumanReview
+	owner, repo := w.parseRepository()
+	if owner == "" || repo == "" {
+		return pr, true
+	}
+
+	// タイムラインやタイトルで紐づいただけのIssueはラベルを変更しない
+	issueNumber := w.linker.TrustedIssueForPullRequest(ctx, pr)
+	if issueNumber == 0 {
+		return pr, true
+	}
+
+	reviews, err := w.client.ListPullRequestReviews(ctx, owner, repo, pr.Number)
+	if err != nil {
+		w.logger.Warn(ctx, "Failed to list pull request reviews",
+			logging.Field{Key: "number", Value: pr.Number},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		// 承認数を確認できない場合はマージしない
+		return pr, opts.RequiredApprovals == 0
+	}
+	summary := summarizeReviews(reviews, pr.User.Login)
+
+	if opts.RequestChanges && len(summary.changeRequests) > 0 {
+		if err := w.requestChangesFromReviews(ctx, owner, repo, pr, issueNumber, summary.changeRequests); err != nil {
+			w.logger.Error(ctx, "Failed to request changes from review",
+				logging.Field{Key: "number", Value: pr.Number},
+				logging.Field{Key: "issue", Value: issueNumber},
+				logging.Field{Key: "error", Value: err.Error()},
+			)
+		}
+		// 修正要求が解消されるまではマージしない
+		return pr, false
+	}
+
+	if opts.RequiredApprovals <= 0 {
+		return pr, true
+	}
+
+	if len(summary.approvals) < opts.RequiredApprovals {
+		if w.hasLGTMLabel(pr) {
+			w.logger.Info(ctx, "PR is waiting for human approvals",
+				logging.Field{Key: "number", Value: pr.Number},
+				logging.Field{Key: "approvals", Value: len(summary.approvals)},
+				logging.Field{Key: "required", Value: opts.RequiredApprovals},
+			)
+		}
+		return pr, false
+	}
+
+	if w.hasLGTMLabel(pr) {
+		return pr, true
+	}
+
+	if opts.RequireAIApproval && !w.issueHasLabel(ctx, owner, repo, issueNumber, domain.LabelDone) {
+		w.logger.Debug(ctx, "PR is approved by humans, waiting for AI review",
+			logging.Field{Key: "number", Value: pr.Number},
+			logging.Field{Key: "issue", Value: issueNumber},
+		)
+		return pr, false
+	}
+
+	if err := w.client.AddLabelToIssue(ctx, owner, repo, pr.Number, domain.LabelLGTM); err != nil {
+		w.logger.Error(ctx, "Failed to add lgtm label to approved PR",
+			logging.Field{Key: "number", Value: pr.Number},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return pr, false
+	}
+	w.logger.Info(ctx, "PR approved by human reviewers",
+		logging.Field{Key: "number", Value: pr.Number},
+		logging.Field{Key: "approvals", Value: len(summary.approvals)},
+	)
+	pr.Labels = append(pr.Labels, github.Label{Name: domain.LabelLGTM})
+	return pr, true
+}
+
+// requestChangesFromReviews は人間の修正要求に応じてIssueをsoba:requires-changesに戻す
+// 修正要求は現在のheadに対するもののみ扱い、同じレビューは一度だけ処理する
+func (w *PRWatcher) requestChangesFromReviews(ctx context.Context, owner, repo string, pr github.PullRequest, issueNumber int, changeRequests []github.PullRequestReview) error {
+	var pending []github.PullRequestReview
+	for _, review := range changeRequests {
+		if review.CommitID != "" && review.CommitID != pr.Head.SHA {
+			continue
+		}
+		if w.handledReviews[review.ID] {
+			continue
+		}
+		pending = append(pending, review)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	labels, err := w.client.GetIssueLabels(ctx, owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get labels of issue #%d: %w", issueNumber, err)
+	}
+	// レビュー待ちかAIレビュー承認後のIssueのみ戻す（実装中・修正中・AIレビュー中は完了を待つ）
+	var current string
+	for _, label := range labels {
+		if label.Name == domain.LabelReviewRequested || label.Name == domain.LabelDone {
+			current = label.Name
+		}
+	}
+	if current == "" {
+		return nil
+	}
+
+	w.logger.Info(ctx, "Human reviewer requested changes",
+		logging.Field{Key: "number", Value: pr.Number},
+		logging.Field{Key: "issue", Value: issueNumber},
+		logging.Field{Key: "reviews", Value: len(pending)},
+	)
+
+	if w.hasLGTMLabel(pr) {
+		if err := w.client.RemoveLabelFromIssue(ctx, owner, repo, pr.Number, domain.LabelLGTM); err != nil {
+			return fmt.Errorf("failed to remove %s from PR #%d: %w", domain.LabelLGTM, pr.Number, err)
+		}
+	}
+
+	// reviseコマンドはPRコメントを参照するため、インラインコメントも含めてPRに残す
+	comments := make(map[int64][]github.ReviewComment)
+	for _, review := range pending {
+		reviewComments, err := w.client.ListReviewComments(ctx, owner, repo, pr.Number, review.ID)
+		if err != nil {
+			w.logger.Warn(ctx, "Failed to list review comments",
+				logging.Field{Key: "number", Value: pr.Number},
+				logging.Field{Key: "review", Value: review.ID},
+				logging.Field{Key: "error", Value: err.Error()},
+			)
+			continue
+		}
+		comments[review.ID] = reviewComments
+	}
+	if err := w.client.CreateComment(ctx, owner, repo, pr.Number, buildReviewFeedbackComment(pending, comments)); err != nil {
+		w.logger.Warn(ctx, "Failed to post review feedback comment",
+			logging.Field{Key: "number", Value: pr.Number},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+
+	if err := w.client.RemoveLabelFromIssue(ctx, owner, repo, issueNumber, current); err != nil {
+		return fmt.Errorf("failed to remove %s from issue #%d: %w", current, issueNumber, err)
+	}
+	if err := w.client.AddLabelToIssue(ctx, owner, repo, issueNumber, domain.LabelRequiresChanges); err != nil {
+		return fmt.Errorf("failed to add %s to issue #%d: %w", domain.LabelRequiresChanges, issueNumber, err)
+	}
+
+	for _, review := range pending {
+		w.handledReviews[review.ID] = true
+	}
+	return nil
+}
+
+// issueHasLabel はIssueが指定したラベルを持つかを返す
+func (w *PRWatcher) issueHasLabel(ctx context.Context, owner, repo string, issueNumber int, name string) bool {
+	labels, err := w.client.GetIssueLabels(ctx, owner, repo, issueNumber)
+	if err != nil {
+		w.logger.Debug(ctx, "Failed to get issue labels",
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return false
+	}
+	for _, label := range labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// buildReviewFeedbackComment は人間の修正要求をまとめたコメント本文を生成する
+func buildReviewFeedbackComment(reviews []github.PullRequestReview, comments map[int64][]github.ReviewComment) string {
+	var b strings.Builder
+	b.WriteString("## Changes requested\n\nThe following review feedback needs to be addressed before this PR can be merged.\n")
+	for _, review := range reviews {
+		b.WriteString(fmt.Sprintf("\n### @%s\n", review.User.Login))
+		if body := strings.TrimSpace(review.Body); body != "" {
+			b.WriteString("\n")
+			for _, line := range strings.Split(body, "\n") {
+				b.WriteString("> " + line + "\n")
+			}
+		}
+		if reviewComments := comments[review.ID]; len(reviewComments) > 0 {
+			b.WriteString("\n")
+			for _, comment := range reviewComments {
+				location := comment.Path
+				if comment.Line != nil {
+					location = fmt.Sprintf("%s:%d", comment.Path, *comment.Line)
+				}
+				body := strings.ReplaceAll(strings.TrimSpace(comment.Body), "\n", "\n  ")
+				b.WriteString(fmt.Sprintf("- `%s`: %s\n", location, body))
+			}
+		}
+		if review.HTMLURL != "" {
+			b.WriteString(fmt.Sprintf("\n%s\n", review.HTMLURL))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+func TestSummarizeReviews(t *testing.T) {
+	t.Run("レビュアーごとの最新状態を集計する", func(t *testing.T) {
+		reviews := []github.PullRequestReview{
+			{ID: 1, User: github.User{Login: "alice"}, State: "CHANGES_REQUESTED"},
+			{ID: 2, User: github.User{Login: "bob"}, State: "APPROVED"},
+			{ID: 3, User: github.User{Login: "alice"}, State: "COMMENTED"},
+			{ID: 4, User: github.User{Login: "alice"}, State: "APPROVED"},
+			{ID: 5, User: github.User{Login: "carol"}, State: "APPROVED"},
+			{ID: 6, User: github.User{Login: "carol"}, State: "DISMISSED"},
+		}
+
+		summary := summarizeReviews(reviews, "soba-bot")
+		require.Len(t, summary.approvals, 2)
+		assert.Equal(t, int64(4), summary.approvals[0].ID)
+		assert.Equal(t, int64(2), summary.approvals[1].ID)
+		assert.Empty(t, summary.changeRequests)
+	})
+
+	t.Run("PR作成者とBotのレビューは除外する", func(t *testing.T) {
+		reviews := []github.PullRequestReview{
+			{ID: 1, User: github.User{Login: "soba-bot"}, State: "APPROVED"},
+			{ID: 2, User: github.User{Login: "ci[bot]", Type: "Bot"}, State: "CHANGES_REQUESTED"},
+			{ID: 3, User: github.User{Login: "dave"}, State: "CHANGES_REQUESTED"},
+		}
+
+		summary := summarizeReviews(reviews, "soba-bot")
+		assert.Empty(t, summary.approvals)
+		require.Len(t, summary.changeRequests, 1)
+		assert.Equal(t, "dave", summary.changeRequests[0].User.Login)
+	})
+}
+
+func TestPRWatcher_HumanReviews(t *testing.T) {
+	newConfig := func(review config.HumanReviewConfig) *config.Config {
+		return &config.Config{
+			GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{Interval: 1, HumanReview: review},
+		}
+	}
+	newPR := func(labels ...string) github.PullRequest {
+		pr := github.PullRequest{
+			Number:         20,
+			Title:          "Implement feature",
+			User:           github.User{Login: "soba-bot"},
+			Mergeable:      true,
+			MergeableState: "clean",
+			Head:           github.PullRequestBranch{Ref: "soba/7", SHA: "head-sha"},
+		}
+		for _, label := range labels {
+			pr.Labels = append(pr.Labels, github.Label{Name: label})
+		}
+		return pr
+	}
+
+	t.Run("修正要求でIssueをrequires-changesに戻しレビュー内容をPRに残す", func(t *testing.T) {
+		line := 12
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{newPR(domain.LabelLGTM)},
+			issueLabels: map[int][]string{7: {domain.LabelDone}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 99, User: github.User{Login: "alice"}, State: "CHANGES_REQUESTED", CommitID: "head-sha", Body: "Please add tests"}},
+			},
+			reviewComments: map[int64][]github.ReviewComment{
+				99: {{Path: "main.go", Line: &line, Body: "handle the error"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequestChanges: true}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Empty(t, mockClient.mergeRequests)
+		assert.Equal(t, []string{domain.LabelLGTM}, mockClient.removedLabels[20])
+		assert.Equal(t, []string{domain.LabelDone}, mockClient.removedLabels[7])
+		assert.Equal(t, []string{domain.LabelRequiresChanges}, mockClient.addedLabels[7])
+		require.Len(t, mockClient.comments, 1)
+		assert.Equal(t, 20, mockClient.comments[0].number)
+		assert.Contains(t, mockClient.comments[0].body, "@alice")
+		assert.Contains(t, mockClient.comments[0].body, "> Please add tests")
+		assert.Contains(t, mockClient.comments[0].body, "`main.go:12`: handle the error")
+
+		// 同じレビューは二度処理しない
+		mockClient.issueLabels[7] = []string{domain.LabelReviewRequested}
+		require.NoError(t, watcher.watchOnce(context.Background()))
+		assert.Len(t, mockClient.comments, 1)
+		assert.Equal(t, []string{domain.LabelRequiresChanges}, mockClient.addedLabels[7])
+	})
+
+	t.Run("修正後のコミットに対する古い修正要求ではIssueを戻さずマージも保留する", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{newPR(domain.LabelLGTM)},
+			issueLabels: map[int][]string{7: {domain.LabelDone}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 99, User: github.User{Login: "alice"}, State: "CHANGES_REQUESTED", CommitID: "old-sha"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequestChanges: true}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Empty(t, mockClient.mergeRequests)
+		assert.Empty(t, mockClient.addedLabels[7])
+		assert.Empty(t, mockClient.comments)
+	})
+
+	t.Run("実装中のIssueは戻さない", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{newPR()},
+			issueLabels: map[int][]string{7: {domain.LabelRevising}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 99, User: github.User{Login: "alice"}, State: "CHANGES_REQUESTED", CommitID: "head-sha"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequestChanges: true}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Empty(t, mockClient.addedLabels[7])
+		assert.Empty(t, mockClient.comments)
+	})
+
+	t.Run("sobaのPRでなければレビューを確認しない", func(t *testing.T) {
+		pr := newPR()
+		pr.Head.Ref = "refactor-parser"
+		pr.Title = "Refactor parser (#7)"
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{pr},
+			issueLabels: map[int][]string{7: {domain.LabelDone}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 99, User: github.User{Login: "alice"}, State: "CHANGES_REQUESTED", CommitID: "head-sha"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequestChanges: true}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Empty(t, mockClient.reviewCalls)
+		assert.Empty(t, mockClient.addedLabels[7])
+		assert.Empty(t, mockClient.comments)
+	})
+
+	t.Run("タイトルで紐づいただけのIssueはrequires-changesに戻さない", func(t *testing.T) {
+		pr := newPR(domain.LabelLGTM)
+		pr.Head.Ref = "refactor-parser"
+		pr.Title = "Refactor parser (#7)"
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{pr},
+			issueLabels: map[int][]string{7: {domain.LabelDone}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 99, User: github.User{Login: "alice"}, State: "CHANGES_REQUESTED", CommitID: "head-sha"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequestChanges: true}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Empty(t, mockClient.addedLabels[7])
+		assert.Empty(t, mockClient.removedLabels[7])
+	})
+
+	t.Run("承認数が足りない場合はlgtmがあってもマージしない", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			prs: []github.PullRequest{newPR(domain.LabelLGTM)},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 1, User: github.User{Login: "alice"}, State: "APPROVED"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequiredApprovals: 2}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Empty(t, mockClient.mergeRequests)
+	})
+
+	t.Run("承認とAIレビューが揃ったらlgtmを付けてマージする", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{newPR()},
+			issueLabels: map[int][]string{7: {domain.LabelDone}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {
+					{ID: 1, User: github.User{Login: "alice"}, State: "APPROVED"},
+					{ID: 2, User: github.User{Login: "bob"}, State: "APPROVED"},
+				},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequiredApprovals: 2, RequireAIApproval: true}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Equal(t, []string{domain.LabelLGTM}, mockClient.addedLabels[20])
+		require.Len(t, mockClient.mergeRequests, 1)
+		assert.Equal(t, 20, mockClient.mergeRequests[0].number)
+	})
+
+	t.Run("AIレビューが必要な場合はsoba:doneになるまで待つ", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{newPR()},
+			issueLabels: map[int][]string{7: {domain.LabelReviewing}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 1, User: github.User{Login: "alice"}, State: "APPROVED"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequiredApprovals: 1, RequireAIApproval: true}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Empty(t, mockClient.addedLabels[20])
+		assert.Empty(t, mockClient.mergeRequests)
+	})
+
+	t.Run("AIレビューが不要な場合は人間の承認だけでマージする", func(t *testing.T) {
+		mockClient := &MockGitHubClientForPR{
+			prs:         []github.PullRequest{newPR()},
+			issueLabels: map[int][]string{7: {domain.LabelReviewRequested}},
+			reviews: map[int][]github.PullRequestReview{
+				20: {{ID: 1, User: github.User{Login: "alice"}, State: "APPROVED"}},
+			},
+		}
+		watcher := NewPRWatcher(mockClient, newConfig(config.HumanReviewConfig{RequiredApprovals: 1}))
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Equal(t, []string{domain.LabelLGTM}, mockClient.addedLabels[20])
+		assert.Len(t, mockClient.mergeRequests, 1)
+	})
+}
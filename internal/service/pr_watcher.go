@@ -2,23 +2,51 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/git"
 	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/internal/infra/metrics"
 	"github.com/douhashi/soba/internal/infra/slack"
+	"github.com/douhashi/soba/internal/infra/tmux"
 	"github.com/douhashi/soba/pkg/logging"
 )
 
+// BranchSyncer はPRブランチをベースブランチに追従させるGit操作のインターフェース
+type BranchSyncer interface {
+	RebaseWorktree(worktreePath, baseBranch string) error
+	ConflictingFiles(worktreePath, baseBranch string) ([]string, error)
+}
+
+const (
+	mergeableStateBehind = "behind"
+	mergeableStateDirty  = "dirty"
+
+	branchUpdateMethodAPI    = "api"
+	branchUpdateMethodRebase = "rebase"
+	branchUpdateMethodNone   = "none"
+)
+
 // PRWatcher はPR監視機能を提供する
 type PRWatcher struct {
-	client   GitHubClientInterface
-	config   *config.Config
-	interval time.Duration
-	logger   logging.Logger
+	client         GitHubClientInterface
+	git            BranchSyncer
+	config         *config.Config
+	interval       time.Duration
+	logger         logging.Logger
+	linker         *IssueLinker
+	postMerge      *PostMergeHandler
+	branchUpdates  map[int]string // PR番号をキーとする更新要求済みのhead SHA
+	handledReviews map[int64]bool // Issueに反映済みの修正要求レビューID
+	control        *DaemonControl // 制御APIから参照・操作される状態
+	history        *EventHistory  // Issueごとのイベント履歴
+	watchLoop
 }
 
 // NewPRWatcher は新しいPRWatcherを作成する
@@ -31,17 +59,66 @@ func NewPRWatcher(client GitHubClientInterface, cfg *config.Config) *PRWatcher {
 	// ロガーの初期化（テスト環境を考慮）
 	log := logging.NewMockLogger() // デフォルトでMockLogger使用
 
+	// PRとIssueの紐付けにはタイムラインも利用する
+	owner, repo := "", ""
+	if parts := strings.Split(cfg.GitHub.Repository, "/"); len(parts) == 2 {
+		owner, repo = parts[0], parts[1]
+	}
+	linker := NewIssueLinker(client, owner, repo)
+	linker.SetLogger(log)
+	postMerge := NewPostMergeHandler(client)
+	postMerge.SetLogger(log)
+
 	return &PRWatcher{
-		client:   client,
-		config:   cfg,
-		interval: time.Duration(cfg.Workflow.Interval) * time.Second,
-		logger:   log,
+		client:    client,
+		config:    cfg,
+		interval:  time.Duration(cfg.Workflow.Interval) * time.Second,
+		logger:    log,
+		linker:    linker,
+		postMerge: postMerge,
+
+		branchUpdates:  make(map[int]string),
+		handledReviews: make(map[int64]bool),
+		watchLoop:      newWatchLoop(),
 	}
 }
 
 // SetLogger はロガーを設定する（運用時用）
 func (w *PRWatcher) SetLogger(log logging.Logger) {
 	w.logger = log
+	w.linker.SetLogger(log)
+	w.postMerge.SetLogger(log)
+}
+
+// SetWorkspaceManager はマージ後のworktree削除に使うGitWorkspaceManagerを設定する
+func (w *PRWatcher) SetWorkspaceManager(workspace GitWorkspaceManager) {
+	w.postMerge.SetWorkspaceManager(workspace)
+}
+
+// SetTmuxClient はマージ後のウィンドウ削除に使うtmuxクライアントを設定する
+func (w *PRWatcher) SetTmuxClient(tmuxClient tmux.TmuxClient) {
+	w.postMerge.SetTmuxClient(tmuxClient)
+}
+
+// Linker はPRとIssueの紐付けに使うIssueLinkerを返す
+func (w *PRWatcher) Linker() *IssueLinker {
+	return w.linker
+}
+
+// SetControl は制御APIから参照・操作される状態を設定する
+func (w *PRWatcher) SetControl(control *DaemonControl) {
+	w.control = control
+}
+
+// SetHistory はイベント履歴の記録先を設定する。マージ後の後片付けにも反映する
+func (w *PRWatcher) SetHistory(history *EventHistory) {
+	w.history = history
+	w.postMerge.SetHistory(history)
+}
+
+// SetGitClient はブランチ追従に使うGitクライアントを設定する
+func (w *PRWatcher) SetGitClient(gitClient BranchSyncer) {
+	w.git = gitClient
 }
 
 // Start はPR監視を開始する
@@ -52,7 +129,7 @@ func (w *PRWatcher) Start(ctx context.Context) error {
 	defer ticker.Stop()
 
 	// 最初に一度実行
-	if err := w.watchOnce(ctx); err != nil {
+	if err := timedCycle(w.control, "pr_watcher", func() error { return w.watchOnce(ctx) }); err != nil {
 		w.logger.Error(ctx, "Initial watch failed", logging.Field{Key: "error", Value: err.Error()})
 	}
 
@@ -62,9 +139,17 @@ func (w *PRWatcher) Start(ctx context.Context) error {
 			w.logger.Info(ctx, "PR watcher stopped due to context cancellation")
 			return nil
 		case <-ticker.C:
-			if err := w.watchOnce(ctx); err != nil {
+			if err := timedCycle(w.control, "pr_watcher", func() error { return w.watchOnce(ctx) }); err != nil {
 				w.logger.Error(ctx, "Watch cycle failed", logging.Field{Key: "error", Value: err.Error()})
 			}
+		case <-w.trigger:
+			if err := timedCycle(w.control, "pr_watcher", func() error { return w.watchOnce(ctx) }); err != nil {
+				w.logger.Error(ctx, "Watch cycle failed", logging.Field{Key: "error", Value: err.Error()})
+			}
+		case request := <-w.requests:
+			request(ctx)
+			// 設定の再読み込みで間隔が変わった場合に備える
+			ticker.Reset(w.interval)
 		}
 	}
 }
@@ -82,7 +167,20 @@ func (w *PRWatcher) watchOnce(ctx context.Context) error {
 
 	// soba:lgtmラベルが付いたPRを処理
 	for _, pr := range prs {
+		// 人間のレビューを反映し、承認が揃っていなければマージを保留する
+		if w.reviewGateEnabled() && isSobaPullRequest(pr) {
+			var mergeable bool
+			if pr, mergeable = w.applyHumanReviews(ctx, pr); !mergeable {
+				continue
+			}
+		}
+
 		if w.hasLGTMLabel(pr) {
+			if w.isIssuePaused(ctx, pr) {
+				w.logger.Info(ctx, "Issue is paused, skipping merge", logging.Field{Key: "number", Value: pr.Number})
+				continue
+			}
+
 			w.logger.Info(ctx, "Found PR with soba:lgtm label",
 				logging.Field{Key: "number", Value: pr.Number},
 				logging.Field{Key: "title", Value: pr.Title},
@@ -103,6 +201,16 @@ func (w *PRWatcher) watchOnce(ctx context.Context) error {
 	return nil
 }
 
+// isIssuePaused はPRに紐づくIssueが一時停止中かチェックする
+func (w *PRWatcher) isIssuePaused(ctx context.Context, pr github.PullRequest) bool {
+	owner, repo := w.parseRepository()
+	issueNumber := w.linker.IssueForPullRequest(ctx, pr)
+	if owner == "" || issueNumber == 0 {
+		return false
+	}
+	return w.issueHasLabel(ctx, owner, repo, issueNumber, domain.LabelPaused)
+}
+
 // fetchOpenPullRequests はオープンなPR一覧を取得する
 func (w *PRWatcher) fetchOpenPullRequests(ctx context.Context) ([]github.PullRequest, error) {
 	owner, repo := w.parseRepository()
@@ -129,6 +237,19 @@ func (w *PRWatcher) fetchOpenPullRequests(ctx context.Context) ([]github.PullReq
 	return prs, nil
 }
 
+// isSobaPullRequest はsobaが扱うPR（soba/Nブランチかsobaのラベル付き）かを返す
+func isSobaPullRequest(pr github.PullRequest) bool {
+	if issueNumberFromBranch(pr.Head.Ref) > 0 {
+		return true
+	}
+	for _, label := range pr.Labels {
+		if strings.HasPrefix(label.Name, "soba:") {
+			return true
+		}
+	}
+	return false
+}
+
 // hasLGTMLabel はPRがsoba:lgtmラベルを持つかチェックする
 func (w *PRWatcher) hasLGTMLabel(pr github.PullRequest) bool {
 	for _, label := range pr.Labels {
@@ -183,6 +304,14 @@ func (w *PRWatcher) mergePullRequest(ctx context.Context, pr github.PullRequest)
 		}
 	}
 
+	// ベースブランチに追従できていないPRを最新化する
+	switch pr.MergeableState {
+	case mergeableStateBehind:
+		return w.updateBehindBranch(ctx, owner, repo, pr)
+	case mergeableStateDirty:
+		return w.requestConflictResolution(ctx, owner, repo, pr, nil)
+	}
+
 	// マージ可能な状態かチェック
 	if !pr.Mergeable || pr.MergeableState != "clean" {
 		w.logger.Info(ctx, "PR is not in mergeable state",
@@ -211,10 +340,22 @@ func (w *PRWatcher) mergePullRequest(ctx context.Context, pr github.PullRequest)
 			logging.Field{Key: "sha", Value: resp.SHA},
 		)
 
+		metrics.IncMerges()
+
 		// Slack通知: PRマージ完了
-		// PR番号からIssue番号を抽出 (ファイル名パターンから推測)
-		issueNumber := w.extractIssueNumber(pr.Title)
-		slack.NotifyPRMerged(pr.Number, issueNumber)
+		link := w.linker.LinkForPullRequest(ctx, pr)
+		slack.NotifyPRMerged(pr.Number, link.Issue)
+		w.history.Record(ctx, HistoryEvent{Issue: link.Issue, Type: HistoryMerged, PR: pr.Number, Detail: resp.SHA})
+
+		// マージ後の後片付け（Issueのクローズ、ブランチ・worktree・ウィンドウの削除）
+		// タイムラインやタイトルで紐づいただけのIssueは片付けない
+		issueNumber := 0
+		if link.Trusted() {
+			issueNumber = link.Issue
+		}
+		w.postMerge.Handle(ctx, w.config, pr, resp.SHA, issueNumber)
+		w.linker.Forget(pr.Number)
+		delete(w.branchUpdates, pr.Number)
 	} else {
 		w.logger.Warn(ctx, "PR merge was not successful",
 			logging.Field{Key: "number", Value: pr.Number},
@@ -225,6 +366,154 @@ func (w *PRWatcher) mergePullRequest(ctx context.Context, pr github.PullRequest)
 	return nil
 }
 
+// updateBehindBranch はベースブランチより遅れているPRを最新化する
+func (w *PRWatcher) updateBehindBranch(ctx context.Context, owner, repo string, pr github.PullRequest) error {
+	method := w.config.Workflow.BranchUpdateMethod
+	if method == "" {
+		method = config.DefaultBranchUpdateMethod
+	}
+
+	if method == branchUpdateMethodNone {
+		w.logger.Info(ctx, "PR is behind base branch, automatic update is disabled",
+			logging.Field{Key: "number", Value: pr.Number},
+		)
+		return nil
+	}
+
+	// 同じheadに対する更新要求は一度だけ行う（GitHub側の反映待ち）
+	if sha, ok := w.branchUpdates[pr.Number]; ok && sha == pr.Head.SHA {
+		w.logger.Debug(ctx, "Branch update already requested, waiting for GitHub",
+			logging.Field{Key: "number", Value: pr.Number},
+			logging.Field{Key: "sha", Value: pr.Head.SHA},
+		)
+		return nil
+	}
+
+	if method == branchUpdateMethodRebase {
+		issueNumber := w.linker.TrustedIssueForPullRequest(ctx, pr)
+		if w.git != nil && issueNumber > 0 {
+			worktreePath := w.worktreePath(issueNumber)
+			if err := w.git.RebaseWorktree(worktreePath, w.config.Git.BaseBranch); err != nil {
+				// ベースブランチを取得できない場合はコンフリクトではないので次のサイクルで再試行する
+				var gitErr *git.GitError
+				if errors.As(err, &gitErr) && gitErr.Op == "fetch" {
+					return fmt.Errorf("failed to fetch base branch for PR #%d: %w", pr.Number, err)
+				}
+				w.logger.Warn(ctx, "Failed to rebase PR branch onto base branch",
+					logging.Field{Key: "number", Value: pr.Number},
+					logging.Field{Key: "issue", Value: issueNumber},
+					logging.Field{Key: "error", Value: err.Error()},
+				)
+				// リベースできない場合はコンフリクトとして扱う
+				return w.requestConflictResolution(ctx, owner, repo, pr, nil)
+			}
+
+			w.branchUpdates[pr.Number] = pr.Head.SHA
+			w.logger.Info(ctx, "Rebased PR branch onto base branch",
+				logging.Field{Key: "number", Value: pr.Number},
+				logging.Field{Key: "issue", Value: issueNumber},
+				logging.Field{Key: "base", Value: w.config.Git.BaseBranch},
+			)
+			return nil
+		}
+
+		w.logger.Debug(ctx, "Worktree rebase unavailable, falling back to update-branch API",
+			logging.Field{Key: "number", Value: pr.Number},
+		)
+	}
+
+	if err := w.client.UpdatePullRequestBranch(ctx, owner, repo, pr.Number, pr.Head.SHA); err != nil {
+		return fmt.Errorf("failed to update branch of PR #%d: %w", pr.Number, err)
+	}
+
+	w.branchUpdates[pr.Number] = pr.Head.SHA
+	w.logger.Info(ctx, "Requested branch update for PR behind base branch",
+		logging.Field{Key: "number", Value: pr.Number},
+		logging.Field{Key: "sha", Value: pr.Head.SHA},
+	)
+	return nil
+}
+
+// requestConflictResolution はコンフリクトのあるPRのIssueをsoba:requires-changesに戻す
+func (w *PRWatcher) requestConflictResolution(ctx context.Context, owner, repo string, pr github.PullRequest, files []string) error {
+	if !w.config.Workflow.RequestChangesOnConflict {
+		w.logger.Info(ctx, "PR has conflicts, automatic revision is disabled",
+			logging.Field{Key: "number", Value: pr.Number},
+		)
+		return nil
+	}
+
+	issueNumber := w.linker.TrustedIssueForPullRequest(ctx, pr)
+	if issueNumber == 0 {
+		w.logger.Warn(ctx, "PR has conflicts but no linked issue was found",
+			logging.Field{Key: "number", Value: pr.Number},
+			logging.Field{Key: "head", Value: pr.Head.Ref},
+		)
+		return nil
+	}
+
+	if files == nil && w.git != nil {
+		conflicts, err := w.git.ConflictingFiles(w.worktreePath(issueNumber), w.config.Git.BaseBranch)
+		if err != nil {
+			w.logger.Debug(ctx, "Failed to list conflicting files",
+				logging.Field{Key: "number", Value: pr.Number},
+				logging.Field{Key: "error", Value: err.Error()},
+			)
+		}
+		files = conflicts
+	}
+
+	w.logger.Info(ctx, "PR has conflicts, requesting changes",
+		logging.Field{Key: "number", Value: pr.Number},
+		logging.Field{Key: "issue", Value: issueNumber},
+		logging.Field{Key: "files", Value: files},
+	)
+
+	// lgtmを外して同じPRを繰り返し処理しないようにする
+	if err := w.client.RemoveLabelFromIssue(ctx, owner, repo, pr.Number, domain.LabelLGTM); err != nil {
+		return fmt.Errorf("failed to remove %s from PR #%d: %w", domain.LabelLGTM, pr.Number, err)
+	}
+
+	// reviseコマンドはPRコメントを参照するため、PRにコンフリクト情報を残す
+	if err := w.client.CreateComment(ctx, owner, repo, pr.Number, buildConflictComment(w.config.Git.BaseBranch, files)); err != nil {
+		w.logger.Warn(ctx, "Failed to post conflict comment",
+			logging.Field{Key: "number", Value: pr.Number},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+
+	if err := w.client.RemoveLabelFromIssue(ctx, owner, repo, issueNumber, domain.LabelDone); err != nil {
+		w.logger.Debug(ctx, "Failed to remove done label from issue",
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+	if err := w.client.AddLabelToIssue(ctx, owner, repo, issueNumber, domain.LabelRequiresChanges); err != nil {
+		return fmt.Errorf("failed to add %s to issue #%d: %w", domain.LabelRequiresChanges, issueNumber, err)
+	}
+
+	return nil
+}
+
+// buildConflictComment はコンフリクト解消を依頼するコメント本文を生成する
+func buildConflictComment(baseBranch string, files []string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("## Merge conflict\n\nThis PR conflicts with `%s` and cannot be merged. ", baseBranch))
+	b.WriteString(fmt.Sprintf("Please rebase onto `%s` and resolve the conflicts.\n", baseBranch))
+	if len(files) > 0 {
+		b.WriteString("\n### Conflicting files\n\n")
+		for _, file := range files {
+			b.WriteString(fmt.Sprintf("- `%s`\n", file))
+		}
+	}
+	return b.String()
+}
+
+// worktreePath はIssueに対応するworktreeのパスを返す
+func (w *PRWatcher) worktreePath(issueNumber int) string {
+	return filepath.Join(w.config.Git.WorktreeBasePath, fmt.Sprintf("issue-%d", issueNumber))
+}
+
 // parseRepository は設定からowner/repoを分解する
 func (w *PRWatcher) parseRepository() (string, string) {
 	repo := w.config.GitHub.Repository
@@ -238,24 +527,3 @@ func (w *PRWatcher) parseRepository() (string, string) {
 	}
 	return parts[0], parts[1]
 }
-
-// extractIssueNumber はPRタイトルからIssue番号を抽出する
-func (w *PRWatcher) extractIssueNumber(title string) int {
-	// PRタイトルから"(#数字)"パターンを探す
-	parts := strings.Split(title, "(#")
-	if len(parts) < 2 {
-		return 0
-	}
-
-	numberPart := strings.Split(parts[1], ")")[0]
-	issueNumber, err := strconv.Atoi(numberPart)
-	if err != nil {
-		w.logger.Debug(context.Background(), "Failed to extract issue number from PR title",
-			logging.Field{Key: "title", Value: title},
-			logging.Field{Key: "error", Value: err.Error()},
-		)
-		return 0
-	}
-
-	return issueNumber
-}
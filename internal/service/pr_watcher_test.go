@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/git"
 	"github.com/douhashi/soba/internal/infra/github"
 	"github.com/douhashi/soba/pkg/logging"
 )
@@ -22,7 +23,25 @@ type MockGitHubClientForPR struct {
 		number int
 		req    *github.MergeRequest
 	}
-	mergeError error
+	mergeError    error
+	branchUpdates []int
+	comments      []struct {
+		number int
+		body   string
+	}
+	addedLabels    map[int][]string
+	removedLabels  map[int][]string
+	timelines      map[int][]github.TimelineEvent
+	issueStates    map[int]string
+	closedIssues   []int
+	deletedRefs    []string
+	issueLabels    map[int][]string
+	reviews        map[int][]github.PullRequestReview
+	reviewCalls    []int
+	reviewComments map[int64][]github.ReviewComment
+	repoComments   []github.IssueComment
+	reactions      map[int64][]string
+	issueTitles    map[int]string
 }
 
 func (m *MockGitHubClientForPR) ListPullRequests(ctx context.Context, owner, repo string, opts *github.ListPullRequestsOptions) ([]github.PullRequest, bool, error) {
@@ -63,10 +82,18 @@ func (m *MockGitHubClientForPR) ListOpenIssues(ctx context.Context, owner, repo
 }
 
 func (m *MockGitHubClientForPR) AddLabelToIssue(ctx context.Context, owner, repo string, issueNumber int, label string) error {
+	if m.addedLabels == nil {
+		m.addedLabels = make(map[int][]string)
+	}
+	m.addedLabels[issueNumber] = append(m.addedLabels[issueNumber], label)
 	return nil
 }
 
 func (m *MockGitHubClientForPR) RemoveLabelFromIssue(ctx context.Context, owner, repo string, issueNumber int, label string) error {
+	if m.removedLabels == nil {
+		m.removedLabels = make(map[int][]string)
+	}
+	m.removedLabels[issueNumber] = append(m.removedLabels[issueNumber], label)
 	return nil
 }
 
@@ -75,7 +102,76 @@ func (m *MockGitHubClientForPR) UpdateIssueLabels(ctx context.Context, owner, re
 }
 
 func (m *MockGitHubClientForPR) GetIssueLabels(ctx context.Context, owner, repo string, issueNumber int) ([]github.Label, error) {
-	return []github.Label{}, nil
+	labels := []github.Label{}
+	for _, name := range m.issueLabels[issueNumber] {
+		labels = append(labels, github.Label{Name: name})
+	}
+	return labels, nil
+}
+
+func (m *MockGitHubClientForPR) UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, expectedHeadSHA string) error {
+	m.branchUpdates = append(m.branchUpdates, number)
+	return nil
+}
+
+func (m *MockGitHubClientForPR) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error) {
+	return m.timelines[issueNumber], nil
+}
+
+func (m *MockGitHubClientForPR) CreateComment(ctx context.Context, owner, repo string, issueNumber int, body string) error {
+	m.comments = append(m.comments, struct {
+		number int
+		body   string
+	}{issueNumber, body})
+	return nil
+}
+
+func (m *MockGitHubClientForPR) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*github.Issue, error) {
+	state := "open"
+	if st, ok := m.issueStates[issueNumber]; ok {
+		state = st
+	}
+	issue := &github.Issue{Number: issueNumber, Title: m.issueTitles[issueNumber], State: state}
+	for _, name := range m.issueLabels[issueNumber] {
+		issue.Labels = append(issue.Labels, github.Label{Name: name})
+	}
+	for _, pr := range m.prs {
+		if pr.Number == issueNumber {
+			issue.PullRequest = &github.IssuePullRequest{}
+		}
+	}
+	return issue, nil
+}
+
+func (m *MockGitHubClientForPR) CloseIssue(ctx context.Context, owner, repo string, issueNumber int) error {
+	m.closedIssues = append(m.closedIssues, issueNumber)
+	return nil
+}
+
+func (m *MockGitHubClientForPR) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	m.deletedRefs = append(m.deletedRefs, branch)
+	return nil
+}
+
+func (m *MockGitHubClientForPR) ListPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]github.PullRequestReview, error) {
+	m.reviewCalls = append(m.reviewCalls, number)
+	return m.reviews[number], nil
+}
+
+func (m *MockGitHubClientForPR) ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]github.ReviewComment, error) {
+	return m.reviewComments[reviewID], nil
+}
+
+func (m *MockGitHubClientForPR) ListRepositoryComments(ctx context.Context, owner, repo string, opts *github.ListCommentsOptions) ([]github.IssueComment, error) {
+	return m.repoComments, nil
+}
+
+func (m *MockGitHubClientForPR) CreateCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	if m.reactions == nil {
+		m.reactions = make(map[int64][]string)
+	}
+	m.reactions[commentID] = append(m.reactions[commentID], content)
+	return nil
 }
 
 func TestNewPRWatcher(t *testing.T) {
@@ -168,6 +264,82 @@ func TestWatchOnce(t *testing.T) {
 		assert.Equal(t, "squash", mockClient.mergeRequests[0].req.MergeMethod)
 	})
 
+	t.Run("マージ後にリンクされたIssueの後片付けを行う", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub: config.GitHubConfig{
+				Repository: "owner/repo",
+			},
+			Workflow: config.WorkflowConfig{
+				Interval: 1,
+				PostMerge: config.PostMergeConfig{
+					CloseIssue:         true,
+					DeleteRemoteBranch: true,
+					RemoveWorktree:     true,
+				},
+			},
+		}
+
+		mockClient := &MockGitHubClientForPR{
+			prs: []github.PullRequest{
+				{
+					Number:         12,
+					Title:          "Implement feature",
+					Body:           "Closes #5",
+					Labels:         []github.Label{{Name: "soba:lgtm"}},
+					Mergeable:      true,
+					MergeableState: "clean",
+					Head:           github.PullRequestBranch{Ref: "feature/five"},
+				},
+			},
+		}
+		workspace := &recordingWorkspaceManager{}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetWorkspaceManager(workspace)
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Equal(t, []int{5}, mockClient.closedIssues)
+		assert.Equal(t, []string{"feature/five"}, mockClient.deletedRefs)
+		assert.Equal(t, []int{5}, workspace.cleaned)
+	})
+
+	t.Run("タイトルで紐づいただけのIssueは後片付けしない", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub: config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{
+				Interval: 1,
+				PostMerge: config.PostMergeConfig{
+					CloseIssue:     true,
+					RemoveWorktree: true,
+				},
+			},
+		}
+
+		mockClient := &MockGitHubClientForPR{
+			prs: []github.PullRequest{
+				{
+					Number:         12,
+					Title:          "Refactor parser (#5)",
+					Labels:         []github.Label{{Name: "soba:lgtm"}},
+					Mergeable:      true,
+					MergeableState: "clean",
+					Head:           github.PullRequestBranch{Ref: "refactor-parser"},
+				},
+			},
+		}
+		workspace := &recordingWorkspaceManager{}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetWorkspaceManager(workspace)
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Len(t, mockClient.mergeRequests, 1)
+		assert.Empty(t, mockClient.closedIssues)
+		assert.Empty(t, workspace.cleaned)
+	})
+
 	t.Run("複数のsoba:lgtm付きPRがある場合は全てマージする", func(t *testing.T) {
 		cfg := &config.Config{
 			GitHub: config.GitHubConfig{
@@ -381,3 +553,147 @@ func TestPRWatcher_WatchCycleLogs(t *testing.T) {
 		assert.True(t, foundCompleteLog, "expected 'PR watch cycle completed' INFO log")
 	})
 }
+
+// mockBranchSyncer はBranchSyncerのモック実装
+type mockBranchSyncer struct {
+	rebased   []string
+	rebaseErr error
+	conflicts []string
+}
+
+func (m *mockBranchSyncer) RebaseWorktree(worktreePath, baseBranch string) error {
+	m.rebased = append(m.rebased, worktreePath)
+	return m.rebaseErr
+}
+
+func (m *mockBranchSyncer) ConflictingFiles(worktreePath, baseBranch string) ([]string, error) {
+	return m.conflicts, nil
+}
+
+func TestPRWatcher_BehindAndConflictingPRs(t *testing.T) {
+	newLGTMPR := func(state string) github.PullRequest {
+		return github.PullRequest{
+			ID:             1,
+			Number:         10,
+			Title:          "PR with LGTM",
+			State:          "open",
+			Labels:         []github.Label{{Name: "soba:lgtm"}},
+			Mergeable:      state != "dirty",
+			MergeableState: state,
+			Head:           github.PullRequestBranch{Ref: "soba/5", SHA: "abc123"},
+		}
+	}
+
+	t.Run("behindのPRはupdate-branch APIで最新化する", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{BranchUpdateMethod: "api"},
+		}
+		mockClient := &MockGitHubClientForPR{prs: []github.PullRequest{newLGTMPR("behind")}}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetLogger(logging.NewMockLogger())
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		// 同じheadに対しては一度だけ更新を要求する
+		assert.Equal(t, []int{10}, mockClient.branchUpdates)
+		assert.Len(t, mockClient.mergeRequests, 0)
+	})
+
+	t.Run("rebase指定の場合はworktreeをリベースする", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{BranchUpdateMethod: "rebase"},
+			Git:      config.GitConfig{WorktreeBasePath: ".git/soba/worktrees", BaseBranch: "main"},
+		}
+		mockClient := &MockGitHubClientForPR{prs: []github.PullRequest{newLGTMPR("behind")}}
+		syncer := &mockBranchSyncer{}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetLogger(logging.NewMockLogger())
+		watcher.SetGitClient(syncer)
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Equal(t, []string{".git/soba/worktrees/issue-5"}, syncer.rebased)
+		assert.Empty(t, mockClient.branchUpdates)
+	})
+
+	t.Run("none指定の場合は何もしない", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{BranchUpdateMethod: "none"},
+		}
+		mockClient := &MockGitHubClientForPR{prs: []github.PullRequest{newLGTMPR("behind")}}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetLogger(logging.NewMockLogger())
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+		assert.Empty(t, mockClient.branchUpdates)
+	})
+
+	t.Run("dirtyのPRはIssueをrequires-changesに戻す", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{RequestChangesOnConflict: true},
+			Git:      config.GitConfig{BaseBranch: "main"},
+		}
+		mockClient := &MockGitHubClientForPR{prs: []github.PullRequest{newLGTMPR("dirty")}}
+		syncer := &mockBranchSyncer{conflicts: []string{"internal/service/daemon.go"}}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetLogger(logging.NewMockLogger())
+		watcher.SetGitClient(syncer)
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Equal(t, []string{"soba:lgtm"}, mockClient.removedLabels[10])
+		assert.Equal(t, []string{"soba:done"}, mockClient.removedLabels[5])
+		assert.Equal(t, []string{"soba:requires-changes"}, mockClient.addedLabels[5])
+		require.Len(t, mockClient.comments, 1)
+		assert.Equal(t, 10, mockClient.comments[0].number)
+		assert.Contains(t, mockClient.comments[0].body, "`internal/service/daemon.go`")
+		assert.Len(t, mockClient.mergeRequests, 0)
+	})
+
+	t.Run("リベースに失敗した場合はコンフリクトとして扱う", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{BranchUpdateMethod: "rebase", RequestChangesOnConflict: true},
+		}
+		mockClient := &MockGitHubClientForPR{prs: []github.PullRequest{newLGTMPR("behind")}}
+		syncer := &mockBranchSyncer{rebaseErr: assert.AnError, conflicts: []string{"README.md"}}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetLogger(logging.NewMockLogger())
+		watcher.SetGitClient(syncer)
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Equal(t, []string{"soba:requires-changes"}, mockClient.addedLabels[5])
+		assert.Empty(t, mockClient.branchUpdates)
+	})
+
+	t.Run("ベースブランチを取得できない場合はコンフリクトとして扱わない", func(t *testing.T) {
+		cfg := &config.Config{
+			GitHub:   config.GitHubConfig{Repository: "owner/repo"},
+			Workflow: config.WorkflowConfig{BranchUpdateMethod: "rebase", RequestChangesOnConflict: true},
+		}
+		mockClient := &MockGitHubClientForPR{prs: []github.PullRequest{newLGTMPR("behind")}}
+		syncer := &mockBranchSyncer{rebaseErr: git.NewGitError("fetch", "worktree", "network is unreachable", assert.AnError)}
+
+		watcher := NewPRWatcher(mockClient, cfg)
+		watcher.SetLogger(logging.NewMockLogger())
+		watcher.SetGitClient(syncer)
+
+		require.NoError(t, watcher.watchOnce(context.Background()))
+
+		assert.Len(t, syncer.rebased, 1)
+		assert.Empty(t, mockClient.addedLabels[5])
+		assert.Empty(t, mockClient.removedLabels[10])
+		assert.Empty(t, mockClient.branchUpdates)
+	})
+}
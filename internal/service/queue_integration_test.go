@@ -71,6 +71,46 @@ func (m *MockIntegrationGitHubClient) GetIssueLabels(ctx context.Context, owner,
 	return []github.Label{}, args.Error(1)
 }
 
+func (m *MockIntegrationGitHubClient) UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, expectedHeadSHA string) error {
+	return nil
+}
+
+func (m *MockIntegrationGitHubClient) CreateComment(ctx context.Context, owner, repo string, issueNumber int, body string) error {
+	return nil
+}
+
+func (m *MockIntegrationGitHubClient) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error) {
+	return nil, nil
+}
+
+func (m *MockIntegrationGitHubClient) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*github.Issue, error) {
+	return &github.Issue{Number: issueNumber, State: "open"}, nil
+}
+
+func (m *MockIntegrationGitHubClient) CloseIssue(ctx context.Context, owner, repo string, issueNumber int) error {
+	return nil
+}
+
+func (m *MockIntegrationGitHubClient) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	return nil
+}
+
+func (m *MockIntegrationGitHubClient) ListPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]github.PullRequestReview, error) {
+	return nil, nil
+}
+
+func (m *MockIntegrationGitHubClient) ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]github.ReviewComment, error) {
+	return nil, nil
+}
+
+func (m *MockIntegrationGitHubClient) ListRepositoryComments(ctx context.Context, owner, repo string, opts *github.ListCommentsOptions) ([]github.IssueComment, error) {
+	return nil, nil
+}
+
+func (m *MockIntegrationGitHubClient) CreateCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	return nil
+}
+
 // MockIntegrationWorkflowExecutor は統合テスト用のモック
 type MockIntegrationWorkflowExecutor struct {
 	mock.Mock
@@ -85,7 +125,18 @@ func (m *MockIntegrationWorkflowExecutor) SetIssueProcessor(processor IssueProce
 	m.Called(processor)
 }
 
+func (m *MockIntegrationWorkflowExecutor) SetGitHubClient(client GitHubClientInterface) {
+}
+
+func (m *MockIntegrationWorkflowExecutor) SetHistory(history *EventHistory) {
+}
+
+func (m *MockIntegrationWorkflowExecutor) SetDryRun(recorder *DryRunRecorder) {
+}
+
 func TestQueueIntegration_TodoToQueuedTransition(t *testing.T) {
+	chdirTemp(t)
+
 	// テスト用のコンテキストと設定
 	ctx := context.Background()
 	cfg := &config.Config{
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/douhashi/soba/internal/domain"
 	"github.com/douhashi/soba/internal/infra/github"
 	"github.com/douhashi/soba/pkg/errors"
 	"github.com/douhashi/soba/pkg/logging"
@@ -11,10 +12,12 @@ import (
 
 // QueueManager はキュー管理機能を提供する
 type QueueManager struct {
-	client GitHubClientInterface
-	owner  string
-	repo   string
-	logger logging.Logger
+	client  GitHubClientInterface
+	owner   string
+	repo    string
+	logger  logging.Logger
+	guard   *WorkGuard    // 予算とスケジュールによるキュー投入の制限（nilなら制限なし）
+	history *EventHistory // Issueごとのイベント履歴
 }
 
 // NewQueueManager は新しいQueueManagerを作成する
@@ -32,6 +35,16 @@ func (q *QueueManager) SetLogger(log logging.Logger) {
 	q.logger = log
 }
 
+// SetHistory はイベント履歴の記録先を設定する
+func (q *QueueManager) SetHistory(history *EventHistory) {
+	q.history = history
+}
+
+// SetWorkGuard は予算とスケジュールの判定を設定する
+func (q *QueueManager) SetWorkGuard(guard *WorkGuard) {
+	q.guard = guard
+}
+
 // EnqueueNextIssue は次のIssueをキューに入れる
 func (q *QueueManager) EnqueueNextIssue(ctx context.Context, issues []github.Issue) error {
 	q.logger.Info(ctx, "Starting queue management",
@@ -54,7 +67,20 @@ func (q *QueueManager) EnqueueNextIssue(ctx context.Context, issues []github.Iss
 		return nil
 	}
 
-	// 3. 最小番号のIssueを選択
+	// 予算の上限やスケジュール外の場合は新しいIssueを始めない
+	if q.guard != nil {
+		if reason := q.guard.EnqueueBlockReason(ctx); reason != "" {
+			q.logger.Info(ctx, "Queue management completed",
+				logging.Field{Key: "result", Value: "skipped_budget"},
+				logging.Field{Key: "reason", Value: reason})
+			return nil
+		}
+	}
+
+	// 3. 優先度の高いIssueがあればその中から、最小番号のIssueを選択
+	if priorityIssues := q.collectPriorityIssues(todoIssues); len(priorityIssues) > 0 {
+		todoIssues = priorityIssues
+	}
 	targetIssue := q.selectMinimumIssue(todoIssues)
 
 	// 4. ラベル変更（soba:todo → soba:queued）
@@ -67,6 +93,11 @@ func (q *QueueManager) EnqueueNextIssue(ctx context.Context, issues []github.Iss
 		return err
 	}
 
+	if q.guard != nil {
+		q.guard.RecordEnqueue(ctx, targetIssue.Number)
+	}
+	q.history.Record(ctx, HistoryEvent{Issue: targetIssue.Number, Type: HistoryEnqueued})
+
 	q.logger.Info(ctx, "Queue management completed",
 		logging.Field{Key: "result", Value: "enqueued"},
 		logging.Field{Key: "issue", Value: targetIssue.Number})
@@ -76,18 +107,30 @@ func (q *QueueManager) EnqueueNextIssue(ctx context.Context, issues []github.Iss
 // hasActiveTask はアクティブなタスクがあるかチェック
 func (q *QueueManager) hasActiveTask(issues []github.Issue) bool {
 	for _, issue := range issues {
-		if q.hasSobaLabel(issue) && !q.hasLabel(issue, "soba:todo") {
-			return true // soba:todo以外のsobaラベルがある
+		if q.hasStateLabel(issue) && !q.hasLabel(issue, "soba:todo") {
+			return true // soba:todo以外の状態ラベルがある
 		}
 	}
 	return false
 }
 
+// collectPriorityIssues は優先度の高いIssueを収集する
+func (q *QueueManager) collectPriorityIssues(issues []github.Issue) []github.Issue {
+	var priorityIssues []github.Issue
+	for _, issue := range issues {
+		if q.hasLabel(issue, domain.LabelPriorityHigh) {
+			priorityIssues = append(priorityIssues, issue)
+		}
+	}
+	return priorityIssues
+}
+
 // collectTodoIssues はtodoラベルを持つIssueを収集する
 func (q *QueueManager) collectTodoIssues(issues []github.Issue) []github.Issue {
 	var todoIssues []github.Issue
 	for _, issue := range issues {
-		if q.hasLabel(issue, "soba:todo") {
+		// 一時停止中のIssueはキューに入れない
+		if q.hasLabel(issue, "soba:todo") && !q.hasLabel(issue, domain.LabelPaused) {
 			todoIssues = append(todoIssues, issue)
 		}
 	}
@@ -187,10 +230,11 @@ func (q *QueueManager) hasLabel(issue github.Issue, labelName string) bool {
 	return false
 }
 
-// hasSobaLabel はIssueがsoba:で始まるラベルを持つかチェックする
-func (q *QueueManager) hasSobaLabel(issue github.Issue) bool {
+// hasStateLabel はIssueがワークフローの状態を表すsobaラベルを持つかチェックする
+// soba:lgtmやsoba:pausedなどの制御用ラベルは含めない
+func (q *QueueManager) hasStateLabel(issue github.Issue) bool {
 	for _, label := range issue.Labels {
-		if strings.HasPrefix(label.Name, "soba:") {
+		if strings.HasPrefix(label.Name, "soba:") && !domain.IsControlLabel(label.Name) {
 			return true
 		}
 	}
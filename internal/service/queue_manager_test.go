@@ -69,6 +69,46 @@ func (m *MockQueueGitHubClient) GetIssueLabels(ctx context.Context, owner, repo
 	return []github.Label{}, args.Error(1)
 }
 
+func (m *MockQueueGitHubClient) UpdatePullRequestBranch(ctx context.Context, owner, repo string, number int, expectedHeadSHA string) error {
+	return nil
+}
+
+func (m *MockQueueGitHubClient) CreateComment(ctx context.Context, owner, repo string, issueNumber int, body string) error {
+	return nil
+}
+
+func (m *MockQueueGitHubClient) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error) {
+	return nil, nil
+}
+
+func (m *MockQueueGitHubClient) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*github.Issue, error) {
+	return &github.Issue{Number: issueNumber, State: "open"}, nil
+}
+
+func (m *MockQueueGitHubClient) CloseIssue(ctx context.Context, owner, repo string, issueNumber int) error {
+	return nil
+}
+
+func (m *MockQueueGitHubClient) DeleteBranch(ctx context.Context, owner, repo, branch string) error {
+	return nil
+}
+
+func (m *MockQueueGitHubClient) ListPullRequestReviews(ctx context.Context, owner, repo string, number int) ([]github.PullRequestReview, error) {
+	return nil, nil
+}
+
+func (m *MockQueueGitHubClient) ListReviewComments(ctx context.Context, owner, repo string, number int, reviewID int64) ([]github.ReviewComment, error) {
+	return nil, nil
+}
+
+func (m *MockQueueGitHubClient) ListRepositoryComments(ctx context.Context, owner, repo string, opts *github.ListCommentsOptions) ([]github.IssueComment, error) {
+	return nil, nil
+}
+
+func (m *MockQueueGitHubClient) CreateCommentReaction(ctx context.Context, owner, repo string, commentID int64, content string) error {
+	return nil
+}
+
 func TestQueueManager_EnqueueNextIssue(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -144,6 +184,29 @@ func TestQueueManager_EnqueueNextIssue(t *testing.T) {
 			expectedError: false,
 			expectedLog:   "Active task exists, skipping enqueue",
 		},
+		{
+			name: "優先度の高いtodoイssueを先にキューに入れる",
+			issues: []github.Issue{
+				{
+					Number: 1,
+					Labels: []github.Label{{Name: "soba:todo"}},
+				},
+				{
+					Number: 5,
+					Labels: []github.Label{{Name: "soba:todo"}, {Name: "soba:priority-high"}},
+				},
+				{
+					Number: 3,
+					Labels: []github.Label{{Name: "soba:todo"}, {Name: "soba:priority-high"}},
+				},
+			},
+			setupMock: func(m *MockQueueGitHubClient) {
+				m.On("RemoveLabelFromIssue", mock.Anything, "owner", "repo", 3, "soba:todo").Return(nil)
+				m.On("AddLabelToIssue", mock.Anything, "owner", "repo", 3, "soba:queued").Return(nil)
+			},
+			expectedError: false,
+			expectedLog:   "Enqueueing issue",
+		},
 	}
 
 	for _, tt := range tests {
@@ -211,6 +274,14 @@ func TestQueueManager_hasActiveTask(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "制御用ラベルのみ",
+			issues: []github.Issue{
+				{Labels: []github.Label{{Name: "soba:priority-high"}}},
+				{Labels: []github.Label{{Name: "soba:todo"}, {Name: "soba:paused"}}},
+			},
+			expected: false,
+		},
 		{
 			name: "sobaラベル以外のみ",
 			issues: []github.Issue{
@@ -240,6 +311,7 @@ func TestQueueManager_collectTodoIssues(t *testing.T) {
 		{Number: 3, Labels: []github.Label{{Name: "soba:todo"}}},
 		{Number: 4, Labels: []github.Label{{Name: "bug"}}},
 		{Number: 5, Labels: []github.Label{{Name: "soba:todo"}, {Name: "enhancement"}}},
+		{Number: 6, Labels: []github.Label{{Name: "soba:todo"}, {Name: "soba:paused"}}},
 	}
 
 	result := qm.collectTodoIssues(issues)
@@ -268,3 +340,24 @@ func TestQueueManager_selectMinimumIssue(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.Equal(t, 1, result.Number)
 }
+
+func TestQueueManager_EnqueueNextIssue_RecordsHistory(t *testing.T) {
+	mockClient := new(MockQueueGitHubClient)
+	mockClient.On("RemoveLabelFromIssue", mock.Anything, "owner", "repo", 4, "soba:todo").Return(nil)
+	mockClient.On("AddLabelToIssue", mock.Anything, "owner", "repo", 4, "soba:queued").Return(nil)
+
+	dir := t.TempDir()
+	qm := NewQueueManager(mockClient, "owner", "repo")
+	qm.SetHistory(NewEventHistory(dir, logging.NewMockLogger()))
+
+	err := qm.EnqueueNextIssue(context.Background(), []github.Issue{
+		{Number: 4, Labels: []github.Label{{Name: "soba:todo"}}},
+	})
+	assert.NoError(t, err)
+
+	events, err := LoadIssueHistory(dir, 4)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, HistoryEnqueued, events[0].Type)
+	}
+}
@@ -0,0 +1,498 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+// レポートの集計元
+const (
+	ReportSourceHistory = "history"
+	ReportSourceGitHub  = "github"
+)
+
+// reportIssuePages はGitHubからIssueを集めるときに辿る最大ページ数
+const reportIssuePages = 10
+
+// reportCompletionWindow はフェーズの実行ラベルが外れてから完了ラベルが付くまでに許す遅れ
+const reportCompletionWindow = time.Minute
+
+// reportPhases はレポートに載せるコマンド実行フェーズの順序
+var reportPhases = []domain.Phase{domain.PhasePlan, domain.PhaseImplement, domain.PhaseReview, domain.PhaseRevise}
+
+// historyFilePattern は.soba/history配下のIssueごとの履歴ファイル名にマッチする
+var historyFilePattern = regexp.MustCompile(`^issue-(\d+)\.jsonl$`)
+
+// ReportIssueReader はGitHubからレポートの集計元を取得するインターフェース
+type ReportIssueReader interface {
+	ListIssues(ctx context.Context, owner, repo string, opts github.ListIssuesOptions) ([]github.Issue, error)
+	ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error)
+}
+
+// WorkflowReport は期間内のワークフローの所要時間と結果の集計
+type WorkflowReport struct {
+	Source           string         `json:"source"`
+	Since            time.Time      `json:"since"`
+	Until            time.Time      `json:"until"`
+	Issues           int            `json:"issues"`
+	Merged           int            `json:"merged"`
+	LeadTime         DurationStats  `json:"lead_time"`
+	QueueWait        DurationStats  `json:"queue_wait"`
+	Phases           []PhaseReport  `json:"phases"`
+	Iterations       IterationStats `json:"iterations"`
+	DispatchFailures int            `json:"dispatch_failures"`
+	MergesPerDay     []DayCount     `json:"merges_per_day"`
+	AvgMergesPerDay  float64        `json:"avg_merges_per_day"`
+}
+
+// DurationStats は所要時間の分布
+type DurationStats struct {
+	Count  int
+	Mean   time.Duration
+	Median time.Duration
+	P90    time.Duration
+	Max    time.Duration
+}
+
+// MarshalJSON は所要時間を秒で出力する
+func (s DurationStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Count  int     `json:"count"`
+		Mean   float64 `json:"mean_seconds"`
+		Median float64 `json:"median_seconds"`
+		P90    float64 `json:"p90_seconds"`
+		Max    float64 `json:"max_seconds"`
+	}{s.Count, s.Mean.Seconds(), s.Median.Seconds(), s.P90.Seconds(), s.Max.Seconds()})
+}
+
+// PhaseReport はフェーズごとの実行回数と所要時間
+// 完了ラベルが付かずに実行ラベルが外れた実行を失敗として数える
+type PhaseReport struct {
+	Phase       string        `json:"phase"`
+	Runs        int           `json:"runs"`
+	Failed      int           `json:"failed"`
+	FailureRate float64       `json:"failure_rate"`
+	Duration    DurationStats `json:"duration"`
+}
+
+// IterationStats はレビューと修正の繰り返し回数
+type IterationStats struct {
+	Issues       int     `json:"issues"`
+	Reviews      int     `json:"reviews"`
+	Revisions    int     `json:"revisions"`
+	AvgReviews   float64 `json:"avg_reviews"`
+	AvgRevisions float64 `json:"avg_revisions"`
+	MaxRevisions int     `json:"max_revisions"`
+}
+
+// DayCount は1日あたりのマージ数
+type DayCount struct {
+	Day    string `json:"day"`
+	Merges int    `json:"merges"`
+}
+
+// IssueTimeline はレポートの集計に使うIssue1件のラベルの変化とマージ
+type IssueTimeline struct {
+	Number           int
+	Labels           []LabelEvent
+	MergedAt         *time.Time
+	DispatchFailures []time.Time
+}
+
+// LabelEvent はラベルが付いた・外れた時刻
+type LabelEvent struct {
+	Time  time.Time
+	Label string
+	Added bool
+}
+
+// ParseReportSince は"30d"、"2w"、"72h"などの期間、または"2006-01-02"形式の日付から集計の開始時刻を求める
+func ParseReportSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+	if len(value) > 1 {
+		unit := value[len(value)-1]
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n >= 0 {
+			switch unit {
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q: use a duration such as 30d, 2w or 72h, or a date such as 2006-01-02", value)
+}
+
+// LoadHistoryTimelines は.soba/history配下の全Issueの履歴をレポート用のタイムラインに変換する
+// 履歴がない場合は空を返す
+func LoadHistoryTimelines(dir string) ([]IssueTimeline, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, WrapServiceError(err, "failed to read history directory")
+	}
+
+	var timelines []IssueTimeline
+	for _, entry := range entries {
+		match := historyFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		number, _ := strconv.Atoi(match[1])
+		events, err := LoadIssueHistory(dir, number)
+		if err != nil {
+			return nil, err
+		}
+		timelines = append(timelines, historyTimeline(number, events))
+	}
+	sort.Slice(timelines, func(i, j int) bool { return timelines[i].Number < timelines[j].Number })
+	return timelines, nil
+}
+
+// historyTimeline はイベント履歴からラベルの変化とマージを取り出す
+func historyTimeline(number int, events []HistoryEvent) IssueTimeline {
+	timeline := IssueTimeline{Number: number}
+	for _, event := range events {
+		switch event.Type {
+		case HistoryLabelChanged:
+			for _, label := range event.Added {
+				timeline.Labels = append(timeline.Labels, LabelEvent{Time: event.Time, Label: label, Added: true})
+			}
+			for _, label := range event.Removed {
+				timeline.Labels = append(timeline.Labels, LabelEvent{Time: event.Time, Label: label})
+			}
+		case HistoryEnqueued:
+			timeline.Labels = append(timeline.Labels,
+				LabelEvent{Time: event.Time, Label: domain.LabelTodo},
+				LabelEvent{Time: event.Time, Label: domain.LabelQueued, Added: true},
+			)
+		case HistoryMerged:
+			if timeline.MergedAt == nil {
+				mergedAt := event.Time
+				timeline.MergedAt = &mergedAt
+			}
+		case HistoryFailed:
+			timeline.DispatchFailures = append(timeline.DispatchFailures, event.Time)
+		}
+	}
+	return timeline
+}
+
+// LoadGitHubTimelines は期間内に更新されたIssueのラベルイベントをGitHubから取得する
+// sobaのラベルが一度も付いていないIssueは除く。PRのマージで閉じられたIssueをマージ済みとみなす
+func LoadGitHubTimelines(ctx context.Context, client ReportIssueReader, owner, repo string, since time.Time) ([]IssueTimeline, error) {
+	var timelines []IssueTimeline
+	for page := 1; page <= reportIssuePages; page++ {
+		issues, err := client.ListIssues(ctx, owner, repo, github.ListIssuesOptions{
+			State:   "all",
+			Since:   &since,
+			Page:    page,
+			PerPage: 100,
+		})
+		if err != nil {
+			return nil, WrapServiceError(err, "failed to list issues")
+		}
+
+		for _, issue := range issues {
+			if issue.IsPullRequest() {
+				continue
+			}
+			events, err := client.ListIssueTimeline(ctx, owner, repo, issue.Number)
+			if err != nil {
+				return nil, WrapServiceError(err, fmt.Sprintf("failed to get timeline of issue #%d", issue.Number))
+			}
+			if timeline, ok := githubTimeline(issue.Number, events); ok {
+				timelines = append(timelines, timeline)
+			}
+		}
+
+		if len(issues) < 100 {
+			break
+		}
+	}
+	sort.Slice(timelines, func(i, j int) bool { return timelines[i].Number < timelines[j].Number })
+	return timelines, nil
+}
+
+// githubTimeline はタイムラインイベントからsobaのラベルの変化とマージを取り出す
+// コミットで閉じられたIssueはそのクローズを、それ以外で閉じられたIssueは参照しているPRの最後のマージをマージとみなす
+func githubTimeline(number int, events []github.TimelineEvent) (IssueTimeline, bool) {
+	timeline := IssueTimeline{Number: number}
+	closed := false
+	var referencedMerge *time.Time
+	for _, event := range events {
+		switch event.Event {
+		case "labeled", "unlabeled":
+			if event.Label == nil || !strings.HasPrefix(event.Label.Name, "soba:") {
+				continue
+			}
+			timeline.Labels = append(timeline.Labels, LabelEvent{Time: event.CreatedAt, Label: event.Label.Name, Added: event.Event == "labeled"})
+		case "closed":
+			closed = true
+			if event.CommitID != "" && timeline.MergedAt == nil {
+				mergedAt := event.CreatedAt
+				timeline.MergedAt = &mergedAt
+			}
+		case "cross-referenced":
+			source := crossReferencedIssue(event)
+			if source == nil || !source.IsPullRequest() || source.PullRequest.MergedAt == nil {
+				continue
+			}
+			if referencedMerge == nil || source.PullRequest.MergedAt.After(*referencedMerge) {
+				referencedMerge = source.PullRequest.MergedAt
+			}
+		}
+	}
+	if timeline.MergedAt == nil && closed && referencedMerge != nil {
+		mergedAt := *referencedMerge
+		timeline.MergedAt = &mergedAt
+	}
+	return timeline, len(timeline.Labels) > 0
+}
+
+// BuildWorkflowReport はsinceからuntilまでの集計を作る
+// 所要時間は期間内に終わったもの（マージ、キュー投入、フェーズの開始）を数える
+func BuildWorkflowReport(timelines []IssueTimeline, source string, since, until time.Time) *WorkflowReport {
+	report := &WorkflowReport{Source: source, Since: since, Until: until}
+	inWindow := func(t time.Time) bool { return !t.Before(since) && !t.After(until) }
+
+	var leadTimes, queueWaits []time.Duration
+	phaseDurations := make(map[domain.Phase][]time.Duration)
+	phaseRuns := make(map[domain.Phase]*PhaseReport)
+	for _, phase := range reportPhases {
+		phaseRuns[phase] = &PhaseReport{Phase: string(phase)}
+	}
+	mergesByDay := make(map[string]int)
+	var revisionsPerIssue []int
+
+	for _, timeline := range timelines {
+		labels := normalizeLabelEvents(timeline.Labels)
+		active := false
+
+		startedAt, hasStart := firstLabelAdded(labels, domain.LabelTodo)
+		queuedAt, hasQueued := firstLabelAdded(labels, domain.LabelQueued)
+		if !hasStart {
+			startedAt, hasStart = queuedAt, hasQueued
+		}
+		if !hasStart && len(labels) > 0 {
+			startedAt, hasStart = labels[0].Time, true
+		}
+
+		if hasQueued && inWindow(queuedAt) {
+			active = true
+			if todoAt, ok := firstLabelAdded(labels, domain.LabelTodo); ok && !queuedAt.Before(todoAt) {
+				queueWaits = append(queueWaits, queuedAt.Sub(todoAt))
+			}
+		}
+
+		if timeline.MergedAt != nil && inWindow(*timeline.MergedAt) {
+			active = true
+			report.Merged++
+			mergesByDay[timeline.MergedAt.In(until.Location()).Format("2006-01-02")]++
+			if hasStart && !timeline.MergedAt.Before(startedAt) {
+				leadTimes = append(leadTimes, timeline.MergedAt.Sub(startedAt))
+			}
+		}
+
+		reviews, revisions := 0, 0
+		for _, phase := range reportPhases {
+			for _, run := range phaseRunsOf(labels, phase) {
+				if !inWindow(run.start) {
+					continue
+				}
+				active = true
+				switch phase {
+				case domain.PhaseReview:
+					reviews++
+				case domain.PhaseRevise:
+					revisions++
+				}
+				if run.end.IsZero() {
+					continue // 実行中
+				}
+				phaseRuns[phase].Runs++
+				if !run.completed {
+					phaseRuns[phase].Failed++
+				}
+				phaseDurations[phase] = append(phaseDurations[phase], run.end.Sub(run.start))
+			}
+		}
+		if reviews > 0 {
+			report.Iterations.Issues++
+			report.Iterations.Reviews += reviews
+			report.Iterations.Revisions += revisions
+			revisionsPerIssue = append(revisionsPerIssue, revisions)
+		}
+
+		for _, failedAt := range timeline.DispatchFailures {
+			if inWindow(failedAt) {
+				active = true
+				report.DispatchFailures++
+			}
+		}
+
+		if active {
+			report.Issues++
+		}
+	}
+
+	report.LeadTime = summarizeDurations(leadTimes)
+	report.QueueWait = summarizeDurations(queueWaits)
+	for _, phase := range reportPhases {
+		run := phaseRuns[phase]
+		run.Duration = summarizeDurations(phaseDurations[phase])
+		if run.Runs > 0 {
+			run.FailureRate = float64(run.Failed) / float64(run.Runs)
+		}
+		report.Phases = append(report.Phases, *run)
+	}
+
+	if report.Iterations.Issues > 0 {
+		report.Iterations.AvgReviews = float64(report.Iterations.Reviews) / float64(report.Iterations.Issues)
+		report.Iterations.AvgRevisions = float64(report.Iterations.Revisions) / float64(report.Iterations.Issues)
+	}
+	for _, revisions := range revisionsPerIssue {
+		if revisions > report.Iterations.MaxRevisions {
+			report.Iterations.MaxRevisions = revisions
+		}
+	}
+
+	report.MergesPerDay = make([]DayCount, 0, len(mergesByDay))
+	for day, merges := range mergesByDay {
+		report.MergesPerDay = append(report.MergesPerDay, DayCount{Day: day, Merges: merges})
+	}
+	sort.Slice(report.MergesPerDay, func(i, j int) bool { return report.MergesPerDay[i].Day < report.MergesPerDay[j].Day })
+	if days := until.Sub(since).Hours() / 24; days > 0 {
+		report.AvgMergesPerDay = float64(report.Merged) / days
+	}
+
+	return report
+}
+
+// normalizeLabelEvents はラベルイベントを時刻順に並べ、既に付いているラベルの追加や付いていないラベルの削除を取り除く
+func normalizeLabelEvents(events []LabelEvent) []LabelEvent {
+	sorted := make([]LabelEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	present := make(map[string]bool)
+	normalized := make([]LabelEvent, 0, len(sorted))
+	for _, event := range sorted {
+		if present[event.Label] == event.Added {
+			continue
+		}
+		present[event.Label] = event.Added
+		normalized = append(normalized, event)
+	}
+	return normalized
+}
+
+func firstLabelAdded(events []LabelEvent, label string) (time.Time, bool) {
+	for _, event := range events {
+		if event.Added && event.Label == label {
+			return event.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// phaseRun はフェーズの実行ラベルが付いてから外れるまで
+type phaseRun struct {
+	start     time.Time
+	end       time.Time // 実行中はゼロ値
+	completed bool
+}
+
+// phaseRunsOf はフェーズの実行を取り出す。実行ラベルが外れた前後に完了ラベルが付いていれば完了とする
+func phaseRunsOf(events []LabelEvent, phase domain.Phase) []phaseRun {
+	phaseDef := domain.PhaseDefinitions[string(phase)]
+	if phaseDef == nil {
+		return nil
+	}
+
+	var runs []phaseRun
+	for i, event := range events {
+		if event.Label != phaseDef.ExecutionLabel || !event.Added {
+			continue
+		}
+		run := phaseRun{start: event.Time}
+		for _, later := range events[i+1:] {
+			if later.Label == phaseDef.ExecutionLabel && !later.Added {
+				run.end = later.Time
+				break
+			}
+		}
+		if !run.end.IsZero() {
+			for _, other := range events {
+				if !other.Added || other.Time.Before(run.start) || other.Time.After(run.end.Add(reportCompletionWindow)) {
+					continue
+				}
+				if _, ok := phaseDef.CompletionLabels[other.Label]; ok {
+					run.completed = true
+					break
+				}
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// summarizeDurations は所要時間の平均・中央値・90パーセンタイル・最大を求める
+func summarizeDurations(durations []time.Duration) DurationStats {
+	stats := DurationStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	stats.Mean = total / time.Duration(len(sorted))
+	stats.Median = percentile(sorted, 0.5)
+	stats.P90 = percentile(sorted, 0.9)
+	stats.Max = sorted[len(sorted)-1]
+	return stats
+}
+
+// percentile は昇順に並んだ所要時間のpパーセンタイルを最近傍法で求める
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	index := int(float64(len(sorted))*p+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// HistoryAvailable はdirにIssueの履歴があるかを返す
+func HistoryAvailable(dir string) bool {
+	matches, err := filepath.Glob(filepath.Join(dir, "issue-*.jsonl"))
+	return err == nil && len(matches) > 0
+}
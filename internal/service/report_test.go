@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+func TestParseReportSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "30d", want: now.AddDate(0, 0, -30)},
+		{value: "2w", want: now.AddDate(0, 0, -14)},
+		{value: "72h", want: now.Add(-72 * time.Hour)},
+		{value: "2026-10-01", want: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+		{value: "-3d", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseReportSince(tt.value, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// labelEvents はテスト用に分単位のオフセットからラベルイベントを作る
+func labelEvents(base time.Time, events ...interface{}) []LabelEvent {
+	var result []LabelEvent
+	for i := 0; i+2 < len(events); i += 3 {
+		result = append(result, LabelEvent{
+			Time:  base.Add(time.Duration(events[i].(int)) * time.Minute),
+			Label: events[i+1].(string),
+			Added: events[i+2].(bool),
+		})
+	}
+	return result
+}
+
+func TestBuildWorkflowReport(t *testing.T) {
+	base := time.Date(2026, 10, 10, 9, 0, 0, 0, time.UTC)
+	mergedAt := base.Add(300 * time.Minute)
+	timelines := []IssueTimeline{
+		{
+			Number: 1,
+			Labels: labelEvents(base,
+				0, "soba:todo", true,
+				10, "soba:todo", false, 10, "soba:queued", true,
+				11, "soba:queued", false, 11, "soba:planning", true,
+				41, "soba:planning", false, 41, "soba:ready", true,
+				50, "soba:ready", false, 50, "soba:doing", true,
+				110, "soba:doing", false, 110, "soba:review-requested", true,
+				111, "soba:review-requested", false, 111, "soba:reviewing", true,
+				121, "soba:reviewing", false, 121, "soba:requires-changes", true,
+				122, "soba:requires-changes", false, 122, "soba:revising", true,
+				152, "soba:revising", false, 152, "soba:review-requested", true,
+				153, "soba:review-requested", false, 153, "soba:reviewing", true,
+				163, "soba:reviewing", false, 163, "soba:done", true,
+				// 同じラベルの重複した追加は無視される
+				163, "soba:done", true,
+			),
+			MergedAt: &mergedAt,
+		},
+		{
+			// 実行ラベルが完了ラベルなしで外れた（キャンセルされた）Issue
+			Number: 2,
+			Labels: labelEvents(base,
+				0, "soba:todo", true,
+				60, "soba:todo", false, 60, "soba:queued", true,
+				61, "soba:queued", false, 61, "soba:planning", true,
+				70, "soba:planning", false,
+			),
+			DispatchFailures: []time.Time{base.Add(65 * time.Minute)},
+		},
+		{
+			// 期間外のIssue
+			Number: 3,
+			Labels: labelEvents(base.AddDate(0, -3, 0),
+				0, "soba:todo", true,
+				10, "soba:queued", true,
+			),
+		},
+	}
+
+	since := base.AddDate(0, 0, -1)
+	until := base.AddDate(0, 0, 1)
+	report := BuildWorkflowReport(timelines, ReportSourceHistory, since, until)
+
+	assert.Equal(t, 2, report.Issues)
+	assert.Equal(t, 1, report.Merged)
+	assert.Equal(t, 1, report.LeadTime.Count)
+	assert.Equal(t, 300*time.Minute, report.LeadTime.Mean)
+	assert.Equal(t, 2, report.QueueWait.Count)
+	assert.Equal(t, 35*time.Minute, report.QueueWait.Mean)
+	assert.Equal(t, 60*time.Minute, report.QueueWait.Max)
+	assert.Equal(t, 1, report.DispatchFailures)
+
+	phases := make(map[string]PhaseReport)
+	for _, phase := range report.Phases {
+		phases[phase.Phase] = phase
+	}
+	assert.Equal(t, 2, phases["plan"].Runs)
+	assert.Equal(t, 1, phases["plan"].Failed)
+	assert.InDelta(t, 0.5, phases["plan"].FailureRate, 1e-9)
+	assert.Equal(t, 30*time.Minute, phases["plan"].Duration.Max)
+	assert.Equal(t, 1, phases["implement"].Runs)
+	assert.Equal(t, 60*time.Minute, phases["implement"].Duration.Mean)
+	assert.Equal(t, 2, phases["review"].Runs)
+	assert.Equal(t, 0, phases["review"].Failed)
+	assert.Equal(t, 1, phases["revise"].Runs)
+
+	assert.Equal(t, 1, report.Iterations.Issues)
+	assert.Equal(t, 2, report.Iterations.Reviews)
+	assert.Equal(t, 1, report.Iterations.Revisions)
+	assert.Equal(t, 1, report.Iterations.MaxRevisions)
+
+	require.Len(t, report.MergesPerDay, 1)
+	assert.Equal(t, DayCount{Day: "2026-10-10", Merges: 1}, report.MergesPerDay[0])
+	assert.InDelta(t, 0.5, report.AvgMergesPerDay, 1e-9)
+}
+
+func TestLoadHistoryTimelines(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	history := NewEventHistory(dir, logging.NewMockLogger())
+	base := time.Date(2026, 10, 10, 9, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	history.Record(ctx, HistoryEvent{Time: base, Issue: 5, Type: HistoryLabelChanged, Added: []string{"soba:todo"}})
+	history.Record(ctx, HistoryEvent{Time: base.Add(time.Minute), Issue: 5, Type: HistoryEnqueued})
+	history.Record(ctx, HistoryEvent{Time: base.Add(time.Hour), Issue: 5, Type: HistoryMerged, PR: 9})
+	history.Record(ctx, HistoryEvent{Time: base, Issue: 6, Type: HistoryFailed, Phase: "plan"})
+
+	assert.True(t, HistoryAvailable(dir))
+	assert.False(t, HistoryAvailable(t.TempDir()))
+
+	timelines, err := LoadHistoryTimelines(dir)
+	require.NoError(t, err)
+	require.Len(t, timelines, 2)
+
+	assert.Equal(t, 5, timelines[0].Number)
+	assert.Len(t, timelines[0].Labels, 3)
+	require.NotNil(t, timelines[0].MergedAt)
+	assert.Equal(t, base.Add(time.Hour), *timelines[0].MergedAt)
+	assert.Len(t, timelines[1].DispatchFailures, 1)
+}
+
+type fakeReportReader struct {
+	issues    []github.Issue
+	timelines map[int][]github.TimelineEvent
+}
+
+func (f *fakeReportReader) ListIssues(ctx context.Context, owner, repo string, opts github.ListIssuesOptions) ([]github.Issue, error) {
+	if opts.Page > 1 {
+		return nil, nil
+	}
+	return f.issues, nil
+}
+
+func (f *fakeReportReader) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error) {
+	return f.timelines[issueNumber], nil
+}
+
+func TestLoadGitHubTimelines(t *testing.T) {
+	base := time.Date(2026, 10, 10, 9, 0, 0, 0, time.UTC)
+	pullRequest := func(number int, mergedAt *time.Time) github.TimelineEvent {
+		return github.TimelineEvent{Event: "cross-referenced", CreatedAt: base, Source: &github.TimelineSource{
+			Type:  "issue",
+			Issue: &github.Issue{Number: number, PullRequest: &github.IssuePullRequest{URL: "pr", MergedAt: mergedAt}},
+		}}
+	}
+	merged := base.Add(90 * time.Minute)
+	mergedLater := base.Add(3 * time.Hour)
+	reader := &fakeReportReader{
+		issues: []github.Issue{
+			{Number: 1},
+			{Number: 2},
+			{Number: 3, PullRequest: &github.IssuePullRequest{URL: "pr"}},
+			{Number: 4},
+			{Number: 5},
+		},
+		timelines: map[int][]github.TimelineEvent{
+			// PRのマージでIssueが閉じられた
+			1: {
+				{Event: "labeled", Label: &github.Label{Name: "soba:todo"}, CreatedAt: base},
+				{Event: "labeled", Label: &github.Label{Name: "bug"}, CreatedAt: base},
+				pullRequest(10, &merged),
+				{Event: "labeled", Label: &github.Label{Name: "soba:done"}, CreatedAt: base.Add(time.Hour)},
+				{Event: "closed", CommitID: "4f2a9c1", CreatedAt: base.Add(2 * time.Hour)},
+			},
+			2: {
+				{Event: "labeled", Label: &github.Label{Name: "bug"}, CreatedAt: base},
+			},
+			// ベースブランチ以外へのマージの後、post_mergeでsobaが閉じた
+			4: {
+				{Event: "labeled", Label: &github.Label{Name: "soba:todo"}, CreatedAt: base},
+				pullRequest(11, nil),
+				pullRequest(12, &mergedLater),
+				{Event: "closed", CreatedAt: mergedLater.Add(time.Minute)},
+			},
+			// マージせずに閉じた
+			5: {
+				{Event: "labeled", Label: &github.Label{Name: "soba:todo"}, CreatedAt: base},
+				pullRequest(13, nil),
+				{Event: "closed", CreatedAt: base.Add(time.Hour)},
+			},
+		},
+	}
+
+	timelines, err := LoadGitHubTimelines(context.Background(), reader, "owner", "repo", base.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	require.Len(t, timelines, 3)
+
+	assert.Equal(t, 1, timelines[0].Number)
+	assert.Len(t, timelines[0].Labels, 2)
+	require.NotNil(t, timelines[0].MergedAt)
+	assert.Equal(t, base.Add(2*time.Hour), *timelines[0].MergedAt)
+
+	assert.Equal(t, 4, timelines[1].Number)
+	require.NotNil(t, timelines[1].MergedAt)
+	assert.Equal(t, mergedLater, *timelines[1].MergedAt)
+
+	assert.Equal(t, 5, timelines[2].Number)
+	assert.Nil(t, timelines[2].MergedAt)
+}
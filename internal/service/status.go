@@ -10,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
 	"github.com/douhashi/soba/internal/infra/github"
 	"github.com/douhashi/soba/internal/infra/tmux"
 	"github.com/douhashi/soba/internal/service/builder"
@@ -222,14 +223,21 @@ func (s *statusService) getIssuesStatus(ctx context.Context) ([]builder.IssueSta
 
 	// Filter issues with soba labels
 	for _, issue := range issues {
+		// Pull requests are listed by the issues API too; they are shown as links instead
+		if issue.IsPullRequest() {
+			continue
+		}
+
 		hasSobaLabel := false
 		sobaState := ""
 
 		for _, label := range issue.Labels {
 			if strings.HasPrefix(label.Name, "soba:") {
 				hasSobaLabel = true
-				sobaState = label.Name
-				break
+				// Control labels such as soba:paused do not describe the state
+				if sobaState == "" && !domain.IsControlLabel(label.Name) {
+					sobaState = label.Name
+				}
 			}
 		}
 
@@ -250,6 +258,45 @@ func (s *statusService) getIssuesStatus(ctx context.Context) ([]builder.IssueSta
 		}
 	}
 
+	s.linkPullRequests(ctx, owner, repo, statuses)
+
 	log.Debug(ctx, "Found issues with soba labels", logging.Field{Key: "count", Value: len(statuses)})
 	return statuses, nil
 }
+
+// linkPullRequests fills in the pull request linked to each issue that has reached a review phase
+func (s *statusService) linkPullRequests(ctx context.Context, owner, repo string, statuses []builder.IssueStatus) {
+	var targets []int
+	for i := range statuses {
+		if hasPullRequestPhase(statuses[i].State) {
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	prs, _, err := s.githubClient.ListPullRequests(ctx, owner, repo, &github.ListPullRequestsOptions{
+		State:   "open",
+		PerPage: 100,
+	})
+	if err != nil {
+		// The PR is informational, so fall back to issue timelines only
+		prs = nil
+	}
+
+	linker := NewIssueLinker(s.githubClient, owner, repo)
+	for _, i := range targets {
+		statuses[i].PullRequest = linker.PullRequestForIssue(ctx, statuses[i].Number, prs)
+	}
+}
+
+// hasPullRequestPhase reports whether an issue in the given soba state is expected to have a PR
+func hasPullRequestPhase(state string) bool {
+	switch state {
+	case domain.LabelReviewRequested, domain.LabelReviewing, domain.LabelDone,
+		domain.LabelRequiresChanges, domain.LabelRevising:
+		return true
+	}
+	return false
+}
@@ -89,6 +89,16 @@ func (m *StatusMockTmuxClient) SendCommand(sessionName, windowName string, paneI
 	return args.Error(0)
 }
 
+func (m *StatusMockTmuxClient) CapturePane(sessionName, windowName string, paneIndex int) (string, error) {
+	args := m.Called(sessionName, windowName, paneIndex)
+	return args.String(0), args.Error(1)
+}
+
+func (m *StatusMockTmuxClient) PipePane(sessionName, windowName string, paneIndex int, logPath string) error {
+	args := m.Called(sessionName, windowName, paneIndex, logPath)
+	return args.Error(0)
+}
+
 // StatusMockGitHubClient for testing
 type StatusMockGitHubClient struct {
 	mock.Mock
@@ -143,6 +153,14 @@ func (m *StatusMockGitHubClient) GetIssueLabels(ctx context.Context, owner, repo
 	return []github.Label{}, args.Error(1)
 }
 
+func (m *StatusMockGitHubClient) ListIssueTimeline(ctx context.Context, owner, repo string, issueNumber int) ([]github.TimelineEvent, error) {
+	args := m.Called(ctx, owner, repo, issueNumber)
+	if events := args.Get(0); events != nil {
+		return events.([]github.TimelineEvent), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func TestStatusService_GetStatus(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -150,6 +168,7 @@ func TestStatusService_GetStatus(t *testing.T) {
 		pidFileExists  bool
 		expectedDaemon bool
 		expectedIssues int
+		expectedPRs    map[int]int
 		expectedTmux   bool
 	}{
 		{
@@ -167,6 +186,32 @@ func TestStatusService_GetStatus(t *testing.T) {
 			expectedIssues: 2,
 			expectedTmux:   true,
 		},
+		{
+			name: "issues in review phases are linked to pull requests",
+			setupMocks: func(gh *StatusMockGitHubClient, tm *StatusMockTmuxClient) {
+				gh.On("ListOpenIssues", mock.Anything, "test-owner", "test-repo", mock.Anything).
+					Return([]github.Issue{
+						{Number: 1, Title: "Issue 1", Labels: []github.Label{{Name: "soba:review-requested"}}},
+						{Number: 2, Title: "Issue 2", Labels: []github.Label{{Name: "soba:done"}}},
+						{Number: 3, Title: "Issue 3", Labels: []github.Label{{Name: "soba:doing"}}},
+						{Number: 10, Title: "PR for issue 1", Labels: []github.Label{{Name: "soba:lgtm"}}, PullRequest: &github.IssuePullRequest{}},
+					}, false, nil)
+				gh.On("ListPullRequests", mock.Anything, "test-owner", "test-repo", mock.Anything).
+					Return([]github.PullRequest{
+						{Number: 10, Head: github.PullRequestBranch{Ref: "soba/1"}},
+					}, false, nil)
+				gh.On("ListIssueTimeline", mock.Anything, "test-owner", "test-repo", 2).
+					Return([]github.TimelineEvent{
+						{Event: "cross-referenced", Source: &github.TimelineSource{Issue: &github.Issue{Number: 11, State: "open", PullRequest: &github.IssuePullRequest{}}}},
+					}, nil)
+				tm.On("SessionExists", mock.Anything).Return(false)
+			},
+			pidFileExists:  false,
+			expectedDaemon: false,
+			expectedIssues: 3,
+			expectedPRs:    map[int]int{1: 10, 2: 11, 3: 0},
+			expectedTmux:   false,
+		},
 		{
 			name: "daemon not running",
 			setupMocks: func(gh *StatusMockGitHubClient, tm *StatusMockTmuxClient) {
@@ -217,6 +262,11 @@ func TestStatusService_GetStatus(t *testing.T) {
 
 			// Verify issues
 			assert.Len(t, status.Issues, tt.expectedIssues)
+			for _, issue := range status.Issues {
+				if expected, ok := tt.expectedPRs[issue.Number]; ok {
+					assert.Equal(t, expected, issue.PullRequest, "issue #%d", issue.Number)
+				}
+			}
 
 			// Verify tmux
 			if tt.expectedTmux {
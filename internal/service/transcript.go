@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/tmux"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+const (
+	// TranscriptLogDir はIssueごとのペイン出力ログを保存するディレクトリ
+	TranscriptLogDir = ".soba/logs/issues"
+
+	transcriptTimeFormat = "20060102-150405"
+
+	// phaseExitMarker はフェーズのコマンドが終了したときにペインへ出力する行
+	phaseExitMarker = "soba: phase command exited"
+	// transcriptExitWindow はコマンドの終了を探すログ末尾のバイト数
+	transcriptExitWindow = 4096
+)
+
+// TranscriptRecorder はフェーズを実行するペインの出力をIssueごとのログファイルに記録する
+type TranscriptRecorder struct {
+	tmux    tmux.TmuxClient
+	logger  logging.Logger
+	baseDir string
+	enabled bool
+	now     func() time.Time
+}
+
+// NewTranscriptRecorder は新しいTranscriptRecorderを作成する
+func NewTranscriptRecorder(tmuxClient tmux.TmuxClient, logger logging.Logger) *TranscriptRecorder {
+	return &TranscriptRecorder{
+		tmux:    tmuxClient,
+		logger:  logger,
+		baseDir: TranscriptLogDir,
+		now:     time.Now,
+	}
+}
+
+// Configure は設定から記録の有効・無効を反映する
+func (r *TranscriptRecorder) Configure(cfg *config.Config) {
+	r.enabled = cfg.Workflow.Transcript.Enabled
+}
+
+// Start はペインの出力を<phase>-<timestamp>.logへ流し始め、ログファイルのパスを返す
+func (r *TranscriptRecorder) Start(sessionName, windowName string, paneIndex, issueNumber int, phase domain.Phase) (string, error) {
+	if !r.enabled {
+		return "", nil
+	}
+
+	path, err := r.newLogPath(issueNumber, string(phase))
+	if err != nil {
+		return "", err
+	}
+	if err := r.tmux.PipePane(sessionName, windowName, paneIndex, path); err != nil {
+		return "", NewTmuxManagementError("pipe pane", windowName, err.Error())
+	}
+
+	r.logger.Debug(context.Background(), "Recording pane output",
+		logging.Field{Key: "issue", Value: issueNumber},
+		logging.Field{Key: "phase", Value: string(phase)},
+		logging.Field{Key: "path", Value: path},
+	)
+	return path, nil
+}
+
+// Archive は削除する直前のペインの表示履歴をevicted-<timestamp>.logに保存する
+// 記録を有効にする前に作られたペインの履歴もここで残る
+func (r *TranscriptRecorder) Archive(sessionName, windowName string, paneIndex, issueNumber int) (string, error) {
+	if !r.enabled {
+		return "", nil
+	}
+
+	output, err := r.tmux.CapturePane(sessionName, windowName, paneIndex)
+	if err != nil {
+		return "", NewTmuxManagementError("capture pane", windowName, err.Error())
+	}
+
+	path, err := r.newLogPath(issueNumber, "evicted")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(output), 0o600); err != nil {
+		return "", WrapServiceError(err, "failed to write pane history")
+	}
+	return path, nil
+}
+
+// newLogPath はIssueのログディレクトリを作成し、新しいログファイルのパスを返す
+func (r *TranscriptRecorder) newLogPath(issueNumber int, prefix string) (string, error) {
+	dir := transcriptIssueDir(r.baseDir, issueNumber)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", WrapServiceError(err, "failed to create transcript directory")
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.log", prefix, r.now().Format(transcriptTimeFormat))), nil
+}
+
+// transcriptCommand はコマンドの終了後にphaseExitMarkerを出力するコマンドを返す
+func transcriptCommand(command string) string {
+	return command + `; echo "` + phaseExitMarker + `"`
+}
+
+// transcriptIssueDir はIssueのログディレクトリを返す
+func transcriptIssueDir(baseDir string, issueNumber int) string {
+	return filepath.Join(baseDir, fmt.Sprintf("%d", issueNumber))
+}
+
+// latestTranscript はIssueとフェーズに対応する最新のログファイルのパスを返す
+func latestTranscript(baseDir string, issueNumber int, phase string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(transcriptIssueDir(baseDir, issueNumber), phase+"-*.log"))
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", os.ErrNotExist
+	}
+	// タイムスタンプは辞書順に並ぶ
+	sort.Strings(paths)
+	return paths[len(paths)-1], nil
+}
+
+// readTranscriptTail はログファイルの制御文字を取り除き、末尾のlines行を返す
+func readTranscriptTail(path string, lines int) (string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - sobaが作成したログファイルのみを読む
+	if err != nil {
+		return "", err
+	}
+
+	var kept []string
+	for _, line := range strings.Split(stripTerminalSequences(string(data)), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && (len(kept) == 0 || kept[len(kept)-1] == "") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	for len(kept) > 0 && kept[len(kept)-1] == "" {
+		kept = kept[:len(kept)-1]
+	}
+	if len(kept) > lines {
+		kept = kept[len(kept)-lines:]
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")), nil
+}
+
+// transcriptExited はログ末尾にphaseExitMarkerがあり、フェーズのコマンドが終了しているか判定する
+// 入力したコマンドの行は引用符で終わるため一致しない
+func transcriptExited(path string) (bool, error) {
+	file, err := os.Open(path) // #nosec G304 - sobaが作成したログファイルのみを読む
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	offset := info.Size() - transcriptExitWindow
+	if offset < 0 {
+		offset = 0
+	}
+	data := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	for _, line := range strings.Split(stripTerminalSequences(string(data)), "\n") {
+		if strings.TrimSpace(line) == phaseExitMarker {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var (
+	// CSI・OSCなどのエスケープシーケンス
+	terminalSequencePattern = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+	controlCharPattern      = regexp.MustCompile(`[\x00-\x08\x0b\x0c\x0e-\x1f\x7f]`)
+
+	// 秘密情報のパターンと置換後の文字列
+	secretPatterns = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		{regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`), logging.RedactedValue},
+		{regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{20,}`), logging.RedactedValue},
+		{regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}`), logging.RedactedValue},
+		{regexp.MustCompile(`https://hooks\.slack\.com/[^\s"'<>]+`), logging.RedactedValue},
+		{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), logging.RedactedValue},
+		{regexp.MustCompile(`(?i)\b(bearer|token)\s+[A-Za-z0-9._\-]{20,}`), "${1} " + logging.RedactedValue},
+		{regexp.MustCompile(`(?i)\b([A-Z0-9_]*(?:TOKEN|SECRET|PASSWORD|API_KEY)[A-Z0-9_]*)(\s*[=:]\s*)("?)[^\s"]+`), "${1}${2}${3}" + logging.RedactedValue},
+	}
+)
+
+// stripTerminalSequences はエスケープシーケンスと制御文字を取り除き、\rで上書きされた行は最後の内容を残す
+func stripTerminalSequences(text string) string {
+	text = terminalSequencePattern.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if idx := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); idx >= 0 {
+			line = line[idx+1:]
+		}
+		lines[i] = controlCharPattern.ReplaceAllString(strings.TrimRight(line, "\r"), "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactSecrets はトークンやWebhook URLなど秘密情報と思われる文字列を伏せる
+// knownSecretsには設定から読み込んだ値を渡し、ログと同じ規則で伏せる
+func redactSecrets(text string, knownSecrets ...string) string {
+	text = logging.Redact(text, knownSecrets...)
+	for _, secret := range secretPatterns {
+		text = secret.pattern.ReplaceAllString(text, secret.replacement)
+	}
+	return text
+}
+
+// buildTranscriptComment はログ末尾を折りたたんだIssueコメントを組み立てる
+func buildTranscriptComment(phase, outcome, path, tail string) string {
+	fence := "```"
+	for strings.Contains(tail, fence) {
+		fence += "`"
+	}
+
+	var b strings.Builder
+	b.WriteString("<details>\n")
+	b.WriteString(fmt.Sprintf("<summary>soba: %s phase %s (last %d lines of <code>%s</code>)</summary>\n\n",
+		phase, outcome, strings.Count(tail, "\n")+1, filepath.ToSlash(path)))
+	b.WriteString(fence + "text\n")
+	b.WriteString(tail)
+	b.WriteString("\n" + fence + "\n\n")
+	b.WriteString("</details>\n")
+	return b.String()
+}
+
+// postTranscriptTail はフェーズのログ末尾を、秘密情報を伏せてIssueにコメントする
+func postTranscriptTail(ctx context.Context, client GitHubClientInterface, cfg *config.Config, path string, issueNumber int, phase, outcome string) error {
+	parts := strings.Split(cfg.GitHub.Repository, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid repository format: %s", cfg.GitHub.Repository)
+	}
+
+	tail, err := readTranscriptTail(path, cfg.Workflow.Transcript.CommentLines)
+	if err != nil {
+		return err
+	}
+	if tail == "" {
+		return nil
+	}
+
+	tail = redactSecrets(tail, cfg.SecretValues()...)
+	return client.CreateComment(ctx, parts[0], parts[1], issueNumber, buildTranscriptComment(phase, outcome, path, tail))
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+)
+
+// UsageLogPath はフェーズ実行ごとの使用量を追記するファイル
+const UsageLogPath = ".soba/usage/usage.jsonl"
+
+// 使用量の集計単位
+const (
+	UsageByIssue = "issue"
+	UsageByDay   = "day"
+	UsageByWeek  = "week"
+
+	usageByPhase = "phase"
+)
+
+// UsageRecord は1回のフェーズ実行でエージェントが使ったコストとトークン数
+type UsageRecord struct {
+	Time                     time.Time `json:"time"`
+	Issue                    int       `json:"issue"`
+	Phase                    string    `json:"phase"`
+	CostUSD                  float64   `json:"cost_usd"`
+	InputTokens              int64     `json:"input_tokens"`
+	OutputTokens             int64     `json:"output_tokens"`
+	CacheCreationInputTokens int64     `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int64     `json:"cache_read_input_tokens,omitempty"`
+	DurationMS               int64     `json:"duration_ms"`
+	NumTurns                 int       `json:"num_turns,omitempty"`
+	SessionID                string    `json:"session_id,omitempty"`
+	ExitCode                 int       `json:"exit_code"`
+}
+
+// agentResult はclaude --output-format json(stream-json)が最後に出力するresultオブジェクト
+type agentResult struct {
+	Type         string   `json:"type"`
+	TotalCostUSD *float64 `json:"total_cost_usd"`
+	CostUSD      *float64 `json:"cost_usd"`
+	DurationMS   int64    `json:"duration_ms"`
+	NumTurns     int      `json:"num_turns"`
+	SessionID    string   `json:"session_id"`
+	Usage        struct {
+		InputTokens              int64 `json:"input_tokens"`
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// ParseAgentUsage はエージェントの出力から最後のresultオブジェクトを探し、使用量を返す
+// 見つからない場合はfalseを返す
+func ParseAgentUsage(output []byte) (UsageRecord, bool) {
+	lines := bytes.Split(output, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := bytes.TrimSpace(lines[i])
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		var result agentResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			continue
+		}
+		if result.Type != "result" && result.TotalCostUSD == nil && result.CostUSD == nil {
+			continue
+		}
+
+		record := UsageRecord{
+			InputTokens:              result.Usage.InputTokens,
+			OutputTokens:             result.Usage.OutputTokens,
+			CacheCreationInputTokens: result.Usage.CacheCreationInputTokens,
+			CacheReadInputTokens:     result.Usage.CacheReadInputTokens,
+			DurationMS:               result.DurationMS,
+			NumTurns:                 result.NumTurns,
+			SessionID:                result.SessionID,
+		}
+		switch {
+		case result.TotalCostUSD != nil:
+			record.CostUSD = *result.TotalCostUSD
+		case result.CostUSD != nil:
+			record.CostUSD = *result.CostUSD
+		}
+		return record, true
+	}
+	return UsageRecord{}, false
+}
+
+// AppendUsageRecord は使用量をファイルに1行追記する
+func AppendUsageRecord(path string, record UsageRecord) error {
+	return appendJSONLine(path, record)
+}
+
+// LoadUsageRecords は使用量ファイルを読み込む。ファイルがない場合は空を返し、壊れた行は読み飛ばす
+func LoadUsageRecords(path string) ([]UsageRecord, error) {
+	return readJSONLines[UsageRecord](path)
+}
+
+// UsageTotal は集計単位ごとの使用量の合計
+type UsageTotal struct {
+	Key          string
+	Runs         int
+	CostUSD      float64
+	InputTokens  int64
+	OutputTokens int64
+	CacheTokens  int64
+	DurationMS   int64
+
+	sortKey string
+}
+
+func (t *UsageTotal) add(record UsageRecord) {
+	t.Runs++
+	t.CostUSD += record.CostUSD
+	t.InputTokens += record.InputTokens
+	t.OutputTokens += record.OutputTokens
+	t.CacheTokens += record.CacheCreationInputTokens + record.CacheReadInputTokens
+	t.DurationMS += record.DurationMS
+}
+
+// Duration は実行時間の合計を秒単位で返す
+func (t UsageTotal) Duration() time.Duration {
+	return (time.Duration(t.DurationMS) * time.Millisecond).Round(time.Second)
+}
+
+// usageGroupKey は集計単位に応じて表示用のキーと並び順のキーを返す
+func usageGroupKey(by string, record UsageRecord) (string, string, error) {
+	switch by {
+	case UsageByIssue:
+		return fmt.Sprintf("#%d", record.Issue), fmt.Sprintf("%010d", record.Issue), nil
+	case UsageByDay:
+		day := record.Time.Local().Format("2006-01-02")
+		return day, day, nil
+	case UsageByWeek:
+		year, week := record.Time.Local().ISOWeek()
+		key := fmt.Sprintf("%04d-W%02d", year, week)
+		return key, key, nil
+	case usageByPhase:
+		return record.Phase, strconv.Itoa(phaseOrder(record.Phase)) + record.Phase, nil
+	default:
+		return "", "", fmt.Errorf("unknown usage breakdown %q (available: issue, day, week)", by)
+	}
+}
+
+// phaseOrder はワークフロー上のフェーズの順番を返す
+func phaseOrder(phase string) int {
+	for i, name := range []string{"plan", "implement", "review", "revise"} {
+		if name == phase {
+			return i
+		}
+	}
+	return 9
+}
+
+// SummarizeUsage は使用量をbyで指定した単位ごとに合計し、キーの順に並べて返す
+func SummarizeUsage(records []UsageRecord, by string) ([]UsageTotal, error) {
+	totals := make(map[string]*UsageTotal)
+	for _, record := range records {
+		key, sortKey, err := usageGroupKey(by, record)
+		if err != nil {
+			return nil, err
+		}
+		total, ok := totals[key]
+		if !ok {
+			total = &UsageTotal{Key: key, sortKey: sortKey}
+			totals[key] = total
+		}
+		total.add(record)
+	}
+
+	result := make([]UsageTotal, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].sortKey < result[j].sortKey })
+	return result, nil
+}
+
+// SumUsage は全ての使用量を合計する
+func SumUsage(records []UsageRecord) UsageTotal {
+	total := UsageTotal{Key: "total"}
+	for _, record := range records {
+		total.add(record)
+	}
+	return total
+}
+
+// buildUsageComment はIssueのこれまでの使用量をフェーズ別にまとめたコメントを組み立てる
+func buildUsageComment(records []UsageRecord) string {
+	byPhase, _ := SummarizeUsage(records, usageByPhase)
+	total := SumUsage(records)
+
+	var b strings.Builder
+	b.WriteString("<details>\n")
+	b.WriteString(fmt.Sprintf("<summary>soba: usage so far $%.2f (%d runs)</summary>\n\n", total.CostUSD, total.Runs))
+	b.WriteString("| Phase | Runs | Cost (USD) | Input tokens | Output tokens | Cache tokens | Duration |\n")
+	b.WriteString("|-------|-----:|-----------:|-------------:|--------------:|-------------:|---------:|\n")
+	for _, phase := range append(byPhase, total) {
+		name := phase.Key
+		if name == "total" {
+			name = "**Total**"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %d | %.2f | %d | %d | %d | %s |\n",
+			name, phase.Runs, phase.CostUSD, phase.InputTokens, phase.OutputTokens, phase.CacheTokens, phase.Duration()))
+	}
+	b.WriteString("\n</details>\n")
+	return b.String()
+}
+
+// postUsageTotal はIssueのこれまでの使用量の合計をコメントする。記録がない場合は何もしない
+func postUsageTotal(ctx context.Context, client GitHubClientInterface, cfg *config.Config, path string, issueNumber int) error {
+	parts := strings.Split(cfg.GitHub.Repository, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid repository format: %s", cfg.GitHub.Repository)
+	}
+
+	records, err := LoadUsageRecords(path)
+	if err != nil {
+		return err
+	}
+
+	var issueRecords []UsageRecord
+	for _, record := range records {
+		if record.Issue == issueNumber {
+			issueRecords = append(issueRecords, record)
+		}
+	}
+	if len(issueRecords) == 0 {
+		return nil
+	}
+
+	return client.CreateComment(ctx, parts[0], parts[1], issueNumber, buildUsageComment(issueRecords))
+}
+
+// wrapUsageCommand はフェーズコマンドをsoba cost recordで包み、終了時に使用量を記録させる
+func wrapUsageCommand(executable, logPath, command string, issueNumber int, phase string) string {
+	return strings.Join([]string{
+		shellQuote(executable), "cost", "record",
+		"--file", shellQuote(logPath),
+		"--issue", strconv.Itoa(issueNumber),
+		"--phase", shellQuote(phase),
+		"--", "sh", "-c", shellQuote(command),
+	}, " ")
+}
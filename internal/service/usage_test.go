@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+)
+
+func TestParseAgentUsage(t *testing.T) {
+	t.Run("最後のresultオブジェクトから使用量を読み取る", func(t *testing.T) {
+		output := []byte("working...\n" +
+			`{"type":"assistant","message":{}}` + "\n" +
+			`{"type":"result","subtype":"success","total_cost_usd":0.1234,"duration_ms":45000,"num_turns":7,"session_id":"abc",` +
+			`"usage":{"input_tokens":1200,"output_tokens":340,"cache_creation_input_tokens":50,"cache_read_input_tokens":8000}}` + "\n")
+
+		record, ok := ParseAgentUsage(output)
+
+		require.True(t, ok)
+		assert.InDelta(t, 0.1234, record.CostUSD, 1e-9)
+		assert.Equal(t, int64(1200), record.InputTokens)
+		assert.Equal(t, int64(340), record.OutputTokens)
+		assert.Equal(t, int64(50), record.CacheCreationInputTokens)
+		assert.Equal(t, int64(8000), record.CacheReadInputTokens)
+		assert.Equal(t, int64(45000), record.DurationMS)
+		assert.Equal(t, 7, record.NumTurns)
+		assert.Equal(t, "abc", record.SessionID)
+	})
+
+	t.Run("resultがない場合はfalse", func(t *testing.T) {
+		_, ok := ParseAgentUsage([]byte("done\n{\"type\":\"assistant\"}\n{broken"))
+		assert.False(t, ok)
+	})
+}
+
+func TestUsageRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage", "usage.jsonl")
+
+	records, err := LoadUsageRecords(path)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	require.NoError(t, AppendUsageRecord(path, UsageRecord{Issue: 1, Phase: "plan", CostUSD: 0.5}))
+	require.NoError(t, AppendUsageRecord(path, UsageRecord{Issue: 2, Phase: "implement", CostUSD: 1.5}))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	records, err = LoadUsageRecords(path)
+
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, 2, records[1].Issue)
+}
+
+func TestSummarizeUsage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 12, 0, 0, 0, time.Local) }
+	records := []UsageRecord{
+		{Time: day(6), Issue: 10, Phase: "implement", CostUSD: 1, InputTokens: 100, OutputTokens: 10, DurationMS: 60000},
+		{Time: day(6), Issue: 9, Phase: "plan", CostUSD: 0.5, InputTokens: 50, OutputTokens: 5, CacheReadInputTokens: 7},
+		{Time: day(13), Issue: 10, Phase: "review", CostUSD: 0.25},
+	}
+
+	t.Run("Issueごとに番号順で集計する", func(t *testing.T) {
+		totals, err := SummarizeUsage(records, UsageByIssue)
+		require.NoError(t, err)
+		require.Len(t, totals, 2)
+		assert.Equal(t, "#9", totals[0].Key)
+		assert.Equal(t, "#10", totals[1].Key)
+		assert.Equal(t, 2, totals[1].Runs)
+		assert.InDelta(t, 1.25, totals[1].CostUSD, 1e-9)
+		assert.Equal(t, time.Minute, totals[1].Duration())
+	})
+
+	t.Run("日ごと・週ごとに集計する", func(t *testing.T) {
+		days, err := SummarizeUsage(records, UsageByDay)
+		require.NoError(t, err)
+		require.Len(t, days, 2)
+		assert.Equal(t, "2025-01-06", days[0].Key)
+
+		weeks, err := SummarizeUsage(records, UsageByWeek)
+		require.NoError(t, err)
+		require.Len(t, weeks, 2)
+		assert.Equal(t, "2025-W02", weeks[0].Key)
+		assert.Equal(t, "2025-W03", weeks[1].Key)
+	})
+
+	t.Run("未知の集計単位はエラー", func(t *testing.T) {
+		_, err := SummarizeUsage(records, "month")
+		assert.ErrorContains(t, err, "month")
+	})
+
+	t.Run("全体の合計", func(t *testing.T) {
+		sum := SumUsage(records)
+		assert.Equal(t, 3, sum.Runs)
+		assert.InDelta(t, 1.75, sum.CostUSD, 1e-9)
+		assert.Equal(t, int64(7), sum.CacheTokens)
+	})
+}
+
+func TestBuildUsageComment(t *testing.T) {
+	comment := buildUsageComment([]UsageRecord{
+		{Phase: "review", CostUSD: 0.25},
+		{Phase: "plan", CostUSD: 0.5, InputTokens: 50},
+		{Phase: "plan", CostUSD: 1},
+	})
+
+	assert.Contains(t, comment, "<summary>soba: usage so far $1.75 (3 runs)</summary>")
+	assert.Contains(t, comment, "| plan | 2 | 1.50 | 50 |")
+	assert.Contains(t, comment, "| **Total** | 3 | 1.75 |")
+	assert.Less(t, strings.Index(comment, "| plan |"), strings.Index(comment, "| review |"))
+}
+
+func TestWrapUsageCommand(t *testing.T) {
+	command := wrapUsageCommand("/usr/local/bin/soba", "/repo/.soba/usage/usage.jsonl", `SOBA_ISSUE=7 claude -p 'it'\''s'`, 7, "implement")
+
+	assert.Equal(t, `/usr/local/bin/soba cost record --file /repo/.soba/usage/usage.jsonl --issue 7 --phase implement -- sh -c 'SOBA_ISSUE=7 claude -p '\''it'\''\'\'''\''s'\'''`, command)
+}
+
+func TestIssueWatcher_ReportFinishedPhase_Usage(t *testing.T) {
+	mockClient := &MockGitHubClientForPR{}
+	cfg := &config.Config{
+		GitHub: config.GitHubConfig{Repository: "owner/repo"},
+		Workflow: config.WorkflowConfig{
+			Interval: 1,
+			Usage:    config.UsageConfig{Enabled: true, CommentEnabled: true},
+		},
+	}
+	watcher := NewIssueWatcher(mockClient, cfg)
+	watcher.usageLogPath = filepath.Join(t.TempDir(), "usage.jsonl")
+	require.NoError(t, AppendUsageRecord(watcher.usageLogPath, UsageRecord{Issue: 7, Phase: "plan", CostUSD: 0.3}))
+	require.NoError(t, AppendUsageRecord(watcher.usageLogPath, UsageRecord{Issue: 8, Phase: "plan", CostUSD: 5}))
+	require.NoError(t, AppendUsageRecord(watcher.usageLogPath, UsageRecord{Issue: 7, Phase: "implement", CostUSD: 0.7}))
+
+	watcher.reportFinishedPhase(context.Background(), newLabelChange(7,
+		[]string{domain.LabelDoing}, []string{domain.LabelReviewRequested}))
+
+	require.Len(t, mockClient.comments, 1)
+	assert.Equal(t, 7, mockClient.comments[0].number)
+	assert.Contains(t, mockClient.comments[0].body, "usage so far $1.00 (2 runs)")
+}
@@ -3,12 +3,16 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/douhashi/soba/internal/config"
 	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/metrics"
 	"github.com/douhashi/soba/internal/infra/slack"
 	"github.com/douhashi/soba/internal/infra/tmux"
 	"github.com/douhashi/soba/pkg/logging"
@@ -25,6 +29,12 @@ type WorkflowExecutor interface {
 	ExecutePhase(ctx context.Context, cfg *config.Config, issueNumber int, phase domain.Phase) error
 	// SetIssueProcessor はIssueProcessorを設定する
 	SetIssueProcessor(processor IssueProcessorUpdater)
+	// SetGitHubClient はコマンドのテンプレート変数を取得するGitHubクライアントを設定する
+	SetGitHubClient(client GitHubClientInterface)
+	// SetHistory はイベント履歴の記録先を設定する
+	SetHistory(history *EventHistory)
+	// SetDryRun はローカルの状態を書き換える代わりに記録するドライランを設定する
+	SetDryRun(recorder *DryRunRecorder)
 }
 
 // workflowExecutor はWorkflowExecutorの実装
@@ -32,8 +42,23 @@ type workflowExecutor struct {
 	tmux           tmux.TmuxClient
 	workspace      GitWorkspaceManager
 	issueProcessor IssueProcessorUpdater
+	githubClient   GitHubClientInterface
 	logger         logging.Logger
 	maxPanes       int
+	transcripts    *TranscriptRecorder
+	executable     string // 使用量を記録するときにフェーズコマンドを包むsobaの実行ファイル
+	usageLogPath   string
+	ledgerPath     string
+	history        *EventHistory
+	dryRun         *DryRunRecorder // nilでなければ予算の台帳に書き込まない
+	terminal       *PhaseTerminal  // nilでなければtmuxの代わりに現在の端末でコマンドを実行する
+}
+
+// PhaseTerminal はフェーズのコマンドを現在の端末で実行するときの入出力
+type PhaseTerminal struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 // IssueProcessorUpdater はラベル更新機能を持つインターフェース
@@ -50,11 +75,42 @@ func NewWorkflowExecutor(tmuxClient tmux.TmuxClient, workspace GitWorkspaceManag
 		issueProcessor: processor,
 		logger:         logger,
 		maxPanes:       DefaultMaxPanes,
+		transcripts:    NewTranscriptRecorder(tmuxClient, logger),
+		executable:     sobaExecutable(),
+		usageLogPath:   UsageLogPath,
+		ledgerPath:     WorkLedgerPath,
+	}
+}
+
+// sobaExecutable は実行中のsobaのパスを返す。取得できない場合はPATH上のsobaを使う
+func sobaExecutable() string {
+	executable, err := os.Executable()
+	if err != nil {
+		return "soba"
 	}
+	return executable
 }
 
 // ExecutePhase は指定されたフェーズを実行する
 func (e *workflowExecutor) ExecutePhase(ctx context.Context, cfg *config.Config, issueNumber int, phase domain.Phase) error {
+	// スケジュール外は新しいフェーズを始めない。トリガーラベルは残るため、次の活動時間に再開される
+	active, err := withinSchedule(cfg.Workflow.Schedule, time.Now())
+	if err != nil {
+		e.logger.Error(ctx, "Invalid schedule, not starting phase",
+			logging.Field{Key: "error", Value: err.Error()},
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "phase", Value: string(phase)},
+		)
+		return nil
+	}
+	if !active {
+		e.logger.Info(ctx, "Outside active schedule, not starting phase",
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "phase", Value: string(phase)},
+		)
+		return nil
+	}
+
 	e.logger.Info(ctx, "Executing phase",
 		logging.Field{Key: "issue", Value: issueNumber},
 		logging.Field{Key: "phase", Value: string(phase)},
@@ -70,6 +126,9 @@ func (e *workflowExecutor) ExecutePhase(ctx context.Context, cfg *config.Config,
 			return WrapServiceError(err, "failed to configure issue processor")
 		}
 	}
+	if e.transcripts != nil {
+		e.transcripts.Configure(cfg)
+	}
 
 	// フェーズ定義を取得
 	phaseDef := domain.PhaseDefinitions[string(phase)]
@@ -99,6 +158,7 @@ func (e *workflowExecutor) ExecutePhase(ctx context.Context, cfg *config.Config,
 					issueNumber, phaseDef.TriggerLabel, phaseDef.ExecutionLabel),
 				err.Error(),
 			)
+			e.recordFailure(ctx, issueNumber, phase, err)
 
 			return WrapServiceError(err, "failed to update labels")
 		}
@@ -115,6 +175,8 @@ func (e *workflowExecutor) ExecutePhase(ctx context.Context, cfg *config.Config,
 		)
 	}
 
+	e.history.Record(ctx, HistoryEvent{Issue: issueNumber, Type: HistoryPhaseStarted, Phase: string(phase)})
+
 	// 実行タイプに応じた処理
 	switch phaseDef.ExecutionType {
 	case domain.ExecutionTypeLabelOnly:
@@ -125,9 +187,24 @@ func (e *workflowExecutor) ExecutePhase(ctx context.Context, cfg *config.Config,
 		)
 	case domain.ExecutionTypeCommand:
 		// コマンド実行が必要な場合
-		if err := e.executeCommandPhase(cfg, issueNumber, phase, phaseDef); err != nil {
+		err := e.executeCommandPhase(cfg, issueNumber, phase, phaseDef)
+		metrics.PhaseStarted(issueNumber, string(phase), err)
+		if err != nil {
+			e.recordFailure(ctx, issueNumber, phase, err)
 			return err
 		}
+		// 1日・1ヶ月あたりのフェーズ実行数の予算に数える
+		// 途中で上限を設定しても集計できるよう、上限がなくても記録する
+		if e.dryRun != nil {
+			e.dryRun.Record("record the %s phase of issue #%d in the budget ledger", phase, issueNumber)
+		} else {
+			if err := recordLedgerEntry(e.ledgerPath, ledgerKindPhase, issueNumber, string(phase), time.Now()); err != nil {
+				e.logger.Warn(ctx, "Failed to record phase run",
+					logging.Field{Key: "error", Value: err.Error()},
+					logging.Field{Key: "issue", Value: issueNumber},
+				)
+			}
+		}
 	default:
 		return NewWorkflowExecutionError("soba", string(phase), fmt.Sprintf("unknown execution type: %s", phaseDef.ExecutionType))
 	}
@@ -146,6 +223,10 @@ func (e *workflowExecutor) executeCommandPhase(cfg *config.Config, issueNumber i
 		return err
 	}
 
+	if e.terminal != nil {
+		return e.runInTerminal(cfg, issueNumber, phase)
+	}
+
 	// tmuxセッション管理
 	sessionName := e.generateSessionName(cfg.GitHub.Repository)
 	windowName := fmt.Sprintf("issue-%d", issueNumber)
@@ -159,7 +240,7 @@ func (e *workflowExecutor) executeCommandPhase(cfg *config.Config, issueNumber i
 	// ペイン管理（必要な場合）
 	// windowが新規作成された場合は、既に1ペインがあるのでスキップ
 	if phaseDef.RequiresPane && !windowCreated {
-		if err := e.managePane(sessionName, windowName); err != nil {
+		if err := e.managePane(sessionName, windowName, issueNumber); err != nil {
 			e.logger.Error(context.Background(), "Failed to manage pane",
 				logging.Field{Key: "error", Value: err.Error()},
 				logging.Field{Key: "session", Value: sessionName},
@@ -241,24 +322,85 @@ func (e *workflowExecutor) setupTmuxSession(sessionName, windowName string) (boo
 	return windowCreated, nil
 }
 
-// executeCommand はフェーズコマンドを実行する
-func (e *workflowExecutor) executeCommand(cfg *config.Config, issueNumber int, phase domain.Phase, sessionName, windowName string) error {
-	phaseCommand := e.getPhaseCommand(cfg, phase)
-	command := e.buildCommand(phaseCommand, issueNumber)
+// shellCommand はフェーズで実行するシェルのコマンドを組み立てる
+// フェーズにコマンドが定義されていない場合は空文字を返す
+func (e *workflowExecutor) shellCommand(cfg *config.Config, issueNumber int, phase domain.Phase) (string, string, error) {
+	data := e.phaseCommandData(context.Background(), cfg, issueNumber, phase)
+	phaseCommand := e.getPhaseCommand(cfg, phase, data.Profile)
+	if phaseCommand.Command == "" {
+		e.logger.Info(context.Background(), "No command defined for phase, skipping execution",
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "phase", Value: string(phase)},
+			logging.Field{Key: "profile", Value: data.Profile},
+		)
+		return "", "", nil
+	}
+
+	command, err := buildPhaseCommand(phaseCommand, data, cfg.Version)
+	if err != nil {
+		return "", "", NewCommandExecutionError(phaseCommand.Command, string(phase), issueNumber, err.Error())
+	}
+	workdir, err := phaseWorkdir(cfg, phaseCommand, issueNumber, phase, data)
+	if err != nil {
+		return "", "", NewCommandExecutionError(phaseCommand.Command, string(phase), issueNumber, err.Error())
+	}
 
 	e.logger.Debug(context.Background(), "Phase command details",
 		logging.Field{Key: "issue", Value: issueNumber},
 		logging.Field{Key: "phase", Value: string(phase)},
+		logging.Field{Key: "profile", Value: data.Profile},
 		logging.Field{Key: "phaseCommand", Value: phaseCommand},
 		logging.Field{Key: "builtCommand", Value: command},
+		logging.Field{Key: "workdir", Value: workdir},
 	)
 
-	if command == "" {
-		e.logger.Info(context.Background(), "No command defined for phase, skipping execution",
-			logging.Field{Key: "issue", Value: issueNumber},
-			logging.Field{Key: "phase", Value: string(phase)},
-		)
-		return nil
+	// 終了時にエージェントの使用量を記録する
+	// 端末で実行する場合は、出力をパイプするとエージェントがTTYを使えなくなるため記録しない
+	if cfg.Workflow.Usage.Enabled && e.terminal == nil {
+		logPath, err := filepath.Abs(e.usageLogPath)
+		if err != nil {
+			logPath = e.usageLogPath
+		}
+		command = wrapUsageCommand(e.executable, logPath, command, issueNumber, string(phase))
+	}
+
+	// worktreeなど作業ディレクトリに移動してから実行する
+	if workdir != "" {
+		command = fmt.Sprintf("cd %s && %s", shellQuote(workdir), command)
+	}
+	return command, workdir, nil
+}
+
+// runInTerminal はフェーズコマンドを現在の端末で実行し、終了を待つ
+func (e *workflowExecutor) runInTerminal(cfg *config.Config, issueNumber int, phase domain.Phase) error {
+	command, _, err := e.shellCommand(cfg, issueNumber, phase)
+	if err != nil || command == "" {
+		return err
+	}
+
+	e.history.Record(context.Background(), HistoryEvent{
+		Issue:   issueNumber,
+		Type:    HistoryCommandDispatched,
+		Phase:   string(phase),
+		Command: command,
+		Detail:  "terminal",
+	})
+
+	child := exec.Command("sh", "-c", command) // #nosec G204 - the phase command comes from the soba config
+	child.Stdin = e.terminal.Stdin
+	child.Stdout = e.terminal.Stdout
+	child.Stderr = e.terminal.Stderr
+	if err := child.Run(); err != nil {
+		return NewCommandExecutionError(command, string(phase), issueNumber, err.Error())
+	}
+	return nil
+}
+
+// executeCommand はフェーズコマンドを実行する
+func (e *workflowExecutor) executeCommand(cfg *config.Config, issueNumber int, phase domain.Phase, sessionName, windowName string) error {
+	command, workdir, err := e.shellCommand(cfg, issueNumber, phase)
+	if err != nil || command == "" {
+		return err
 	}
 
 	// 最後のペインインデックスを取得（新しく作成されたペイン）
@@ -272,60 +414,44 @@ func (e *workflowExecutor) executeCommand(cfg *config.Config, issueNumber int, p
 		return NewTmuxManagementError("get pane index", windowName, err.Error())
 	}
 
-	// worktreeを必要とするフェーズかチェック
-	if requiresWorktree(phase) {
-		worktreeDir := fmt.Sprintf("%s/issue-%d", cfg.Git.WorktreeBasePath, issueNumber)
-		cdCommand := fmt.Sprintf("cd %s && %s", worktreeDir, command)
-
-		// tmuxペインの準備完了を待つ（コマンド実行の直前）
-		if cfg.Workflow.TmuxCommandDelay > 0 {
-			delay := time.Duration(cfg.Workflow.TmuxCommandDelay) * time.Second
-			e.logger.Debug(context.Background(), "Waiting for tmux pane to be ready before command execution",
-				logging.Field{Key: "delay", Value: delay},
-				logging.Field{Key: "issue", Value: issueNumber},
-			)
-			time.Sleep(delay)
-		}
+	// コマンドを送る前にペイン出力の記録を始める
+	// 記録する場合はコマンドの終了をログに残し、実行中ラベルが残ったまま終わったことを検知できるようにする
+	sent := command
+	if e.recordTranscript(sessionName, windowName, paneIndex, issueNumber, phase) {
+		sent = transcriptCommand(command)
+	}
 
-		if err := e.tmux.SendCommand(sessionName, windowName, paneIndex, cdCommand); err != nil {
-			e.logger.Error(context.Background(), "Failed to send command",
-				logging.Field{Key: "error", Value: err.Error()},
-				logging.Field{Key: "command", Value: cdCommand},
-				logging.Field{Key: "pane", Value: paneIndex},
-			)
-			return NewCommandExecutionError(cdCommand, string(phase), issueNumber, err.Error())
-		}
-		e.logger.Info(context.Background(), "Command sent with worktree cd",
+	// tmuxペインの準備完了を待つ（コマンド実行の直前）
+	if cfg.Workflow.TmuxCommandDelay > 0 {
+		delay := time.Duration(cfg.Workflow.TmuxCommandDelay) * time.Second
+		e.logger.Debug(context.Background(), "Waiting for tmux pane to be ready before command execution",
+			logging.Field{Key: "delay", Value: delay},
 			logging.Field{Key: "issue", Value: issueNumber},
-			logging.Field{Key: "phase", Value: string(phase)},
-			logging.Field{Key: "worktree", Value: worktreeDir},
-			logging.Field{Key: "command", Value: command},
 		)
-	} else {
-		// tmuxペインの準備完了を待つ（コマンド実行の直前）
-		if cfg.Workflow.TmuxCommandDelay > 0 {
-			delay := time.Duration(cfg.Workflow.TmuxCommandDelay) * time.Second
-			e.logger.Debug(context.Background(), "Waiting for tmux pane to be ready before command execution",
-				logging.Field{Key: "delay", Value: delay},
-				logging.Field{Key: "issue", Value: issueNumber},
-			)
-			time.Sleep(delay)
-		}
+		time.Sleep(delay)
+	}
 
-		if err := e.tmux.SendCommand(sessionName, windowName, paneIndex, command); err != nil {
-			e.logger.Error(context.Background(), "Failed to send command",
-				logging.Field{Key: "error", Value: err.Error()},
-				logging.Field{Key: "command", Value: command},
-				logging.Field{Key: "pane", Value: paneIndex},
-			)
-			return NewCommandExecutionError(command, string(phase), issueNumber, err.Error())
-		}
-		e.logger.Info(context.Background(), "Command sent",
-			logging.Field{Key: "issue", Value: issueNumber},
-			logging.Field{Key: "phase", Value: string(phase)},
+	if err := e.tmux.SendCommand(sessionName, windowName, paneIndex, sent); err != nil {
+		e.logger.Error(context.Background(), "Failed to send command",
+			logging.Field{Key: "error", Value: err.Error()},
 			logging.Field{Key: "command", Value: command},
+			logging.Field{Key: "pane", Value: paneIndex},
 		)
+		return NewCommandExecutionError(command, string(phase), issueNumber, err.Error())
 	}
+	e.logger.Info(context.Background(), "Command sent",
+		logging.Field{Key: "issue", Value: issueNumber},
+		logging.Field{Key: "phase", Value: string(phase)},
+		logging.Field{Key: "workdir", Value: workdir},
+		logging.Field{Key: "command", Value: command},
+	)
+	e.history.Record(context.Background(), HistoryEvent{
+		Issue:   issueNumber,
+		Type:    HistoryCommandDispatched,
+		Phase:   string(phase),
+		Command: command,
+		Detail:  fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex),
+	})
 
 	return nil
 }
@@ -339,8 +465,26 @@ func requiresWorktree(phase domain.Phase) bool {
 	return phaseDef.RequiresWorktree
 }
 
+// recordTranscript はペイン出力のログファイルへの記録を開始し、記録しているかを返す
+// 記録に失敗してもフェーズの実行は続ける
+func (e *workflowExecutor) recordTranscript(sessionName, windowName string, paneIndex, issueNumber int, phase domain.Phase) bool {
+	if e.transcripts == nil {
+		return false
+	}
+	path, err := e.transcripts.Start(sessionName, windowName, paneIndex, issueNumber, phase)
+	if err != nil {
+		e.logger.Warn(context.Background(), "Failed to record pane output",
+			logging.Field{Key: "error", Value: err.Error()},
+			logging.Field{Key: "issue", Value: issueNumber},
+			logging.Field{Key: "phase", Value: string(phase)},
+		)
+		return false
+	}
+	return path != ""
+}
+
 // managePane はペインを管理する（制限数チェック、古いペイン削除、新規作成、リサイズ）
-func (e *workflowExecutor) managePane(sessionName, windowName string) error {
+func (e *workflowExecutor) managePane(sessionName, windowName string, issueNumber int) error {
 	// 現在のペイン数を取得
 	paneCount, err := e.tmux.GetPaneCount(sessionName, windowName)
 	if err != nil {
@@ -360,6 +504,22 @@ func (e *workflowExecutor) managePane(sessionName, windowName string) error {
 			return NewTmuxManagementError("get first pane index", windowName, err.Error())
 		}
 
+		// 削除するペインの履歴を残す
+		if e.transcripts != nil {
+			if path, err := e.transcripts.Archive(sessionName, windowName, firstPaneIndex, issueNumber); err != nil {
+				e.logger.Warn(context.Background(), "Failed to archive pane history",
+					logging.Field{Key: "error", Value: err.Error()},
+					logging.Field{Key: "window", Value: windowName},
+					logging.Field{Key: "index", Value: firstPaneIndex},
+				)
+			} else if path != "" {
+				e.logger.Debug(context.Background(), "Archived pane history",
+					logging.Field{Key: "window", Value: windowName},
+					logging.Field{Key: "path", Value: path},
+				)
+			}
+		}
+
 		if err := e.tmux.DeletePane(sessionName, windowName, firstPaneIndex); err != nil {
 			return NewTmuxManagementError("delete pane", windowName, err.Error())
 		}
@@ -391,67 +551,70 @@ func (e *workflowExecutor) managePane(sessionName, windowName string) error {
 	return nil
 }
 
-// buildCommand はフェーズコマンドからコマンド文字列を構築する
-func (e *workflowExecutor) buildCommand(phaseCommand config.PhaseCommand, issueNumber int) string {
-	parts := []string{phaseCommand.Command}
-	parts = append(parts, phaseCommand.Options...)
-
-	// パラメータがある場合は追加
-	if phaseCommand.Parameter != "" {
-		param := phaseCommand.Parameter
-
-		// {{issue-number}}プレースホルダーを置換
-		param = strings.ReplaceAll(param, "{{issue-number}}", strconv.Itoa(issueNumber))
-
-		// 後方互換性のために{issue_number}も置換
-		param = strings.ReplaceAll(param, "{issue_number}", strconv.Itoa(issueNumber))
-
-		// パラメータ全体をダブルクォートで囲む
-		param = `"` + param + `"`
-
-		parts = append(parts, param)
-	}
-
-	return strings.Join(parts, " ")
-}
-
 // generateSessionName はリポジトリ情報からセッション名を生成する
 func (e *workflowExecutor) generateSessionName(repository string) string {
-	if repository == "" {
-		return DefaultSessionName
-	}
+	return tmuxSessionName(repository)
+}
 
-	// スラッシュで分割して所有者とリポジトリ名を結合
+// tmuxSessionName はリポジトリごとのtmuxセッション名を"soba-{owner}-{repo}"形式で返す
+// リポジトリが未設定または不正な形式の場合はデフォルトのセッション名を返す
+func tmuxSessionName(repository string) string {
 	parts := strings.Split(repository, "/")
-	if len(parts) < 2 {
-		// 不正な形式の場合はデフォルトに戻る
+	if repository == "" || len(parts) < 2 {
 		return DefaultSessionName
 	}
-
-	// "soba-{owner}-{repo}"形式で生成
-	// 複数のスラッシュがある場合も全て結合
-	sessionName := "soba-" + strings.Join(parts, "-")
-	return sessionName
+	return DefaultSessionName + "-" + strings.Join(parts, "-")
 }
 
 // getPhaseCommand は設定からフェーズ用のコマンドを取得する
-func (e *workflowExecutor) getPhaseCommand(cfg *config.Config, phase domain.Phase) config.PhaseCommand {
-	switch phase {
-	case domain.PhasePlan:
-		return cfg.Phase.Plan
-	case domain.PhaseImplement:
-		return cfg.Phase.Implement
-	case domain.PhaseReview:
-		return cfg.Phase.Review
-	case domain.PhaseRevise:
-		return cfg.Phase.Revise
-	default:
-		// Queue, Mergeなどのフェーズはコマンドなし
-		return config.PhaseCommand{}
+// プロファイルが指定されていれば、そのプロファイルで設定された項目だけを既定のコマンドに上書きする
+func (e *workflowExecutor) getPhaseCommand(cfg *config.Config, phase domain.Phase, profile string) config.PhaseCommand {
+	// Queue, Mergeなどのフェーズはコマンドなし
+	phaseCommand := cfg.Phase.ForPhase(string(phase))
+	if profile == "" {
+		return phaseCommand
+	}
+
+	profileConfig, ok := cfg.Profiles[profile]
+	if !ok {
+		e.logger.Warn(context.Background(), "Unknown command profile, using default phase commands",
+			logging.Field{Key: "profile", Value: profile},
+			logging.Field{Key: "phase", Value: string(phase)},
+		)
+		return phaseCommand
 	}
+	return phaseCommand.Merge(profileConfig.ForPhase(string(phase)))
 }
 
 // SetIssueProcessor はIssueProcessorを設定する
 func (e *workflowExecutor) SetIssueProcessor(processor IssueProcessorUpdater) {
 	e.issueProcessor = processor
 }
+
+// SetHistory はイベント履歴の記録先を設定する
+func (e *workflowExecutor) SetHistory(history *EventHistory) {
+	e.history = history
+}
+
+// SetDryRun はドライランを設定する。ペイン出力のログも残さない
+func (e *workflowExecutor) SetDryRun(recorder *DryRunRecorder) {
+	e.dryRun = recorder
+	if recorder != nil {
+		e.transcripts = nil
+	}
+}
+
+// recordFailure はフェーズを始められなかったことを履歴に記録する
+func (e *workflowExecutor) recordFailure(ctx context.Context, issueNumber int, phase domain.Phase, err error) {
+	e.history.Record(ctx, HistoryEvent{
+		Issue:  issueNumber,
+		Type:   HistoryFailed,
+		Phase:  string(phase),
+		Detail: err.Error(),
+	})
+}
+
+// SetGitHubClient はコマンドのテンプレート変数を取得するGitHubクライアントを設定する
+func (e *workflowExecutor) SetGitHubClient(client GitHubClientInterface) {
+	e.githubClient = client
+}
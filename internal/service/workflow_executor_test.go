@@ -87,6 +87,16 @@ func (m *MockTmuxClient) SendCommand(sessionName, windowName string, paneIndex i
 	return args.Error(0)
 }
 
+func (m *MockTmuxClient) CapturePane(sessionName, windowName string, paneIndex int) (string, error) {
+	args := m.Called(sessionName, windowName, paneIndex)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTmuxClient) PipePane(sessionName, windowName string, paneIndex int, logPath string) error {
+	args := m.Called(sessionName, windowName, paneIndex, logPath)
+	return args.Error(0)
+}
+
 type MockWorkspaceManager struct {
 	mock.Mock
 }
@@ -116,6 +126,8 @@ func (m *MockIssueProcessorUpdater) Configure(cfg *config.Config) error {
 }
 
 func TestWorkflowExecutor_ExecutePhase(t *testing.T) {
+	chdirTemp(t)
+
 	tests := []struct {
 		name         string
 		issueNumber  int
@@ -263,6 +275,7 @@ func TestWorkflowExecutor_managePane(t *testing.T) {
 		name        string
 		sessionName string
 		windowName  string
+		issueNumber int
 		maxPanes    int
 		setupMocks  func(*MockTmuxClient)
 		wantErr     bool
@@ -272,6 +285,7 @@ func TestWorkflowExecutor_managePane(t *testing.T) {
 			name:        "Create new pane when under limit",
 			sessionName: "soba",
 			windowName:  "issue-123",
+			issueNumber: 123,
 			maxPanes:    3,
 			setupMocks: func(tmux *MockTmuxClient) {
 				tmux.On("GetPaneCount", "soba", "issue-123").Return(2, nil)
@@ -284,6 +298,7 @@ func TestWorkflowExecutor_managePane(t *testing.T) {
 			name:        "Delete oldest pane when at limit",
 			sessionName: "soba",
 			windowName:  "issue-456",
+			issueNumber: 456,
 			maxPanes:    3,
 			setupMocks: func(tmux *MockTmuxClient) {
 				tmux.On("GetPaneCount", "soba", "issue-456").Return(3, nil)
@@ -298,6 +313,7 @@ func TestWorkflowExecutor_managePane(t *testing.T) {
 			name:        "Error getting pane count",
 			sessionName: "soba",
 			windowName:  "issue-789",
+			issueNumber: 789,
 			maxPanes:    3,
 			setupMocks: func(tmux *MockTmuxClient) {
 				tmux.On("GetPaneCount", "soba", "issue-789").Return(0, errors.New("tmux error"))
@@ -325,7 +341,7 @@ func TestWorkflowExecutor_managePane(t *testing.T) {
 				maxPanes:       tt.maxPanes,
 			}
 
-			err := executor.managePane(tt.sessionName, tt.windowName)
+			err := executor.managePane(tt.sessionName, tt.windowName, tt.issueNumber)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -342,6 +358,8 @@ func TestWorkflowExecutor_managePane(t *testing.T) {
 }
 
 func TestWorkflowExecutor_ExecutePhase_WithWorktreePreparation(t *testing.T) {
+	chdirTemp(t)
+
 	// Planフェーズ開始時にworktreeが準備されることを確認
 	mockTmux := new(MockTmuxClient)
 	mockWorkspace := new(MockWorkspaceManager)
@@ -554,8 +572,8 @@ func TestWorkflowExecutor_buildCommand(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			executor := &workflowExecutor{}
-			result := executor.buildCommand(tt.phaseCommand, tt.issueNumber)
+			result, err := buildPhaseCommand(tt.phaseCommand, PhaseCommandData{IssueNumber: tt.issueNumber}, 1)
+			assert.NoError(t, err)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
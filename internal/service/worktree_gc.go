@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/domain"
+	"github.com/douhashi/soba/internal/infra/git"
+	"github.com/douhashi/soba/internal/infra/github"
+	"github.com/douhashi/soba/pkg/logging"
+)
+
+// WorktreeGitClient はworktreeの整理に必要なGit操作のインターフェース
+type WorktreeGitClient interface {
+	ListWorktrees() ([]git.Worktree, error)
+	PruneWorktrees() error
+	RemoveWorktree(worktreePath string) error
+	MergedBranches(baseBranch, pattern string) ([]string, error)
+	BranchExists(branchName string) (bool, error)
+	DeleteBranch(branchName string, force bool) error
+}
+
+// WorktreeIssueReader はworktreeに対応するIssue/PRの状態を取得するインターフェース
+type WorktreeIssueReader interface {
+	GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*github.Issue, error)
+	ListPullRequests(ctx context.Context, owner, repo string, opts *github.ListPullRequestsOptions) ([]github.PullRequest, bool, error)
+}
+
+// worktree整理のアクション
+const (
+	WorktreeActionKeep   = ""
+	WorktreeActionRemove = "remove" // Issueがクローズ済み、またはPRがマージ済み
+	WorktreeActionEvict  = "evict"  // 件数・容量の上限を超えたため古いものから削除
+)
+
+// worktreeDirPattern はworktree_base_path配下のIssue用ディレクトリ名にマッチする
+var worktreeDirPattern = regexp.MustCompile(`^issue-(\d+)$`)
+
+// WorktreeStatus はIssue用worktreeの状態を表す
+type WorktreeStatus struct {
+	IssueNumber int
+	Path        string
+	Branch      string
+	SizeBytes   int64
+	LastUsed    time.Time
+	IssueState  string // open, closed, または取得できない場合は空
+	Active      bool   // Issueが実行中のフェーズにある
+	Merged      bool   // PRがマージ済み
+	Action      string
+	Reason      string
+}
+
+// WorktreePruneResult はworktree整理の結果を表す
+type WorktreePruneResult struct {
+	Removed         []WorktreeStatus
+	DeletedBranches []string
+}
+
+// WorktreeCollector はworktree_base_path配下のworktreeを整理する
+type WorktreeCollector struct {
+	git    WorktreeGitClient
+	issues WorktreeIssueReader
+	config *config.Config
+	logger logging.Logger
+}
+
+// NewWorktreeCollector は新しいWorktreeCollectorを作成する
+// issuesがnilの場合、Issue/PRの状態による削除は行わない
+func NewWorktreeCollector(gitClient WorktreeGitClient, issues WorktreeIssueReader, cfg *config.Config) *WorktreeCollector {
+	return &WorktreeCollector{
+		git:    gitClient,
+		issues: issues,
+		config: cfg,
+		logger: logging.NewMockLogger(),
+	}
+}
+
+// SetLogger はロガーを設定する
+func (c *WorktreeCollector) SetLogger(log logging.Logger) {
+	c.logger = log
+}
+
+// SetConfig は設定を更新する
+func (c *WorktreeCollector) SetConfig(cfg *config.Config) {
+	c.config = cfg
+}
+
+// List はIssue用worktreeの一覧と、整理した場合に実行されるアクションを返す
+func (c *WorktreeCollector) List(ctx context.Context) ([]WorktreeStatus, error) {
+	worktrees, err := c.git.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	merged := c.mergedIssues(ctx)
+
+	var statuses []WorktreeStatus
+	for _, wt := range worktrees {
+		issueNumber := c.issueNumberForPath(wt.Path)
+		if issueNumber == 0 || wt.Prunable {
+			continue
+		}
+
+		status := WorktreeStatus{
+			IssueNumber: issueNumber,
+			Path:        wt.Path,
+			Branch:      wt.Branch,
+			Merged:      merged[issueNumber],
+		}
+		status.SizeBytes, status.LastUsed = directoryUsage(wt.Path)
+		c.fillIssueState(ctx, &status)
+		statuses = append(statuses, status)
+	}
+
+	c.planActions(statuses)
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].IssueNumber < statuses[j].IssueNumber
+	})
+	return statuses, nil
+}
+
+// Prune はクローズ済み・マージ済みのworktreeと上限を超えたworktreeを削除し、
+// 不要になったworktreeのメタデータとマージ済みのsoba/Nブランチを削除する
+// dryRunの場合は削除対象を返すだけで何も削除しない
+func (c *WorktreeCollector) Prune(ctx context.Context, dryRun bool) (*WorktreePruneResult, error) {
+	statuses, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WorktreePruneResult{}
+	for _, status := range statuses {
+		if status.Action == WorktreeActionKeep {
+			continue
+		}
+		if !dryRun {
+			if err := c.git.RemoveWorktree(status.Path); err != nil {
+				c.logger.Warn(ctx, "Failed to remove worktree",
+					logging.Field{Key: "issue", Value: status.IssueNumber},
+					logging.Field{Key: "path", Value: status.Path},
+					logging.Field{Key: "error", Value: err.Error()},
+				)
+				continue
+			}
+			c.logger.Info(ctx, "Removed worktree",
+				logging.Field{Key: "issue", Value: status.IssueNumber},
+				logging.Field{Key: "reason", Value: status.Reason},
+			)
+		}
+		result.Removed = append(result.Removed, status)
+
+		// マージ済みのPRのブランチは不要（squash mergeでは祖先にならないため強制削除）
+		if status.Merged && status.Branch != "" {
+			if dryRun || c.deleteBranch(ctx, status.Branch, true) {
+				result.DeletedBranches = append(result.DeletedBranches, status.Branch)
+			}
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := c.git.PruneWorktrees(); err != nil {
+		c.logger.Warn(ctx, "Failed to prune worktree metadata",
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+	}
+
+	result.DeletedBranches = append(result.DeletedBranches, c.deleteMergedBranches(ctx, statuses)...)
+	return result, nil
+}
+
+// planActions は各worktreeに対するアクションを決定する
+func (c *WorktreeCollector) planActions(statuses []WorktreeStatus) {
+	var candidates []*WorktreeStatus
+	for i := range statuses {
+		status := &statuses[i]
+		switch {
+		case status.Merged:
+			status.Action, status.Reason = WorktreeActionRemove, "pull request merged"
+		case status.IssueState == "closed":
+			status.Action, status.Reason = WorktreeActionRemove, "issue closed"
+		case !status.Active:
+			candidates = append(candidates, status)
+		}
+	}
+
+	maxCount := c.config.Git.WorktreeMaxCount
+	maxSize := int64(c.config.Git.WorktreeMaxSizeMB) * 1024 * 1024
+	if maxCount <= 0 && maxSize <= 0 {
+		return
+	}
+
+	// 削除後に残るworktreeの件数と容量を集計
+	count := 0
+	var size int64
+	for _, status := range statuses {
+		if status.Action == WorktreeActionKeep {
+			count++
+			size += status.SizeBytes
+		}
+	}
+
+	// 実行中でないものを最終利用日時の古い順に削除
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].LastUsed.Before(candidates[j].LastUsed)
+	})
+	for _, status := range candidates {
+		overCount := maxCount > 0 && count > maxCount
+		overSize := maxSize > 0 && size > maxSize
+		if !overCount && !overSize {
+			break
+		}
+		status.Action = WorktreeActionEvict
+		if overCount {
+			status.Reason = fmt.Sprintf("exceeds worktree_max_count (%d)", maxCount)
+		} else {
+			status.Reason = fmt.Sprintf("exceeds worktree_max_size_mb (%d)", c.config.Git.WorktreeMaxSizeMB)
+		}
+		count--
+		size -= status.SizeBytes
+	}
+}
+
+// fillIssueState はworktreeに対応するIssueの状態を設定する
+func (c *WorktreeCollector) fillIssueState(ctx context.Context, status *WorktreeStatus) {
+	owner, repo := c.ownerAndRepo()
+	if c.issues == nil || owner == "" {
+		// 状態が不明なworktreeは実行中として扱い、削除対象にしない
+		status.Active = true
+		return
+	}
+
+	issue, err := c.issues.GetIssue(ctx, owner, repo, status.IssueNumber)
+	if err != nil {
+		c.logger.Debug(ctx, "Failed to get issue for worktree",
+			logging.Field{Key: "issue", Value: status.IssueNumber},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		status.Active = true
+		return
+	}
+
+	status.IssueState = issue.State
+	for _, label := range issue.Labels {
+		if domain.GetPhaseByExecutionLabel(label.Name) != nil {
+			status.Active = true
+		}
+	}
+}
+
+// mergedIssues はマージ済みPRに対応するIssue番号を返す
+func (c *WorktreeCollector) mergedIssues(ctx context.Context) map[int]bool {
+	merged := make(map[int]bool)
+	owner, repo := c.ownerAndRepo()
+	if c.issues == nil || owner == "" {
+		return merged
+	}
+
+	prs, _, err := c.issues.ListPullRequests(ctx, owner, repo, &github.ListPullRequestsOptions{
+		State:     "closed",
+		Sort:      "updated",
+		Direction: "desc",
+		PerPage:   100,
+	})
+	if err != nil {
+		c.logger.Debug(ctx, "Failed to list closed pull requests",
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return merged
+	}
+
+	for _, pr := range prs {
+		if pr.MergedAt == nil {
+			continue
+		}
+		if issueNumber := issueNumberFromBranch(pr.Head.Ref); issueNumber > 0 {
+			merged[issueNumber] = true
+		}
+	}
+	return merged
+}
+
+// deleteMergedBranches はworktreeで使用されていないマージ済みのsoba/Nブランチを削除する
+func (c *WorktreeCollector) deleteMergedBranches(ctx context.Context, statuses []WorktreeStatus) []string {
+	inUse := make(map[string]bool)
+	for _, status := range statuses {
+		if status.Action == WorktreeActionKeep {
+			inUse[status.Branch] = true
+		}
+	}
+
+	branches, err := c.git.MergedBranches(c.config.Git.BaseBranch, "soba/*")
+	if err != nil {
+		c.logger.Warn(ctx, "Failed to list merged branches",
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return nil
+	}
+
+	var deleted []string
+	for _, branch := range branches {
+		if inUse[branch] {
+			continue
+		}
+		if c.deleteBranch(ctx, branch, false) {
+			deleted = append(deleted, branch)
+		}
+	}
+	return deleted
+}
+
+func (c *WorktreeCollector) deleteBranch(ctx context.Context, branch string, force bool) bool {
+	exists, err := c.git.BranchExists(branch)
+	if err != nil || !exists {
+		return false
+	}
+	if err := c.git.DeleteBranch(branch, force); err != nil {
+		c.logger.Warn(ctx, "Failed to delete branch",
+			logging.Field{Key: "branch", Value: branch},
+			logging.Field{Key: "error", Value: err.Error()},
+		)
+		return false
+	}
+	c.logger.Info(ctx, "Deleted local branch", logging.Field{Key: "branch", Value: branch})
+	return true
+}
+
+// issueNumberForPath はworktree_base_path直下のissue-Nディレクトリの場合にIssue番号を返す
+func (c *WorktreeCollector) issueNumberForPath(path string) int {
+	base, err := filepath.Abs(c.config.Git.WorktreeBasePath)
+	if err != nil {
+		return 0
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return 0
+	}
+	// macOSの/private等のシンボリックリンクを考慮
+	if resolved, err := filepath.EvalSymlinks(base); err == nil {
+		base = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if filepath.Dir(abs) != base {
+		return 0
+	}
+
+	match := worktreeDirPattern.FindStringSubmatch(filepath.Base(abs))
+	if match == nil {
+		return 0
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+func (c *WorktreeCollector) ownerAndRepo() (string, string) {
+	parts := strings.Split(c.config.GitHub.Repository, "/")
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// directoryUsage はディレクトリの合計サイズと最終更新日時を返す
+func directoryUsage(path string) (int64, time.Time) {
+	var size int64
+	var latest time.Time
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			size += info.Size()
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	if latest.IsZero() {
+		if info, err := os.Stat(path); err == nil {
+			latest = info.ModTime()
+		}
+	}
+	return size, latest
+}
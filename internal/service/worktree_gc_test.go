@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/douhashi/soba/internal/config"
+	"github.com/douhashi/soba/internal/infra/git"
+	"github.com/douhashi/soba/internal/infra/github"
+)
+
+type fakeWorktreeGitClient struct {
+	worktrees       []git.Worktree
+	mergedBranches  []string
+	branches        map[string]bool
+	removed         []string
+	deletedBranches []string
+	forced          map[string]bool
+	pruned          bool
+}
+
+func (f *fakeWorktreeGitClient) ListWorktrees() ([]git.Worktree, error) {
+	return f.worktrees, nil
+}
+
+func (f *fakeWorktreeGitClient) PruneWorktrees() error {
+	f.pruned = true
+	return nil
+}
+
+func (f *fakeWorktreeGitClient) RemoveWorktree(worktreePath string) error {
+	f.removed = append(f.removed, worktreePath)
+	return nil
+}
+
+func (f *fakeWorktreeGitClient) MergedBranches(baseBranch, pattern string) ([]string, error) {
+	return f.mergedBranches, nil
+}
+
+func (f *fakeWorktreeGitClient) BranchExists(branchName string) (bool, error) {
+	return f.branches[branchName], nil
+}
+
+func (f *fakeWorktreeGitClient) DeleteBranch(branchName string, force bool) error {
+	f.deletedBranches = append(f.deletedBranches, branchName)
+	if f.forced == nil {
+		f.forced = make(map[string]bool)
+	}
+	f.forced[branchName] = force
+	return nil
+}
+
+type fakeWorktreeIssueReader struct {
+	issues map[int]github.Issue
+	prs    []github.PullRequest
+}
+
+func (f *fakeWorktreeIssueReader) GetIssue(ctx context.Context, owner, repo string, issueNumber int) (*github.Issue, error) {
+	issue, ok := f.issues[issueNumber]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return &issue, nil
+}
+
+func (f *fakeWorktreeIssueReader) ListPullRequests(ctx context.Context, owner, repo string, opts *github.ListPullRequestsOptions) ([]github.PullRequest, bool, error) {
+	return f.prs, false, nil
+}
+
+// createIssueWorktrees はworktree_base_path配下にissue-Nディレクトリを作成し、最終更新日時を設定する
+func createIssueWorktrees(t *testing.T, base string, lastUsed map[int]time.Time) []git.Worktree {
+	t.Helper()
+	var worktrees []git.Worktree
+	for number, ts := range lastUsed {
+		dir := filepath.Join(base, fmt.Sprintf("issue-%d", number))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		file := filepath.Join(dir, "README.md")
+		require.NoError(t, os.WriteFile(file, []byte("worktree"), 0644))
+		require.NoError(t, os.Chtimes(file, ts, ts))
+		require.NoError(t, os.Chtimes(dir, ts, ts))
+		worktrees = append(worktrees, git.Worktree{Path: dir, Branch: fmt.Sprintf("soba/%d", number)})
+	}
+	return worktrees
+}
+
+func newWorktreeTestConfig(base string) *config.Config {
+	return &config.Config{
+		GitHub: config.GitHubConfig{Repository: "owner/repo"},
+		Git: config.GitConfig{
+			BaseBranch:       "main",
+			WorktreeBasePath: base,
+		},
+	}
+}
+
+func TestWorktreeCollector_List(t *testing.T) {
+	now := time.Now()
+
+	t.Run("クローズ済みIssueとマージ済みPRのworktreeを削除対象とする", func(t *testing.T) {
+		base := t.TempDir()
+		worktrees := createIssueWorktrees(t, base, map[int]time.Time{
+			1: now, 2: now, 3: now,
+		})
+		// worktree_base_path外のworktreeは対象外
+		worktrees = append(worktrees, git.Worktree{Path: t.TempDir(), Branch: "main"})
+
+		gitClient := &fakeWorktreeGitClient{worktrees: worktrees}
+		mergedAt := now
+		reader := &fakeWorktreeIssueReader{
+			issues: map[int]github.Issue{
+				1: {Number: 1, State: "closed"},
+				2: {Number: 2, State: "open", Labels: []github.Label{{Name: "soba:reviewing"}}},
+				3: {Number: 3, State: "open", Labels: []github.Label{{Name: "soba:doing"}}},
+			},
+			prs: []github.PullRequest{
+				{Number: 10, MergedAt: &mergedAt, Head: github.PullRequestBranch{Ref: "soba/2"}},
+			},
+		}
+
+		collector := NewWorktreeCollector(gitClient, reader, newWorktreeTestConfig(base))
+		statuses, err := collector.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, statuses, 3)
+
+		assert.Equal(t, 1, statuses[0].IssueNumber)
+		assert.Equal(t, WorktreeActionRemove, statuses[0].Action)
+		assert.Equal(t, "issue closed", statuses[0].Reason)
+
+		assert.Equal(t, 2, statuses[1].IssueNumber)
+		assert.True(t, statuses[1].Merged)
+		assert.Equal(t, WorktreeActionRemove, statuses[1].Action)
+		assert.Equal(t, "pull request merged", statuses[1].Reason)
+
+		assert.Equal(t, 3, statuses[2].IssueNumber)
+		assert.True(t, statuses[2].Active)
+		assert.Equal(t, WorktreeActionKeep, statuses[2].Action)
+		assert.Greater(t, statuses[2].SizeBytes, int64(0))
+	})
+
+	t.Run("上限を超えた場合は実行中でないworktreeを古い順に削除対象とする", func(t *testing.T) {
+		base := t.TempDir()
+		gitClient := &fakeWorktreeGitClient{worktrees: createIssueWorktrees(t, base, map[int]time.Time{
+			1: now.Add(-3 * time.Hour),
+			2: now.Add(-2 * time.Hour),
+			3: now.Add(-4 * time.Hour),
+			4: now.Add(-1 * time.Hour),
+		})}
+		reader := &fakeWorktreeIssueReader{
+			issues: map[int]github.Issue{
+				1: {Number: 1, State: "open"},
+				2: {Number: 2, State: "open"},
+				// 最も古いが実行中のため削除しない
+				3: {Number: 3, State: "open", Labels: []github.Label{{Name: "soba:doing"}}},
+				4: {Number: 4, State: "open"},
+			},
+		}
+		cfg := newWorktreeTestConfig(base)
+		cfg.Git.WorktreeMaxCount = 2
+
+		collector := NewWorktreeCollector(gitClient, reader, cfg)
+		statuses, err := collector.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, statuses, 4)
+
+		assert.Equal(t, WorktreeActionEvict, statuses[0].Action)
+		assert.Equal(t, WorktreeActionEvict, statuses[1].Action)
+		assert.Equal(t, WorktreeActionKeep, statuses[2].Action)
+		assert.Equal(t, WorktreeActionKeep, statuses[3].Action)
+		assert.Contains(t, statuses[0].Reason, "worktree_max_count")
+	})
+
+	t.Run("Issueの状態が取得できない場合は削除しない", func(t *testing.T) {
+		base := t.TempDir()
+		gitClient := &fakeWorktreeGitClient{worktrees: createIssueWorktrees(t, base, map[int]time.Time{
+			1: now.Add(-time.Hour),
+			2: now,
+		})}
+		cfg := newWorktreeTestConfig(base)
+		cfg.Git.WorktreeMaxCount = 1
+
+		collector := NewWorktreeCollector(gitClient, nil, cfg)
+		statuses, err := collector.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		for _, status := range statuses {
+			assert.True(t, status.Active)
+			assert.Equal(t, WorktreeActionKeep, status.Action)
+		}
+	})
+}
+
+func TestWorktreeCollector_Prune(t *testing.T) {
+	now := time.Now()
+	mergedAt := now
+
+	setup := func(t *testing.T) (*fakeWorktreeGitClient, *WorktreeCollector) {
+		base := t.TempDir()
+		gitClient := &fakeWorktreeGitClient{
+			worktrees: createIssueWorktrees(t, base, map[int]time.Time{
+				1: now, 2: now, 3: now,
+			}),
+			mergedBranches: []string{"soba/3", "soba/7"},
+			branches: map[string]bool{
+				"soba/1": true, "soba/2": true, "soba/3": true, "soba/7": true,
+			},
+		}
+		reader := &fakeWorktreeIssueReader{
+			issues: map[int]github.Issue{
+				1: {Number: 1, State: "closed"},
+				2: {Number: 2, State: "open"},
+				3: {Number: 3, State: "open", Labels: []github.Label{{Name: "soba:doing"}}},
+			},
+			prs: []github.PullRequest{
+				{Number: 20, MergedAt: &mergedAt, Head: github.PullRequestBranch{Ref: "soba/2"}},
+			},
+		}
+		return gitClient, NewWorktreeCollector(gitClient, reader, newWorktreeTestConfig(base))
+	}
+
+	t.Run("worktreeとマージ済みブランチを削除する", func(t *testing.T) {
+		gitClient, collector := setup(t)
+
+		result, err := collector.Prune(context.Background(), false)
+		require.NoError(t, err)
+
+		require.Len(t, result.Removed, 2)
+		assert.Equal(t, 1, result.Removed[0].IssueNumber)
+		assert.Equal(t, 2, result.Removed[1].IssueNumber)
+		assert.Len(t, gitClient.removed, 2)
+		assert.True(t, gitClient.pruned)
+
+		// マージ済みPRのブランチは強制削除、使用中のsoba/3は残す
+		assert.Equal(t, []string{"soba/2", "soba/7"}, result.DeletedBranches)
+		assert.True(t, gitClient.forced["soba/2"])
+		assert.False(t, gitClient.forced["soba/7"])
+		assert.NotContains(t, gitClient.deletedBranches, "soba/1")
+		assert.NotContains(t, gitClient.deletedBranches, "soba/3")
+	})
+
+	t.Run("dry-runの場合は何も削除しない", func(t *testing.T) {
+		gitClient, collector := setup(t)
+
+		result, err := collector.Prune(context.Background(), true)
+		require.NoError(t, err)
+
+		assert.Len(t, result.Removed, 2)
+		assert.Equal(t, []string{"soba/2"}, result.DeletedBranches)
+		assert.Empty(t, gitClient.removed)
+		assert.Empty(t, gitClient.deletedBranches)
+		assert.False(t, gitClient.pruned)
+	})
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -13,8 +14,14 @@ import (
 
 var (
 	cfg        *config.Config
-	logFactory *logging.Factory
-	mu         sync.RWMutex
+	configPath string
+	// configLayers and configOrigins are the layers cfg was merged from and where each setting came from
+	configLayers  config.Layers
+	configOrigins config.Origins
+	logFactory    *logging.Factory
+	// logLevelFlag is the log level set by --log-level or --verbose, which takes priority over the config
+	logLevelFlag string
+	mu           sync.RWMutex
 
 	initialized bool
 )
@@ -23,6 +30,10 @@ var (
 type InitOptions struct {
 	LogLevel string
 	Verbose  bool
+	Profile  string // config profile merged over the project config
+	// SkipSecretCommands leaves ${cmd:...} and ${keyring:...} references
+	// unresolved, for commands that only show the config
+	SkipSecretCommands bool
 }
 
 // MustInitialize initializes the application (panics on failure)
@@ -31,7 +42,7 @@ func MustInitialize(configPath string) {
 }
 
 // MustInitializeWithOptions initializes the application with CLI options
-func MustInitializeWithOptions(configPath string, opts *InitOptions) {
+func MustInitializeWithOptions(path string, opts *InitOptions) {
 	mu.Lock()
 	defer mu.Unlock()
 
@@ -39,22 +50,39 @@ func MustInitializeWithOptions(configPath string, opts *InitOptions) {
 		panic("app already initialized")
 	}
 
-	// Load config
+	// Load config from the global, project, profile and environment layers
+	var profile string
+	if opts != nil {
+		profile = opts.Profile
+	}
+	layers := config.DefaultLayers(path, profile)
+	if opts != nil {
+		layers.SkipSecretCommands = opts.SkipSecretCommands
+	}
 	var err error
-	cfg, err = config.Load(configPath)
+	cfg, configOrigins, err = config.LoadLayers(layers)
 	if err != nil {
 		panic("failed to load config: " + err.Error())
 	}
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	configPath = path
+	configLayers = layers
 
 	// Determine effective log level (CLI > verbose > config > default)
-	logLevel := cfg.Log.Level
+	logLevelFlag = ""
 	if opts != nil {
 		if opts.LogLevel != "" {
-			logLevel = opts.LogLevel
+			logLevelFlag = opts.LogLevel
 		} else if opts.Verbose {
-			logLevel = "debug"
+			logLevelFlag = "debug"
 		}
 	}
+	logLevel := cfg.Log.Level
+	if logLevelFlag != "" {
+		logLevel = logLevelFlag
+	}
 	if logLevel == "" {
 		logLevel = "warn" // Default
 	}
@@ -75,6 +103,8 @@ func MustInitializeWithOptions(configPath string, opts *InitOptions) {
 	if err != nil {
 		panic("failed to create logger factory: " + err.Error())
 	}
+	// Mask tokens, webhooks and other secret settings in the logs
+	logFactory.SetSecrets(cfg.SecretValues()...)
 
 	// Initialize Slack Manager
 	logger := logFactory.CreateComponentLogger("slack")
@@ -152,6 +182,52 @@ func Config() *config.Config {
 	return cfg
 }
 
+// ConfigPath returns the path the global Config was loaded from.
+// It is empty when the app was initialized without a config file.
+func ConfigPath() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return configPath
+}
+
+// ConfigLayers returns the layers the global Config was merged from.
+// Reloading them gives the same result as a restart.
+func ConfigLayers() config.Layers {
+	mu.RLock()
+	defer mu.RUnlock()
+	return configLayers
+}
+
+// ConfigOrigins returns where each setting of the global Config came from.
+// It is nil when the app was initialized without a config file.
+func ConfigOrigins() config.Origins {
+	mu.RLock()
+	defer mu.RUnlock()
+	return configOrigins
+}
+
+// ApplyConfig replaces the global Config with a reloaded one and applies its secrets and
+// log level, unless the level was set on the command line
+func ApplyConfig(newCfg *config.Config, origins config.Origins) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !initialized {
+		panic("app not initialized")
+	}
+	cfg = newCfg
+	configOrigins = origins
+	logFactory.SetSecrets(newCfg.SecretValues()...)
+
+	if logLevelFlag == "" {
+		level := newCfg.Log.Level
+		if level == "" {
+			level = "warn" // Default
+		}
+		logFactory.SetLevel(level)
+	}
+}
+
 // LogFactory returns the global Logger Factory
 func LogFactory() *logging.Factory {
 	mu.RLock()
@@ -192,7 +268,11 @@ func Reset() {
 	defer mu.Unlock()
 
 	cfg = nil
+	configPath = ""
+	configLayers = config.Layers{}
+	configOrigins = nil
 	logFactory = nil
+	logLevelFlag = ""
 	initialized = false
 	// Reset Slack Manager singleton
 	slack.Reset()
@@ -21,7 +21,9 @@ type Config struct {
 // Factory creates logger instances with consistent configuration
 type Factory struct {
 	config  Config
-	Handler slog.Handler // Exposed for testing
+	level   *slog.LevelVar // shared by every logger created by the factory
+	secrets *secretSet     // values redacted by every logger created by the factory
+	Handler slog.Handler   // Exposed for testing
 }
 
 // NewFactory creates a new logger factory
@@ -68,12 +70,34 @@ func NewFactory(cfg Config) (*Factory, error) {
 		handler = NewPrettyTextHandler(writer, opts)
 	}
 
+	secrets := &secretSet{}
 	return &Factory{
 		config:  cfg,
-		Handler: handler,
+		level:   levelVar,
+		secrets: secrets,
+		Handler: &redactHandler{next: handler, secrets: secrets},
 	}, nil
 }
 
+// SetLevel changes the minimum level of every logger created by the factory,
+// including the loggers created before the call
+func (f *Factory) SetLevel(level string) {
+	if f.level == nil {
+		return
+	}
+	f.level.Set(parseLevel(level))
+	f.config.Level = level
+}
+
+// SetSecrets replaces the values masked in the records of every logger
+// created by the factory, including the loggers created before the call
+func (f *Factory) SetSecrets(values ...string) {
+	if f.secrets == nil {
+		return
+	}
+	f.secrets.set(values)
+}
+
 // CreateLogger creates a new logger instance
 func (f *Factory) CreateLogger() Logger {
 	return NewContextLogger(f.Handler)
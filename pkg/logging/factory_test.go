@@ -2,6 +2,7 @@ package logging_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -238,6 +239,49 @@ func TestFactory(t *testing.T) {
 		assert.NotEqual(t, logger1, logger1WithFields)
 		assert.NotEqual(t, logger2, logger2WithFields)
 	})
+
+	t.Run("should change the level of existing loggers", func(t *testing.T) {
+		// Arrange
+		logFile := filepath.Join(t.TempDir(), "test.log")
+		factory, err := logging.NewFactory(logging.Config{Level: "warn", Format: "json", Output: logFile})
+		require.NoError(t, err)
+		logger := factory.CreateComponentLogger("test")
+
+		// Act
+		logger.Debug(context.Background(), "before")
+		factory.SetLevel("debug")
+		logger.Debug(context.Background(), "after")
+
+		// Assert
+		data, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "before")
+		assert.Contains(t, string(data), "after")
+	})
+
+	t.Run("should mask secrets in messages and fields", func(t *testing.T) {
+		// Arrange
+		logFile := filepath.Join(t.TempDir(), "test.log")
+		factory, err := logging.NewFactory(logging.Config{Level: "info", Format: "json", Output: logFile})
+		require.NoError(t, err)
+		logger := factory.CreateComponentLogger("test").WithFields(logging.Field{Key: "token", Value: "ghp_bound"})
+
+		// Act
+		factory.SetSecrets("ghp_bound", "sk-secret")
+		logger.Info(context.Background(), "using sk-secret",
+			logging.Field{Key: "command", Value: "API_KEY='sk-secret' claude"},
+			logging.Field{Key: "error", Value: fmt.Errorf("bad key sk-secret")},
+			logging.Field{Key: "issue", Value: 42},
+		)
+
+		// Assert
+		data, err := os.ReadFile(logFile)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "sk-secret")
+		assert.NotContains(t, string(data), "ghp_bound")
+		assert.Contains(t, string(data), `"command":"API_KEY='***MASKED***' claude"`)
+		assert.Contains(t, string(data), `"issue":42`)
+	})
 }
 
 func TestMockFactory(t *testing.T) {
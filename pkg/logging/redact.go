@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces secret values in log records
+const RedactedValue = "***MASKED***"
+
+// secretSet holds the values to redact, shared by every handler of a factory
+type secretSet struct {
+	mu     sync.RWMutex
+	values []string
+}
+
+func (s *secretSet) set(values []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = nil
+	for _, value := range values {
+		// Short values would redact unrelated text
+		if len(value) >= 4 {
+			s.values = append(s.values, value)
+		}
+	}
+}
+
+func (s *secretSet) redact(text string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, value := range s.values {
+		text = strings.ReplaceAll(text, value, RedactedValue)
+	}
+	return text
+}
+
+// Redact replaces the secret values in text by the rules of the log records,
+// e.g. in transcripts posted to an issue
+func Redact(text string, secrets ...string) string {
+	var set secretSet
+	set.set(secrets)
+	return set.redact(text)
+}
+
+func (s *secretSet) empty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.values) == 0
+}
+
+// redactHandler replaces secret values in the message and attributes before
+// passing the record to the next handler
+type redactHandler struct {
+	next    slog.Handler
+	secrets *secretSet
+}
+
+// Enabled implements slog.Handler
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.secrets.empty() {
+		return h.next.Handle(ctx, record)
+	}
+	redacted := slog.NewRecord(record.Time, record.Level, h.secrets.redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs implements slog.Handler
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), secrets: h.secrets}
+}
+
+// WithGroup implements slog.Handler
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), secrets: h.secrets}
+}
+
+func (h *redactHandler) redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.secrets.redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, child := range group {
+			redacted[i] = h.redactAttr(child)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		// Errors and other values are logged as text; redact them only when they contain a secret
+		text := fmt.Sprint(value.Any())
+		if redacted := h.secrets.redact(text); redacted != text {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}